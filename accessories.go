@@ -0,0 +1,186 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxExcludedCategories Is how many categories the Finding API's ExcludeCategory filter takes
+const maxExcludedCategories = 25
+
+// defaultAccessoryTerms Are the words of the listings "exclude accessories" leaves out unless
+// ACCESSORY_TERMS_FILE replaces them: the straps, boxes, papers and cases sold on their own
+var defaultAccessoryTerms = []string{
+	"strap", "straps", "watch band", "watchband", "nato", "empty box", "box only", "boxes",
+	"papers only", "booklet", "manual", "warranty card", "card holder insert", "hang tag", "tags only",
+	"phone case", "iphone case", "airpods case", "case cover", "screen protector",
+	"decal", "sticker", "charm", "keychain", "key chain", "key ring", "dust bag", "dustbag",
+	"shopping bag", "paper bag", "gift bag", "ribbon", "receipt", "catalog", "catalogue", "brochure",
+	"replica box", "display stand", "organizer", "insert", "shaper", "replacement", "spare",
+	"links only", "extra link", "dial only", "bezel insert", "movement only", "parts only",
+}
+
+// excludableCategory Is an eBay category "exclude category" knows by name
+type excludableCategory struct {
+	ID    string
+	Name  string
+	Names []string // the other names it is known by
+}
+
+// excludableCategories Are the categories of the accessories drowning luxury searches, other ones go by id
+var excludableCategories = []excludableCategory{
+	{ID: "20349", Name: "phone cases", Names: []string{"cell phone cases", "cases", "phone covers"}},
+	{ID: "98624", Name: "watch bands", Names: []string{"watch straps", "straps", "bands"}},
+	{ID: "57720", Name: "watch parts", Names: []string{"parts"}},
+	{ID: "15032", Name: "cell phones", Names: []string{"phones", "phone accessories"}},
+	{ID: "267", Name: "books", Names: []string{"magazines", "catalogs"}},
+	{ID: "11232", Name: "movies", Names: []string{"dvds"}},
+	{ID: "220", Name: "toys", Names: []string{"toys and hobbies"}},
+	{ID: "1", Name: "collectibles"},
+}
+
+var (
+	// excludeAccessoriesCommand and includeAccessoriesCommand Toggle leaving accessories out of the results
+	excludeAccessoriesCommand = regexp.MustCompile(`(?i)^\s*(?:exclude|no|hide|without)\s+(?:the\s+)?accessories\W*$`)
+	includeAccessoriesCommand = regexp.MustCompile(`(?i)^\s*(?:include|show)\s+(?:the\s+)?accessories(?:\s+again)?\W*$`)
+	// excludeCategoryCommand and includeCategoryCommand Match "exclude category phone cases" and "include category phone cases"
+	excludeCategoryCommand = regexp.MustCompile(`(?i)^\s*(?:exclude|no|hide|without)\s+(?:the\s+)?category\s+(.+?)\W*$`)
+	includeCategoryCommand = regexp.MustCompile(`(?i)^\s*(?:include|show)\s+(?:the\s+)?category\s+(.+?)\W*$`)
+	// categoryID Matches the eBay category ids typed as they are
+	categoryID = regexp.MustCompile(`^\d{1,9}$`)
+)
+
+// categoryByName Returns the id of the category name, or of the id itself, "" when it isn't one
+func categoryByName(name string) string {
+	name = strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	if categoryID.MatchString(name) {
+		return name
+	}
+	for _, category := range excludableCategories {
+		if name == category.Name || containsStep(category.Names, name) {
+			return category.ID
+		}
+	}
+	return ""
+}
+
+// categoryLabel Names the category id for the replies
+func categoryLabel(id string) string {
+	for _, category := range excludableCategories {
+		if category.ID == id {
+			return category.Name + " (category " + id + ")"
+		}
+	}
+	return "category " + id
+}
+
+// excluding Returns the terms of b the keyword doesn't name itself, so searching "rolex strap" with
+// the accessories left out still finds straps
+func (b *keywordBlocklist) excluding(keyword string) *keywordBlocklist {
+	kept := &keywordBlocklist{}
+	for i, pattern := range b.patterns {
+		if !pattern.MatchString(keyword) {
+			kept.terms = append(kept.terms, b.terms[i])
+			kept.patterns = append(kept.patterns, pattern)
+		}
+	}
+	return kept
+}
+
+// negativeTerms Returns the single word terms of b eBay can leave out through the keyword itself,
+// as many as fit into its limit next to keyword. Phrases and wildcards are only filtered by title.
+func (b *keywordBlocklist) negativeTerms(keyword string) []string {
+	var terms []string
+	// " -()" wraps them
+	size := len(keyword) + 4
+	for _, term := range b.terms {
+		if strings.ContainsAny(term, " *?,()\"-") {
+			continue
+		}
+		if size+len(term)+1 > maxKeywordLength {
+			break
+		}
+		terms = append(terms, term)
+		size += len(term) + 1
+	}
+	return terms
+}
+
+// withNegativeTerms Returns keyword with terms excluded in eBay's syntax, like "rolex -(strap,box)"
+func withNegativeTerms(keyword string, terms []string) string {
+	if len(terms) == 0 {
+		return keyword
+	}
+	return keyword + " -(" + strings.Join(terms, ",") + ")"
+}
+
+// withoutAccessories Drops the items of result whose title mentions a term of accessories
+func withoutAccessories(result SearchResult, accessories *keywordBlocklist) SearchResult {
+	if len(accessories.terms) == 0 {
+		return result
+	}
+	kept := result.Items[:0:0]
+	for _, item := range result.Items {
+		if _, found := accessories.Match(item.Title); !found {
+			kept = append(kept, item)
+		}
+	}
+	result.Items = kept
+	result.Count = len(kept)
+	return result
+}
+
+// handleAccessoriesCommand Answers "exclude accessories", "include accessories", "exclude category <name>"
+// and "include category <name>", reporting whether message was one. Like lots, the choices outlive
+// the search so every later search of the conversation follows them.
+func handleAccessoriesCommand(session Session, message string, w http.ResponseWriter) bool {
+	var response string
+	excluded, _ := session["excludedCategories"].([]string)
+	switch exclude, include := excludeCategoryCommand.FindStringSubmatch(message), includeCategoryCommand.FindStringSubmatch(message); {
+	case excludeAccessoriesCommand.MatchString(message):
+		session["excludeAccessories"] = true
+		response = "OK, I'll leave out straps, boxes, cases and the other accessories sold on their own."
+	case includeAccessoriesCommand.MatchString(message):
+		delete(session, "excludeAccessories")
+		response = "OK, accessories are back in."
+	case exclude != nil:
+		id := categoryByName(exclude[1])
+		switch {
+		case id == "":
+			response = "Sorry, I don't know the category " + exclude[1] + ", try a name like phone cases or watch bands, or its eBay category id."
+		case containsStep(excluded, id):
+			response = "Items of " + categoryLabel(id) + " are already left out."
+		case len(excluded) >= maxExcludedCategories:
+			response = "eBay leaves out at most " + strconv.Itoa(maxExcludedCategories) + " categories, say 'include category <name>' to bring one back first."
+		default:
+			// A new slice, the query of the listed results may still share the old one
+			session["excludedCategories"] = append(append([]string{}, excluded...), id)
+			response = "OK, I'll leave out items of " + categoryLabel(id) + "."
+		}
+	case include != nil:
+		id := categoryByName(include[1])
+		if !containsStep(excluded, id) {
+			response = "Items of " + categoryLabel(id) + " aren't left out."
+			if id == "" {
+				response = "Sorry, I don't know the category " + include[1] + "."
+			}
+			break
+		}
+		kept := make([]string, 0, len(excluded))
+		for _, other := range excluded {
+			if other != id {
+				kept = append(kept, other)
+			}
+		}
+		session["excludedCategories"] = kept
+		response = "OK, items of " + categoryLabel(id) + " are back in."
+	default:
+		return false
+	}
+	WriteReply(w, ReplyInfo, JSON{
+		"message": response + "\n " + strings.TrimSpace(resumeSummary(session)),
+	})
+	return true
+}
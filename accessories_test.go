@@ -0,0 +1,106 @@
+package theluxuryshopper
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCategoryByName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"phone cases", "20349"},
+		{"  Watch   Straps ", "98624"},
+		{"parts", "57720"},
+		{"11450", "11450"},
+		{"1234567890", ""},
+		{"handbags", ""},
+	}
+	for _, test := range tests {
+		if got := categoryByName(test.name); got != test.want {
+			t.Errorf("categoryByName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNegativeTerms(t *testing.T) {
+	accessories := newKeywordBlocklist([]string{"strap", "watch band", "box*", "case", "dust-bag"})
+	tests := []struct {
+		keyword string
+		want    []string
+	}{
+		{"rolex", []string{"strap", "case"}},
+		{"rolex strap", []string{"case"}},
+		{strings.Repeat("x", maxKeywordLength-10), []string{"strap"}},
+		{strings.Repeat("x", maxKeywordLength-4), nil},
+	}
+	for _, test := range tests {
+		got := accessories.excluding(test.keyword).negativeTerms(test.keyword)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("negativeTerms(%q) = %q, want %q", test.keyword, got, test.want)
+		}
+	}
+	if got := withNegativeTerms("rolex", []string{"strap", "case"}); got != "rolex -(strap,case)" {
+		t.Errorf("withNegativeTerms() = %q", got)
+	}
+}
+
+func TestWithoutAccessories(t *testing.T) {
+	items := []Item{{ID: "1", Title: "Rolex Submariner 116610LN"}, {ID: "2", Title: "Rubber STRAP for Rolex"}, {ID: "3", Title: "Rolex empty box only"}}
+	result := withoutAccessories(SearchResult{Items: items, Count: 3}, newKeywordBlocklist(defaultAccessoryTerms))
+	if result.Count != 1 || len(result.Items) != 1 || result.Items[0].ID != "1" {
+		t.Errorf("withoutAccessories() kept %+v", result.Items)
+	}
+	if len(items) != 3 || items[1].ID != "2" {
+		t.Errorf("withoutAccessories() changed the items it was given: %+v", items)
+	}
+}
+
+func TestHandleAccessoriesCommand(t *testing.T) {
+	full := make([]string, maxExcludedCategories)
+	for i := range full {
+		full[i] = "9" + strings.Repeat("0", i%8) + string(rune('1'+i%9))
+	}
+	tests := []struct {
+		name       string
+		excluded   []string
+		message    string
+		handled    bool
+		want       []string
+		wantPrefix string
+	}{
+		{"exclude accessories", nil, "no accessories", true, nil, "OK, I'll leave out straps"},
+		{"exclude by name", nil, "exclude category phone cases", true, []string{"20349"}, "OK, I'll leave out items of phone cases (category 20349)."},
+		{"exclude by id", []string{"20349"}, "hide category 11450!", true, []string{"20349", "11450"}, "OK, I'll leave out items of category 11450."},
+		{"already excluded", []string{"20349"}, "exclude category cases", true, []string{"20349"}, "Items of phone cases (category 20349) are already left out."},
+		{"unknown", nil, "exclude category handbags", true, nil, "Sorry, I don't know the category handbags,"},
+		{"too many", full, "exclude category phone cases", true, full, "eBay leaves out at most 25 categories"},
+		{"include", []string{"20349", "98624"}, "include category watch bands", true, []string{"20349"}, "OK, items of watch bands (category 98624) are back in."},
+		{"include not excluded", []string{"20349"}, "show category toys", true, []string{"20349"}, "Items of toys (category 220) aren't left out."},
+		{"not a command", nil, "rolex strap", false, nil, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			session := Session{}
+			if test.excluded != nil {
+				session["excludedCategories"] = test.excluded
+			}
+			w := httptest.NewRecorder()
+			if handled := handleAccessoriesCommand(session, test.message, w); handled != test.handled {
+				t.Fatalf("handleAccessoriesCommand(%q) = %v, want %v", test.message, handled, test.handled)
+			}
+			if !test.handled {
+				return
+			}
+			excluded, _ := session["excludedCategories"].([]string)
+			if !reflect.DeepEqual(excluded, test.want) {
+				t.Errorf("handleAccessoriesCommand(%q) left %q excluded, want %q", test.message, excluded, test.want)
+			}
+			if message, _ := recordedReply(t, w)["message"].(string); !strings.HasPrefix(message, test.wantPrefix) {
+				t.Errorf("handleAccessoriesCommand(%q) answered %q, want it to start with %q", test.message, message, test.wantPrefix)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package theluxuryshopper
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// apiKeyHeader Is the header frontends present their API key in
+const apiKeyHeader = "X-Api-Key"
+
+// apiKeys Holds the keys of API_KEYS and counts the requests made with each of them.
+// A nil apiKeys accepts every request.
+type apiKeys struct {
+	keys     []*apiKey
+	rejected int64
+}
+
+// apiKey Is one configured key. Only its digest is kept, and it is known by a label
+// made of the start of that digest, so the key itself never ends up in logs or metrics.
+type apiKey struct {
+	digest   [32]byte
+	label    string
+	requests int64
+}
+
+// newAPIKeys Creates the key set of keys, or nil when none is configured
+func newAPIKeys(keys []string) *apiKeys {
+	set := &apiKeys{}
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+		digest := sha256.Sum256([]byte(key))
+		set.keys = append(set.keys, &apiKey{digest: digest, label: "key-" + hex.EncodeToString(digest[:4])})
+	}
+	if len(set.keys) == 0 {
+		return nil
+	}
+	return set
+}
+
+// Check Returns the key matching presented, comparing it to every key in constant time
+func (set *apiKeys) Check(presented string) (*apiKey, bool) {
+	digest := sha256.Sum256([]byte(presented))
+	var matched *apiKey
+	for _, key := range set.keys {
+		if subtle.ConstantTimeCompare(digest[:], key.digest[:]) == 1 {
+			matched = key
+		}
+	}
+	if matched == nil || presented == "" {
+		atomic.AddInt64(&set.rejected, 1)
+		return nil, false
+	}
+	atomic.AddInt64(&matched.requests, 1)
+	return matched, true
+}
+
+// Counts Returns the requests made with each key by its label, and the requests rejected
+func (set *apiKeys) Counts() (map[string]int64, int64) {
+	counts := make(map[string]int64, len(set.keys))
+	for _, key := range set.keys {
+		counts[key.label] = atomic.LoadInt64(&key.requests)
+	}
+	return counts, atomic.LoadInt64(&set.rejected)
+}
+
+// requireAPIKey Wraps handle so it answers 401 unless the request carries one of the keys of API_KEYS
+func (s *Server) requireAPIKey(handle httprouter.Handle) httprouter.Handle {
+	if s.apiKeys == nil {
+		return handle
+	}
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if _, valid := s.apiKeys.Check(r.Header.Get(apiKeyHeader)); !valid {
+			writeError(w, http.StatusUnauthorized, "invalid_api_key", "A valid "+apiKeyHeader+" header is required.")
+			return
+		}
+		handle(w, r, ps)
+	}
+}
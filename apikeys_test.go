@@ -0,0 +1,70 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAPIKeysCheck(t *testing.T) {
+	if newAPIKeys([]string{" ", ""}) != nil {
+		t.Fatal("newAPIKeys() of blank keys isn't nil")
+	}
+	keys := newAPIKeys([]string{"frontend-key", " mobile-key "})
+	tests := []struct {
+		presented string
+		valid     bool
+	}{
+		{"frontend-key", true},
+		{"mobile-key", true},
+		{" mobile-key ", false},
+		{"FRONTEND-KEY", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		key, valid := keys.Check(test.presented)
+		if valid != test.valid || (key != nil) != test.valid {
+			t.Errorf("Check(%q) = %v, %v, want valid %v", test.presented, key, valid, test.valid)
+		}
+		if valid && (key.label == "" || key.label == "key-"+test.presented) {
+			t.Errorf("Check(%q) labeled the key %q", test.presented, key.label)
+		}
+	}
+	counts, rejected := keys.Counts()
+	if len(counts) != 2 || rejected != 3 {
+		t.Errorf("Counts() = %v, %v, want 2 keys and 3 rejected", counts, rejected)
+	}
+	for label, requests := range counts {
+		if requests != 1 {
+			t.Errorf("Counts() counted %v requests for %v, want 1", requests, label)
+		}
+	}
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	s := newTestServer(t, Config{APIKeys: []string{"frontend-key"}}, &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("belt", 1, 1)}}})
+	tests := []struct {
+		name, method, target, key string
+		wantStatus                int
+	}{
+		{"keyed without a key", http.MethodGet, "/v1/search?keyword=gucci", "", http.StatusUnauthorized},
+		{"keyed with a wrong key", http.MethodGet, "/v1/search?keyword=gucci", "other-key", http.StatusUnauthorized},
+		{"keyed with the key", http.MethodGet, "/v1/search?keyword=gucci", "frontend-key", http.StatusOK},
+		{"welcome without a key", http.MethodGet, "/v1/welcome", "", http.StatusUnauthorized},
+		{"not keyed", http.MethodGet, "/healthz", "", http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := http.Header{}
+			if test.key != "" {
+				header.Set(apiKeyHeader, test.key)
+			}
+			reply := do(t, s.Routes(), test.method, test.target, "", header)
+			if reply.Status != test.wantStatus {
+				t.Errorf("%v %v answered %v, want %v: %v", test.method, test.target, reply.Status, test.wantStatus, reply.Raw)
+			}
+			if test.wantStatus == http.StatusUnauthorized && reply.errorCode() != "invalid_api_key" {
+				t.Errorf("%v %v answered the error %q, want invalid_api_key", test.method, test.target, reply.errorCode())
+			}
+		})
+	}
+}
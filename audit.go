@@ -0,0 +1,323 @@
+package theluxuryshopper
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// defaultAuditLogMaxSize Is the size the audit log is rotated at unless AUDIT_LOG_MAX_SIZE is set
+	defaultAuditLogMaxSize = 10 << 20
+	// auditKeptFiles Is how many rotated audit logs are kept next to the current one, as path.1 to path.3
+	auditKeptFiles = 3
+	// auditWriteTimeout Is the longest an admin request waits for its entry to be on disk
+	auditWriteTimeout = time.Second
+	// auditQueueSize Is how many entries can wait for the writer
+	auditQueueSize = 64
+	// maxAuditedBody Is how much of a request body an entry keeps
+	maxAuditedBody = 4 << 10
+	// defaultAuditLimit And maxAuditLimit Bound the entries GET /admin/audit answers
+	defaultAuditLimit, maxAuditLimit = 50, 500
+)
+
+// errAuditTimeout Is the failure of an entry the writer didn't get on disk within auditWriteTimeout
+var errAuditTimeout = errors.New("the audit log didn't take the entry in time")
+
+// auditEntry Is one line of the audit log, describing one admin request
+type auditEntry struct {
+	Time             string  `json:"time"`
+	Method           string  `json:"method"`
+	Endpoint         string  `json:"endpoint"`
+	Parameters       JSON    `json:"parameters,omitempty"`
+	Status           int     `json:"status"`
+	Outcome          string  `json:"outcome"` // "ok" or the error code answered
+	DurationMs       float64 `json:"durationMs"`
+	TokenFingerprint string  `json:"tokenFingerprint"`
+}
+
+// auditWrite Is one entry waiting for the writer, done gets the outcome of the write
+type auditWrite struct {
+	line []byte
+	done chan error
+}
+
+// auditLog Appends the audit entries to a JSON lines file, rotated by size. One writer goroutine syncs
+// every entry to disk before the request it describes is answered.
+type auditLog struct {
+	path    string
+	maxSize int64
+	queue   chan auditWrite
+
+	mu   sync.Mutex // guards the files against the reads of GET /admin/audit
+	file *os.File   // nil until the first write and after a failure
+	size int64
+
+	written, failed int64 // atomic
+}
+
+// newAuditLog Starts the writer of the audit log of path, rotated at maxSize bytes (0 takes the default).
+// The file is opened on the first entry, so a path that can't be written only fails the writes.
+func newAuditLog(path string, maxSize int64) *auditLog {
+	if maxSize <= 0 {
+		maxSize = defaultAuditLogMaxSize
+	}
+	a := &auditLog{path: path, maxSize: maxSize, queue: make(chan auditWrite, auditQueueSize)}
+	go a.run()
+	return a
+}
+
+// run Writes the queued entries one at a time
+func (a *auditLog) run() {
+	for write := range a.queue {
+		write.done <- a.append(write.line)
+	}
+}
+
+// append Writes line at the end of the log and syncs it, rotating the log first when line would overflow it
+func (a *auditLog) append(line []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file != nil && a.size > 0 && a.size+int64(len(line)) > a.maxSize {
+		a.rotate()
+	}
+	if a.file == nil {
+		file, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return err
+		}
+		a.file, a.size = file, info.Size()
+	}
+	n, err := a.file.Write(line)
+	a.size += int64(n)
+	if err == nil {
+		err = a.file.Sync()
+	}
+	if err != nil {
+		// Opened again by the next entry, which may get past what failed
+		a.file.Close()
+		a.file = nil
+	}
+	return err
+}
+
+// rotate Moves the current log to path.1, the older ones one number up, dropping the oldest
+func (a *auditLog) rotate() {
+	a.file.Close()
+	a.file = nil
+	for i := auditKeptFiles - 1; i >= 1; i-- {
+		os.Rename(a.path+"."+strconv.Itoa(i), a.path+"."+strconv.Itoa(i+1))
+	}
+	if err := os.Rename(a.path, a.path+".1"); err != nil {
+		log.Printf("couldn't rotate the audit log: %v", err)
+	}
+}
+
+// Record Writes entry, waiting at most auditWriteTimeout. A failure is logged and counted, never returned:
+// the admin request it describes goes on.
+func (a *auditLog) Record(entry auditEntry) {
+	line, err := json.Marshal(entry)
+	if err == nil {
+		err = a.write(append(line, '\n'))
+	}
+	if err != nil {
+		atomic.AddInt64(&a.failed, 1)
+		log.Printf("couldn't audit %v %v: %v", entry.Method, entry.Endpoint, err)
+		return
+	}
+	atomic.AddInt64(&a.written, 1)
+}
+
+// write Hands line to the writer and waits for it to be on disk, within auditWriteTimeout
+func (a *auditLog) write(line []byte) error {
+	timeout := time.NewTimer(auditWriteTimeout)
+	defer timeout.Stop()
+	write := auditWrite{line: line, done: make(chan error, 1)}
+	select {
+	case a.queue <- write:
+	case <-timeout.C:
+		return errAuditTimeout
+	}
+	select {
+	case err := <-write.done:
+		return err
+	case <-timeout.C:
+		// The writer still writes it, it just can't hold the request any longer
+		return errAuditTimeout
+	}
+}
+
+// Recent Returns the last limit entries, newest first, reading the rotated logs when the current one
+// holds fewer. Lines cut short by a crash are skipped.
+func (a *auditLog) Recent(limit int) ([]json.RawMessage, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entries := []json.RawMessage{}
+	for i := 0; i <= auditKeptFiles && len(entries) < limit; i++ {
+		path := a.path
+		if i > 0 {
+			path += "." + strconv.Itoa(i)
+		}
+		lines, err := readAuditFile(path)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for j := len(lines) - 1; j >= 0 && len(entries) < limit; j-- {
+			entries = append(entries, lines[j])
+		}
+	}
+	return entries, nil
+}
+
+// readAuditFile Returns the entries of the audit log of path in the order they were written
+func readAuditFile(path string) ([]json.RawMessage, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var lines []json.RawMessage
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		if line := scanner.Bytes(); json.Valid(line) {
+			lines = append(lines, append(json.RawMessage{}, line...))
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// Counts Returns the entries written and those that failed since the start, for /healthz
+func (a *auditLog) Counts() JSON {
+	return JSON{"written": atomic.LoadInt64(&a.written), "failed": atomic.LoadInt64(&a.failed)}
+}
+
+// tokenFingerprint Names an admin token in the audit log without giving it away
+func tokenFingerprint(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(digest[:8])
+}
+
+// auditParameters Returns the route parameters, query and body of r for its entry. The body is kept as
+// JSON when it is, the handler still reads all of it.
+func auditParameters(r *http.Request, ps httprouter.Params) JSON {
+	parameters := JSON{}
+	for _, p := range ps {
+		parameters[p.Key] = p.Value
+	}
+	if query := r.URL.Query(); len(query) > 0 {
+		parameters["query"] = query
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return parameters
+	}
+	head, _ := io.ReadAll(io.LimitReader(r.Body, maxAuditedBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	switch {
+	case len(head) > maxAuditedBody:
+		parameters["bodyTruncated"] = true
+	case json.Valid(head):
+		parameters["body"] = json.RawMessage(head)
+	case len(head) > 0:
+		parameters["body"] = string(head)
+	}
+	return parameters
+}
+
+// auditOutcome Returns "ok" for a successful reply, otherwise the code of its error envelope
+func auditOutcome(status int, body []byte) string {
+	if status < http.StatusBadRequest {
+		return "ok"
+	}
+	var envelope struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && envelope.Error.Code != "" {
+		return envelope.Error.Code
+	}
+	return "failed"
+}
+
+// audited Records the requests to an admin route that carry the admin token in the audit log. The reply
+// is held back until its entry is on disk, so an action is never acknowledged without its trail.
+func (s *Server) audited(handle httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if s.audit == nil || s.adminToken == nil || subtle.ConstantTimeCompare([]byte(token), s.adminToken) != 1 {
+			handle(w, r, ps)
+			return
+		}
+		started := time.Now()
+		parameters := auditParameters(r, ps)
+		reply := &capturedReply{header: w.Header(), status: http.StatusOK}
+		handle(reply, r, ps)
+		s.audit.Record(auditEntry{
+			Time:             started.UTC().Format(time.RFC3339Nano),
+			Method:           r.Method,
+			Endpoint:         r.URL.Path,
+			Parameters:       parameters,
+			Status:           reply.status,
+			Outcome:          auditOutcome(reply.status, reply.body.Bytes()),
+			DurationMs:       milliseconds(time.Since(started)),
+			TokenFingerprint: tokenFingerprint(token),
+		})
+		w.WriteHeader(reply.status)
+		w.Write(reply.body.Bytes())
+	}
+}
+
+// handleAudit Handles GET /admin/audit, answering the last admin requests of the audit log, newest first
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if !s.authorizeAdmin(w, r, "Audit entries") {
+		return
+	}
+	if s.audit == nil {
+		writeError(w, http.StatusNotFound, "not_found", "The audit log isn't enabled, set AUDIT_LOG_FILE.")
+		return
+	}
+	limit := defaultAuditLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxAuditLimit {
+			writeError(w, http.StatusBadRequest, "invalid_limit", "limit must be a number of entries from 1 to "+strconv.Itoa(maxAuditLimit)+".")
+			return
+		}
+		limit = parsed
+	}
+	entries, err := s.audit.Recent(limit)
+	if err != nil {
+		log.Printf("couldn't read the audit log: %v", err)
+		writeError(w, http.StatusInternalServerError, "audit_unreadable", "Couldn't read the audit log.")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, JSON{"entries": entries, "limit": limit})
+}
@@ -0,0 +1,153 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestAuditLogRotatesAndReadsNewestFirst(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	// Each entry is about 150 bytes, so every file holds two
+	audit := newAuditLog(path, 350)
+	for _, endpoint := range []string{"/1", "/2", "/3", "/4", "/5", "/6", "/7", "/8", "/9", "/10"} {
+		audit.Record(auditEntry{Method: http.MethodGet, Endpoint: endpoint, Status: http.StatusOK, Outcome: "ok", TokenFingerprint: tokenFingerprint("secret")})
+	}
+	if counts := audit.Counts(); counts["written"] != int64(10) || counts["failed"] != int64(0) {
+		t.Fatalf("Counts() = %v", counts)
+	}
+	if _, err := os.Stat(path + "." + "4"); !os.IsNotExist(err) {
+		t.Errorf("kept more than %v rotated logs", auditKeptFiles)
+	}
+
+	tests := []struct {
+		limit int
+		want  []string
+	}{
+		{1, []string{"/10"}},
+		{3, []string{"/10", "/9", "/8"}},
+		// The 2 oldest entries were rotated away
+		{50, []string{"/10", "/9", "/8", "/7", "/6", "/5", "/4", "/3"}},
+	}
+	for _, test := range tests {
+		entries, err := audit.Recent(test.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, raw := range entries {
+			var entry auditEntry
+			json.Unmarshal(raw, &entry)
+			got = append(got, entry.Endpoint)
+		}
+		if strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("Recent(%v) = %v, want %v", test.limit, got, test.want)
+		}
+	}
+}
+
+func TestReadAuditFileSkipsTornLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	os.WriteFile(path, []byte(`{"endpoint":"/a"}`+"\n"+`{"endpoint":"/b`+"\n"+`{"endpoint":"/c"}`+"\n"), 0o600)
+	lines, err := readAuditFile(path)
+	if err != nil || len(lines) != 2 || string(lines[1]) != `{"endpoint":"/c"}` {
+		t.Errorf("readAuditFile() = %q, %v", lines, err)
+	}
+}
+
+func TestAuditOutcome(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   string
+	}{
+		{http.StatusOK, `{"enabled":true}`, "ok"},
+		{http.StatusNoContent, ``, "ok"},
+		{http.StatusBadRequest, `{"error":{"code":"invalid_json","message":"..."}}`, "invalid_json"},
+		{http.StatusInternalServerError, `oops`, "failed"},
+		{http.StatusForbidden, `{"error":{}}`, "failed"},
+	}
+	for _, test := range tests {
+		if got := auditOutcome(test.status, []byte(test.body)); got != test.want {
+			t.Errorf("auditOutcome(%v, %q) = %q, want %q", test.status, test.body, got, test.want)
+		}
+	}
+}
+
+func TestAuditParameters(t *testing.T) {
+	tests := []struct {
+		name, body string
+		wantBody   interface{}
+		truncated  bool
+	}{
+		{"json", `{"enabled": true}`, json.RawMessage(`{"enabled": true}`), false},
+		{"text", `enabled=true`, "enabled=true", false},
+		{"too long", strings.Repeat("x", maxAuditedBody+1), nil, true},
+		{"empty", ``, nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/admin/maintenance?dryRun=1", strings.NewReader(test.body))
+			parameters := auditParameters(r, httprouter.Params{{Key: "name", Value: "acme"}})
+			if parameters["name"] != "acme" || parameters["query"] == nil {
+				t.Errorf("auditParameters() = %v, want the route parameter and the query", parameters)
+			}
+			body, found := parameters["body"]
+			if test.wantBody == nil && found || test.wantBody != nil && string(mustJSON(t, body)) != string(mustJSON(t, test.wantBody)) {
+				t.Errorf("auditParameters() kept the body %v, want %v", body, test.wantBody)
+			}
+			if truncated, _ := parameters["bodyTruncated"].(bool); truncated != test.truncated {
+				t.Errorf("auditParameters() truncated %v, want %v", truncated, test.truncated)
+			}
+			// The handler still reads the whole body
+			rest := new(bytes.Buffer)
+			if _, err := rest.ReadFrom(r.Body); err != nil || rest.String() != test.body {
+				t.Errorf("the body left for the handler is %d bytes, want %d", rest.Len(), len(test.body))
+			}
+		})
+	}
+}
+
+// mustJSON Encodes value for comparisons
+func mustJSON(t *testing.T, value interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestAuditedAdminRequests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	s := newTestServer(t, Config{AdminToken: "secret", AuditLogFile: path}, &fakeSearcher{})
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+
+	do(t, s.Routes(), http.MethodPost, "/admin/maintenance", `{"enabled": true}`, admin)
+	do(t, s.Routes(), http.MethodPost, "/admin/maintenance", `{"enabled": true}`, http.Header{"Authorization": {"Bearer wrong"}})
+	do(t, s.Routes(), http.MethodPost, "/admin/maintenance", `not json`, admin)
+
+	reply := do(t, s.Routes(), http.MethodGet, "/admin/audit?limit=10", "", admin)
+	entries, _ := reply.Body["entries"].([]interface{})
+	if len(entries) != 2 {
+		t.Fatalf("/admin/audit answered %v entries, want the 2 requests carrying the token: %v", len(entries), reply.Raw)
+	}
+	newest, _ := entries[0].(map[string]interface{})
+	oldest, _ := entries[1].(map[string]interface{})
+	if newest["outcome"] != "invalid_request" || oldest["outcome"] != "ok" || oldest["endpoint"] != "/admin/maintenance" || oldest["tokenFingerprint"] != tokenFingerprint("secret") {
+		t.Errorf("/admin/audit answered %v", reply.Raw)
+	}
+
+	for _, limit := range []string{"0", "501", "ten"} {
+		if reply := do(t, s.Routes(), http.MethodGet, "/admin/audit?limit="+limit, "", admin); reply.errorCode() != "invalid_limit" {
+			t.Errorf("/admin/audit?limit=%v answered %v", limit, reply.Raw)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package theluxuryshopper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// backupVersion Is the version of the backups written, imports only accept it
+	backupVersion = 1
+	// maxBackupSize Caps the body of an import
+	maxBackupSize = 1 << 20
+)
+
+// sessionBackup Holds the durable parts of a session: its preferences, the saved searches and the
+// search history of the active conversation, never the state of a search in progress
+type sessionBackup struct {
+	Version       int                     `json:"version"`
+	ExportedAt    time.Time               `json:"exportedAt"`
+	Preferences   backupPreferences       `json:"preferences"`
+	SavedSearches map[string]*savedSearch `json:"savedSearches,omitempty"`
+	SearchHistory []pastSearch            `json:"searchHistory,omitempty"`
+}
+
+// backupPreferences Holds the preferences a backup carries, an import overwrites the ones it sets
+type backupPreferences struct {
+	Timezone        string                 `json:"timezone,omitempty"`
+	Display         *displayPreference     `json:"display,omitempty"`
+	ExcludeLots     bool                   `json:"excludeLots,omitempty"`
+	ShipsTo         string                 `json:"shipsTo,omitempty"`
+	SkipQuestions   bool                   `json:"skipQuestions,omitempty"`
+	FilterOverrides map[string]interface{} `json:"filterOverrides,omitempty"`
+}
+
+// signedBackup Is the blob clients keep, payload is the base64 JSON of a sessionBackup
+type signedBackup struct {
+	Version   int    `json:"version"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// backupSignature Returns the HMAC of payload
+func (s *Server) backupSignature(payload string) string {
+	mac := hmac.New(sha256.New, s.backupSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// exportSession Collects the durable parts of session
+func exportSession(session Session, now time.Time) sessionBackup {
+	_, conversation := activeConversation(session)
+	backup := sessionBackup{Version: backupVersion, ExportedAt: now.UTC()}
+	backup.Preferences.Timezone, _ = session["timezone"].(string)
+	if preference, found := session["display"].(displayPreference); found {
+		backup.Preferences.Display = &preference
+	}
+	backup.Preferences.ExcludeLots, _ = conversation["excludeLots"].(bool)
+	backup.Preferences.ShipsTo, _ = conversation["shipsTo"].(string)
+	backup.Preferences.SkipQuestions, _ = conversation["skipQuestions"].(bool)
+	backup.Preferences.FilterOverrides, _ = conversation["filterOverrides"].(map[string]interface{})
+	if searches := savedSearches(conversation); len(searches) > 0 {
+		backup.SavedSearches = searches
+	}
+	backup.SearchHistory = searchHistory(conversation)
+	return backup
+}
+
+// importSession Merges backup into session: preferences overwrite, saved searches are added under
+// names not taken yet, up to maxSavedSearches, and the past searches join the history in the order
+// they ran. It returns what was imported and what was skipped.
+func importSession(session Session, backup sessionBackup) (imported []string, skipped []string) {
+	_, conversation := activeConversation(session)
+	preferences := backup.Preferences
+	if location, valid := loadTimezone(preferences.Timezone); valid {
+		session["timezone"] = location.String()
+		imported = append(imported, "timezone")
+	}
+	if preferences.Display != nil && displayModes[preferences.Display.Mode] && preferences.Display.Entries <= maxDisplayEntries {
+		session["display"] = *preferences.Display
+		imported = append(imported, "display")
+	}
+	if preferences.ExcludeLots {
+		conversation["excludeLots"] = true
+		imported = append(imported, "excludeLots")
+	}
+	if code := countryCode(preferences.ShipsTo); code != "" {
+		conversation["shipsTo"] = code
+		imported = append(imported, "shipsTo")
+	}
+	if preferences.SkipQuestions {
+		conversation["skipQuestions"] = true
+		imported = append(imported, "skipQuestions")
+	}
+	if overrides := validOverrides(preferences.FilterOverrides); len(overrides) > 0 {
+		conversation["filterOverrides"] = overrides
+		imported = append(imported, "filterOverrides")
+	}
+
+	searches := savedSearches(conversation)
+	names := make([]string, 0, len(backup.SavedSearches))
+	for name := range backup.SavedSearches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		saved := backup.SavedSearches[name]
+		_, taken := searches[name]
+		if saved == nil || taken || len(searches) >= maxSavedSearches {
+			skipped = append(skipped, "savedSearch "+name)
+			continue
+		}
+		searches[name] = saved
+		imported = append(imported, "savedSearch "+name)
+	}
+
+	if len(backup.SearchHistory) > 0 {
+		history := append([]pastSearch{}, searchHistory(conversation)...)
+		for _, past := range backup.SearchHistory {
+			// The searches of the session itself, exported once and imported back, are there already
+			known := false
+			for _, other := range history {
+				known = known || other.At.Equal(past.At) && other.Query.Keyword == past.Query.Keyword
+			}
+			if !known {
+				history = append(history, past)
+			}
+		}
+		sort.SliceStable(history, func(i, j int) bool { return history[i].At.Before(history[j].At) })
+		if len(history) > maxSearchHistory {
+			history = history[len(history)-maxSearchHistory:]
+		}
+		conversation["searchHistory"] = history
+		imported = append(imported, "searchHistory")
+	}
+	return imported, skipped
+}
+
+// validOverrides Keeps the overrides of filters that exist with values of the type the filter takes
+func validOverrides(overrides map[string]interface{}) map[string]interface{} {
+	valid := map[string]interface{}{}
+	for _, key := range defaultFilterKeys {
+		switch value := overrides[key].(type) {
+		case string, bool:
+			valid[key] = value
+		}
+	}
+	return valid
+}
+
+// handleSessionExport Handles GET /session/export, answering the signed backup of the caller's session
+func (s *Server) handleSessionExport(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	session, unlock, ok := s.backupSession(w, r)
+	if !ok {
+		return
+	}
+	defer unlock()
+	payload, err := json.Marshal(exportSession(session, time.Now()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "export_failed", "Couldn't export the session.")
+		return
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, JSON{
+		"version":   backupVersion,
+		"payload":   encoded,
+		"signature": s.backupSignature(encoded),
+	})
+}
+
+// handleSessionImport Handles POST /session/import, merging a backup made by handleSessionExport into the caller's session
+func (s *Server) handleSessionImport(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	session, unlock, ok := s.backupSession(w, r)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	var blob signedBackup
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBackupSize)).Decode(&blob); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", "Couldn't decode the backup.")
+		return
+	}
+	if blob.Version != backupVersion {
+		writeError(w, http.StatusBadRequest, "unsupported_backup_version", "Only backups of version "+strconv.Itoa(backupVersion)+" can be imported.")
+		return
+	}
+	expected := s.backupSignature(blob.Payload)
+	if blob.Payload == "" || !hmac.Equal([]byte(expected), []byte(blob.Signature)) {
+		writeError(w, http.StatusBadRequest, "backup_tampered", "The backup wasn't exported by this server or was changed since.")
+		return
+	}
+	var backup sessionBackup
+	payload, err := base64.RawURLEncoding.DecodeString(blob.Payload)
+	if err == nil {
+		err = json.Unmarshal(payload, &backup)
+	}
+	if err != nil || backup.Version != backupVersion {
+		writeError(w, http.StatusBadRequest, "invalid_backup", "The backup is signed but can't be read.")
+		return
+	}
+
+	imported, skipped := importSession(session, backup)
+	s.saveProfile(session)
+	writeJSON(w, JSON{
+		"imported": nonNilStrings(imported),
+		"skipped":  nonNilStrings(skipped),
+	})
+}
+
+// backupSession Returns the live session of the Authorization header locked for the caller,
+// or writes the error and reports false
+func (s *Server) backupSession(w http.ResponseWriter, r *http.Request) (Session, func(), bool) {
+	if s.backupSecret == nil {
+		writeError(w, http.StatusNotFound, "not_found", "Session backups aren't enabled.")
+		return nil, nil, false
+	}
+	uuid := r.Header.Get("Authorization")
+	if uuid == "" {
+		writeError(w, http.StatusUnauthorized, "missing_authorization", "Missing or empty Authorization header.")
+		return nil, nil, false
+	}
+	switch s.sessions.State(uuid) {
+	case sessionExpired:
+		writeError(w, http.StatusUnauthorized, "session_expired", "The session "+uuid+" expired.")
+		return nil, nil, false
+	case sessionUnknown:
+		writeError(w, http.StatusUnauthorized, "unknown_session", "No session found for: "+uuid+".")
+		return nil, nil, false
+	}
+	unlock, err := s.sessions.Lock(r.Context(), uuid)
+	if err != nil {
+		writeError(w, http.StatusConflict, "session_busy", "The previous message of this session is still being answered.")
+		return nil, nil, false
+	}
+	session, _, state := s.sessions.Get(uuid)
+	if state != sessionActive {
+		unlock()
+		writeError(w, http.StatusUnauthorized, "session_expired", "The session "+uuid+" expired.")
+		return nil, nil, false
+	}
+	return session, unlock, true
+}
+
+// nonNilStrings Returns values, or an empty slice so it encodes as [] rather than null
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,197 @@
+package theluxuryshopper
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImportSession(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	full := map[string]*savedSearch{}
+	for i := 0; i < maxSavedSearches; i++ {
+		full[string(rune('a'+i))] = &savedSearch{Query: SearchQuery{Keyword: "bag"}}
+	}
+	tests := []struct {
+		name         string
+		session      func() Session
+		backup       sessionBackup
+		wantImported []string
+		wantSkipped  []string
+		check        func(t *testing.T, session, conversation Session)
+	}{
+		{
+			name:    "preferences",
+			session: func() Session { return Session{} },
+			backup: sessionBackup{Preferences: backupPreferences{
+				Timezone: "Europe/Paris", Display: &displayPreference{Mode: displayCompact, Entries: 5}, ExcludeLots: true,
+				ShipsTo: "France", SkipQuestions: true, FilterOverrides: map[string]interface{}{"condition": "New", "topRated": true},
+			}},
+			wantImported: []string{"timezone", "display", "excludeLots", "shipsTo", "skipQuestions", "filterOverrides"},
+			check: func(t *testing.T, session, conversation Session) {
+				if session["timezone"] != "Europe/Paris" || session["display"] != (displayPreference{Mode: displayCompact, Entries: 5}) || conversation["shipsTo"] != "FR" {
+					t.Errorf("importSession() left %v", session)
+				}
+			},
+		},
+		{
+			name:    "invalid preferences",
+			session: func() Session { return Session{"timezone": "Asia/Tokyo"} },
+			backup: sessionBackup{Preferences: backupPreferences{
+				Timezone: "Mars/Olympus", Display: &displayPreference{Mode: "fancy"}, ShipsTo: "Atlantis",
+				FilterOverrides: map[string]interface{}{"condition": 3.0, "color": "red"},
+			}},
+			check: func(t *testing.T, session, conversation Session) {
+				if session["timezone"] != "Asia/Tokyo" || session["display"] != nil || conversation["filterOverrides"] != nil {
+					t.Errorf("importSession() took invalid preferences: %v", session)
+				}
+			},
+		},
+		{
+			name:    "too many display entries",
+			session: func() Session { return Session{} },
+			backup:  sessionBackup{Preferences: backupPreferences{Display: &displayPreference{Mode: displayDetailed, Entries: maxDisplayEntries + 1}}},
+		},
+		{
+			name: "saved searches",
+			session: func() Session {
+				session := Session{}
+				_, conversation := activeConversation(session)
+				savedSearches(conversation)["belts"] = &savedSearch{Query: SearchQuery{Keyword: "mine"}}
+				return session
+			},
+			backup: sessionBackup{SavedSearches: map[string]*savedSearch{
+				"belts": {Query: SearchQuery{Keyword: "theirs"}},
+				"bags":  {Query: SearchQuery{Keyword: "kelly"}},
+				"empty": nil,
+			}},
+			wantImported: []string{"savedSearch bags"},
+			wantSkipped:  []string{"savedSearch belts", "savedSearch empty"},
+			check: func(t *testing.T, session, conversation Session) {
+				if savedSearches(conversation)["belts"].Query.Keyword != "mine" {
+					t.Errorf("importSession() overwrote a saved search")
+				}
+			},
+		},
+		{
+			name: "saved searches past the limit",
+			session: func() Session {
+				session := Session{}
+				_, conversation := activeConversation(session)
+				conversation["savedSearches"] = full
+				return session
+			},
+			backup:      sessionBackup{SavedSearches: map[string]*savedSearch{"z": {}}},
+			wantSkipped: []string{"savedSearch z"},
+		},
+		{
+			name: "search history",
+			session: func() Session {
+				session := Session{}
+				_, conversation := activeConversation(session)
+				conversation["searchHistory"] = []pastSearch{{Query: SearchQuery{Keyword: "own"}, At: at.Add(time.Hour)}}
+				return session
+			},
+			backup: sessionBackup{SearchHistory: []pastSearch{
+				{Query: SearchQuery{Keyword: "own"}, At: at.Add(time.Hour)},
+				{Query: SearchQuery{Keyword: "older"}, At: at},
+				{Query: SearchQuery{Keyword: "newer"}, At: at.Add(2 * time.Hour)},
+			}},
+			wantImported: []string{"searchHistory"},
+			check: func(t *testing.T, session, conversation Session) {
+				var keywords []string
+				for _, past := range searchHistory(conversation) {
+					keywords = append(keywords, past.Query.Keyword)
+				}
+				if strings.Join(keywords, " ") != "older own newer" {
+					t.Errorf("importSession() made the history %v, want older own newer", keywords)
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			session := test.session()
+			imported, skipped := importSession(session, test.backup)
+			if !reflect.DeepEqual(imported, test.wantImported) || !reflect.DeepEqual(skipped, test.wantSkipped) {
+				t.Errorf("importSession() = %q, %q, want %q, %q", imported, skipped, test.wantImported, test.wantSkipped)
+			}
+			if test.check != nil {
+				_, conversation := activeConversation(session)
+				test.check(t, session, conversation)
+			}
+		})
+	}
+}
+
+func TestSessionBackupRoundTrip(t *testing.T) {
+	s := newTestServer(t, Config{SessionBackupSecret: "secret"}, &fakeSearcher{})
+	from, to := startConversation(t, s), startConversation(t, s)
+	session, _, _ := s.sessions.Get(from.uuid)
+	session["timezone"] = "Europe/Rome"
+	_, conversation := activeConversation(session)
+	conversation["shipsTo"] = "IT"
+	savedSearches(conversation)["belts"] = &savedSearch{Query: SearchQuery{Keyword: "gucci belt"}}
+
+	exported := do(t, s.Routes(), http.MethodGet, "/v1/session/export", "", http.Header{"Authorization": {from.uuid}})
+	if exported.Status != http.StatusOK || exported.Header.Get("Cache-Control") != "no-store" {
+		t.Fatalf("/v1/session/export answered %v %v", exported.Status, exported.Raw)
+	}
+	reply := do(t, s.Routes(), http.MethodPost, "/v1/session/import", exported.Raw, http.Header{"Authorization": {to.uuid}})
+	if want := `{"imported":["timezone","shipsTo","savedSearch belts"],"skipped":[]}`; strings.TrimSpace(reply.Raw) != want {
+		t.Errorf("/v1/session/import answered %v, want %v", reply.Raw, want)
+	}
+	imported, _, _ := s.sessions.Get(to.uuid)
+	if _, conversation := activeConversation(imported); imported["timezone"] != "Europe/Rome" || savedSearches(conversation)["belts"] == nil {
+		t.Errorf("the imported session is %v", imported)
+	}
+}
+
+func TestSessionImportRefusesBadBackups(t *testing.T) {
+	s := newTestServer(t, Config{SessionBackupSecret: "secret"}, &fakeSearcher{})
+	c := startConversation(t, s)
+	blob := func(version int, payload string, sign bool) string {
+		signature := "forged"
+		if sign {
+			signature = s.backupSignature(payload)
+		}
+		data, _ := json.Marshal(signedBackup{Version: version, Payload: payload, Signature: signature})
+		return string(data)
+	}
+	valid := do(t, s.Routes(), http.MethodGet, "/v1/session/export", "", http.Header{"Authorization": {c.uuid}})
+	payload, _ := valid.Body["payload"].(string)
+
+	tests := []struct {
+		name, uuid, body string
+		wantStatus       int
+		wantCode         string
+	}{
+		{"not json", c.uuid, "{", http.StatusBadRequest, "invalid_json"},
+		{"other version", c.uuid, blob(backupVersion+1, payload, true), http.StatusBadRequest, "unsupported_backup_version"},
+		{"forged", c.uuid, blob(backupVersion, payload, false), http.StatusBadRequest, "backup_tampered"},
+		{"empty payload", c.uuid, blob(backupVersion, "", true), http.StatusBadRequest, "backup_tampered"},
+		{"signed garbage", c.uuid, blob(backupVersion, "not-base64!", true), http.StatusBadRequest, "invalid_backup"},
+		{"no session", "", blob(backupVersion, payload, true), http.StatusUnauthorized, "missing_authorization"},
+		{"unknown session", "nope", blob(backupVersion, payload, true), http.StatusUnauthorized, "unknown_session"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := http.Header{}
+			if test.uuid != "" {
+				header.Set("Authorization", test.uuid)
+			}
+			reply := do(t, s.Routes(), http.MethodPost, "/v1/session/import", test.body, header)
+			if reply.Status != test.wantStatus || reply.errorCode() != test.wantCode {
+				t.Errorf("/v1/session/import answered %v %v, want %v %v", reply.Status, reply.Raw, test.wantStatus, test.wantCode)
+			}
+		})
+	}
+
+	disabled := newTestServer(t, Config{}, &fakeSearcher{})
+	if reply := do(t, disabled.Routes(), http.MethodGet, "/v1/session/export", "", http.Header{"Authorization": {c.uuid}}); reply.Status != http.StatusNotFound {
+		t.Errorf("/v1/session/export without SESSION_BACKUP_SECRET answered %v", reply.Status)
+	}
+}
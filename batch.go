@@ -0,0 +1,192 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// maxBatchQueries Caps the queries of one batch
+	maxBatchQueries = 50
+	// maxBatchSize Caps the body of a batch
+	maxBatchSize = 1 << 20
+	// batchWorkers Is how many queries of a batch run at once, the eBay client limits its calls on top of it
+	batchWorkers = defaultMaxConcurrent
+	// batchCallbackTimeout Bounds the delivery of an asynchronous batch result
+	batchCallbackTimeout = 10 * time.Second
+)
+
+// batchRequest Is the body of POST /batch/search. Every query takes the parameters of /search,
+// as strings, numbers or booleans.
+type batchRequest struct {
+	Queries     []map[string]interface{} `json:"queries"`
+	CallbackURL string                   `json:"callbackUrl,omitempty"`
+}
+
+// batchError Is why one query of a batch failed
+type batchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// batchResult Is the outcome of one query of a batch, in the order the queries were sent
+type batchResult struct {
+	Index      int          `json:"index"`
+	Query      *SearchQuery `json:"query,omitempty"`
+	Count      int          `json:"count"`
+	Items      []Item       `json:"items"`
+	PageURL    string       `json:"pageURL,omitempty"`
+	SearchURL  string       `json:"searchURL,omitempty"`
+	DurationMs float64      `json:"durationMs"`
+	Error      *batchError  `json:"error,omitempty"`
+}
+
+// batchOutcome Is the outcome of a whole batch
+type batchOutcome struct {
+	results   []batchResult
+	succeeded int
+	failed    int
+	duration  time.Duration
+}
+
+// reply Returns the JSON answering the batch
+func (b batchOutcome) reply() JSON {
+	return JSON{
+		"results":    b.results,
+		"succeeded":  b.succeeded,
+		"failed":     b.failed,
+		"durationMs": milliseconds(b.duration),
+	}
+}
+
+// handleBatchSearch Handles POST /batch/search, running up to maxBatchQueries searches for an admin.
+// With a callbackUrl it answers 202 right away and posts the batchReply there once every query ran.
+func (s *Server) handleBatchSearch(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if !s.authorizeAdmin(w, r, "Batch searches") {
+		return
+	}
+
+	var request batchRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBatchSize)).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", fmt.Sprintf("Couldn't decode JSON: %v.", err))
+		return
+	}
+	if len(request.Queries) == 0 || len(request.Queries) > maxBatchQueries {
+		writeError(w, http.StatusBadRequest, "invalid_batch", "A batch holds 1 to "+strconv.Itoa(maxBatchQueries)+" queries.")
+		return
+	}
+	if request.CallbackURL != "" {
+		if u, err := url.Parse(request.CallbackURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			writeError(w, http.StatusBadRequest, "invalid_batch", "callbackUrl must be an http or https URL.")
+			return
+		}
+		batchID := newUUID()[:16]
+		go s.deliverBatch(batchID, request)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(JSON{"batchId": batchID, "queries": len(request.Queries), "callbackUrl": request.CallbackURL})
+		return
+	}
+	writeJSON(w, s.runBatch(r.Context(), request.Queries).reply())
+}
+
+// deliverBatch Runs the queries of request and posts the result to its callbackUrl
+func (s *Server) deliverBatch(batchID string, request batchRequest) {
+	reply := s.runBatch(context.Background(), request.Queries).reply()
+	reply["batchId"] = batchID
+	body, err := json.Marshal(reply)
+	if err != nil {
+		log.Printf("couldn't encode batch %v: %v", batchID, err)
+		return
+	}
+	client := &http.Client{Timeout: batchCallbackTimeout}
+	res, err := client.Post(request.CallbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("couldn't deliver batch %v: %v", batchID, err)
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode >= 300 {
+		log.Printf("couldn't deliver batch %v: callback answered %v", batchID, res.Status)
+	}
+}
+
+// runBatch Searches every query with batchWorkers workers. A query that is invalid or fails only fails its own result.
+func (s *Server) runBatch(ctx context.Context, queries []map[string]interface{}) batchOutcome {
+	start := time.Now()
+	results := make([]batchResult, len(queries))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < batchWorkers && worker < len(queries); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = s.batchSearch(ctx, i, queries[i])
+			}
+		}()
+	}
+	for i := range queries {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	outcome := batchOutcome{results: results, duration: time.Since(start)}
+	for _, result := range results {
+		if result.Error != nil {
+			outcome.failed++
+		} else {
+			outcome.succeeded++
+		}
+	}
+	return outcome
+}
+
+// batchSearch Runs query number index of a batch
+func (s *Server) batchSearch(ctx context.Context, index int, parameters map[string]interface{}) batchResult {
+	start := time.Now()
+	result := batchResult{Index: index, Items: []Item{}}
+	fail := func(code, message string) batchResult {
+		result.Error = &batchError{Code: code, Message: message}
+		result.DurationMs = milliseconds(time.Since(start))
+		return result
+	}
+
+	values := url.Values{}
+	for key, value := range parameters {
+		values.Set(key, fmt.Sprint(value))
+	}
+	query, err := ParseSearchQuery(values)
+	if err != nil {
+		return fail("invalid_query", err.Error())
+	}
+	result.Query = &query
+	if allowed, reason := s.allowKeyword(query.Keyword); !allowed {
+		return fail("keyword_blocked", strings.TrimSpace("Sorry, I can't search for that. "+reason))
+	}
+
+	found, err := s.search(ctx, query)
+	if err != nil {
+		_, code, message, _ := describeSearchError(err)
+		return fail(code, message)
+	}
+	result.Count, result.PageURL, result.SearchURL = found.Count, found.PageURL, found.SearchURL
+	if found.Items != nil {
+		result.Items = found.Items
+	}
+	result.DurationMs = milliseconds(time.Since(start))
+	return result
+}
@@ -0,0 +1,108 @@
+package theluxuryshopper
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchSearch(t *testing.T) {
+	searcher := &fakeSearcher{
+		results: map[string]SearchResult{"": {Items: testItems("bag", 1, 2)}},
+		errs:    map[string]error{"broken": errors.New("connection reset")},
+	}
+	s := newTestServer(t, Config{AdminToken: "secret", KeywordBlocklist: []string{"replica"}}, searcher)
+	body := `{"queries": [
+		{"keyword": "kelly bag", "entries": 2},
+		{"condition": "new"},
+		{"keyword": "replica bag"},
+		{"keyword": "broken", "maxPrice": 100}
+	]}`
+	reply := do(t, s.Routes(), http.MethodPost, "/v1/batch/search", body, http.Header{"Authorization": {"Bearer secret"}})
+	if reply.Status != http.StatusOK {
+		t.Fatalf("/v1/batch/search answered %v %v", reply.Status, reply.Raw)
+	}
+	var got struct {
+		Results   []batchResult `json:"results"`
+		Succeeded int           `json:"succeeded"`
+		Failed    int           `json:"failed"`
+	}
+	json.Unmarshal([]byte(reply.Raw), &got)
+	if got.Succeeded != 1 || got.Failed != 3 || len(got.Results) != 4 {
+		t.Fatalf("/v1/batch/search answered %v", reply.Raw)
+	}
+
+	wantCodes := []string{"", "invalid_query", "keyword_blocked", "upstream_unavailable"}
+	for i, result := range got.Results {
+		code := ""
+		if result.Error != nil {
+			code = result.Error.Code
+		}
+		if result.Index != i || code != wantCodes[i] || result.Items == nil {
+			t.Errorf("result %v = %+v, want index %v and error %q", i, result, i, wantCodes[i])
+		}
+	}
+	if first := got.Results[0]; first.Count != 2 || first.Query == nil || first.Query.Entries != 2 {
+		t.Errorf("the first result is %+v", first)
+	}
+	if len(searcher.Queries()) != 2 {
+		t.Errorf("the batch searched %v queries, want the 2 valid ones", len(searcher.Queries()))
+	}
+}
+
+func TestBatchSearchRefusesBadBatches(t *testing.T) {
+	s := newTestServer(t, Config{AdminToken: "secret"}, &fakeSearcher{})
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+	tooMany := `{"queries": [` + strings.Repeat(`{"keyword": "bag"},`, maxBatchQueries) + `{"keyword": "bag"}]}`
+	tests := []struct {
+		name, body string
+		header     http.Header
+		wantStatus int
+		wantCode   string
+	}{
+		{"no admin token", `{"queries": [{"keyword": "bag"}]}`, nil, http.StatusUnauthorized, "invalid_admin_token"},
+		{"not json", `{"queries":`, admin, http.StatusBadRequest, "invalid_json"},
+		{"no queries", `{"queries": []}`, admin, http.StatusBadRequest, "invalid_batch"},
+		{"too many queries", tooMany, admin, http.StatusBadRequest, "invalid_batch"},
+		{"relative callback", `{"queries": [{"keyword": "bag"}], "callbackUrl": "/done"}`, admin, http.StatusBadRequest, "invalid_batch"},
+		{"ftp callback", `{"queries": [{"keyword": "bag"}], "callbackUrl": "ftp://example.com/done"}`, admin, http.StatusBadRequest, "invalid_batch"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reply := do(t, s.Routes(), http.MethodPost, "/v1/batch/search", test.body, test.header)
+			if reply.Status != test.wantStatus || reply.errorCode() != test.wantCode {
+				t.Errorf("/v1/batch/search answered %v %v, want %v %v", reply.Status, reply.Raw, test.wantStatus, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestBatchSearchCallback(t *testing.T) {
+	delivered := make(chan JSON, 1)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body JSON
+		json.NewDecoder(r.Body).Decode(&body)
+		delivered <- body
+	}))
+	defer callback.Close()
+
+	s := newTestServer(t, Config{AdminToken: "secret"}, &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 1)}}})
+	body := `{"queries": [{"keyword": "kelly bag"}], "callbackUrl": "` + callback.URL + `"}`
+	reply := do(t, s.Routes(), http.MethodPost, "/v1/batch/search", body, http.Header{"Authorization": {"Bearer secret"}})
+	batchID, _ := reply.Body["batchId"].(string)
+	if reply.Status != http.StatusAccepted || batchID == "" {
+		t.Fatalf("/v1/batch/search with a callbackUrl answered %v %v", reply.Status, reply.Raw)
+	}
+	select {
+	case body := <-delivered:
+		if body["batchId"] != batchID || body["succeeded"] != 1.0 {
+			t.Errorf("the callback got %v", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the batch was never delivered")
+	}
+}
@@ -0,0 +1,100 @@
+package theluxuryshopper
+
+import (
+	"bufio"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// KeywordFilter Decides whether a keyword may be searched, reason is shown to the user when it may not
+type KeywordFilter func(keyword string) (allowed bool, reason string)
+
+// keywordBlocklist Matches keywords against disallowed terms.
+// Terms match whole words case-insensitively, "*" stands for any run of
+// letters or digits and "?" for a single one.
+type keywordBlocklist struct {
+	terms    []string
+	patterns []*regexp.Regexp
+}
+
+// newKeywordBlocklist Compiles terms into a keywordBlocklist
+func newKeywordBlocklist(terms []string) *keywordBlocklist {
+	blocklist := &keywordBlocklist{}
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		pattern := regexp.QuoteMeta(term)
+		pattern = strings.Replace(pattern, `\*`, `\w*`, -1)
+		pattern = strings.Replace(pattern, `\?`, `\w`, -1)
+		pattern = strings.Replace(pattern, " ", `\s+`, -1)
+		blocklist.terms = append(blocklist.terms, term)
+		blocklist.patterns = append(blocklist.patterns, regexp.MustCompile(`(?i)(^|\W)`+pattern+`($|\W)`))
+	}
+	return blocklist
+}
+
+// Match Returns the first blocked term found in keyword
+func (b *keywordBlocklist) Match(keyword string) (term string, found bool) {
+	for i, pattern := range b.patterns {
+		if pattern.MatchString(keyword) {
+			return b.terms[i], true
+		}
+	}
+	return "", false
+}
+
+// readBlocklistFile Reads one term per line from path, skipping blank lines and # comments
+func readBlocklistFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var terms []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	return terms, scanner.Err()
+}
+
+// WithKeywordFilter Consults filter for every keyword after the configured blocklist
+func WithKeywordFilter(filter KeywordFilter) ServerOption {
+	return func(s *Server) {
+		s.keywordFilter = filter
+	}
+}
+
+// allowKeyword Checks keyword against the blocklist and the KeywordFilter hook
+func (s *Server) allowKeyword(keyword string) (bool, string) {
+	if _, blocked := s.blocklist.Match(keyword); blocked {
+		return false, ""
+	}
+	if s.keywordFilter != nil {
+		return s.keywordFilter(keyword)
+	}
+	return true, ""
+}
+
+// refuseKeyword Replies to a disallowed keyword and starts over
+func refuseKeyword(reason string, session Session, w http.ResponseWriter) {
+	response := "Sorry, I can't search for that."
+	if reason != "" {
+		response += " " + reason
+	}
+	response += "\n What else would you like to search for? "
+	WriteReply(w, ReplyQuestion, JSON{
+		"message": response,
+		"step":    "keyword",
+	})
+	resetSession(session)
+}
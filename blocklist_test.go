@@ -0,0 +1,71 @@
+package theluxuryshopper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestKeywordBlocklist(t *testing.T) {
+	blocklist := newKeywordBlocklist([]string{"replica", "fake*", "r?plica", "mirror copy", "  ", "c++"})
+	tests := []struct {
+		keyword  string
+		wantTerm string
+		blocked  bool
+	}{
+		{"gucci belt", "", false},
+		{"Gucci REPLICA belt", "replica", true},
+		{"fakes of rolex", "fake*", true},
+		{"rolex rEplica", "replica", true},
+		{"rolex roplica", "r?plica", true},
+		{"mirror   copy watch", "mirror copy", true},
+		{"mirrorcopy watch", "", false},
+		{"replicas", "", false},
+		{"learn c++ book", "c++", true},
+	}
+	for _, test := range tests {
+		term, blocked := blocklist.Match(test.keyword)
+		if term != test.wantTerm || blocked != test.blocked {
+			t.Errorf("Match(%q) = %q, %v, want %q, %v", test.keyword, term, blocked, test.wantTerm, test.blocked)
+		}
+	}
+}
+
+func TestReadBlocklistFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(path, []byte("# counterfeits\nreplica\n\n  fake*  \n#mirror\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	terms, err := readBlocklistFile(path)
+	if err != nil || !reflect.DeepEqual(terms, []string{"replica", "fake*"}) {
+		t.Errorf("readBlocklistFile() = %q, %v", terms, err)
+	}
+	if _, err := readBlocklistFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("readBlocklistFile() of a missing file didn't fail")
+	}
+}
+
+func TestAllowKeyword(t *testing.T) {
+	filter := func(keyword string) (bool, string) {
+		if keyword == "fur coat" {
+			return false, "We don't list fur."
+		}
+		return true, ""
+	}
+	s := newTestServer(t, Config{KeywordBlocklist: []string{"replica"}}, &fakeSearcher{}, WithKeywordFilter(filter))
+	tests := []struct {
+		keyword    string
+		allowed    bool
+		wantReason string
+	}{
+		{"gucci belt", true, ""},
+		{"replica belt", false, ""},
+		{"fur coat", false, "We don't list fur."},
+	}
+	for _, test := range tests {
+		if allowed, reason := s.allowKeyword(test.keyword); allowed != test.allowed || reason != test.wantReason {
+			t.Errorf("allowKeyword(%q) = %v, %q, want %v, %q", test.keyword, allowed, reason, test.allowed, test.wantReason)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package theluxuryshopper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// defaultBot Names the bot of the deployment itself, the one the routes outside of /bots serve
+const defaultBot = "default"
+
+// botName Matches the names of the bots of BOTS_FILE as they appear in /bots/:name
+var botName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// BotProfile Is one white-labeled bot of BOTS_FILE. The fields it leaves empty keep the settings of the
+// deployment; sessions, the eBay client and its quota are shared by every bot.
+type BotProfile struct {
+	BrandName        string             `json:"brandName"`
+	BrandAccentColor string             `json:"brandAccentColor"`
+	DefaultFilters   *botDefaultFilters `json:"defaultFilters"`
+	Steps            []string           `json:"steps"`
+	NoneSynonyms     []string           `json:"noneSynonyms"`
+	Ranking          *RankingWeights    `json:"ranking"`
+}
+
+// botDefaultFilters Are the DefaultFilters of a bot as BOTS_FILE writes them
+type botDefaultFilters struct {
+	Condition    string `json:"condition"`
+	MinPrice     string `json:"minPrice"`
+	MaxPrice     string `json:"maxPrice"`
+	Site         string `json:"site"`
+	FreeShipping bool   `json:"freeShipping"`
+	TopRated     bool   `json:"topRated"`
+}
+
+// readBotsFile Reads the profiles of path, a JSON object of profiles by bot name
+func readBotsFile(path string) (map[string]BotProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var bots map[string]BotProfile
+	if err := json.Unmarshal(data, &bots); err != nil {
+		return nil, err
+	}
+	for name, profile := range bots {
+		if name == defaultBot || !botName.MatchString(name) {
+			return nil, fmt.Errorf("%q isn't a bot name, use up to 32 lowercase letters, digits and dashes other than %q", name, defaultBot)
+		}
+		if profile.BrandAccentColor != "" && !brandColor.MatchString(profile.BrandAccentColor) {
+			return nil, fmt.Errorf("bot %v: brandAccentColor must be a color like #c48843, not %q", name, profile.BrandAccentColor)
+		}
+		if profile.Steps != nil {
+			if _, err := parseSteps(strings.Join(profile.Steps, ",")); err != nil {
+				return nil, fmt.Errorf("bot %v: %v", name, err)
+			}
+		}
+		if profile.DefaultFilters != nil {
+			if _, err := profile.DefaultFilters.filters(); err != nil {
+				return nil, fmt.Errorf("bot %v: %v", name, err)
+			}
+		}
+	}
+	return bots, nil
+}
+
+// filters Validates the default filters like the DEFAULT_ variables are
+func (f botDefaultFilters) filters() (DefaultFilters, error) {
+	defaults := DefaultFilters{Site: strings.ToUpper(f.Site), FreeShipping: f.FreeShipping, TopRated: f.TopRated}
+	if f.Condition != "" {
+		if defaults.Condition = normalizeCondition(f.Condition); defaults.Condition == "" {
+			return defaults, fmt.Errorf("the default condition must be New, Used or None, not %q", f.Condition)
+		}
+	}
+	if f.MinPrice != "" {
+		if defaults.MinPrice = normalizePrice(f.MinPrice); defaults.MinPrice == "" {
+			return defaults, fmt.Errorf("the default minPrice must be a number, not %q", f.MinPrice)
+		}
+	}
+	if f.MaxPrice != "" {
+		if defaults.MaxPrice = normalizePrice(f.MaxPrice); defaults.MaxPrice == "" {
+			return defaults, fmt.Errorf("the default maxPrice must be a number, not %q", f.MaxPrice)
+		}
+	}
+	return defaults, nil
+}
+
+// apply Returns config with the settings of the profile
+func (p BotProfile) apply(config Config) Config {
+	if p.BrandName != "" {
+		config.BrandName = p.BrandName
+	}
+	if p.BrandAccentColor != "" {
+		config.BrandAccentColor = p.BrandAccentColor
+	}
+	if p.DefaultFilters != nil {
+		config.DefaultFilters, _ = p.DefaultFilters.filters()
+	}
+	if p.Steps != nil {
+		config.Steps, _ = parseSteps(strings.Join(p.Steps, ","))
+	}
+	if p.NoneSynonyms != nil {
+		config.NoneSynonyms = p.NoneSynonyms
+	}
+	if p.Ranking != nil {
+		config.Ranking = *p.Ranking
+	}
+	return config
+}
+
+// newBot Creates the server of the bot name, sharing everything but its settings and processor with s
+func (s *Server) newBot(name string, profile BotProfile) *Server {
+	config := profile.apply(s.config)
+	// What these would start is shared from s below
+	config.Bots, config.QuotaFile, config.SearchWebhookURL, config.FunnelFlushInterval, config.TraceEndpoint, config.Chaos = nil, "", "", 0, "", ChaosConfig{}
+	config.AuditLogFile = ""
+	bot := NewServer(config, WithSessionStore(s.sessions), WithSearcher(s.searcher))
+	bot.botName = name
+	bot.tracer, bot.events, bot.quota, bot.throttle = s.tracer, s.events, s.quota, s.throttle
+	bot.prices, bot.profiles, bot.apiKeys, bot.funnel, bot.feedback = s.prices, s.profiles, s.apiKeys, s.funnel, s.feedback
+	bot.jobs, bot.webhook, bot.images, bot.imageClient, bot.keywordFilter, bot.messageFilter = s.jobs, s.webhook, s.images, s.imageClient, s.keywordFilter, s.messageFilter
+	bot.maintenance, bot.audit, bot.resultProcessors = s.maintenance, s.audit, s.resultProcessors
+	return bot
+}
+
+// Bot Returns the server of the bot name of BOTS_FILE, to give it its own processor, or nil if there is
+// none. The default bot is s itself.
+func (s *Server) Bot(name string) *Server {
+	if name == defaultBot {
+		return s
+	}
+	return s.bots[name]
+}
+
+// ownBot Reports whether session was started by the bot of s, sessions of before the bots are the default one's
+func (s *Server) ownBot(session Session) bool {
+	return s.ownBotName(session.GetString("bot", ""))
+}
+
+// ownBotName Reports whether bot, "" for the default one, is the bot of s
+func (s *Server) ownBotName(bot string) bool {
+	if bot == "" {
+		bot = defaultBot
+	}
+	return bot == s.bot()
+}
+
+// bot Returns the name of the bot s serves
+func (s *Server) bot() string {
+	if s.botName == "" {
+		return defaultBot
+	}
+	return s.botName
+}
+
+// botRoute Serves a /bots/:name route with the handler handler picks on the server of the bot
+func (s *Server) botRoute(handler func(bot *Server) httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		bot := s.Bot(ps.ByName("name"))
+		if bot == nil {
+			writeError(w, http.StatusNotFound, "unknown_bot", "No bot is named "+ps.ByName("name")+".")
+			return
+		}
+		handler(bot)(w, r, ps)
+	}
+}
+
+// countBotRequest Counts a welcome or chat request of the bot of s for /healthz
+func (s *Server) countBotRequest() {
+	atomic.AddInt64(&s.botRequests, 1)
+}
+
+// botRequestCounts Returns the welcome and chat requests of every bot by name
+func (s *Server) botRequestCounts() map[string]int64 {
+	counts := map[string]int64{defaultBot: atomic.LoadInt64(&s.botRequests)}
+	for name, bot := range s.bots {
+		counts[name] = atomic.LoadInt64(&bot.botRequests)
+	}
+	return counts
+}
@@ -0,0 +1,105 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadBotsFile(t *testing.T) {
+	tests := []struct {
+		name, contents string
+		wantErr        string
+	}{
+		{"valid", `{"acme": {"brandName": "Acme", "brandAccentColor": "#c48843", "steps": ["condition"], "defaultFilters": {"condition": "used", "maxPrice": "$500"}}}`, ""},
+		{"empty", `{}`, ""},
+		{"not json", `{"acme": `, "unexpected end"},
+		{"default name", `{"default": {}}`, "isn't a bot name"},
+		{"uppercase name", `{"Acme": {}}`, "isn't a bot name"},
+		{"long name", `{"` + strings.Repeat("a", 33) + `": {}}`, "isn't a bot name"},
+		{"bad color", `{"acme": {"brandAccentColor": "orange"}}`, "brandAccentColor"},
+		{"bad step", `{"acme": {"steps": ["colour"]}}`, "bot acme"},
+		{"bad condition", `{"acme": {"defaultFilters": {"condition": "mint"}}}`, "default condition"},
+		{"bad price", `{"acme": {"defaultFilters": {"minPrice": "cheap"}}}`, "default minPrice"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bots.json")
+			os.WriteFile(path, []byte(test.contents), 0o600)
+			_, err := readBotsFile(path)
+			if test.wantErr == "" && err != nil || test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)) {
+				t.Errorf("readBotsFile() = %v, want an error containing %q", err, test.wantErr)
+			}
+		})
+	}
+	if _, err := readBotsFile(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("readBotsFile() of a missing file = %v", err)
+	}
+}
+
+func TestBotProfileApply(t *testing.T) {
+	base := Config{BrandName: "Shop", BrandAccentColor: "#000000", NoneSynonyms: []string{"any"}, Steps: []string{"condition", "minPrice", "maxPrice"}}
+	tests := []struct {
+		name    string
+		profile BotProfile
+		want    Config
+	}{
+		{"empty keeps everything", BotProfile{}, base},
+		{
+			"brand",
+			BotProfile{BrandName: "Acme", BrandAccentColor: "#ffffff"},
+			Config{BrandName: "Acme", BrandAccentColor: "#ffffff", NoneSynonyms: base.NoneSynonyms, Steps: base.Steps},
+		},
+		{
+			"questions",
+			BotProfile{Steps: []string{"maxPrice"}, NoneSynonyms: []string{}, DefaultFilters: &botDefaultFilters{Condition: "new", Site: "ebay-de"}},
+			Config{BrandName: "Shop", BrandAccentColor: "#000000", NoneSynonyms: []string{}, Steps: []string{"maxPrice"}, DefaultFilters: DefaultFilters{Condition: "New", Site: "EBAY-DE"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.profile.apply(base); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("apply() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBotsKeepTheirSessions(t *testing.T) {
+	s := newTestServer(t, Config{Bots: map[string]BotProfile{"acme": {BrandName: "Acme"}}}, &fakeSearcher{})
+	if s.Bot(defaultBot) != s.Server || s.Bot("acme") == nil || s.Bot("other") != nil {
+		t.Fatal("Bot() doesn't return the bots of the config")
+	}
+
+	welcome := do(t, s.Routes(), http.MethodGet, "/v1/bots/acme/welcome", "", nil)
+	uuid, _ := welcome.Body["uuid"].(string)
+	if welcome.Status != http.StatusOK || !strings.HasPrefix(welcome.message(), "Welcome to Acme.") {
+		t.Fatalf("/v1/bots/acme/welcome answered %v %v", welcome.Status, welcome.Raw)
+	}
+
+	tests := []struct {
+		name, target string
+		wantStatus   int
+		wantCode     string
+	}{
+		{"own bot", "/v1/bots/acme/chat", http.StatusOK, ""},
+		{"default bot", "/v1/chat", http.StatusUnauthorized, "unknown_session"},
+		{"unknown bot", "/v1/bots/other/chat", http.StatusNotFound, "unknown_bot"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reply := do(t, s.Routes(), http.MethodPost, test.target, `{"message": "gucci belt"}`, http.Header{"Authorization": {uuid}})
+			if reply.Status != test.wantStatus || reply.errorCode() != test.wantCode {
+				t.Errorf("%v answered %v %v, want %v %v", test.target, reply.Status, reply.Raw, test.wantStatus, test.wantCode)
+			}
+		})
+	}
+
+	health := do(t, s.Routes(), http.MethodGet, "/healthz", "", nil)
+	if counts, _ := health.Body["botRequests"].(map[string]interface{}); counts["acme"] != 2.0 || counts[defaultBot] != 1.0 {
+		t.Errorf("/healthz counted the bot requests %v, want 2 for acme and 1 for the default bot", health.Body["botRequests"])
+	}
+}
@@ -0,0 +1,29 @@
+package theluxuryshopper
+
+import "regexp"
+
+const (
+	// defaultBrandName Is the name the chatbot greets with unless BRAND_NAME says otherwise
+	defaultBrandName = "The Luxury Shopper"
+	// defaultBrandAccentColor Is the gold of the pages unless BRAND_ACCENT_COLOR says otherwise
+	defaultBrandAccentColor = "#c48843"
+)
+
+// brandColor Matches the #rgb and #rrggbb colors BRAND_ACCENT_COLOR accepts
+var brandColor = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// brandName Returns the name of the deployment, for embedders leaving Config.BrandName empty too
+func (s *Server) brandName() string {
+	if s.config.BrandName == "" {
+		return defaultBrandName
+	}
+	return s.config.BrandName
+}
+
+// brandAccentColor Returns the accent color of the pages of the deployment
+func (s *Server) brandAccentColor() string {
+	if !brandColor.MatchString(s.config.BrandAccentColor) {
+		return defaultBrandAccentColor
+	}
+	return s.config.BrandAccentColor
+}
@@ -0,0 +1,63 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBrand(t *testing.T) {
+	tests := []struct {
+		name                string
+		config              Config
+		wantName, wantColor string
+	}{
+		{"defaults", Config{}, defaultBrandName, defaultBrandAccentColor},
+		{"configured", Config{BrandName: "Acme & Sons", BrandAccentColor: "#1a2b3c"}, "Acme & Sons", "#1a2b3c"},
+		{"short color", Config{BrandAccentColor: "#FFF"}, defaultBrandName, "#FFF"},
+		// Embedders skip validate, the pages never get a color that could break out of the style
+		{"invalid color", Config{BrandAccentColor: "red;background:url(x)"}, defaultBrandName, defaultBrandAccentColor},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Server{config: test.config}
+			if got := s.brandName(); got != test.wantName {
+				t.Errorf("brandName() = %q, want %q", got, test.wantName)
+			}
+			if got := s.brandAccentColor(); got != test.wantColor {
+				t.Errorf("brandAccentColor() = %q, want %q", got, test.wantColor)
+			}
+		})
+	}
+}
+
+func TestValidateBrandAccentColor(t *testing.T) {
+	tests := []struct {
+		color   string
+		wantErr bool
+	}{
+		{"", false},
+		{"#c48843", false},
+		{"#abc", false},
+		{"c48843", true},
+		{"#c4884", true},
+		{"gold", true},
+	}
+	for _, test := range tests {
+		config := Config{EbayEnv: "production", BrandAccentColor: test.color}
+		if err := config.validate(); (err != nil) != test.wantErr {
+			t.Errorf("validate() with BRAND_ACCENT_COLOR %q = %v, want an error: %v", test.color, err, test.wantErr)
+		}
+	}
+}
+
+func TestBrandedPages(t *testing.T) {
+	s := newTestServer(t, Config{BrandName: "<Acme>", BrandAccentColor: "#123456"}, &fakeSearcher{})
+	page := do(t, s.Routes(), http.MethodGet, "/", "", nil).Raw
+	if !strings.Contains(page, "<title>&lt;Acme&gt;</title>") || !strings.Contains(page, "color: #123456;") {
+		t.Errorf("/ answered %v", page)
+	}
+	if reply := do(t, s.Routes(), http.MethodGet, "/v1/welcome", "", nil); !strings.HasPrefix(reply.message(), "Welcome to <Acme>.") {
+		t.Errorf("/v1/welcome answered %v", reply.Raw)
+	}
+}
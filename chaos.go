@@ -0,0 +1,149 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Faults a chaosSearcher injects, by the name they are logged and counted under
+const (
+	chaosLatency       = "latency"
+	chaosServerError   = "server_error"
+	chaosMalformedJSON = "malformed_json"
+	chaosThrottle      = "throttle"
+)
+
+// defaultChaosLatency Is the delay CHAOS_LATENCY adds when CHAOS_LATENCY_DURATION isn't set
+const defaultChaosLatency = 2 * time.Second
+
+// ChaosConfig Holds the probabilities, from 0 to 1, of the faults injected into the searches to test
+// how the server copes with a failing eBay. It is refused in production.
+type ChaosConfig struct {
+	Latency         float64       // CHAOS_LATENCY, delays the search by LatencyDuration
+	LatencyDuration time.Duration // CHAOS_LATENCY_DURATION
+	ServerError     float64       // CHAOS_SERVER_ERROR, fails the search like a 5xx answer
+	MalformedJSON   float64       // CHAOS_MALFORMED_JSON, fails the search like an undecodable answer
+	Throttle        float64       // CHAOS_THROTTLE, fails the search like eBay throttling the app id
+	Seed            int64         // CHAOS_SEED, makes the faults repeatable when not 0
+}
+
+// Enabled Reports whether any fault may be injected
+func (c ChaosConfig) Enabled() bool {
+	return c.Latency > 0 || c.ServerError > 0 || c.MalformedJSON > 0 || c.Throttle > 0
+}
+
+// chaosFromEnv Reads the CHAOS_ variables
+func chaosFromEnv() (ChaosConfig, error) {
+	chaos := ChaosConfig{LatencyDuration: defaultChaosLatency}
+	for name, probability := range map[string]*float64{
+		"CHAOS_LATENCY":        &chaos.Latency,
+		"CHAOS_SERVER_ERROR":   &chaos.ServerError,
+		"CHAOS_MALFORMED_JSON": &chaos.MalformedJSON,
+		"CHAOS_THROTTLE":       &chaos.Throttle,
+	} {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				return chaos, fmt.Errorf("%v must be a probability from 0 to 1, not %q", name, value)
+			}
+			*probability = parsed
+		}
+	}
+	if value := os.Getenv("CHAOS_LATENCY_DURATION"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return chaos, fmt.Errorf("CHAOS_LATENCY_DURATION must be a duration like 2s, not %q", value)
+		}
+		chaos.LatencyDuration = parsed
+	}
+	if value := os.Getenv("CHAOS_SEED"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return chaos, fmt.Errorf("CHAOS_SEED must be an integer, not %q", value)
+		}
+		chaos.Seed = parsed
+	}
+	return chaos, nil
+}
+
+// errChaosInProduction Is returned for chaos settings on a server searching production eBay
+var errChaosInProduction = errors.New("the CHAOS_ variables are refused with EBAY_ENV=production, use the sandbox")
+
+// chaosSearcher Is a Searcher injecting faults in front of another one, so the code paths of a
+// server without chaos settings never see it
+type chaosSearcher struct {
+	next   Searcher
+	config ChaosConfig
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	injected map[string]int64
+}
+
+// newChaosSearcher Wraps next with the faults of config
+func newChaosSearcher(next Searcher, config ChaosConfig) *chaosSearcher {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &chaosSearcher{next: next, config: config, rng: rand.New(rand.NewSource(seed)), injected: map[string]int64{}}
+}
+
+// roll Reports whether the fault of probability happens this time, counting and logging it when it does
+func (c *chaosSearcher) roll(fault string, probability float64) bool {
+	if probability <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	hit := c.rng.Float64() < probability
+	if hit {
+		c.injected[fault]++
+	}
+	c.mu.Unlock()
+	if hit {
+		log.Printf("chaos: injecting %v", fault)
+	}
+	return hit
+}
+
+// Search Delays or fails the search as rolled, and searches next otherwise.
+// The failures are the errors ebayClient returns for the same faults.
+func (c *chaosSearcher) Search(ctx context.Context, query SearchQuery) (SearchResult, error) {
+	if c.roll(chaosLatency, c.config.Latency) {
+		select {
+		case <-time.After(c.config.LatencyDuration):
+		case <-ctx.Done():
+			return SearchResult{}, &upstreamError{category: failureDeadline, err: ctx.Err()}
+		}
+	}
+	switch {
+	case c.roll(chaosServerError, c.config.ServerError):
+		return SearchResult{}, &upstreamError{category: failureOther, err: errors.New("eBay answered 503 Service Unavailable (injected)")}
+	case c.roll(chaosMalformedJSON, c.config.MalformedJSON):
+		var response findingResponse
+		err := json.Unmarshal([]byte(`{"findItemsAdvancedResponse":[`), &response)
+		return SearchResult{}, fmt.Errorf("couldn't decode eBay response: %v", err)
+	case c.roll(chaosThrottle, c.config.Throttle):
+		return SearchResult{}, &throttled{message: "Call usage limit has been reached (injected)", retryAfter: time.Minute}
+	}
+	return c.next.Search(ctx, query)
+}
+
+// Injected Returns how many times each fault was injected
+func (c *chaosSearcher) Injected() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	injected := make(map[string]int64, len(c.injected))
+	for fault, count := range c.injected {
+		injected[fault] = count
+	}
+	return injected
+}
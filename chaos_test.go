@@ -0,0 +1,123 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChaosFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    ChaosConfig
+		wantErr string
+	}{
+		{"unset", nil, ChaosConfig{LatencyDuration: defaultChaosLatency}, ""},
+		{
+			"every fault",
+			map[string]string{"CHAOS_LATENCY": "0.5", "CHAOS_LATENCY_DURATION": "300ms", "CHAOS_SERVER_ERROR": "0.1", "CHAOS_MALFORMED_JSON": "1", "CHAOS_THROTTLE": "0", "CHAOS_SEED": "42"},
+			ChaosConfig{Latency: 0.5, LatencyDuration: 300 * time.Millisecond, ServerError: 0.1, MalformedJSON: 1, Seed: 42},
+			"",
+		},
+		{"probability above 1", map[string]string{"CHAOS_THROTTLE": "1.5"}, ChaosConfig{}, "CHAOS_THROTTLE"},
+		{"negative probability", map[string]string{"CHAOS_SERVER_ERROR": "-0.1"}, ChaosConfig{}, "CHAOS_SERVER_ERROR"},
+		{"not a number", map[string]string{"CHAOS_LATENCY": "often"}, ChaosConfig{}, "CHAOS_LATENCY"},
+		{"bad duration", map[string]string{"CHAOS_LATENCY_DURATION": "2"}, ChaosConfig{}, "CHAOS_LATENCY_DURATION"},
+		{"bad seed", map[string]string{"CHAOS_SEED": "1.5"}, ChaosConfig{}, "CHAOS_SEED"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{"CHAOS_LATENCY", "CHAOS_LATENCY_DURATION", "CHAOS_SERVER_ERROR", "CHAOS_MALFORMED_JSON", "CHAOS_THROTTLE", "CHAOS_SEED"} {
+				t.Setenv(name, test.env[name])
+			}
+			got, err := chaosFromEnv()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("chaosFromEnv() = %v, want an error naming %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Errorf("chaosFromEnv() = %+v, %v, want %+v", got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestChaosRefusedInProduction(t *testing.T) {
+	tests := []struct {
+		env     string
+		chaos   ChaosConfig
+		wantErr bool
+	}{
+		{"production", ChaosConfig{}, false},
+		{"production", ChaosConfig{Throttle: 0.1}, true},
+		{"sandbox", ChaosConfig{Throttle: 0.1}, false},
+	}
+	for _, test := range tests {
+		config := Config{EbayEnv: test.env, Chaos: test.chaos}
+		if err := config.validate(); (err == errChaosInProduction) != test.wantErr {
+			t.Errorf("validate() of %+v on %v = %v, want errChaosInProduction: %v", test.chaos, test.env, err, test.wantErr)
+		}
+	}
+}
+
+func TestChaosSearcher(t *testing.T) {
+	next := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 1)}}}
+	tests := []struct {
+		name      string
+		config    ChaosConfig
+		check     func(err error) bool
+		wantFault string
+	}{
+		{"no faults", ChaosConfig{}, func(err error) bool { return err == nil }, ""},
+		{"server error", ChaosConfig{ServerError: 1}, func(err error) bool { _, ok := err.(*upstreamError); return ok }, chaosServerError},
+		{"malformed json", ChaosConfig{MalformedJSON: 1}, func(err error) bool {
+			return err != nil && strings.HasPrefix(err.Error(), "couldn't decode eBay response")
+		}, chaosMalformedJSON},
+		{"throttle", ChaosConfig{Throttle: 1}, func(err error) bool { _, ok := err.(*throttled); return ok }, chaosThrottle},
+		{"latency", ChaosConfig{Latency: 1, LatencyDuration: time.Millisecond}, func(err error) bool { return err == nil }, chaosLatency},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chaos := newChaosSearcher(next, test.config)
+			if _, err := chaos.Search(context.Background(), SearchQuery{Keyword: "bag"}); !test.check(err) {
+				t.Errorf("Search() = %v", err)
+			}
+			injected := chaos.Injected()
+			if test.wantFault == "" && len(injected) != 0 || test.wantFault != "" && injected[test.wantFault] != 1 {
+				t.Errorf("Injected() = %v, want one %q", injected, test.wantFault)
+			}
+		})
+	}
+}
+
+func TestChaosLatencyEndsWithTheContext(t *testing.T) {
+	chaos := newChaosSearcher(&fakeSearcher{}, ChaosConfig{Latency: 1, LatencyDuration: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := chaos.Search(ctx, SearchQuery{Keyword: "bag"})
+	if upstream, ok := err.(*upstreamError); !ok || upstream.category != failureDeadline {
+		t.Errorf("Search() past the deadline = %v, want a deadline upstreamError", err)
+	}
+}
+
+func TestChaosSeedRepeatsTheFaults(t *testing.T) {
+	rolls := func() []bool {
+		chaos := newChaosSearcher(&fakeSearcher{}, ChaosConfig{ServerError: 0.5, Seed: 7})
+		var hits []bool
+		for i := 0; i < 20; i++ {
+			_, err := chaos.Search(context.Background(), SearchQuery{Keyword: "bag"})
+			hits = append(hits, err != nil)
+		}
+		return hits
+	}
+	first, second := rolls(), rolls()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("the same CHAOS_SEED rolled %v then %v", first, second)
+		}
+	}
+}
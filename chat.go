@@ -0,0 +1,807 @@
+package theluxuryshopper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type FetchedData struct {
+	Items []Item
+}
+
+type Item struct {
+	ID         string `json:"id"`
+	GalleryURL string `json:"galleryURL"`
+	ImageURL   string `json:"imageURL,omitempty"` // The largest picture, galleryURL when there is no other
+	ItemURL    string `json:"itemURL"`
+	Title      string `json:"title"`
+	Condition  string `json:"condition"`
+	Price      string `json:"price"`
+	Currency   string `json:"currency"`
+	Keyword    string `json:"keyword,omitempty"`    // Set when several keywords were searched at once
+	Lot        bool   `json:"lot,omitempty"`        // Guessed from the lot quantity and the title
+	LotSize    int    `json:"lotSize,omitempty"`    // 0 when the count of a lot isn't known
+	Country    string `json:"country,omitempty"`    // The ISO code of the country the item is located in
+	Location   string `json:"location,omitempty"`   // Where the item is located, like "Milano,Italy"
+	Category   string `json:"category,omitempty"`   // The name of its primary eBay category
+	LandedCost string `json:"landedCost,omitempty"` // Estimated price with VAT and import duty, in Currency
+	EndTime    string `json:"endTime,omitempty"`    // When the listing ends, in UTC
+	// ListingType is the Finding API listing type, like Auction or FixedPrice
+	ListingType  string `json:"listingType,omitempty"`
+	ShippingCost string `json:"shippingCost,omitempty"` // In Currency, "" when eBay didn't say
+	SellerRating string `json:"sellerRating,omitempty"` // Positive feedback percentage of the seller
+	TopRated     bool   `json:"topRated,omitempty"`     // A listing of a top rated seller
+	Seller       string `json:"seller,omitempty"`       // The eBay username of the seller, "" when eBay didn't say
+	// Extra holds the fields ResultProcessors add, like the inventory data of an embedder
+	Extra map[string]interface{} `json:"extra,omitempty"`
+}
+
+type (
+	// Session Holds info about a session
+	Session map[string]interface{}
+
+	// JSON Holds a JSON object
+	JSON map[string]interface{}
+
+	// Processor Alias for Process func
+	Processor func(session Session, message string, w http.ResponseWriter)
+)
+
+// The questions asked to collect the filters of a search
+const (
+	keywordQuestion   = "What are you looking for? say something like 'Gucci Tshirt' "
+	conditionQuestion = "Please specify the condition of the required item. (New, Used or None)"
+	minPriceQuestion  = "Please specify the minimum price of the required item. (None in case you dont want to filter with minimum price)"
+	maxPriceQuestion  = "Please specify the maximum price of the required item. (None in case you dont want to filter with maximum price)"
+)
+
+// welcome Returns the greeting of every new session, mentioning the default filters of the deployment
+func (s *Server) welcome() string {
+	return s.greeting() + "\n " + keywordQuestion
+}
+
+// greeting Returns the welcome without its question, for sessions whose first message is already answered
+func (s *Server) greeting() string {
+	if notice := s.defaultFiltersNotice(); notice != "" {
+		return "Welcome to " + s.brandName() + ".\n " + notice
+	}
+	return "Welcome to " + s.brandName() + "."
+}
+
+func (s *Server) handleWelcome(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	// Every call creates a session, so no response may ever be reused
+	w.Header().Set("Cache-Control", "no-store")
+	negotiateSemantics(w, r)
+	w = s.withPlainText(w, r)
+	s.countBotRequest()
+	// Maintenance leaves every session as it is, new ones included
+	if s.refuseInMaintenance(w) {
+		return
+	}
+
+	message := s.welcome()
+
+	// A userId keeps the preferences and saved searches across sessions
+	userID := r.URL.Query().Get("userId")
+	if userID != "" && !validUserID(userID) {
+		writeError(w, http.StatusBadRequest, "invalid_user_id", "The userId must be 1 to "+strconv.Itoa(maxUserIDLength)+" printable characters without spaces.")
+		return
+	}
+	// Only the client that got its userToken may use the profile of a userId
+	var profile sessionBackup
+	var hasProfile bool
+	var userToken string
+	if userID != "" {
+		var err error
+		if profile, hasProfile, userToken, err = s.profiles.Claim(userID, r.Header.Get("X-User-Token")); err != nil {
+			writeError(w, http.StatusForbidden, "invalid_user_token", "This userId belongs to another user, send the userToken it was given as the X-User-Token header.")
+			return
+		}
+	}
+
+	// The preferences of a new session may come along, valid ones are kept even when others aren't
+	bundle, err := readWelcomePreferences(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", fmt.Sprintf("Couldn't decode JSON: %v.", err))
+		return
+	}
+
+	// Continue the conversation of ?resume=<uuid> if it is still alive
+	if resume := r.URL.Query().Get("resume"); resume != "" {
+		session, activity, state := s.sessions.Get(resume)
+		var unlock func()
+		if state == sessionActive {
+			if unlock, err = s.sessions.Lock(r.Context(), resume); err != nil {
+				writeError(w, http.StatusConflict, "session_busy", "The previous message of this session is still being answered.")
+				return
+			} else if !s.ownBot(session) {
+				// Another bot's session isn't this one's to resume
+				unlock()
+				state = sessionUnknown
+			}
+		}
+		switch state {
+		case sessionActive:
+			defer unlock()
+			name, conversation := activeConversation(session)
+			reply := JSON{
+				"message":          "Welcome back to " + s.brandName() + ".\n " + resumeSummary(conversation),
+				"uuid":             resume,
+				"resumed":          true,
+				"state":            sessionProgress(conversation),
+				"step":             sessionProgress(conversation)["step"],
+				"conversation":     name,
+				"sessionExpiresAt": activity.expiresAt.UTC().Format(time.RFC3339),
+			}
+			if progress := s.questionProgress(conversation, sessionProgress(conversation)["step"].(string)); progress != nil {
+				reply["progress"] = progress
+			}
+			WriteReply(w, ReplyQuestion, reply)
+			return
+		case sessionExpired:
+			message = "Your previous conversation expired, so let's start over.\n " + s.welcome()
+		}
+	}
+
+	// Create a session for a new UUID
+	uuid, session, activity := s.sessions.create(s.botName)
+	s.events.SessionStarted(uuid)
+	s.funnel.add(funnelStarted)
+	var restored []string
+	if userID != "" {
+		restored = attachProfile(session, userID, profile, hasProfile)
+	}
+
+	// Clients that know the timezone of the user can set it right away
+	if timezone := r.Header.Get("X-Timezone"); timezone != "" {
+		if location, valid := loadTimezone(timezone); valid {
+			session["timezone"] = location.String()
+		} else {
+			message = "Sorry, I don't know the timezone " + timezone + ", so times are in UTC. Try a name like " + timezoneExamples + ".\n " + message
+		}
+	}
+	preferences, problems := seedPreferences(session, bundle)
+	if len(problems) > 0 {
+		message = "Sorry, I couldn't use your " + preferenceFields(problems) + " (see invalidPreferences).\n " + message
+	}
+
+	reply := JSON{
+		"message":          message,
+		"step":             "keyword",
+		"uuid":             uuid,
+		"sessionExpiresAt": activity.expiresAt.UTC().Format(time.RFC3339),
+	}
+	if userID != "" {
+		reply["userId"], reply["restored"] = userID, nonNilStrings(restored)
+	}
+	if userToken != "" {
+		reply["userToken"] = userToken
+	}
+	if len(preferences) > 0 {
+		reply["preferences"] = preferences
+	}
+	if len(problems) > 0 {
+		reply["invalidPreferences"] = problems
+	}
+	// ?firstMessage= answers the first message right away, sparing the client a round trip
+	if first := strings.TrimSpace(r.URL.Query().Get("firstMessage")); first != "" {
+		s.writeFirstTurn(w, r, reply, first)
+		return
+	}
+	_, conversation := activeConversation(session)
+	reply["progress"] = s.questionProgress(conversation, "keyword")
+	WriteReply(w, ReplyQuestion, reply)
+}
+
+// sessionProgress Describes where the conversation of session stands
+func sessionProgress(session Session) JSON {
+	progress := JSON{"step": "keyword"}
+	keyword, found := session["searchByKeyword"].(string)
+	if !found {
+		return progress
+	}
+	progress["keyword"] = keyword
+	progress["step"] = pendingQuestion(session)
+	return progress
+}
+
+// resumeSummary Reminds the user of the search in progress and repeats the pending question
+func resumeSummary(session Session) string {
+	keyword, found := session["searchByKeyword"].(string)
+	if !found {
+		return keywordQuestion
+	}
+	summary := "You were searching for '" + keyword + "'."
+	switch pendingQuestion(session) {
+	case "condition":
+		summary += " " + conditionQuestion
+	case "minPrice":
+		summary += " " + minPriceQuestion
+	case "maxPrice":
+		summary += " " + maxPriceQuestion
+	}
+	return summary
+}
+
+// idleRecap Summarizes whichever parts of the search in session were already given
+func idleRecap(session Session) string {
+	keyword, found := session["searchByKeyword"].(string)
+	if !found {
+		return ""
+	}
+	recap := "Picking up where we left off, you were searching for '" + keyword + "'"
+	if condition, found := session["condition"].(string); found && !strings.EqualFold(condition, "none") {
+		recap += " in " + condition + " condition"
+	}
+	if minPrice, found := session["minPrice"].(string); found && !strings.EqualFold(minPrice, "none") {
+		recap += ", from " + minPrice
+	}
+	if maxPrice, found := session["maxPrice"].(string); found && !strings.EqualFold(maxPrice, "none") {
+		recap += ", up to " + maxPrice
+	}
+	if sortOrder, found := session["sortOrder"].(string); found {
+		recap += ", sorted by " + sortOrder
+	}
+	return recap + "."
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	// Make sure only POST requests are handled
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only POST requests are allowed.")
+		return
+	}
+
+	negotiateSemantics(w, r)
+	// Bodies that can't be read are refused before the session is touched
+	contentType := chatContentType(r)
+	if contentType != jsonContentType && contentType != formContentType {
+		writeUnsupportedMediaType(w, contentType)
+		return
+	}
+	// Retries are told apart by arriving before the reply they repeat was sent
+	arrived := s.sessions.now()
+	// A message answered for a poll was counted when it arrived
+	if r.Context().Value(asyncTurnKey{}) == nil {
+		s.countBotRequest()
+	}
+	// The session isn't even read during maintenance, it is left for when it is lifted
+	if s.refuseInMaintenance(w) {
+		return
+	}
+
+	// Make sure a UUID exists in the Authorization header
+	uuid := r.Header.Get("Authorization")
+	if uuid == "" {
+		writeError(w, http.StatusUnauthorized, "missing_authorization", "Missing or empty Authorization header.")
+		return
+	}
+
+	// Make sure a live session exists for the extracted UUID
+	session, activity, state := s.sessions.Get(uuid)
+	provisioned := false
+	if state == sessionUnknown && s.config.AutoProvisionSessions {
+		if !validClientSessionID(uuid) {
+			writeError(w, http.StatusBadRequest, "malformed_session_id", "The Authorization header must be a UUID like "+exampleClientSessionID+", or a uuid from /welcome.")
+			return
+		}
+		session, activity, provisioned = s.sessions.Provision(uuid, s.botName)
+		if provisioned {
+			s.events.SessionStarted(uuid)
+			s.funnel.add(funnelStarted)
+		}
+		state = sessionActive
+	}
+	if state == sessionExpired {
+		writeError(w, http.StatusUnauthorized, "session_expired", fmt.Sprintf("The session %v expired.", uuid))
+		return
+	}
+	if state != sessionActive {
+		writeError(w, http.StatusUnauthorized, "unknown_session", fmt.Sprintf("No session found for: %v.", uuid))
+		return
+	}
+
+	// A client sending twice must not interleave two turns on the same session
+	unlock, err := s.sessions.Lock(r.Context(), uuid)
+	if err == errSessionGone {
+		writeError(w, http.StatusUnauthorized, "session_expired", fmt.Sprintf("The session %v expired.", uuid))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusConflict, "session_busy", "The previous message of this session is still being answered.")
+		return
+	}
+	defer unlock()
+	// The sessions of the other bots are unknown to this one
+	if !s.ownBot(session) {
+		writeError(w, http.StatusUnauthorized, "unknown_session", fmt.Sprintf("No session found for: %v.", uuid))
+		return
+	}
+	// Runs before unlock, so the next message of the user sees the saved profile
+	defer s.saveProfile(session)
+
+	// Parse and validate the JSON or form body of the request
+	defer r.Body.Close()
+	body := io.Reader(r.Body)
+	if contentType == formContentType {
+		if body, err = formChatBody(r.Body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_form", fmt.Sprintf("Couldn't decode the form: %v.", err))
+			return
+		}
+	}
+	request, problems, err := decodeChatRequest(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_json", fmt.Sprintf("Couldn't decode JSON: %v.", err))
+		return
+	}
+	if len(problems) > 0 {
+		writeValidationError(w, problems)
+		return
+	}
+	message := request.Message
+
+	// "deliver": "poll" answers right away, the reply waits in the session for GET /chat/poll
+	if request.Deliver == deliverPoll {
+		s.answerForPoll(r, uuid, request)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(JSON{"type": ReplyInfo, "queued": true, "message": "Your reply will be waiting at GET /chat/poll."})
+		return
+	}
+
+	// A client retrying a message that was already answered gets the same reply, the conversation moves once
+	if replayDuplicate(session, request, arrived, w) {
+		return
+	}
+	recorder := &turnRecorder{ResponseWriter: w, status: http.StatusOK}
+	w = recorder
+	defer func() { recordTurn(session, request, recorder, s.sessions.now()) }()
+
+	_, conversation := activeConversation(session)
+	ctx, sp := s.tracer.Start(withSessionHash(r.Context(), uuid), "chat "+chatStep(conversation), spanInternal)
+	defer sp.End()
+	// Sessions whose searches eBay keeps refusing for their input are kept from searching for a while
+	guard := s.newSearchGuard(session)
+	ctx = withSearchGuard(ctx, guard)
+	defer s.finishSearchGuard(session, guard)
+	// DELETE /jobs can stop a message taking too long to answer
+	ctx, finished := s.jobs.start(ctx, sessionOf(ctx), jobTurn)
+	defer finished()
+	w = s.withPlainText(withMeta(w, r.WithContext(ctx)), r)
+	if request.Format == "text" {
+		w = plainTextWriter(w, r)
+	}
+	if request.Meta {
+		enableMeta(w)
+	}
+
+	// After a long pause remind the user of the search before answering
+	recap := ""
+	if provisioned {
+		// Clients skipping /welcome still greet their users
+		recap = s.greeting()
+	} else if s.sessions.recapDue(activity) {
+		recap = idleRecap(conversation)
+	}
+	noteSession(w, activity.expiresAt, recap)
+	noteLocation(w, sessionLocation(session))
+	noteLocale(w, session.GetString("locale", ""))
+	if request.Display != "" {
+		setDisplayMode(session, request.Display)
+	}
+	noteDisplay(w, sessionDisplay(session))
+	noteProgress(w, func(step string) JSON {
+		_, conversation := activeConversation(session)
+		return s.questionProgress(conversation, step)
+	})
+
+	// Refused messages are answered without touching the conversation
+	message, allowed := s.filterMessage(message)
+	if !allowed {
+		WriteReply(w, ReplyInfo, JSON{
+			"message": offTopicMessage + "\n " + strings.TrimSpace(resumeSummary(conversation)),
+		})
+		return
+	}
+
+	// Aliases and typos of the commands are rewritten before anything reads the message
+	message, handled := matchControlCommand(session, message, w)
+	if handled {
+		return
+	}
+
+	// Conversation commands work whatever the processor, everything else goes to the active conversation
+	now := s.sessions.now()
+	if handleConversationCommand(session, message, now, w) || handleTimezoneCommand(session, message, w) || handleDisplayCommand(session, message, w) {
+		return
+	}
+	if handleRestartCommand(session, message, now, w) || handleDeleteSearchCommand(session, message, now, w) || handleUndoCommand(session, message, now, w) {
+		return
+	}
+	_, conversation = activeConversation(session)
+
+	// Feedback on the last results comes with a message or instead of one
+	if request.Feedback != "" {
+		acknowledgment := s.recordFeedback(conversation, request.Feedback, now)
+		if strings.TrimSpace(message) == "" {
+			WriteReply(w, ReplyInfo, JSON{"message": acknowledgment, "feedback": request.Feedback})
+			return
+		}
+	}
+
+	// Load the processor once so a concurrent swap can't change it mid-message
+	s.Processor()(conversation, message, w)
+	_, conversation = activeConversation(session)
+	s.sessions.noteTurns(uuid, conversation.GetInt("turns", 0))
+}
+
+// ReplyType Tells clients what kind of answer a chat response is, without parsing its message
+type ReplyType string
+
+const (
+	ReplyQuestion    ReplyType = "question"    // a filter is asked for, step names it
+	ReplyResults     ReplyType = "results"     // a search found items
+	ReplyZeroResults ReplyType = "zeroResults" // a search found nothing
+	ReplyError       ReplyType = "error"       // the message couldn't be answered, see the error envelope
+	ReplyInfo        ReplyType = "info"        // a command was answered
+)
+
+// WriteReply Writes a chat response of type t, every chat and welcome response goes through it
+func WriteReply(w http.ResponseWriter, t ReplyType, data JSON) {
+	data["type"] = t
+	if t == ReplyQuestion {
+		addSuggestions(data)
+		addProgress(w, data)
+	}
+	writeJSON(w, data)
+}
+
+// resultsType Returns the ReplyType of a reply listing items
+func resultsType(items []Item) ReplyType {
+	if len(items) == 0 {
+		return ReplyZeroResults
+	}
+	return ReplyResults
+}
+
+// writeJSON Writes the JSON equivilant for data into ResponseWriter w.
+// Chat responses go through WriteReply instead.
+func writeJSON(w http.ResponseWriter, data JSON) {
+	addSession(w, data)
+	if message, found := data["message"].(string); found && plainReplies(w) {
+		data["message"] = plainMessage(message)
+	}
+	addSlow(w, data)
+	if meta := responseMeta(w); meta != nil {
+		data["meta"] = meta
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if status := replyStatus(w, data); status != http.StatusOK {
+		w.WriteHeader(status)
+	}
+	json.NewEncoder(w).Encode(data)
+}
+
+// sampleProcessor Is the default Processor, running the stages of pipeline.go one after the other
+func (s *Server) sampleProcessor(session Session, message string, w http.ResponseWriter) {
+	if s.RouteMessage(session, message, w) {
+		return
+	}
+
+	query, err := BuildQuery(session)
+	if err != nil {
+		WriteReply(w, ReplyQuestion, JSON{"message": keywordQuestion, "step": "keyword"})
+		return
+	}
+	query = withDisplay(query, requestDisplay(w))
+	// Remembered past the search so it can be saved afterwards
+	session["lastQuery"] = query
+
+	// Several keywords separated by commas or "or" are searched side by side
+	if keywords := splitKeywords(query.Keyword); len(keywords) > 1 {
+		s.funnel.mark(session, funnelSearched)
+		s.multiSearch(query, keywords, session, w)
+		return
+	}
+
+	result, err := s.ExecuteSearch(requestContext(w), query)
+	s.funnel.mark(session, funnelSearched)
+	if err == nil && len(result.Items) > 0 {
+		s.funnel.mark(session, funnelResults)
+	}
+	RenderSearch(query, result, err, session, w)
+}
+
+//Helper methods
+
+func (s *Server) filterByCondition(session Session, message string, w http.ResponseWriter) int {
+	_, found1 := session["conditionBool"]
+	if !found1 {
+		session["conditionBool"] = false
+	}
+	_, found2 := session["condition"]
+	if !found2 {
+		//Respond with question about condition
+		if !session.GetBool("conditionBool", false) {
+			WriteReply(w, ReplyQuestion, JSON{
+				"message": conditionQuestion,
+				"step":    "condition",
+			})
+			session["conditionBool"] = true
+			s.events.QuestionAsked("condition")
+			return 1
+		} else {
+			session["condition"] = s.normalizeAnswer(message, normalizeCondition)
+			if session["condition"] == "" {
+				delete(session, "condition")
+				session["conditionBool"] = true
+				s.answerRejected(w, "condition")
+				WriteReply(w, ReplyQuestion, JSON{
+					"message": conditionQuestion,
+					"step":    "condition",
+				})
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+func (s *Server) filterByMinPrice(session Session, message string, w http.ResponseWriter) int {
+	_, found3 := session["minPriceBool"]
+	if !found3 {
+		session["minPriceBool"] = false
+	}
+	_, found4 := session["minPrice"]
+	if !found4 {
+		//Respond with question about condition
+		if !session.GetBool("minPriceBool", false) {
+			WriteReply(w, ReplyQuestion, JSON{
+				"message": minPriceQuestion,
+				"step":    "minPrice",
+			})
+			session["minPriceBool"] = true
+			s.events.QuestionAsked("minPrice")
+			return 1
+		}
+		message = takePriceCurrency(session, message)
+		locale := requestLocale(w)
+		if session["minPrice"] = s.normalizeAnswer(message, func(price string) string { return localizedPrice(price, locale) }); session["minPrice"] == "" && !minPriceRange(session, message, locale) {
+			delete(session, "minPrice")
+			s.answerRejected(w, "minPrice")
+			WriteReply(w, ReplyQuestion, JSON{
+				"message": minPriceQuestion,
+				"step":    "minPrice",
+			})
+			return 1
+		}
+	}
+	return 0
+}
+
+func (s *Server) filterByMaxPrice(session Session, message string, w http.ResponseWriter) int {
+	if pending, _ := session["maxPriceConfirm"].(bool); pending {
+		return s.confirmMaxPrice(session, message, w)
+	}
+	_, found5 := session["maxPriceBool"]
+	if !found5 {
+		session["maxPriceBool"] = false
+	}
+	_, found6 := session["maxPrice"]
+	if !found6 {
+		//Respond with question about condition
+		if !session.GetBool("maxPriceBool", false) {
+			WriteReply(w, ReplyQuestion, JSON{
+				"message": maxPriceQuestion,
+				"step":    "maxPrice",
+			})
+			session["maxPriceBool"] = true
+			s.events.QuestionAsked("maxPrice")
+			return 1
+		}
+		message = takePriceCurrency(session, message)
+		locale := requestLocale(w)
+		if session["maxPrice"] = s.normalizeAnswer(message, func(price string) string { return localizedPrice(price, locale) }); session["maxPrice"] == "" && !maxPriceRange(session, message, locale) {
+			delete(session, "maxPrice")
+			s.answerRejected(w, "maxPrice")
+			WriteReply(w, ReplyQuestion, JSON{
+				"message": maxPriceQuestion,
+				"step":    "maxPrice",
+			})
+			return 1
+		}
+		if session["maxPrice"] != "none" && s.warnMaxPrice(session, w) {
+			return 1
+		}
+	}
+	return 0
+}
+
+func handleError(err error, session Session, w http.ResponseWriter) int {
+	if err == nil {
+		return 0
+	}
+	if failure, ok := err.(*searchFailure); ok {
+		writeError(w, http.StatusBadRequest, "search_failed", failure.userMessage()+"\n  What else would you like to search for? ")
+	} else if exhausted, ok := err.(*quotaExhausted); ok {
+		writeError(w, http.StatusTooManyRequests, "quota_exhausted", exhausted.Error())
+	} else if _, ok := err.(*throttled); ok {
+		writeError(w, http.StatusTooManyRequests, "throttled", throttledMessage+"\n  What else would you like to search for? ")
+	} else if restricted, ok := err.(*sessionRestricted); ok {
+		location := requestLocation(w)
+		if location == nil {
+			location = time.UTC
+		}
+		writeError(w, http.StatusTooManyRequests, "session_restricted", restricted.userMessage(location))
+	} else if upstream, ok := err.(*upstreamError); ok {
+		log.Printf("eBay search failed: %v", err)
+		writeError(w, http.StatusBadGateway, "upstream_unavailable", upstream.userMessage()+"\n  What else would you like to search for? ")
+	} else {
+		log.Printf("eBay search failed: %v", err)
+		writeError(w, http.StatusBadGateway, "upstream_unavailable", "Couldn't reach eBay right now.\n  What else would you like to search for? ")
+	}
+	//Reset session in case an error occured
+	resetSession(session)
+	return 1
+}
+
+func handleCaseZero(query SearchQuery, result SearchResult, session Session, w http.ResponseWriter) int {
+	noteSearch(w, result.Stats)
+	if result.Count == 0 {
+		response := "There are no items matching your criteria. \n What else would you like to search for? "
+		WriteReply(w, ReplyZeroResults, JSON{
+			"message": response,
+			"items":   []Item{},
+			"query":   query,
+		})
+		//Reset session in case no items were found
+		resetSession(session)
+		recordSearch(session, query, 0)
+		return 1
+	}
+	return 0
+}
+
+func generateResponse(query SearchQuery, result SearchResult, session Session, w http.ResponseWriter) int {
+	numOfResults := strconv.Itoa(query.Entries)
+	if result.Count < query.Entries {
+		numOfResults = strconv.Itoa(result.Count)
+	}
+	var response strings.Builder
+	response.Grow(estimateRenderSize(result.Items) + 256)
+	response.WriteString("There are " + numOfResults + " items matching your criteria")
+	if prices := priceFilterSummary(query); prices != "" {
+		response.WriteString(", priced " + prices)
+	}
+	if summary := pageSummary(query.page(), result.Stats); summary != "" {
+		response.WriteString(", " + summary)
+	}
+	response.WriteString(" : \n")
+	writeItems(&response, result.Items, requestView(w))
+	writeCollapsedNote(&response, result.Collapsed)
+	if note := displayCurrencyNote(session, result.Items); note != "" {
+		response.WriteString("\n " + note)
+	}
+	response.WriteString("\n Results Page URL : " + resultsURL(result))
+	if trend := priceTrend(result.PriceHistory); trend != "" {
+		response.WriteString("\n The " + trend + ".")
+	}
+	response.WriteString("\n\n What else would you like to search for?")
+	if result.Stats.TotalPages > 1 {
+		response.WriteString(" Or say 'more' or 'page <number>' for other items.")
+	}
+	WriteReply(w, ReplyResults, JSON{
+		"message":      response.String(),
+		"items":        result.Items,
+		"query":        query,
+		"priceHistory": result.PriceHistory,
+		"pagination":   pagination(query, result.Stats),
+		"sellers":      sellerGrouping(result.Items, result.Collapsed),
+		"collapsed":    nonNilGroups(result.Collapsed),
+		"searchURL":    result.SearchURL,
+		"pageURL":      result.PageURL,
+		"suggestions":  resultSuggestions(1, len(result.Items), result.Stats.TotalPages > 1),
+	})
+	resetSession(session)
+	rememberResults(session, query, result, true)
+	recordSearch(session, query, matchCount(result))
+	return 1
+}
+
+// renderItems Lists the details of every item, numbered from 1
+func renderItems(items []Item, view itemView) string {
+	var response strings.Builder
+	response.Grow(estimateRenderSize(items))
+	writeItems(&response, items, view)
+	return response.String()
+}
+
+// writeItems Writes the lines view selects of every item, numbered from 1, into response.
+// End times are shown in the location of view, or in UTC with a hint when it is nil.
+func writeItems(response *strings.Builder, items []Item, view itemView) {
+	writeNumberedItems(response, items, 1, view)
+}
+
+// writeNumberedItems Writes the details of every item like writeItems, numbering them from first
+func writeNumberedItems(response *strings.Builder, items []Item, first int, view itemView) {
+	now := time.Now()
+	endTimes := false
+	for index, element := range items {
+		number := strconv.Itoa(first + index)
+		for _, line := range []struct {
+			label, value string
+			shown        bool
+		}{
+			{"Title", element.Title, true},
+			{"Condition", element.Condition, view.fields.condition},
+			{"Price", element.Price + " " + element.Currency + lotNote(element) + landedCostNote(element), true},
+			{"Ships from", shipsFrom(element), view.fields.location && element.Country != ""},
+			{"Gallery", element.GalleryURL, view.fields.image},
+			{"URL", element.ItemURL, view.fields.link},
+		} {
+			if line.shown {
+				response.WriteString("\n Item " + number + " " + line.label + " : " + line.value)
+			}
+		}
+		if end, err := time.Parse(time.RFC3339, element.EndTime); err == nil && view.fields.endTime {
+			response.WriteString("\n Item " + number + " Ends : " + formatEndTime(end, now, view.location))
+			endTimes = true
+		}
+		response.WriteString("\n")
+		if element.Keyword != "" && view.fields.matched {
+			response.WriteString(" Item " + number + " Matched : " + element.Keyword + "\n")
+		}
+	}
+	if endTimes && view.location == nil {
+		response.WriteString("\n " + timezoneHint + "\n")
+	}
+}
+
+// estimateRenderSize Estimates how many bytes writeItems needs for items
+func estimateRenderSize(items []Item) int {
+	size := 0
+	for _, element := range items {
+		// Labels, numbering, notes and end times take about 260 bytes per item
+		size += 260 + len(element.Title) + len(element.Condition) + len(element.Price) + len(element.Currency) + len(element.GalleryURL) + len(element.ItemURL) + len(element.Keyword) + len(element.Location)
+	}
+	return size
+}
+
+// persistentSessionKeys Holds the session keys that outlive a search
+var persistentSessionKeys = map[string]bool{
+	"lastSurprise":       true,
+	"excludeLots":        true,
+	"excludeAccessories": true,
+	"excludedCategories": true,
+	"shipsTo":            true,
+	"locatedIn":          true,
+	"excludedCountries":  true,
+	"lastQuery":          true,
+	"originalSite":       true,
+	"savedSearches":      true,
+	"searchHistory":      true,
+	"lastRun":            true,
+	"filterOverrides":    true,
+	"skipQuestions":      true,
+	"results":            true,
+	"funnel":             true,
+	"currency":           true,
+}
+
+// resetSession Clears the search keys of session so the next message starts a new search
+func resetSession(session Session) {
+	for k := range session {
+		if !persistentSessionKeys[k] {
+			delete(session, k)
+		}
+	}
+}
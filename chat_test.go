@@ -0,0 +1,20 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+)
+
+// BenchmarkGenerateResponse Renders the results reply of a 100 item page, run with go test -bench GenerateResponse -benchmem
+func BenchmarkGenerateResponse(b *testing.B) {
+	result, err := decodeStreamed(bytes.NewReader(readFixture(b, "page_100")))
+	if err != nil {
+		b.Fatal(err)
+	}
+	query := SearchQuery{Keyword: "luxury", Condition: "none", MinPrice: "none", MaxPrice: "none", Entries: 100}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		generateResponse(query, result, Session{"searchByKeyword": "luxury"}, httptest.NewRecorder())
+	}
+}
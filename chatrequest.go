@@ -0,0 +1,140 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/El-Etreby/theluxuryshopper/internal/fuzzy"
+)
+
+// chatRequest Is the body of a /chat request, only message is required
+type chatRequest struct {
+	Message        string `json:"message"`
+	Format         string `json:"format,omitempty"`
+	Meta           bool   `json:"meta,omitempty"`
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+	Display        string `json:"display,omitempty"`
+	Feedback       string `json:"feedback,omitempty"`
+	Deliver        string `json:"deliver,omitempty"`
+}
+
+// chatRequestFields Maps every field of a chatRequest to the JSON type it takes
+var chatRequestFields = map[string]string{
+	"message":        "string",
+	"format":         "string",
+	"meta":           "boolean",
+	"idempotencyKey": "string",
+	"display":        "string",
+	"feedback":       "string",
+	"deliver":        "string",
+}
+
+// unsupportedChatFields Are the fields /chat refuses rather than ignore, with what to do instead
+var unsupportedChatFields = map[string]string{
+	"callbackUrl": "unsupported field, use \"deliver\": \"poll\" and GET /chat/poll",
+	"messages":    "unsupported field, send one message per request",
+}
+
+// chatFormats Are the values format accepts
+var chatFormats = map[string]bool{"text": true, "json": true}
+
+// fieldProblem Describes what is wrong with one field of a request
+type fieldProblem struct {
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+}
+
+// decodeChatRequest Decodes and validates a /chat body.
+// Every unknown, mistyped or missing field is reported rather than just the first,
+// err is only set when the body isn't a JSON object at all.
+func decodeChatRequest(body io.Reader) (chatRequest, []fieldProblem, error) {
+	var request chatRequest
+	var raw map[string]json.RawMessage
+	decoder := json.NewDecoder(body)
+	if err := decoder.Decode(&raw); err != nil {
+		return request, nil, err
+	}
+	if raw == nil {
+		return request, nil, fmt.Errorf("the body must be a JSON object")
+	}
+
+	var problems []fieldProblem
+	for field, value := range raw {
+		if problem, unsupported := unsupportedChatFields[field]; unsupported {
+			problems = append(problems, fieldProblem{field, problem})
+			continue
+		}
+		expected, known := chatRequestFields[field]
+		if !known {
+			problem := "unknown field"
+			if suggestion := closestChatField(field); suggestion != "" {
+				problem += ", did you mean " + suggestion + "?"
+			}
+			problems = append(problems, fieldProblem{field, problem})
+			continue
+		}
+		// Decoding the single field into the struct checks its type without touching the others
+		single, _ := json.Marshal(map[string]json.RawMessage{field: value})
+		strict := json.NewDecoder(bytes.NewReader(single))
+		strict.DisallowUnknownFields()
+		if err := strict.Decode(&request); err != nil || string(value) == "null" {
+			problems = append(problems, fieldProblem{field, "must be a " + expected})
+		}
+	}
+
+	if _, found := raw["message"]; !found {
+		problems = append(problems, fieldProblem{"message", "required"})
+	}
+	if request.Format != "" && !chatFormats[request.Format] {
+		problems = append(problems, fieldProblem{"format", "must be text or json"})
+	}
+	if request.Display != "" && !displayModes[request.Display] {
+		problems = append(problems, fieldProblem{"display", "must be compact or detailed"})
+	}
+	if request.Feedback != "" && request.Feedback != feedbackHelpful && request.Feedback != feedbackNotHelpful {
+		problems = append(problems, fieldProblem{"feedback", "must be helpful or notHelpful"})
+	}
+	if request.Deliver != "" && request.Deliver != deliverPoll {
+		problems = append(problems, fieldProblem{"deliver", "must be poll"})
+	}
+	if len(request.IdempotencyKey) > 255 {
+		problems = append(problems, fieldProblem{"idempotencyKey", "must be at most 255 characters"})
+	}
+
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Field < problems[j].Field })
+	return request, problems, nil
+}
+
+// closestChatField Suggests the known field a typo like "mesage" was meant to be
+func closestChatField(field string) string {
+	best, bestDistance := "", 3
+	for known := range chatRequestFields {
+		if distance := fuzzy.Distance(strings.ToLower(field), strings.ToLower(known)); distance < bestDistance {
+			best, bestDistance = known, distance
+		}
+	}
+	return best
+}
+
+// writeValidationError Writes the error envelope with every problem of a request
+func writeValidationError(w http.ResponseWriter, problems []fieldProblem) {
+	descriptions := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		descriptions = append(descriptions, problem.Field+": "+problem.Problem)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(JSON{
+		"type": ReplyError,
+		"error": JSON{
+			"code":    "invalid_request",
+			"message": "Invalid request body. " + strings.Join(descriptions, "; ") + ".",
+			"fields":  problems,
+		},
+	})
+}
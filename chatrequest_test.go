@@ -0,0 +1,128 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeChatRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		want         chatRequest
+		wantProblems []fieldProblem
+		wantErr      bool
+	}{
+		{
+			name: "bare message",
+			body: `{"message": "gucci"}`,
+			want: chatRequest{Message: "gucci"},
+		},
+		{
+			name: "every optional field",
+			body: `{"message": "gucci", "format": "text", "meta": true, "idempotencyKey": "k1", "display": "compact", "feedback": "helpful", "deliver": "poll"}`,
+			want: chatRequest{Message: "gucci", Format: "text", Meta: true, IdempotencyKey: "k1", Display: "compact", Feedback: "helpful", Deliver: "poll"},
+		},
+		{
+			name:         "typo of message",
+			body:         `{"mesage": "gucci"}`,
+			wantProblems: []fieldProblem{{"mesage", "unknown field, did you mean message?"}, {"message", "required"}},
+		},
+		{
+			name:         "unknown field",
+			body:         `{"message": "gucci", "colour": "red"}`,
+			wantProblems: []fieldProblem{{"colour", "unknown field"}},
+		},
+		{
+			name: "wrong types",
+			body: `{"message": 3, "format": true, "meta": "yes", "idempotencyKey": 1, "display": [], "feedback": {}, "deliver": null}`,
+			wantProblems: []fieldProblem{
+				{"deliver", "must be a string"},
+				{"display", "must be a string"},
+				{"feedback", "must be a string"},
+				{"format", "must be a string"},
+				{"idempotencyKey", "must be a string"},
+				{"message", "must be a string"},
+				{"meta", "must be a boolean"},
+			},
+		},
+		{
+			name: "bad values",
+			body: `{"message": "gucci", "format": "xml", "display": "tiny", "feedback": "meh", "deliver": "email", "idempotencyKey": "` + strings.Repeat("k", 256) + `"}`,
+			wantProblems: []fieldProblem{
+				{"deliver", "must be poll"},
+				{"display", "must be compact or detailed"},
+				{"feedback", "must be helpful or notHelpful"},
+				{"format", "must be text or json"},
+				{"idempotencyKey", "must be at most 255 characters"},
+			},
+		},
+		{
+			name: "unsupported fields",
+			body: `{"message": "gucci", "callbackUrl": "https://example.com/hook", "messages": ["a", "b"]}`,
+			wantProblems: []fieldProblem{
+				{"callbackUrl", `unsupported field, use "deliver": "poll" and GET /chat/poll`},
+				{"messages", "unsupported field, send one message per request"},
+			},
+		},
+		{name: "not an object", body: `["gucci"]`, wantErr: true},
+		{name: "null", body: `null`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request, problems, err := decodeChatRequest(strings.NewReader(test.body))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("decodeChatRequest(%s) failed with %v", test.body, err)
+			}
+			if test.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(problems, test.wantProblems) {
+				t.Errorf("decodeChatRequest(%s) found %v, want %v", test.body, problems, test.wantProblems)
+			}
+			if test.wantProblems == nil && request != test.want {
+				t.Errorf("decodeChatRequest(%s) = %+v, want %+v", test.body, request, test.want)
+			}
+		})
+	}
+}
+
+func TestChatRequestFields(t *testing.T) {
+	items := testItems("bag", 1, 2)
+	items[0].Title = "<b>Gucci</b> bag"
+	c := startConversation(t, newTestServer(t, Config{}, &fakeSearcher{results: map[string]SearchResult{"": {Items: items}}}))
+	send := func(body string) testReply {
+		t.Helper()
+		c.clock.Advance(2 * duplicateGrace)
+		return do(t, c.handler, http.MethodPost, "/v1/chat", body, http.Header{"Authorization": {c.uuid}})
+	}
+
+	// Questions carry what the client shows, never the session
+	reply := send(`{"message": "gucci bag", "idempotencyKey": "k1"}`)
+	if reply.Body["step"] != "condition" || reply.Body["session"] != nil {
+		t.Fatalf("the keyword answered %v, want the condition question without the session", reply.Raw)
+	}
+
+	// The same key is a retry however late, the same message with another key isn't
+	retry := send(`{"message": "gucci bag", "idempotencyKey": "k1"}`)
+	if retry.Raw != reply.Raw || retry.Header.Get("X-Duplicate-Message") != "true" {
+		t.Errorf("the retry answered %v, want the reply again", retry.Raw)
+	}
+	reply = send(`{"message": "new", "idempotencyKey": "k2"}`)
+	if reply.Body["step"] != "minPrice" || reply.Header.Get("X-Duplicate-Message") != "" {
+		t.Errorf("the condition answered %v, want the minPrice question", reply.Raw)
+	}
+
+	send(`{"message": "none"}`)
+	reply = send(`{"message": "none", "format": "text"}`)
+	if reply.Body["type"] != "results" || !strings.Contains(reply.message(), "Gucci bag") || strings.ContainsAny(reply.message(), "<>") {
+		t.Errorf("format text answered %q, want results without tags", reply.message())
+	}
+
+	reply = send(`{"message": "more", "callbackUrl": "https://example.com/hook"}`)
+	if reply.Status != http.StatusBadRequest || reply.errorCode() != "invalid_request" {
+		t.Errorf("callbackUrl answered %v %v, want 400 invalid_request", reply.Status, reply.Raw)
+	}
+}
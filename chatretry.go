@@ -0,0 +1,74 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+)
+
+// duplicateGrace Is how long after a reply was sent a repeat of its message still counts as a retry.
+// A client retrying a timed out request sends it before or right as the reply goes out, while
+// someone answering the same words again first has to read the new question.
+const duplicateGrace = time.Second
+
+// chatTurn Is the last message of a session with the reply it got, kept at the top of the session
+type chatTurn struct {
+	message     string
+	key         string // the idempotencyKey of the request, if any
+	finished    time.Time
+	status      int
+	contentType string
+	body        []byte
+}
+
+// turnRecorder Copies the reply of a chat turn while it is written
+type turnRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *turnRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *turnRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// recordTurn Remembers the reply recorder got for request
+func recordTurn(session Session, request chatRequest, recorder *turnRecorder, finished time.Time) {
+	session["lastTurn"] = &chatTurn{
+		message:     request.Message,
+		key:         request.IdempotencyKey,
+		finished:    finished,
+		status:      recorder.status,
+		contentType: recorder.Header().Get("Content-Type"),
+		body:        recorder.body.Bytes(),
+	}
+}
+
+// replayDuplicate Sends the previous reply again when request is a retry of the previous message of session,
+// reporting whether it did: answering it would advance the conversation a second time. A request with an
+// idempotencyKey is a retry when the previous one had the same key, whenever it arrives. Without one, it is
+// when it repeats the previous message exactly and arrived before that reply could have been read.
+func replayDuplicate(session Session, request chatRequest, arrived time.Time, w http.ResponseWriter) bool {
+	last, found := session["lastTurn"].(*chatTurn)
+	switch {
+	case !found:
+		return false
+	case request.IdempotencyKey != "":
+		if last.key != request.IdempotencyKey {
+			return false
+		}
+	case last.message != request.Message || arrived.After(last.finished.Add(duplicateGrace)):
+		return false
+	}
+	w.Header().Set("Content-Type", last.contentType)
+	w.Header().Set("X-Duplicate-Message", "true")
+	w.WriteHeader(last.status)
+	w.Write(last.body)
+	return true
+}
@@ -0,0 +1,89 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReplayDuplicate(t *testing.T) {
+	finished := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	last := &chatTurn{message: "gucci", key: "k1", finished: finished, status: http.StatusOK, contentType: "application/json", body: []byte(`{"message":"first"}`)}
+	plain := &chatTurn{message: "gucci", finished: finished, status: http.StatusOK, contentType: "application/json", body: []byte(`{"message":"first"}`)}
+	tests := []struct {
+		name       string
+		last       *chatTurn
+		request    chatRequest
+		arrived    time.Time
+		wantReplay bool
+	}{
+		{"first turn", nil, chatRequest{Message: "gucci"}, finished, false},
+		{"same message in the grace", plain, chatRequest{Message: "gucci"}, finished.Add(duplicateGrace), true},
+		{"same message after the grace", plain, chatRequest{Message: "gucci"}, finished.Add(duplicateGrace + time.Millisecond), false},
+		{"other message in the grace", plain, chatRequest{Message: "prada"}, finished, false},
+		{"same key much later", last, chatRequest{Message: "gucci", IdempotencyKey: "k1"}, finished.Add(time.Hour), true},
+		{"same key, other message", last, chatRequest{Message: "prada", IdempotencyKey: "k1"}, finished, true},
+		{"other key, same message", last, chatRequest{Message: "gucci", IdempotencyKey: "k2"}, finished, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			session := Session{}
+			if test.last != nil {
+				session["lastTurn"] = test.last
+			}
+			w := httptest.NewRecorder()
+			if got := replayDuplicate(session, test.request, test.arrived, w); got != test.wantReplay {
+				t.Fatalf("replayDuplicate() = %v, want %v", got, test.wantReplay)
+			}
+			if !test.wantReplay {
+				if w.Body.Len() != 0 || len(w.Header()) != 0 {
+					t.Errorf("replayDuplicate() wrote %v though it didn't replay", w.Body.String())
+				}
+				return
+			}
+			if w.Code != test.last.status || w.Body.String() != string(test.last.body) || w.Header().Get("X-Duplicate-Message") != "true" {
+				t.Errorf("replayDuplicate() wrote %v %v %v", w.Code, w.Header(), w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRecordTurn(t *testing.T) {
+	session := Session{}
+	recorder := &turnRecorder{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+	recorder.Header().Set("Content-Type", "text/plain")
+	recorder.WriteHeader(http.StatusAccepted)
+	recorder.Write([]byte("part one, "))
+	recorder.Write([]byte("part two"))
+	finished := time.Now()
+	recordTurn(session, chatRequest{Message: "hello", IdempotencyKey: "k"}, recorder, finished)
+
+	turn, _ := session["lastTurn"].(*chatTurn)
+	if turn == nil || turn.message != "hello" || turn.key != "k" || !turn.finished.Equal(finished) ||
+		turn.status != http.StatusAccepted || turn.contentType != "text/plain" || string(turn.body) != "part one, part two" {
+		t.Errorf("recordTurn() kept %+v", turn)
+	}
+}
+
+func TestRetriedMessageWithoutKey(t *testing.T) {
+	s := newTestServer(t, Config{}, &fakeSearcher{})
+	c := startConversation(t, s)
+	send := func() testReply {
+		return do(t, c.handler, http.MethodPost, "/v1/chat", `{"message": "gucci belt"}`, http.Header{"Authorization": {c.uuid}})
+	}
+	first := send()
+	retry := send()
+	if retry.Raw != first.Raw || retry.Header.Get("X-Duplicate-Message") != "true" {
+		t.Errorf("a retry within duplicateGrace answered %v, want the replay of %v", retry.Raw, first.Raw)
+	}
+	// Past the grace the same words are an answer to the new question
+	s.clock.Advance(2 * duplicateGrace)
+	if answer := send(); answer.Header.Get("X-Duplicate-Message") != "" {
+		t.Errorf("the same message past duplicateGrace answered the replay %v", answer.Raw)
+	}
+	session, _, _ := s.sessions.Get(c.uuid)
+	if _, conversation := activeConversation(session); conversation.GetInt("turns", 0) != 2 {
+		t.Errorf("the session counted %v turns, want the 2 that weren't retries", conversation.GetInt("turns", 0))
+	}
+}
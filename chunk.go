@@ -0,0 +1,138 @@
+package theluxuryshopper
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Message size limits of the transports a reply may be relayed to, in characters
+const (
+	TelegramMessageLimit = 4096
+	SlackSectionLimit    = 3000
+)
+
+// itemLine Matches the lines writeNumberedItems writes for an item, capturing its number and label
+var itemLine = regexp.MustCompile(`^ Item (\d+) ([A-Za-z ]+?) : `)
+
+// chunkSegment Is a run of reply lines that must stay in one message: the text before the items,
+// one item, or the text after them
+type chunkSegment struct {
+	lines []string
+	item  bool
+}
+
+func (s chunkSegment) String() string {
+	return strings.Join(s.lines, "\n")
+}
+
+// ChunkMessage Splits the message of a reply into ordered messages of at most limit characters for
+// transports that can't deliver longer ones. Results are only split between items; an item too long
+// for a message on its own keeps only its title and price, cut if needed. The HTTP API never chunks,
+// adapters call it with their own limit and send the chunks one after the other.
+func ChunkMessage(message string, limit int) []string {
+	if limit <= 0 || utf8.RuneCountInString(message) <= limit {
+		return []string{message}
+	}
+	var chunks []string
+	current := ""
+	flush := func() {
+		if strings.TrimSpace(current) != "" {
+			chunks = append(chunks, strings.TrimRight(current, "\n "))
+		}
+		current = ""
+	}
+	for _, segment := range chunkSegments(message) {
+		text := segment.String()
+		if utf8.RuneCountInString(text) > limit {
+			if segment.item {
+				text = shortenItem(segment.lines, limit)
+			} else {
+				// Text around the items only gets this long with a custom processor, it is cut at lines
+				flush()
+				for _, line := range splitLines(text, limit) {
+					addChunkText(&current, line, limit, flush)
+				}
+				continue
+			}
+		}
+		addChunkText(&current, text, limit, flush)
+	}
+	flush()
+	return chunks
+}
+
+// addChunkText Appends text to the message being filled, starting a new one when it wouldn't fit
+func addChunkText(current *string, text string, limit int, flush func()) {
+	joined := text
+	if *current != "" {
+		joined = *current + "\n" + text
+	}
+	if utf8.RuneCountInString(joined) > limit {
+		flush()
+		joined = text
+	}
+	*current = joined
+}
+
+// chunkSegments Cuts message into the text before the items, one segment per item and the text after them
+func chunkSegments(message string) []chunkSegment {
+	var segments []chunkSegment
+	var current chunkSegment
+	number := ""
+	for _, line := range strings.Split(message, "\n") {
+		match := itemLine.FindStringSubmatch(line)
+		switch {
+		case match != nil && match[1] != number:
+			// A new item starts, the blank line ending the previous one stays with it
+			if len(current.lines) > 0 {
+				segments = append(segments, current)
+			}
+			current, number = chunkSegment{lines: []string{line}, item: true}, match[1]
+		case match != nil, current.item && strings.TrimSpace(line) == "":
+			current.lines = append(current.lines, line)
+		default:
+			if current.item {
+				segments = append(segments, current)
+				current, number = chunkSegment{}, ""
+			}
+			current.lines = append(current.lines, line)
+		}
+	}
+	return append(segments, current)
+}
+
+// shortenItem Renders an item in its compact form, its title and price, cutting the title if even that is too long
+func shortenItem(lines []string, limit int) string {
+	var kept []string
+	for _, line := range lines {
+		if match := itemLine.FindStringSubmatch(line); match != nil && (match[2] == "Title" || match[2] == "Price") {
+			kept = append(kept, line)
+		}
+	}
+	text := strings.Join(kept, "\n")
+	for utf8.RuneCountInString(text) > limit && len(kept) > 0 {
+		excess := utf8.RuneCountInString(text) - limit
+		title := []rune(kept[0])
+		if excess+1 >= len(title) {
+			return string([]rune(text)[:limit])
+		}
+		kept[0] = string(title[:len(title)-excess-1]) + "…"
+		text = strings.Join(kept, "\n")
+	}
+	return text
+}
+
+// splitLines Splits text into pieces of at most limit characters at line breaks, cutting lines longer than limit
+func splitLines(text string, limit int) []string {
+	var pieces []string
+	for _, line := range strings.Split(text, "\n") {
+		runes := []rune(line)
+		for len(runes) > limit {
+			pieces = append(pieces, string(runes[:limit]))
+			runes = runes[limit:]
+		}
+		pieces = append(pieces, string(runes))
+	}
+	return pieces
+}
@@ -0,0 +1,107 @@
+package theluxuryshopper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// chunkedResults Is a results message with the items numbered 1 to n
+func chunkedResults(n int) string {
+	lines := []string{"Here are the results:", ""}
+	for i := 1; i <= n; i++ {
+		number := string(rune('0' + i))
+		lines = append(lines,
+			" Item "+number+" Title : Gucci belt "+number,
+			" Item "+number+" Price : 100.0 USD",
+			" Item "+number+" Link : https://www.ebay.com/itm/"+number,
+			"")
+	}
+	return strings.Join(append(lines, "Say more for more items."), "\n")
+}
+
+func TestChunkMessage(t *testing.T) {
+	results := chunkedResults(3)
+	item := func(number string) string {
+		return " Item " + number + " Title : Gucci belt " + number + "\n Item " + number + " Price : 100.0 USD\n Item " + number + " Link : https://www.ebay.com/itm/" + number
+	}
+	tests := []struct {
+		name    string
+		message string
+		limit   int
+		want    []string
+	}{
+		{"fits", results, len(results), []string{results}},
+		{"no limit", results, 0, []string{results}},
+		{
+			"between items",
+			results, 160,
+			[]string{
+				"Here are the results:\n\n" + item("1"),
+				item("2"),
+				item("3") + "\n\nSay more for more items.",
+			},
+		},
+		{
+			"item too long",
+			results, 54,
+			[]string{
+				"Here are the results:",
+				" Item 1 Title : Gucci belt 1\n Item 1 Price : 100.0 USD",
+				" Item 2 Title : Gucci belt 2\n Item 2 Price : 100.0 USD",
+				" Item 3 Title : Gucci belt 3\n Item 3 Price : 100.0 USD",
+				"Say more for more items.",
+			},
+		},
+		{
+			"title cut to the limit",
+			" Item 1 Title : Hermes Birkin 30 Togo leather gold hardware\n Item 1 Price : 9500.0 USD", 60,
+			[]string{" Item 1 Title : Hermes Birkin 30…\n Item 1 Price : 9500.0 USD"},
+		},
+		{"text without items", "aaaa bbbb\ncccc", 6, []string{"aaaa b", "bbb", "cccc"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ChunkMessage(test.message, test.limit)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ChunkMessage() = %q, want %q", got, test.want)
+			}
+			for _, chunk := range got {
+				if test.limit > 0 && utf8.RuneCountInString(chunk) > test.limit {
+					t.Errorf("ChunkMessage() made a chunk of %v characters over the limit %v", utf8.RuneCountInString(chunk), test.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestChunkMessageKeepsEveryItem(t *testing.T) {
+	results := chunkedResults(9)
+	for _, limit := range []int{SlackSectionLimit, 200, 120, 80} {
+		joined := strings.Join(ChunkMessage(results, limit), "\n")
+		for i := 1; i <= 9; i++ {
+			if title := " Item " + string(rune('0'+i)) + " Title : "; !strings.Contains(joined, title) {
+				t.Errorf("ChunkMessage() at %v characters lost item %v", limit, i)
+			}
+		}
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  []string
+	}{
+		{"short", 10, []string{"short"}},
+		{"one\ntwo", 10, []string{"one", "two"}},
+		{"abcdefgh", 3, []string{"abc", "def", "gh"}},
+		{"ééééé", 2, []string{"éé", "éé", "é"}},
+	}
+	for _, test := range tests {
+		if got := splitLines(test.text, test.limit); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitLines(%q, %v) = %q, want %q", test.text, test.limit, got, test.want)
+		}
+	}
+}
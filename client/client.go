@@ -0,0 +1,175 @@
+// Package client Calls the /v1 HTTP API of a theluxuryshopper server from Go
+//
+//	c := client.New("https://theluxuryshopper.herokuapp.com")
+//	session, greeting, err := c.Welcome(ctx)
+//	reply, err := c.Chat(ctx, session, "gucci loafers")
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	theluxuryshopper "github.com/El-Etreby/theluxuryshopper"
+)
+
+// maxResponseSize Caps how much of a response is read
+const maxResponseSize = 16 << 20
+
+// SessionID Is the uuid of a session, sent as the Authorization header of its messages
+type SessionID string
+
+// Reply Is the answer to one chat message
+type Reply struct {
+	Type             theluxuryshopper.ReplyType          `json:"type"`
+	Message          string                              `json:"message"`
+	Step             string                              `json:"step,omitempty"` // The filter asked for by questions
+	Items            []theluxuryshopper.Item             `json:"items,omitempty"`
+	Query            *theluxuryshopper.SearchQuery       `json:"query,omitempty"`
+	Pagination       *Pagination                         `json:"pagination,omitempty"`
+	PriceHistory     []theluxuryshopper.PriceObservation `json:"priceHistory,omitempty"`
+	SessionExpiresAt time.Time                           `json:"sessionExpiresAt"`
+	// Raw is the whole response, for the fields Reply doesn't name
+	Raw json.RawMessage `json:"-"`
+}
+
+// Pagination Tells which page of a search a reply lists
+type Pagination struct {
+	Page         int `json:"page"`
+	TotalPages   int `json:"totalPages"`
+	TotalEntries int `json:"totalEntries"`
+	PerPage      int `json:"perPage"`
+}
+
+// Client Calls one server
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+}
+
+// Option Customizes a Client created by New
+type Option func(*Client)
+
+// WithHTTPClient Sends the requests of the client through httpClient, to set timeouts or test against httptest
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey Sends key in the X-Api-Key header, for servers configured with API_KEYS
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// New Creates a Client for the server at baseURL, like https://theluxuryshopper.herokuapp.com
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{baseURL: strings.TrimSuffix(baseURL, "/") + "/v1", httpClient: &http.Client{Timeout: 30 * time.Second}}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Welcome Starts a session and returns it with the greeting of the server
+func (c *Client) Welcome(ctx context.Context) (SessionID, string, error) {
+	var welcome struct {
+		UUID    SessionID `json:"uuid"`
+		Message string    `json:"message"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/welcome", "", nil, &welcome); err != nil {
+		return "", "", err
+	}
+	return welcome.UUID, welcome.Message, nil
+}
+
+// Chat Sends message to session and returns the reply
+func (c *Client) Chat(ctx context.Context, session SessionID, message string) (Reply, error) {
+	body, err := json.Marshal(map[string]string{"message": message})
+	if err != nil {
+		return Reply{}, err
+	}
+	var reply Reply
+	raw, err := c.do(ctx, http.MethodPost, "/chat", session, body, &reply)
+	reply.Raw = raw
+	return reply, err
+}
+
+// Search Runs query without a conversation
+func (c *Client) Search(ctx context.Context, query theluxuryshopper.SearchQuery) (theluxuryshopper.SearchResult, error) {
+	values := url.Values{"keyword": {query.Keyword}}
+	for key, value := range map[string]string{"condition": query.Condition, "minPrice": query.MinPrice, "maxPrice": query.MaxPrice, "shipsTo": query.ShipsTo, "locatedIn": query.LocatedIn} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	if query.SortOrder != "" {
+		values.Set("sort", strings.ToLower(query.SortOrder))
+	}
+	if query.Entries > 0 {
+		values.Set("entries", strconv.Itoa(query.Entries))
+	}
+	if query.ExcludeLots {
+		values.Set("excludeLots", "true")
+	}
+	if len(query.ExcludeCountries) > 0 {
+		values.Set("excludeCountries", strings.Join(query.ExcludeCountries, ","))
+	}
+
+	var found struct {
+		Count        int                                 `json:"count"`
+		Items        []theluxuryshopper.Item             `json:"items"`
+		PageURL      string                              `json:"pageURL"`
+		SearchURL    string                              `json:"searchURL"`
+		PriceHistory []theluxuryshopper.PriceObservation `json:"priceHistory"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/search?"+values.Encode(), "", nil, &found); err != nil {
+		return theluxuryshopper.SearchResult{}, err
+	}
+	return theluxuryshopper.SearchResult{Items: found.Items, Count: found.Count, PageURL: found.PageURL, SearchURL: found.SearchURL, PriceHistory: found.PriceHistory}, nil
+}
+
+// do Sends one request and decodes its JSON response into out, returning the raw response.
+// Error envelopes are returned as an *Error.
+func (c *Client) do(ctx context.Context, method, path string, session SessionID, body []byte, out interface{}) (json.RawMessage, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if session != "" {
+		req.Header.Set("Authorization", string(session))
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-Api-Key", c.apiKey)
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the response of %v %v: %v", method, path, err)
+	}
+	if res.StatusCode >= 300 {
+		return raw, decodeError(res, raw)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return raw, fmt.Errorf("couldn't decode the response of %v %v: %v", method, path, err)
+	}
+	return raw, nil
+}
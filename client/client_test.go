@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	theluxuryshopper "github.com/El-Etreby/theluxuryshopper"
+)
+
+// catalog Is a Searcher answering every search with the same items
+type catalog []theluxuryshopper.Item
+
+// Search Implements theluxuryshopper.Searcher
+func (c catalog) Search(ctx context.Context, query theluxuryshopper.SearchQuery) (theluxuryshopper.SearchResult, error) {
+	return theluxuryshopper.SearchResult{Items: c, Count: len(c)}, nil
+}
+
+func TestClientConversation(t *testing.T) {
+	bot := theluxuryshopper.NewServer(theluxuryshopper.Config{EbayEnv: "production"}, theluxuryshopper.WithSearcher(catalog{
+		{ID: "1", Title: "Hermes Kelly 28", Condition: "Used", Price: "9500.0", Currency: "USD"},
+	}))
+	defer bot.Shutdown(context.Background())
+	server := httptest.NewServer(bot.Routes())
+	defer server.Close()
+
+	c := New(server.URL+"/", WithHTTPClient(server.Client()))
+	session, greeting, err := c.Welcome(context.Background())
+	if err != nil || session == "" || greeting == "" {
+		t.Fatalf("Welcome() = %q, %q, %v", session, greeting, err)
+	}
+	tests := []struct {
+		message   string
+		wantType  theluxuryshopper.ReplyType
+		wantStep  string
+		wantItems int
+	}{
+		{"hermes kelly", theluxuryshopper.ReplyQuestion, "condition", 0},
+		{"used", theluxuryshopper.ReplyQuestion, "minPrice", 0},
+		{"100", theluxuryshopper.ReplyQuestion, "maxPrice", 0},
+		{"none", theluxuryshopper.ReplyResults, "", 1},
+	}
+	for _, test := range tests {
+		reply, err := c.Chat(context.Background(), session, test.message)
+		if err != nil {
+			t.Fatalf("Chat(%q) = %v", test.message, err)
+		}
+		if reply.Type != test.wantType || reply.Step != test.wantStep || len(reply.Items) != test.wantItems || len(reply.Raw) == 0 {
+			t.Errorf("Chat(%q) = %v %q with %v items, want %v %q with %v", test.message, reply.Type, reply.Step, len(reply.Items), test.wantType, test.wantStep, test.wantItems)
+		}
+	}
+
+	if _, err := c.Chat(context.Background(), "unknown", "hermes kelly"); !errors.Is(err, ErrUnknownSession) {
+		t.Errorf("Chat() of an unknown session = %v, want %v", err, ErrUnknownSession)
+	}
+}
+
+func TestClientSearchQuery(t *testing.T) {
+	var got url.Values
+	var apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, apiKey = r.URL.Query(), r.Header.Get("X-Api-Key")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"count":1,"items":[{"id":"1"}],"pageURL":"https://www.ebay.com/sch/i.html"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		query theluxuryshopper.SearchQuery
+		want  url.Values
+	}{
+		{"keyword only", theluxuryshopper.SearchQuery{Keyword: "gucci bag"}, url.Values{"keyword": {"gucci bag"}}},
+		{
+			"every filter",
+			theluxuryshopper.SearchQuery{Keyword: "gucci bag", Condition: "New", MinPrice: "100", MaxPrice: "500", SortOrder: "PricePlusShippingLowest", Entries: 5, ExcludeLots: true, ShipsTo: "DE", LocatedIn: "IT", ExcludeCountries: []string{"CN", "HK"}},
+			url.Values{"keyword": {"gucci bag"}, "condition": {"New"}, "minPrice": {"100"}, "maxPrice": {"500"}, "sort": {"priceplusshippinglowest"}, "entries": {"5"}, "excludeLots": {"true"}, "shipsTo": {"DE"}, "locatedIn": {"IT"}, "excludeCountries": {"CN,HK"}},
+		},
+	}
+	c := New(server.URL, WithAPIKey("key"))
+	for _, test := range tests {
+		result, err := c.Search(context.Background(), test.query)
+		if err != nil || result.Count != 1 || len(result.Items) != 1 || result.PageURL == "" {
+			t.Errorf("%v: Search() = %+v, %v", test.name, result, err)
+		}
+		if got.Encode() != test.want.Encode() || apiKey != "key" {
+			t.Errorf("%v: Search() sent %v with the key %q, want %v", test.name, got.Encode(), apiKey, test.want.Encode())
+		}
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		header         http.Header
+		body           string
+		want           *Error
+		wantSentinel   error
+		wantNotMatched error
+	}{
+		{"envelope", http.StatusUnauthorized, nil, `{"error":{"code":"session_expired","message":"Session expired."}}`, &Error{Status: 401, Code: "session_expired", Message: "Session expired."}, ErrSessionExpired, ErrUnknownSession},
+		{"retry after", http.StatusTooManyRequests, http.Header{"Retry-After": {"30"}}, `{"error":{"code":"quota_exhausted","message":"Daily search limit reached."}}`, &Error{Status: 429, Code: "quota_exhausted", Message: "Daily search limit reached.", RetryAfter: 30 * time.Second}, ErrQuotaExhausted, ErrThrottled},
+		{"not an envelope", http.StatusBadGateway, nil, `<html>bad gateway</html>`, &Error{Status: 502, Code: "http_502", Message: "Bad Gateway"}, nil, ErrUpstreamUnavailable},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, values := range test.header {
+					w.Header()[key] = values
+				}
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			_, _, err := New(server.URL).Welcome(context.Background())
+			var got *Error
+			if !errors.As(err, &got) || *got != *test.want {
+				t.Fatalf("Welcome() = %#v, want %#v", err, test.want)
+			}
+			if test.wantSentinel != nil && !errors.Is(err, test.wantSentinel) {
+				t.Errorf("Welcome() = %v doesn't match %v", err, test.wantSentinel)
+			}
+			if errors.Is(err, test.wantNotMatched) {
+				t.Errorf("Welcome() = %v matches %v", err, test.wantNotMatched)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Error Is an error envelope answered by the server
+type Error struct {
+	Status     int    // The HTTP status of the response
+	Code       string // Like session_expired, see the sentinel errors
+	Message    string
+	RetryAfter time.Duration // Set by the 429 answers of /search
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// Is Lets errors.Is match an *Error against the sentinel of its code
+func (e *Error) Is(target error) bool {
+	sentinel, ok := target.(*Error)
+	return ok && sentinel.Status == 0 && sentinel.Code == e.Code
+}
+
+// The sentinels of the error codes callers usually tell apart, match them with errors.Is
+var (
+	ErrMissingAuthorization = &Error{Code: "missing_authorization"}
+	ErrInvalidAPIKey        = &Error{Code: "invalid_api_key"}
+	ErrUnknownSession       = &Error{Code: "unknown_session"}
+	ErrSessionExpired       = &Error{Code: "session_expired"}
+	ErrSessionBusy          = &Error{Code: "session_busy"}
+	ErrInvalidRequest       = &Error{Code: "invalid_request"}
+	ErrInvalidQuery         = &Error{Code: "invalid_query"}
+	ErrKeywordBlocked       = &Error{Code: "keyword_blocked"}
+	ErrSearchFailed         = &Error{Code: "search_failed"}
+	ErrQuotaExhausted       = &Error{Code: "quota_exhausted"}
+	ErrThrottled            = &Error{Code: "throttled"}
+	ErrUpstreamUnavailable  = &Error{Code: "upstream_unavailable"}
+
+	// ErrSessionIncompatible Matched the sessions written by a newer server.
+	//
+	// Deprecated: the server never answers it, sessions don't outlive the server that created them.
+	ErrSessionIncompatible = &Error{Code: "session_incompatible"}
+)
+
+// decodeError Returns the *Error of a failed response, naming the status when it isn't an envelope
+func decodeError(res *http.Response, raw []byte) error {
+	var envelope struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	e := &Error{Status: res.StatusCode}
+	if json.Unmarshal(raw, &envelope) == nil && envelope.Error.Code != "" {
+		e.Code, e.Message = envelope.Error.Code, envelope.Error.Message
+	} else {
+		e.Code, e.Message = "http_"+strconv.Itoa(res.StatusCode), http.StatusText(res.StatusCode)
+	}
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return e
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	theluxuryshopper "github.com/El-Etreby/theluxuryshopper"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// shutdownTimeout Bounds how long in-flight requests get to finish on shutdown
+const shutdownTimeout = 10 * time.Second
+
+// serve Serves handler as the config asks for, until an interrupt shuts every listener down
+func serve(config theluxuryshopper.Config, handler http.Handler) error {
+	var servers []*http.Server
+	errs := make(chan error, 2)
+	start := func(server *http.Server, listen func() error) {
+		servers = append(servers, server)
+		go func() {
+			if err := listen(); err != http.ErrServerClosed {
+				errs <- err
+			}
+		}()
+	}
+
+	primary := &http.Server{Addr: ":" + config.Port, Handler: handler}
+	switch {
+	case config.TLSCertFile != "":
+		log.Printf("Serving HTTPS on :%v", config.Port)
+		start(primary, func() error { return primary.ListenAndServeTLS(config.TLSCertFile, config.TLSKeyFile) })
+	case len(config.AutocertDomains) > 0:
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(config.AutocertDomains...),
+			Cache:      autocert.DirCache(config.AutocertCacheDir),
+			Email:      config.AutocertEmail,
+		}
+		primary.TLSConfig = manager.TLSConfig()
+		challenge := &http.Server{Addr: ":" + config.HTTPPort, Handler: manager.HTTPHandler(redirectHandler(config.Port))}
+		log.Printf("Serving HTTPS for %v on :%v, ACME challenges and redirects on :%v", config.AutocertDomains, config.Port, config.HTTPPort)
+		start(primary, func() error { return primary.ListenAndServeTLS("", "") })
+		start(challenge, challenge.ListenAndServe)
+	default:
+		log.Printf("Serving HTTP on :%v", config.Port)
+		start(primary, primary.ListenAndServe)
+	}
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupts)
+
+	var err error
+	select {
+	case err = <-errs:
+	case sig := <-interrupts:
+		log.Printf("Received %v, shutting down", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, server := range servers {
+		if shutdownErr := server.Shutdown(ctx); shutdownErr != nil && err == nil {
+			err = shutdownErr
+		}
+	}
+	return err
+}
+
+// redirectHandler Redirects plain HTTP requests to the same URL over HTTPS on httpsPort
+func redirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+
+		status := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
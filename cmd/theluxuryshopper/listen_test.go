@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		httpsPort    string
+		method       string
+		target       string
+		wantStatus   int
+		wantLocation string
+	}{
+		{"default port", "443", http.MethodGet, "http://shop.example.com/v1/welcome?resume=abc", http.StatusMovedPermanently, "https://shop.example.com/v1/welcome?resume=abc"},
+		{"port dropped", "443", http.MethodHead, "http://shop.example.com:80/", http.StatusMovedPermanently, "https://shop.example.com/"},
+		{"custom port", "8443", http.MethodGet, "http://shop.example.com:8080/healthz", http.StatusMovedPermanently, "https://shop.example.com:8443/healthz"},
+		{"post keeps its method", "443", http.MethodPost, "http://shop.example.com/v1/chat", http.StatusPermanentRedirect, "https://shop.example.com/v1/chat"},
+		{"ipv6", "8443", http.MethodGet, "http://[::1]:8080/", http.StatusMovedPermanently, "https://[::1]:8443/"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		redirectHandler(test.httpsPort).ServeHTTP(w, httptest.NewRequest(test.method, test.target, nil))
+		if location := w.Header().Get("Location"); w.Code != test.wantStatus || location != test.wantLocation {
+			t.Errorf("%v: redirected %v to %q, want %v to %q", test.name, w.Code, location, test.wantStatus, test.wantLocation)
+		}
+	}
+}
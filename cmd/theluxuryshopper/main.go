@@ -0,0 +1,85 @@
+// Command theluxuryshopper Serves the chatbot configured from the environment.
+//
+// Usage:
+//
+//	theluxuryshopper [serve]
+//	theluxuryshopper search --keyword "gucci belt" [--condition new] [--max 500] [--json]
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	theluxuryshopper "github.com/El-Etreby/theluxuryshopper"
+)
+
+func main() {
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	config, err := theluxuryshopper.LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch command {
+	case "serve":
+		runServe(config)
+	case "search":
+		os.Exit(runSearch(config, args, os.Stdout, os.Stderr))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q, the commands are serve and search\n", command)
+		os.Exit(exitUsage)
+	}
+}
+
+// runServe Serves the chatbot until the listener fails
+func runServe(config theluxuryshopper.Config) {
+	var options []theluxuryshopper.ServerOption
+	if config.EventsFile != "" {
+		sink, err := theluxuryshopper.NewJSONLinesSink(config.EventsFile)
+		if err != nil {
+			log.Fatalf("couldn't open EVENTS_FILE: %v", err)
+		}
+		options = append(options, theluxuryshopper.WithEventSink(sink))
+	}
+	server := theluxuryshopper.NewServer(config, options...)
+
+	// Use the NLU processor if one was requested, the scripted flow stays the fallback
+	if os.Getenv("PROCESSOR") == "nlu" {
+		timeout, err := time.ParseDuration(os.Getenv("NLU_TIMEOUT"))
+		if err != nil {
+			timeout = 1500 * time.Millisecond
+		}
+		parser := &theluxuryshopper.DialogflowParser{
+			Endpoint: os.Getenv("NLU_ENDPOINT"),
+			Token:    os.Getenv("NLU_TOKEN"),
+			Language: os.Getenv("NLU_LANGUAGE"),
+		}
+		server.SetProcessor(theluxuryshopper.NLUProcessor(parser, timeout, server.Processor()))
+	}
+	// Check the eBay credentials before taking traffic
+	switch config.SelfTest {
+	case "strict":
+		if err := server.SelfTest(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+	case "lenient":
+		// /readyz answers 503 until the self-test passed, the process keeps serving /healthz
+		go server.SelfTest(context.Background())
+	}
+	err := serve(config, server.Handler())
+
+	// Send the spans of the last requests before exiting
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	server.Shutdown(ctx)
+	cancel()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"text/tabwriter"
+
+	theluxuryshopper "github.com/El-Etreby/theluxuryshopper"
+)
+
+// Exit codes of the search subcommand, 1 is left to the config errors both commands share
+const (
+	exitFound     = 0
+	exitUsage     = 2 // The flags were invalid
+	exitUpstream  = 3 // eBay couldn't be searched
+	exitNoResults = 4 // The search found nothing
+)
+
+// searchFlags Maps the flags of the search subcommand to the /search parameters they set
+var searchFlags = []struct {
+	name, param, usage string
+}{
+	{"keyword", "keyword", "what to search for (required)"},
+	{"condition", "condition", "new, used or none"},
+	{"min", "minPrice", "minimum price"},
+	{"max", "maxPrice", "maximum price"},
+	{"sort", "sort", "sort order, like cheapest or ending soonest"},
+	{"entries", "entries", "items to fetch, 1 to 100"},
+	{"ships-to", "shipsTo", "country code the items must ship to"},
+	{"located-in", "locatedIn", "country code the items must be located in"},
+	{"exclude-lots", "excludeLots", "true to leave out lots and bulk listings"},
+	{"exclude-accessories", "excludeAccessories", "true to leave out straps, boxes, cases and other accessories"},
+	{"exclude-categories", "excludeCategories", "comma separated eBay category names or ids to leave out"},
+}
+
+// runSearch Runs the search subcommand: one Finding query through the same parsing, quota and client as
+// /search, printed as a table or as the JSON of /search. It returns the exit code.
+func runSearch(config theluxuryshopper.Config, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	params := map[string]*string{}
+	for _, f := range searchFlags {
+		params[f.param] = flags.String(f.name, "", f.usage)
+	}
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments %q\n", flags.Args())
+		return exitUsage
+	}
+
+	values := url.Values{}
+	for param, value := range params {
+		if *value != "" {
+			values.Set(param, *value)
+		}
+	}
+	query, err := theluxuryshopper.ParseSearchQuery(values)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	server := theluxuryshopper.NewServer(config)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		server.Shutdown(ctx)
+		cancel()
+	}()
+	result, err := server.ExecuteSearch(context.Background(), query)
+	if err != nil {
+		fmt.Fprintf(stderr, "the search failed: %v\n", err)
+		return exitUpstream
+	}
+
+	if *asJSON {
+		items := result.Items
+		if items == nil {
+			items = []theluxuryshopper.Item{}
+		}
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(map[string]interface{}{
+			"query":     query,
+			"count":     result.Count,
+			"items":     items,
+			"pageURL":   result.PageURL,
+			"searchURL": result.SearchURL,
+		})
+	} else {
+		printItems(stdout, result)
+	}
+	if len(result.Items) == 0 {
+		return exitNoResults
+	}
+	return exitFound
+}
+
+// printItems Writes the items of result as a table, with the link to see them on eBay
+func printItems(w io.Writer, result theluxuryshopper.SearchResult) {
+	if len(result.Items) == 0 {
+		fmt.Fprintln(w, "No items found.")
+		return
+	}
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "#\tPRICE\tCONDITION\tTITLE\tURL")
+	for i, item := range result.Items {
+		fmt.Fprintln(table, strconv.Itoa(i+1)+"\t"+item.Price+" "+item.Currency+"\t"+item.Condition+"\t"+item.Title+"\t"+item.ItemURL)
+	}
+	table.Flush()
+	if link := result.SearchURL; link != "" {
+		fmt.Fprintln(w, "\nSee them on eBay: "+link)
+	} else if result.PageURL != "" {
+		fmt.Fprintln(w, "\nSee them on eBay: "+result.PageURL)
+	}
+}
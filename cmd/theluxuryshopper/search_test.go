@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	theluxuryshopper "github.com/El-Etreby/theluxuryshopper"
+)
+
+func TestRunSearchUsage(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantStderr string
+	}{
+		{"no keyword", nil, "keyword"},
+		{"unknown flag", []string{"--keyword", "gucci belt", "--colour", "red"}, "flag provided but not defined: -colour"},
+		{"stray argument", []string{"--keyword", "gucci belt", "red"}, `unexpected arguments ["red"]`},
+		{"invalid condition", []string{"--keyword", "gucci belt", "--condition", "mint"}, "Condition must be New, Used or None."},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		code := runSearch(theluxuryshopper.Config{EbayEnv: "production"}, test.args, &stdout, &stderr)
+		if code != exitUsage || stdout.Len() != 0 {
+			t.Errorf("%v: runSearch() = %v printing %q, want %v and nothing", test.name, code, stdout.String(), exitUsage)
+		}
+		if !strings.Contains(stderr.String(), test.wantStderr) {
+			t.Errorf("%v: runSearch() complained %q, want it to mention %q", test.name, stderr.String(), test.wantStderr)
+		}
+	}
+}
+
+func TestPrintItems(t *testing.T) {
+	items := []theluxuryshopper.Item{
+		{Title: "Gucci belt", Condition: "New", Price: "450.0", Currency: "USD", ItemURL: "https://www.ebay.com/itm/1"},
+		{Title: "Gucci GG belt", Condition: "Used", Price: "120.0", Currency: "USD", ItemURL: "https://www.ebay.com/itm/2"},
+	}
+	tests := []struct {
+		name   string
+		result theluxuryshopper.SearchResult
+		want   []string
+	}{
+		{"nothing found", theluxuryshopper.SearchResult{}, []string{"No items found.\n"}},
+		{"search link", theluxuryshopper.SearchResult{Items: items, SearchURL: "https://www.ebay.com/sch/i.html?_nkw=gucci+belt", PageURL: "https://www.ebay.com/page"}, []string{
+			"#  PRICE      CONDITION  TITLE          URL\n",
+			"1  450.0 USD  New        Gucci belt     https://www.ebay.com/itm/1\n",
+			"2  120.0 USD  Used       Gucci GG belt  https://www.ebay.com/itm/2\n",
+			"\nSee them on eBay: https://www.ebay.com/sch/i.html?_nkw=gucci+belt\n",
+		}},
+		{"page link", theluxuryshopper.SearchResult{Items: items[:1], PageURL: "https://www.ebay.com/page"}, []string{"\nSee them on eBay: https://www.ebay.com/page\n"}},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		printItems(&out, test.result)
+		for _, want := range test.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%v: printItems() wrote %q, want it to contain %q", test.name, out.String(), want)
+			}
+		}
+	}
+}
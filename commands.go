@@ -0,0 +1,199 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/El-Etreby/theluxuryshopper/internal/fuzzy"
+)
+
+const (
+	// maxFuzzyCommandLength Caps the messages typo matching looks at, longer ones are keywords
+	maxFuzzyCommandLength = 20
+	// maxFuzzyCommandWords Caps the words of the messages typo matching looks at
+	maxFuzzyCommandWords = 3
+	// minFuzzyPhraseLength Keeps very short phrases like "prev" exact, a typo of them is any word
+	minFuzzyPhraseLength = 4
+)
+
+// commandStems Are the first words of the commands taking an argument, messages starting with one are
+// never typos: "details 3" is not "detailed"
+var commandStems = map[string]bool{
+	"details": true,
+	"item":    true,
+	"page":    true,
+	"compare": true,
+	"run":     true,
+	"repeat":  true,
+	"expand":  true,
+}
+
+var (
+	confirmCommandAnswer = regexp.MustCompile(`(?i)^\s*(?:y|yes|yeah|yep|yup|ok|okay|sure)\W*$`)
+	rejectCommandAnswer  = regexp.MustCompile(`(?i)^\s*(?:n|no|nope|nah|no\s+thanks)\W*$`)
+)
+
+// controlCommand Is a command with the message the handlers understand and the phrases meaning the same
+type controlCommand struct {
+	Name    string
+	Aliases []string
+}
+
+// controlCommands Are the commands the control matcher rewrites near-misses of
+var controlCommands = newCommandMatcher([]controlCommand{
+	{"more", []string{"next", "next page", "show more", "more results", "more please"}},
+	{"previous", []string{"prev", "previous page", "back a page"}},
+	{"restart", []string{"start over", "start again", "reset", "new search", "begin again"}},
+	{"show all", nil},
+	{"saved searches", []string{"favorites", "favourites", "fav", "favs", "saved", "my searches", "list searches"}},
+	{"refresh all", []string{"refresh favorites", "refresh favourites", "refresh saved"}},
+	{"conversations", []string{"list conversations", "my conversations"}},
+	{"surprise me", []string{"surprise"}},
+	{"skip questions", []string{"skip the questions", "just search"}},
+	{"ask questions", []string{"ask me questions", "ask me the questions"}},
+	{"compact", []string{"compact mode"}},
+	{"detailed", []string{"detailed mode"}},
+	{"undo", []string{"undo that", "restore it"}},
+})
+
+// commandMatcher Recognizes commands by their name, their aliases and typos of either.
+// Typos are only looked for in short messages, so keywords are never taken for commands.
+type commandMatcher struct {
+	phrases map[string]string
+	guesses map[string]bool // one-word aliases, which are words of their own too, like "next", and need confirming
+}
+
+// newCommandMatcher Creates a matcher for commands
+func newCommandMatcher(commands []controlCommand) *commandMatcher {
+	m := &commandMatcher{phrases: map[string]string{}, guesses: map[string]bool{}}
+	for _, command := range commands {
+		m.phrases[commandKey(command.Name)] = command.Name
+		for _, alias := range command.Aliases {
+			m.phrases[commandKey(alias)] = command.Name
+			if !strings.Contains(commandKey(alias), " ") {
+				m.guesses[commandKey(alias)] = true
+			}
+		}
+	}
+	return m
+}
+
+// Match Returns the command message means. name is set when message is the name of a command, an alias
+// of several words or a typo one edit away from a single command. suggestions lists the commands a
+// one-word alias or any other typo may mean, which needs confirming.
+func (m *commandMatcher) Match(message string) (name string, suggestions []string) {
+	key := commandKey(message)
+	if name, found := m.phrases[key]; found {
+		if m.guesses[key] {
+			return "", []string{name}
+		}
+		return name, nil
+	}
+	words := strings.Fields(key)
+	if utf8.RuneCountInString(key) > maxFuzzyCommandLength || len(words) > maxFuzzyCommandWords || len(words) == 0 || commandStems[words[0]] || isNone(key) || hasReference(key) {
+		return "", nil
+	}
+
+	best := map[string]int{}
+	for phrase, command := range m.phrases {
+		if utf8.RuneCountInString(phrase) < minFuzzyPhraseLength {
+			continue
+		}
+		distance := fuzzy.TypoDistance(key, phrase)
+		if distance > typoBound(phrase) {
+			continue
+		}
+		if previous, found := best[command]; !found || distance < previous {
+			best[command] = distance
+		}
+	}
+	if len(best) == 0 {
+		return "", nil
+	}
+	for command := range best {
+		suggestions = append(suggestions, command)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if best[suggestions[i]] != best[suggestions[j]] {
+			return best[suggestions[i]] < best[suggestions[j]]
+		}
+		return suggestions[i] < suggestions[j]
+	})
+	if len(suggestions) == 1 && best[suggestions[0]] == 1 {
+		return suggestions[0], nil
+	}
+	return "", suggestions
+}
+
+// commandKey Normalizes message for the lookup of its command
+func commandKey(message string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.Trim(strings.TrimSpace(message), "!.?"))), " ")
+}
+
+// typoBound Returns how many edits a message may be away from phrase to still mean it
+func typoBound(phrase string) int {
+	if utf8.RuneCountInString(phrase) <= 5 {
+		return 1
+	}
+	return 2
+}
+
+// matchControlCommand Rewrites message to the command it means before the processor sees it.
+// A guess is confirmed first: the reply asks "did you mean", and the next message answers it,
+// running the command on yes and the message it was asked about on no.
+// handled reports whether the reply was already written.
+func matchControlCommand(session Session, message string, w http.ResponseWriter) (rewritten string, handled bool) {
+	if suggested, found := session["suggestedCommand"].(string); found {
+		original := session.GetString("suggestedFor", "")
+		delete(session, "suggestedCommand")
+		delete(session, "suggestedFor")
+		switch {
+		case confirmCommandAnswer.MatchString(message):
+			return suggested, false
+		case rejectCommandAnswer.MatchString(message):
+			return original, false
+		}
+	}
+
+	name, suggestions := controlCommands.Match(message)
+	if name != "" {
+		return name, false
+	}
+	if len(suggestions) == 0 {
+		return message, false
+	}
+	session["suggestedCommand"] = suggestions[0]
+	session["suggestedFor"] = message
+	WriteReply(w, ReplyQuestion, JSON{
+		"message":     "Did you mean '" + strings.Join(suggestions, "' or '") + "'? Say yes for '" + suggestions[0] + "', or no to use '" + strings.TrimSpace(message) + "' as it is.",
+		"step":        "confirmCommand",
+		"suggestions": suggestions,
+	})
+	return message, true
+}
+
+// handleRestartCommand Answers "restart" by dropping the search in progress of the active conversation,
+// reporting whether message was it. "undo" brings the whole conversation back.
+func handleRestartCommand(session Session, message string, now time.Time, w http.ResponseWriter) bool {
+	if message != "restart" {
+		return false
+	}
+	name, conversation := activeConversation(session)
+	before := copySession(conversation)
+	resetSession(conversation)
+	delete(conversation, "results")
+	stashUndo(session, now, func(session Session) string {
+		conversations(session)[name] = before
+		session["activeConversation"] = name
+		return "Restored conversation " + name + " as it was before starting over."
+	})
+	WriteReply(w, ReplyQuestion, JSON{
+		"message": "Starting over. " + undoHint + "\n " + keywordQuestion,
+		"step":    "keyword",
+	})
+	return true
+}
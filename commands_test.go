@@ -0,0 +1,70 @@
+package theluxuryshopper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommandMatcher(t *testing.T) {
+	tests := []struct {
+		message         string
+		wantName        string
+		wantSuggestions []string
+	}{
+		{"more", "more", nil},
+		{"More!", "more", nil},
+		{"next page", "more", nil},
+		{"start over", "restart", nil},
+		{"mroe", "more", nil},
+		{"restrat", "restart", nil},
+		{"compct", "compact", nil},
+		{"favorite", "saved searches", nil},
+		{"next", "", []string{"more"}},
+		{"reset", "", []string{"restart"}},
+		{"favorites", "", []string{"saved searches"}},
+		{"favs", "", []string{"saved searches"}},
+		{"surpise mee", "", []string{"surprise me"}},
+		{"sk questions", "", []string{"ask questions", "skip questions"}},
+		{"details 3", "", nil},
+		{"page 2", "", nil},
+		{"gucci loafers", "", nil},
+		{"rolex 1675", "", nil},
+		{"none", "", nil},
+		{"a very long message that is a keyword", "", nil},
+	}
+	for _, test := range tests {
+		name, suggestions := controlCommands.Match(test.message)
+		if name != test.wantName || !reflect.DeepEqual(suggestions, test.wantSuggestions) {
+			t.Errorf("Match(%q) = %q, %q, want %q, %q", test.message, name, suggestions, test.wantName, test.wantSuggestions)
+		}
+	}
+}
+
+func TestConfirmCommand(t *testing.T) {
+	c := startConversation(t, newTestServer(t, Config{}, &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 3)}}}))
+	c.sayAll("gucci bag", "none", "none", "none")
+	c.say("save search as bags")
+
+	reply := c.say("favorites")
+	if reply.Body["step"] != "confirmCommand" {
+		t.Fatalf("favorites answered %v, want the confirmCommand step", reply.Raw)
+	}
+	reply = c.say("yes")
+	if !strings.Contains(reply.message(), "bags : 'gucci bag', never run") {
+		t.Errorf("confirming favorites answered %q, want the saved searches", reply.message())
+	}
+
+	// A close typo of one command runs it right away
+	reply = c.say("saved serches")
+	if reply.Body["step"] == "confirmCommand" || !strings.Contains(reply.message(), "Your saved searches") {
+		t.Errorf("saved serches answered %q, want the saved searches", reply.message())
+	}
+
+	// No searches the one-word alias as a keyword
+	c.say("fav")
+	reply = c.say("no")
+	if reply.Body["step"] != "condition" {
+		t.Errorf("rejecting fav answered %v, want the condition question of a search for fav", reply.Raw)
+	}
+}
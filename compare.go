@@ -0,0 +1,179 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// compareCommand Matches "compare 1 and 4", "compare #2 vs 3" and the like
+var compareCommand = regexp.MustCompile(`(?i)^\s*compare\s+(?:items?\s+)?#?(\d+)\s*(?:and|&|,|vs\.?|versus|with|to)\s*#?(\d+)\W*$`)
+
+// listingTypes Names the Finding API listing types for people
+var listingTypes = map[string]string{
+	"Auction":        "Auction",
+	"AuctionWithBIN": "Auction with Buy It Now",
+	"FixedPrice":     "Buy It Now",
+	"StoreInventory": "Buy It Now (store)",
+	"Classified":     "Classified ad",
+}
+
+// comparedItem Is one side of a comparison
+type comparedItem struct {
+	Number       int     `json:"number"`
+	Title        string  `json:"title"`
+	Currency     string  `json:"currency"`
+	Price        float64 `json:"price"`
+	Shipping     float64 `json:"shipping"`
+	Total        float64 `json:"total"` // Price plus shipping
+	LandedCost   string  `json:"landedCost,omitempty"`
+	Condition    string  `json:"condition,omitempty"`
+	ListingType  string  `json:"listingType,omitempty"`
+	EndTime      string  `json:"endTime,omitempty"`
+	TimeLeft     string  `json:"timeLeft,omitempty"`
+	SellerRating string  `json:"sellerRating,omitempty"`
+	URL          string  `json:"url"`
+	priced       bool
+	ends         time.Time
+}
+
+// comparison Holds two items side by side, Cheaper and EndsSooner are item numbers or 0 when it can't be told
+type comparison struct {
+	Items      [2]comparedItem `json:"items"`
+	Cheaper    int             `json:"cheaper,omitempty"`
+	EndsSooner int             `json:"endsSooner,omitempty"`
+}
+
+// compareItem Describes item number for a comparison as of now
+func compareItem(number int, item Item, now time.Time, location *time.Location) comparedItem {
+	compared := comparedItem{
+		Number:       number,
+		Title:        item.Title,
+		Currency:     item.Currency,
+		LandedCost:   item.LandedCost,
+		Condition:    item.Condition,
+		ListingType:  listingTypes[item.ListingType],
+		SellerRating: item.SellerRating,
+		URL:          item.ItemURL,
+	}
+	if compared.ListingType == "" {
+		compared.ListingType = item.ListingType
+	}
+	if price := itemPrice(item); price != unknownPrice {
+		compared.Price, compared.priced = price, true
+		compared.Shipping, _ = strconv.ParseFloat(item.ShippingCost, 64)
+		compared.Total = compared.Price + compared.Shipping
+	}
+	if end, err := time.Parse(time.RFC3339, item.EndTime); err == nil {
+		compared.EndTime, compared.ends = item.EndTime, end
+		compared.TimeLeft = formatEndTime(end, now, location)
+	}
+	return compared
+}
+
+// compareItems Compares two items, the cheaper is the one with the lower total in the same currency
+func compareItems(a, b comparedItem) comparison {
+	result := comparison{Items: [2]comparedItem{a, b}}
+	if a.priced && b.priced && a.Currency == b.Currency && a.Total != b.Total {
+		result.Cheaper = a.Number
+		if b.Total < a.Total {
+			result.Cheaper = b.Number
+		}
+	}
+	if !a.ends.IsZero() && !b.ends.IsZero() && !a.ends.Equal(b.ends) {
+		result.EndsSooner = a.Number
+		if b.ends.Before(a.ends) {
+			result.EndsSooner = b.Number
+		}
+	}
+	return result
+}
+
+// renderComparison Writes the comparison as a text table with a line telling which item wins what
+func renderComparison(c comparison) string {
+	a, b := c.Items[0], c.Items[1]
+	money := func(item comparedItem, value float64) string {
+		if !item.priced {
+			return "unknown"
+		}
+		return strconv.FormatFloat(value, 'f', 2, 64) + " " + item.Currency
+	}
+	rows := [][3]string{
+		{"", "Item " + strconv.Itoa(a.Number), "Item " + strconv.Itoa(b.Number)},
+		{"Title", a.Title, b.Title},
+		{"Price", money(a, a.Price), money(b, b.Price)},
+		{"Shipping", money(a, a.Shipping), money(b, b.Shipping)},
+		{"Total", money(a, a.Total), money(b, b.Total)},
+		{"Landed cost", orDash(a.LandedCost), orDash(b.LandedCost)},
+		{"Condition", orDash(a.Condition), orDash(b.Condition)},
+		{"Listing", orDash(a.ListingType), orDash(b.ListingType)},
+		{"Ends", orDash(a.TimeLeft), orDash(b.TimeLeft)},
+		{"Seller rating", orDash(a.SellerRating), orDash(b.SellerRating)},
+		{"URL", a.URL, b.URL},
+	}
+	labelWidth, firstWidth := 0, 0
+	for _, row := range rows {
+		if len(row[0]) > labelWidth {
+			labelWidth = len(row[0])
+		}
+		if len(row[1]) > firstWidth && row[0] != "URL" && row[0] != "Title" {
+			firstWidth = len(row[1])
+		}
+	}
+	var table strings.Builder
+	for _, row := range rows {
+		table.WriteString("\n " + padRight(row[0], labelWidth+1) + "| " + padRight(row[1], firstWidth+1) + "| " + row[2])
+	}
+
+	var verdict []string
+	if c.Cheaper != 0 {
+		verdict = append(verdict, "item "+strconv.Itoa(c.Cheaper)+" is cheaper")
+	}
+	if c.EndsSooner != 0 {
+		verdict = append(verdict, "item "+strconv.Itoa(c.EndsSooner)+" ends sooner")
+	}
+	if len(verdict) > 0 {
+		table.WriteString("\n\n In short, " + strings.Join(verdict, " and ") + ".")
+	}
+	return table.String()
+}
+
+// orDash Returns value, or "-" for a value that is missing
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// handleCompareCommand Answers "compare <n> and <m>" about the items of the last search, reporting whether message was one
+func handleCompareCommand(session Session, message string, w http.ResponseWriter) bool {
+	match := compareCommand.FindStringSubmatch(message)
+	if match == nil {
+		return false
+	}
+	first, _ := strconv.Atoi(match[1])
+	second, _ := strconv.Atoi(match[2])
+	if first == second {
+		WriteReply(w, ReplyInfo, JSON{"message": "Comparing item " + match[1] + " with itself won't tell you much, pick two different numbers."})
+		return true
+	}
+	var compared []comparedItem
+	now := time.Now()
+	for _, number := range []int{first, second} {
+		item, problem := listedItem(session, number)
+		if problem != "" {
+			WriteReply(w, ReplyInfo, JSON{"message": problem})
+			return true
+		}
+		compared = append(compared, compareItem(number, item, now, requestLocation(w)))
+	}
+	result := compareItems(compared[0], compared[1])
+	WriteReply(w, ReplyInfo, JSON{
+		"message":    "Item " + match[1] + " and item " + match[2] + " side by side:\n" + renderComparison(result),
+		"comparison": result,
+	})
+	return true
+}
@@ -0,0 +1,120 @@
+package theluxuryshopper
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareCommand(t *testing.T) {
+	tests := []struct {
+		message       string
+		first, second string
+	}{
+		{"compare 1 and 4", "1", "4"},
+		{"Compare #2 vs 3", "2", "3"},
+		{"compare items 2 & 5!", "2", "5"},
+		{"compare 10, 12", "10", "12"},
+		{"compare item 1 versus item 2", "", ""},
+		{"compare 1", "", ""},
+		{"please compare 1 and 2", "", ""},
+	}
+	for _, test := range tests {
+		match := compareCommand.FindStringSubmatch(test.message)
+		first, second := "", ""
+		if match != nil {
+			first, second = match[1], match[2]
+		}
+		if first != test.first || second != test.second {
+			t.Errorf("compareCommand on %q matched %q and %q, want %q and %q", test.message, first, second, test.first, test.second)
+		}
+	}
+}
+
+func TestCompareItems(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	item := func(price, shipping, currency, end string) Item {
+		return Item{Title: "Bag", Price: price, ShippingCost: shipping, Currency: currency, EndTime: end, ListingType: "FixedPrice"}
+	}
+	tomorrow, nextWeek := now.Add(24*time.Hour).Format(time.RFC3339), now.Add(7*24*time.Hour).Format(time.RFC3339)
+	tests := []struct {
+		name                    string
+		a, b                    Item
+		wantCheaper, wantSooner int
+	}{
+		{"cheaper and sooner", item("100", "10", "USD", tomorrow), item("90", "30", "USD", nextWeek), 1, 1},
+		{"shipping decides", item("100", "0", "USD", nextWeek), item("90", "20", "USD", tomorrow), 1, 2},
+		{"same total", item("100", "10", "USD", ""), item("105", "5", "USD", ""), 0, 0},
+		{"other currency", item("100", "0", "USD", tomorrow), item("50", "0", "EUR", tomorrow), 0, 0},
+		{"unknown price", item("", "0", "USD", tomorrow), item("50", "0", "USD", nextWeek), 0, 1},
+		{"no end time", item("100", "0", "USD", "soon"), item("150", "0", "USD", tomorrow), 1, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := compareItem(1, test.a, now, nil), compareItem(2, test.b, now, nil)
+			got := compareItems(a, b)
+			if got.Cheaper != test.wantCheaper || got.EndsSooner != test.wantSooner {
+				t.Errorf("compareItems() = cheaper %v, sooner %v, want %v and %v", got.Cheaper, got.EndsSooner, test.wantCheaper, test.wantSooner)
+			}
+			if a.ListingType != "Buy It Now" {
+				t.Errorf("compareItem() named the listing type %q", a.ListingType)
+			}
+		})
+	}
+}
+
+func TestRenderComparison(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	a := compareItem(1, Item{Title: "Kelly 28", Price: "9500", ShippingCost: "0", Currency: "USD", ItemURL: "https://www.ebay.com/itm/1"}, now, nil)
+	b := compareItem(3, Item{Title: "Kelly 32", Currency: "USD", Condition: "Used", ItemURL: "https://www.ebay.com/itm/3"}, now, nil)
+	want := strings.Join([]string{
+		"",
+		"               | Item 1      | Item 3",
+		" Title         | Kelly 28    | Kelly 32",
+		" Price         | 9500.00 USD | unknown",
+		" Shipping      | 0.00 USD    | unknown",
+		" Total         | 9500.00 USD | unknown",
+		" Landed cost   | -           | -",
+		" Condition     | -           | Used",
+		" Listing       | -           | -",
+		" Ends          | -           | -",
+		" Seller rating | -           | -",
+		" URL           | https://www.ebay.com/itm/1 | https://www.ebay.com/itm/3",
+	}, "\n")
+	if got := renderComparison(compareItems(a, b)); got != want {
+		t.Errorf("renderComparison() =\n%v\nwant\n%v", got, want)
+	}
+
+	verdict := renderComparison(comparison{Items: [2]comparedItem{a, b}, Cheaper: 1, EndsSooner: 3})
+	if !strings.HasSuffix(verdict, "\n\n In short, item 1 is cheaper and item 3 ends sooner.") {
+		t.Errorf("renderComparison() ends with %q", verdict[strings.LastIndex(verdict, "\n"):])
+	}
+}
+
+func TestHandleCompareCommand(t *testing.T) {
+	s := newTestServer(t, Config{}, &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 3)}}})
+	c := startConversation(t, s)
+	if reply := c.say("compare 1 and 2"); !strings.HasPrefix(reply.message(), "There are no results to pick from yet") {
+		t.Errorf("compare before a search answered %v", reply.Raw)
+	}
+	c.sayAll("gucci bag", "new", "none", "none")
+
+	tests := []struct {
+		message, want string
+	}{
+		{"compare 1 and 3", "Item 1 and item 3 side by side:\n"},
+		{"compare 2 and 2", "Comparing item 2 with itself"},
+		{"compare 1 and 9", "There is no item 9 on the pages shown"},
+	}
+	for _, test := range tests {
+		reply := c.say(test.message)
+		if reply.Body["type"] != string(ReplyInfo) || !strings.HasPrefix(reply.message(), test.want) {
+			t.Errorf("%q answered %v, want a message starting with %q", test.message, reply.Raw, test.want)
+		}
+	}
+	reply := c.say("compare 3 vs 1")
+	comparison, _ := reply.Body["comparison"].(map[string]interface{})
+	if comparison["cheaper"] != 1.0 || !strings.Contains(reply.message(), "In short, item 1 is cheaper.") {
+		t.Errorf("compare 3 vs 1 answered %v", reply.Raw)
+	}
+}
@@ -0,0 +1,482 @@
+package theluxuryshopper
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config Holds the deployment settings read from the environment
+type Config struct {
+	Port string
+
+	// TLSCertFile and TLSKeyFile serve HTTPS from a certificate pair
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// AutocertDomains serve HTTPS with Let's Encrypt certificates for these domains
+	AutocertDomains  []string
+	AutocertCacheDir string
+	AutocertEmail    string
+	// HTTPPort answers the ACME HTTP-01 challenges and redirects everything else to HTTPS
+	HTTPPort string
+
+	// EbayEnv is production or sandbox, EbayAppID is the app id of that environment
+	EbayEnv   string
+	EbayAppID string
+	// EbayEndpoint replaces the Finding API URL of EbayEnv, to search through a proxy or a stub
+	EbayEndpoint string
+	// EbayShoppingEndpoint replaces the Shopping API URL of EbayEnv the same way, for GET /items/:itemId
+	EbayShoppingEndpoint string
+
+	// DailyCallLimit is the eBay calls allowed per 24 hours, 0 means unlimited.
+	// The counts survive restarts when QuotaFile is set.
+	DailyCallLimit int
+	QuotaFile      string
+
+	// SessionTTL is how long a session survives without messages
+	SessionTTL time.Duration
+	// ProfileTTL is how long the preferences and saved searches of a userId survive without sessions.
+	// The profiles survive restarts when ProfileFile is set.
+	ProfileTTL  time.Duration
+	ProfileFile string
+
+	// SearchTimeout bounds one search, 0 means no bound. Searches outliving
+	// SearchSoftDeadline still answer but their reply is marked slow.
+	SearchTimeout      time.Duration
+	SearchSoftDeadline time.Duration
+
+	// EbayConnectTimeout, EbayTLSTimeout and EbayHeaderTimeout bound the dial, the TLS handshake
+	// and the wait for the response headers of one eBay call, 0 means the default
+	EbayConnectTimeout time.Duration
+	EbayTLSTimeout     time.Duration
+	EbayHeaderTimeout  time.Duration
+	// ThrottleCooldown is how long searches skip eBay after it throttled the app id, 0 means the default
+	ThrottleCooldown time.Duration
+
+	// Chaos injects faults into the searches for resilience testing, it is refused in production
+	Chaos ChaosConfig
+	// Ranking re-ranks the items of every search by the weighted signals, eBay's order stays while it is off
+	Ranking RankingWeights
+
+	// SelfTest is strict or lenient to run one search at startup, empty disables the self-test.
+	// A failed strict self-test stops the startup, a failed lenient one fails /readyz.
+	SelfTest string
+
+	// DefaultFilters apply to every search unless the user overrides them
+	DefaultFilters DefaultFilters
+
+	// Steps lists the conversation steps asked in order, nil means defaultSteps.
+	// The filters of the steps left out are searched with "none".
+	Steps []string
+
+	// NoneSynonyms are answers meaning "none" on top of defaultNoneSynonyms
+	NoneSynonyms []string
+
+	// KeywordBlocklist holds the terms that are never searched
+	KeywordBlocklist []string
+
+	// ProfanityList holds the terms chat messages are refused for
+	ProfanityList []string
+	// AccessoryTerms holds the terms of the titles "exclude accessories" leaves out, like the blocklist
+	// terms. The built-in list is used when it is nil.
+	AccessoryTerms []string
+
+	// LandedCostRates holds the VAT and duty rates of the landed cost estimates, nil means the defaults
+	LandedCostRates LandedCostRates
+
+	// ImageProxySecret signs the item image URLs served through /img, the proxy is disabled when it is empty
+	ImageProxySecret string
+	// PublicBaseURL is where clients reach the server, an absolute URL like https://shop.example.com or a
+	// path prefix like /shopper behind a proxy. The /img URLs start with it; when it is empty they start with
+	// the first of AutocertDomains, or are bare paths.
+	PublicBaseURL string
+
+	// SessionBackupSecret signs the session backups of /session/export, backups are disabled when it is empty
+	SessionBackupSecret string
+
+	// APIKeys are the keys /welcome and /search need in the X-Api-Key header, they are open when it is empty
+	APIKeys []string
+
+	// AdminToken is the bearer token of the admin routes like /batch/search, they are disabled when it is empty
+	AdminToken string
+	// AutoProvisionSessions lets /chat create the session of a well-formed uuid it doesn't know,
+	// so clients can skip /welcome
+	AutoProvisionSessions bool
+
+	// MaxTurns is how many messages a search may take, the last one searches with what was answered
+	// so far or starts over when there is no keyword yet. 0 allows any number.
+	MaxTurns int
+
+	// SellerCollapseShare is the share of a page one seller may own before their listings past the
+	// first are collapsed under a note. 0, the default, never collapses.
+	SellerCollapseShare float64
+
+	// RestrictAfterFailures is how many searches in a row eBay may refuse for their input before the
+	// session can't search for RestrictCooldown. 0 never restricts.
+	RestrictAfterFailures int
+	RestrictCooldown      time.Duration
+
+	// DisableHTMLMessages strips every tag and angle bracket from the messages of the replies, for
+	// embedders putting them into their page as they are
+	DisableHTMLMessages bool
+
+	// SearchWebhookURL is posted a copy of every successful search, SearchWebhookSecret signs it
+	// in the X-Webhook-Signature header when it is set
+	SearchWebhookURL    string
+	SearchWebhookSecret string
+
+	// Maintenance starts the server in maintenance mode, where /welcome and /chat answer 503 with
+	// MaintenanceMessage and a Retry-After of MaintenanceRetryAfter. POST /admin/maintenance toggles it.
+	Maintenance           bool
+	MaintenanceMessage    string
+	MaintenanceRetryAfter time.Duration
+
+	// FunnelFlushInterval is how often the funnel counts are sent to an EventSink taking them, 0 never sends them
+	FunnelFlushInterval time.Duration
+
+	// BrandName names the chatbot in its greetings and pages, BrandAccentColor is the #rrggbb color of the pages
+	BrandName        string
+	BrandAccentColor string
+	// Bots are the white-labeled bots served under /bots/:name, each with its own settings on top of these
+	Bots map[string]BotProfile
+
+	// EventsFile is the JSON lines file the analytics events are appended to, if set
+	EventsFile string
+	// AuditLogFile is the JSON lines file every admin request is recorded in, if set. It is rotated
+	// once it reaches AuditLogMaxSize bytes.
+	AuditLogFile    string
+	AuditLogMaxSize int64
+
+	// TraceEndpoint is the OTLP/HTTP traces endpoint, tracing is disabled when it is empty
+	TraceEndpoint    string
+	TraceServiceName string
+}
+
+// LoadConfig Reads the Config from the environment and validates it
+func LoadConfig() (Config, error) {
+	config := Config{
+		Port:             os.Getenv("PORT"),
+		TLSCertFile:      os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:       os.Getenv("TLS_KEY_FILE"),
+		AutocertCacheDir: os.Getenv("AUTOCERT_CACHE_DIR"),
+		AutocertEmail:    os.Getenv("AUTOCERT_EMAIL"),
+		HTTPPort:         os.Getenv("HTTP_PORT"),
+	}
+	// Default to 8080 if no PORT environment variable was defined
+	if config.Port == "" {
+		config.Port = "8080"
+	}
+	if config.HTTPPort == "" {
+		config.HTTPPort = "80"
+	}
+
+	_, autocertRequested := os.LookupEnv("AUTOCERT_DOMAINS")
+	for _, domain := range strings.Split(os.Getenv("AUTOCERT_DOMAINS"), ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			config.AutocertDomains = append(config.AutocertDomains, domain)
+		}
+	}
+	if autocertRequested && len(config.AutocertDomains) == 0 {
+		return config, errors.New("AUTOCERT_DOMAINS is set but lists no domain")
+	}
+	if config.AutocertCacheDir == "" {
+		config.AutocertCacheDir = "autocert-cache"
+	}
+
+	config.EbayEnv = os.Getenv("EBAY_ENV")
+	if config.EbayEnv == "" {
+		config.EbayEnv = "production"
+	}
+	switch config.EbayEnv {
+	case "production":
+		config.EbayAppID = os.Getenv("EBAY_APP_ID")
+		if config.EbayAppID == "" {
+			config.EbayAppID = defaultAppID
+		}
+	case "sandbox":
+		config.EbayAppID = os.Getenv("EBAY_SANDBOX_APP_ID")
+	}
+	if endpoint := os.Getenv("EBAY_ENDPOINT"); endpoint != "" {
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return config, fmt.Errorf("EBAY_ENDPOINT must be an http or https URL, got %q", endpoint)
+		}
+		config.EbayEndpoint = endpoint
+	}
+	if endpoint := os.Getenv("EBAY_SHOPPING_ENDPOINT"); endpoint != "" {
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return config, fmt.Errorf("EBAY_SHOPPING_ENDPOINT must be an http or https URL, got %q", endpoint)
+		}
+		config.EbayShoppingEndpoint = endpoint
+	}
+
+	config.DailyCallLimit = defaultDailyCallLimit
+	if limit := os.Getenv("EBAY_DAILY_CALL_LIMIT"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 0 {
+			return config, fmt.Errorf("EBAY_DAILY_CALL_LIMIT must be a number of calls, not %q", limit)
+		}
+		config.DailyCallLimit = parsed
+	}
+	config.QuotaFile = os.Getenv("QUOTA_FILE")
+
+	config.SessionTTL = defaultSessionTTL
+	if ttl := os.Getenv("SESSION_TTL"); ttl != "" {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil || parsed <= 0 {
+			return config, fmt.Errorf("SESSION_TTL must be a positive duration like 30m, not %q", ttl)
+		}
+		config.SessionTTL = parsed
+	}
+
+	config.MaxTurns = defaultMaxTurns
+	if turns := os.Getenv("MAX_TURNS"); turns != "" {
+		parsed, err := strconv.Atoi(turns)
+		if err != nil || parsed < 0 {
+			return config, fmt.Errorf("MAX_TURNS must be a number of messages, 0 for no limit, not %q", turns)
+		}
+		config.MaxTurns = parsed
+	}
+
+	if value := os.Getenv("SELLER_COLLAPSE_SHARE"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return config, fmt.Errorf("SELLER_COLLAPSE_SHARE must be a share from 0 to 1, 0 to never collapse, not %q", value)
+		}
+		config.SellerCollapseShare = parsed
+	}
+	config.RestrictAfterFailures = defaultRestrictAfterFailures
+	if failures := os.Getenv("RESTRICT_AFTER_FAILURES"); failures != "" {
+		parsed, err := strconv.Atoi(failures)
+		if err != nil || parsed < 0 {
+			return config, fmt.Errorf("RESTRICT_AFTER_FAILURES must be a number of searches, 0 to never restrict, not %q", failures)
+		}
+		config.RestrictAfterFailures = parsed
+	}
+
+	config.ProfileTTL = defaultProfileTTL
+	if ttl := os.Getenv("PROFILE_TTL"); ttl != "" {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil || parsed <= 0 {
+			return config, fmt.Errorf("PROFILE_TTL must be a positive duration like 2160h, not %q", ttl)
+		}
+		config.ProfileTTL = parsed
+	}
+	config.ProfileFile = os.Getenv("PROFILE_FILE")
+
+	config.SearchTimeout, config.SearchSoftDeadline = defaultSearchTimeout, defaultSearchSoftDeadline
+	config.RestrictCooldown = defaultRestrictCooldown
+	for name, limit := range map[string]*time.Duration{
+		"SEARCH_TIMEOUT":          &config.SearchTimeout,
+		"SEARCH_SOFT_DEADLINE":    &config.SearchSoftDeadline,
+		"EBAY_CONNECT_TIMEOUT":    &config.EbayConnectTimeout,
+		"EBAY_TLS_TIMEOUT":        &config.EbayTLSTimeout,
+		"EBAY_HEADER_TIMEOUT":     &config.EbayHeaderTimeout,
+		"EBAY_THROTTLE_COOLDOWN":  &config.ThrottleCooldown,
+		"FUNNEL_FLUSH_INTERVAL":   &config.FunnelFlushInterval,
+		"RESTRICT_COOLDOWN":       &config.RestrictCooldown,
+		"MAINTENANCE_RETRY_AFTER": &config.MaintenanceRetryAfter,
+	} {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed < 0 {
+				return config, fmt.Errorf("%v must be a duration like 1500ms, not %q", name, value)
+			}
+			*limit = parsed
+		}
+	}
+
+	config.SelfTest = strings.ToLower(os.Getenv("SELF_TEST"))
+
+	chaos, err := chaosFromEnv()
+	if err != nil {
+		return config, err
+	}
+	config.Chaos = chaos
+	if config.Ranking, err = rankingFromEnv(); err != nil {
+		return config, err
+	}
+
+	defaults, err := defaultFiltersFromEnv()
+	if err != nil {
+		return config, err
+	}
+	config.DefaultFilters = defaults
+
+	if list, found := os.LookupEnv("STEPS"); found {
+		if config.Steps, err = parseSteps(list); err != nil {
+			return config, err
+		}
+	}
+
+	config.NoneSynonyms = strings.Split(os.Getenv("NONE_SYNONYMS"), ",")
+
+	config.KeywordBlocklist = strings.Split(os.Getenv("KEYWORD_BLOCKLIST"), ",")
+	if path := os.Getenv("KEYWORD_BLOCKLIST_FILE"); path != "" {
+		terms, err := readBlocklistFile(path)
+		if err != nil {
+			return config, fmt.Errorf("couldn't read KEYWORD_BLOCKLIST_FILE: %v", err)
+		}
+		config.KeywordBlocklist = append(config.KeywordBlocklist, terms...)
+	}
+
+	config.ProfanityList = strings.Split(os.Getenv("PROFANITY_LIST"), ",")
+	if path := os.Getenv("PROFANITY_LIST_FILE"); path != "" {
+		terms, err := readBlocklistFile(path)
+		if err != nil {
+			return config, fmt.Errorf("couldn't read PROFANITY_LIST_FILE: %v", err)
+		}
+		config.ProfanityList = append(config.ProfanityList, terms...)
+	}
+
+	if path := os.Getenv("ACCESSORY_TERMS_FILE"); path != "" {
+		if config.AccessoryTerms, err = readBlocklistFile(path); err != nil {
+			return config, fmt.Errorf("couldn't read ACCESSORY_TERMS_FILE: %v", err)
+		}
+	}
+
+	config.LandedCostRates = defaultLandedCostRates()
+	if path := os.Getenv("LANDED_COST_FILE"); path != "" {
+		if err := readLandedCostFile(path, config.LandedCostRates); err != nil {
+			return config, fmt.Errorf("couldn't read LANDED_COST_FILE: %v", err)
+		}
+	}
+
+	config.EventsFile = os.Getenv("EVENTS_FILE")
+	config.AuditLogFile = os.Getenv("AUDIT_LOG_FILE")
+	config.AuditLogMaxSize = defaultAuditLogMaxSize
+	if size := os.Getenv("AUDIT_LOG_MAX_SIZE"); size != "" {
+		parsed, err := strconv.ParseInt(size, 10, 64)
+		if err != nil || parsed <= 0 {
+			return config, fmt.Errorf("AUDIT_LOG_MAX_SIZE must be a positive number of bytes, not %q", size)
+		}
+		config.AuditLogMaxSize = parsed
+	}
+	config.ImageProxySecret = os.Getenv("IMAGE_PROXY_SECRET")
+	config.PublicBaseURL = strings.TrimSuffix(os.Getenv("PUBLIC_BASE_URL"), "/")
+	config.SessionBackupSecret = os.Getenv("SESSION_BACKUP_SECRET")
+	config.AdminToken = os.Getenv("ADMIN_TOKEN")
+	config.SearchWebhookURL = os.Getenv("SEARCH_WEBHOOK_URL")
+	config.SearchWebhookSecret = os.Getenv("SEARCH_WEBHOOK_SECRET")
+	config.APIKeys = strings.Split(os.Getenv("API_KEYS"), ",")
+	if value := os.Getenv("AUTO_PROVISION_SESSIONS"); value != "" {
+		if config.AutoProvisionSessions, err = strconv.ParseBool(value); err != nil {
+			return config, fmt.Errorf("AUTO_PROVISION_SESSIONS must be true or false, not %q", value)
+		}
+	}
+	if value := os.Getenv("MAINTENANCE"); value != "" {
+		if config.Maintenance, err = strconv.ParseBool(value); err != nil {
+			return config, fmt.Errorf("MAINTENANCE must be true or false, not %q", value)
+		}
+	}
+	config.MaintenanceMessage = strings.TrimSpace(os.Getenv("MAINTENANCE_MESSAGE"))
+	if value := os.Getenv("DISABLE_HTML_MESSAGES"); value != "" {
+		if config.DisableHTMLMessages, err = strconv.ParseBool(value); err != nil {
+			return config, fmt.Errorf("DISABLE_HTML_MESSAGES must be true or false, not %q", value)
+		}
+	}
+	config.BrandName = strings.TrimSpace(os.Getenv("BRAND_NAME"))
+	if config.BrandName == "" {
+		config.BrandName = defaultBrandName
+	}
+	config.BrandAccentColor = strings.TrimSpace(os.Getenv("BRAND_ACCENT_COLOR"))
+	if config.BrandAccentColor == "" {
+		config.BrandAccentColor = defaultBrandAccentColor
+	}
+	if path := os.Getenv("BOTS_FILE"); path != "" {
+		if config.Bots, err = readBotsFile(path); err != nil {
+			return config, fmt.Errorf("couldn't read BOTS_FILE: %v", err)
+		}
+	}
+
+	config.TraceEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); config.TraceEndpoint == "" && base != "" {
+		config.TraceEndpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+	config.TraceServiceName = os.Getenv("OTEL_SERVICE_NAME")
+	if config.TraceServiceName == "" {
+		config.TraceServiceName = "theluxuryshopper"
+	}
+
+	return config, config.validate()
+}
+
+// defaultFiltersFromEnv Reads the DEFAULT_* filter variables
+func defaultFiltersFromEnv() (DefaultFilters, error) {
+	var defaults DefaultFilters
+	if condition := os.Getenv("DEFAULT_CONDITION"); condition != "" {
+		if defaults.Condition = normalizeCondition(condition); defaults.Condition == "" {
+			return defaults, fmt.Errorf("DEFAULT_CONDITION must be New, Used or None, not %q", condition)
+		}
+	}
+	if minPrice := os.Getenv("DEFAULT_MIN_PRICE"); minPrice != "" {
+		if defaults.MinPrice = normalizePrice(minPrice); defaults.MinPrice == "" {
+			return defaults, fmt.Errorf("DEFAULT_MIN_PRICE must be a number, not %q", minPrice)
+		}
+	}
+	if maxPrice := os.Getenv("DEFAULT_MAX_PRICE"); maxPrice != "" {
+		if defaults.MaxPrice = normalizePrice(maxPrice); defaults.MaxPrice == "" {
+			return defaults, fmt.Errorf("DEFAULT_MAX_PRICE must be a number, not %q", maxPrice)
+		}
+	}
+	defaults.Site = strings.ToUpper(os.Getenv("DEFAULT_SITE"))
+	for name, flag := range map[string]*bool{"DEFAULT_FREE_SHIPPING": &defaults.FreeShipping, "DEFAULT_TOP_RATED": &defaults.TopRated} {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return defaults, fmt.Errorf("%v must be true or false, not %q", name, value)
+			}
+			*flag = parsed
+		}
+	}
+	return defaults, nil
+}
+
+// validate Rejects settings that can't be served together
+func (c Config) validate() error {
+	if c.TLSCertFile != "" && c.TLSKeyFile == "" {
+		return errors.New("TLS_CERT_FILE is set without TLS_KEY_FILE")
+	}
+	if c.TLSKeyFile != "" && c.TLSCertFile == "" {
+		return errors.New("TLS_KEY_FILE is set without TLS_CERT_FILE")
+	}
+	if c.TLSCertFile != "" && len(c.AutocertDomains) > 0 {
+		return errors.New("TLS_CERT_FILE and AUTOCERT_DOMAINS can't be used together")
+	}
+	if _, found := ebayHosts[c.EbayEnv]; !found {
+		return fmt.Errorf("EBAY_ENV must be production or sandbox, not %q", c.EbayEnv)
+	}
+	if c.EbayEnv == "sandbox" && c.EbayAppID == "" {
+		return errors.New("EBAY_ENV=sandbox requires EBAY_SANDBOX_APP_ID")
+	}
+	if c.Chaos.Enabled() && c.EbayEnv != "sandbox" {
+		return errChaosInProduction
+	}
+	if c.SearchWebhookURL != "" {
+		if u, err := url.Parse(c.SearchWebhookURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("SEARCH_WEBHOOK_URL must be an http or https URL, not %q", c.SearchWebhookURL)
+		}
+	}
+	if c.PublicBaseURL != "" && !strings.HasPrefix(c.PublicBaseURL, "/") {
+		if u, err := url.Parse(c.PublicBaseURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("PUBLIC_BASE_URL must be an http or https URL or a path starting with /, not %q", c.PublicBaseURL)
+		}
+	}
+	if c.SearchTimeout > 0 && c.SearchSoftDeadline >= c.SearchTimeout {
+		return errors.New("SEARCH_SOFT_DEADLINE must be shorter than SEARCH_TIMEOUT")
+	}
+	if c.SelfTest != "" && c.SelfTest != selfTestStrict && c.SelfTest != selfTestLenient {
+		return fmt.Errorf("SELF_TEST must be strict or lenient, not %q", c.SelfTest)
+	}
+	if c.BrandAccentColor != "" && !brandColor.MatchString(c.BrandAccentColor) {
+		return fmt.Errorf("BRAND_ACCENT_COLOR must be a color like #c48843, not %q", c.BrandAccentColor)
+	}
+	if len(c.AutocertDomains) > 0 && c.HTTPPort == c.Port {
+		return errors.New("HTTP_PORT must differ from PORT when AUTOCERT_DOMAINS is set")
+	}
+	return nil
+}
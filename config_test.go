@@ -0,0 +1,149 @@
+package theluxuryshopper
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setEnv Sets the variables of env for the test and unsets the other names, so the environment of the machine doesn't leak in
+func setEnv(t *testing.T, env map[string]string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		// Setenv first so the value is restored after the test
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+}
+
+// configVariables Are the variables the LoadConfig tests set
+var configVariables = []string{
+	"PORT", "HTTP_PORT", "AUTOCERT_DOMAINS", "EBAY_ENV", "EBAY_APP_ID", "EBAY_SANDBOX_APP_ID", "EBAY_ENDPOINT",
+	"EBAY_DAILY_CALL_LIMIT", "SESSION_TTL", "MAX_TURNS", "SEARCH_TIMEOUT", "SEARCH_SOFT_DEADLINE",
+	"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "BRAND_NAME", "MAINTENANCE", "API_KEYS", "PUBLIC_BASE_URL",
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(c Config) bool
+		wantErr string
+	}{
+		{"defaults", nil, func(c Config) bool {
+			return c.Port == "8080" && c.HTTPPort == "80" && c.EbayEnv == "production" && c.EbayAppID == defaultAppID &&
+				c.DailyCallLimit == defaultDailyCallLimit && c.SessionTTL == defaultSessionTTL && c.MaxTurns == defaultMaxTurns &&
+				c.BrandName == defaultBrandName && c.TraceServiceName == "theluxuryshopper" && c.TraceEndpoint == ""
+		}, ""},
+		{"sandbox", map[string]string{"EBAY_ENV": "sandbox", "EBAY_SANDBOX_APP_ID": "sandbox-id", "EBAY_APP_ID": "prod-id"}, func(c Config) bool {
+			return c.EbayEnv == "sandbox" && c.EbayAppID == "sandbox-id"
+		}, ""},
+		{"limits", map[string]string{"EBAY_DAILY_CALL_LIMIT": "0", "SESSION_TTL": "1h", "MAX_TURNS": "0", "SEARCH_TIMEOUT": "3s", "SEARCH_SOFT_DEADLINE": "1s"}, func(c Config) bool {
+			return c.DailyCallLimit == 0 && c.SessionTTL == time.Hour && c.MaxTurns == 0 && c.SearchTimeout == 3*time.Second && c.SearchSoftDeadline == time.Second
+		}, ""},
+		{"trace endpoint from the base", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318/"}, func(c Config) bool {
+			return c.TraceEndpoint == "http://collector:4318/v1/traces"
+		}, ""},
+		{"trace endpoint beats the base", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://traces:4318/t"}, func(c Config) bool {
+			return c.TraceEndpoint == "http://traces:4318/t"
+		}, ""},
+		{"autocert", map[string]string{"AUTOCERT_DOMAINS": " shop.example.com, ,www.shop.example.com", "PORT": "443"}, func(c Config) bool {
+			return strings.Join(c.AutocertDomains, " ") == "shop.example.com www.shop.example.com"
+		}, ""},
+		{"switches", map[string]string{"MAINTENANCE": "true", "BRAND_NAME": "  Acme  ", "API_KEYS": "a,b", "PUBLIC_BASE_URL": "https://shop.example.com/"}, func(c Config) bool {
+			return c.Maintenance && c.BrandName == "Acme" && len(c.APIKeys) == 2 && c.PublicBaseURL == "https://shop.example.com"
+		}, ""},
+		{"autocert without domains", map[string]string{"AUTOCERT_DOMAINS": " , "}, nil, "AUTOCERT_DOMAINS"},
+		{"autocert on the http port", map[string]string{"AUTOCERT_DOMAINS": "shop.example.com", "PORT": "80"}, nil, "HTTP_PORT"},
+		{"unknown environment", map[string]string{"EBAY_ENV": "staging"}, nil, "EBAY_ENV"},
+		{"sandbox without app id", map[string]string{"EBAY_ENV": "sandbox"}, nil, "EBAY_SANDBOX_APP_ID"},
+		{"relative endpoint", map[string]string{"EBAY_ENDPOINT": "/finding"}, nil, "EBAY_ENDPOINT"},
+		{"negative call limit", map[string]string{"EBAY_DAILY_CALL_LIMIT": "-1"}, nil, "EBAY_DAILY_CALL_LIMIT"},
+		{"zero session ttl", map[string]string{"SESSION_TTL": "0s"}, nil, "SESSION_TTL"},
+		{"soft deadline past the timeout", map[string]string{"SEARCH_TIMEOUT": "1s", "SEARCH_SOFT_DEADLINE": "2s"}, nil, "SEARCH_SOFT_DEADLINE"},
+		{"bad duration", map[string]string{"SEARCH_TIMEOUT": "soon"}, nil, "SEARCH_TIMEOUT"},
+		{"bad switch", map[string]string{"MAINTENANCE": "maybe"}, nil, "MAINTENANCE"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setEnv(t, test.env, configVariables...)
+			config, err := LoadConfig()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("LoadConfig() = %v, want an error naming %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() = %v", err)
+			}
+			if !test.check(config) {
+				t.Errorf("LoadConfig() = %+v", config)
+			}
+		})
+	}
+}
+
+func TestDefaultFiltersFromEnv(t *testing.T) {
+	names := []string{"DEFAULT_CONDITION", "DEFAULT_MIN_PRICE", "DEFAULT_MAX_PRICE", "DEFAULT_SITE", "DEFAULT_FREE_SHIPPING", "DEFAULT_TOP_RATED"}
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    DefaultFilters
+		wantErr string
+	}{
+		{"unset", nil, DefaultFilters{}, ""},
+		{
+			"every filter",
+			map[string]string{"DEFAULT_CONDITION": "used", "DEFAULT_MIN_PRICE": "$100", "DEFAULT_MAX_PRICE": "2,000", "DEFAULT_SITE": "ebay-gb", "DEFAULT_FREE_SHIPPING": "true", "DEFAULT_TOP_RATED": "1"},
+			DefaultFilters{Condition: "Used", MinPrice: "100", MaxPrice: "2000", Site: "EBAY-GB", FreeShipping: true, TopRated: true},
+			"",
+		},
+		{"bad condition", map[string]string{"DEFAULT_CONDITION": "mint"}, DefaultFilters{}, "DEFAULT_CONDITION"},
+		{"bad price", map[string]string{"DEFAULT_MAX_PRICE": "lots"}, DefaultFilters{}, "DEFAULT_MAX_PRICE"},
+		{"bad switch", map[string]string{"DEFAULT_TOP_RATED": "sure"}, DefaultFilters{}, "DEFAULT_TOP_RATED"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setEnv(t, test.env, names...)
+			got, err := defaultFiltersFromEnv()
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("defaultFiltersFromEnv() = %v, want an error naming %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Errorf("defaultFiltersFromEnv() = %+v, %v, want %+v", got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"minimal", Config{EbayEnv: "production"}, ""},
+		{"cert without key", Config{EbayEnv: "production", TLSCertFile: "cert.pem"}, "TLS_KEY_FILE"},
+		{"key without cert", Config{EbayEnv: "production", TLSKeyFile: "key.pem"}, "TLS_CERT_FILE"},
+		{"cert and autocert", Config{EbayEnv: "production", TLSCertFile: "cert.pem", TLSKeyFile: "key.pem", AutocertDomains: []string{"a.com"}}, "AUTOCERT_DOMAINS"},
+		{"relative webhook", Config{EbayEnv: "production", SearchWebhookURL: "hooks/search"}, "SEARCH_WEBHOOK_URL"},
+		{"unknown self test", Config{EbayEnv: "production", SelfTest: "thorough"}, "SELF_TEST"},
+		{"lenient self test", Config{EbayEnv: "production", SelfTest: selfTestLenient}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.validate()
+			if test.wantErr == "" && err != nil || test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)) {
+				t.Errorf("validate() = %v, want an error naming %q", err, test.wantErr)
+			}
+		})
+	}
+}
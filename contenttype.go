@@ -0,0 +1,126 @@
+package theluxuryshopper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	jsonContentType = "application/json"
+	formContentType = "application/x-www-form-urlencoded"
+	// maxFormBody Caps the form bodies of /chat, JSON ones are read by the decoder as they come
+	maxFormBody = 1 << 20
+)
+
+// chatContentTypes Are the bodies /chat accepts, as advertised by Accept-Post
+var chatContentTypes = []string{jsonContentType, formContentType}
+
+// chatContentType Returns the media type of the body of r, which /chat may or may not accept.
+// Clients older than the check often sent no Content-Type, their bodies are taken for JSON when
+// they start like a JSON object.
+func chatContentType(r *http.Request) string {
+	if header := r.Header.Get("Content-Type"); header != "" {
+		mediaType, _, err := mime.ParseMediaType(header)
+		if err != nil {
+			return header
+		}
+		// Like application/merge-patch+json, still JSON
+		if strings.HasSuffix(mediaType, "+json") {
+			return jsonContentType
+		}
+		return mediaType
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return jsonContentType
+	}
+	body := bufio.NewReader(r.Body)
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, r.Body}
+	for {
+		b, err := body.ReadByte()
+		if err != nil {
+			// An empty body answers invalid_json like it always did
+			return jsonContentType
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b)) {
+			body.UnreadByte()
+			if b == '{' {
+				return jsonContentType
+			}
+			return ""
+		}
+	}
+}
+
+// advertiseChatContentTypes Tells the client the methods and bodies /chat takes
+func advertiseChatContentTypes(w http.ResponseWriter) {
+	w.Header().Set("Allow", http.MethodPost+", "+http.MethodOptions)
+	w.Header().Set("Accept-Post", strings.Join(chatContentTypes, ", "))
+}
+
+// writeUnsupportedMediaType Answers 415 for a body of contentType, "" when it had none and didn't look like JSON
+func writeUnsupportedMediaType(w http.ResponseWriter, contentType string) {
+	advertiseChatContentTypes(w)
+	described := "a body without Content-Type that isn't a JSON object"
+	if contentType != "" {
+		described = contentType
+	}
+	writeError(w, http.StatusUnsupportedMediaType, "unsupported_media_type", "The body must be "+strings.Join(chatContentTypes, " or ")+", not "+described+".")
+}
+
+// formChatBody Converts the form body of a /chat request to the JSON object decodeChatRequest reads:
+// strings as they are, booleans parsed, and every value of a repeated field for the arrays.
+// Values that don't parse stay strings, so they answer the usual invalid_request.
+func formChatBody(body io.Reader) (io.Reader, error) {
+	raw, err := io.ReadAll(io.LimitReader(body, maxFormBody))
+	if err != nil {
+		return nil, err
+	}
+	values, err := url.ParseQuery(string(raw))
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	for field, list := range values {
+		switch chatRequestFields[field] {
+		case "boolean":
+			if parsed, err := strconv.ParseBool(list[0]); err == nil {
+				fields[field] = parsed
+				continue
+			}
+			fields[field] = list[0]
+		default:
+			fields[field] = list[0]
+		}
+	}
+	encoded, err := json.Marshal(fields)
+	return bytes.NewReader(encoded), err
+}
+
+// handleChatOptions Handles OPTIONS /chat, advertising its methods and the bodies it accepts
+func (s *Server) handleChatOptions(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	advertiseChatContentTypes(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// withChatOptions Advertises the bodies of /chat on its OPTIONS requests, which the CORS handler
+// answers itself without reaching the router
+func withChatOptions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions && strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/chat") {
+			advertiseChatContentTypes(w)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,122 @@
+package theluxuryshopper
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChatContentType(t *testing.T) {
+	tests := []struct {
+		name, header, body string
+		want               string
+	}{
+		{"json", "application/json", `{}`, jsonContentType},
+		{"json with charset", "application/json; charset=utf-8", `{}`, jsonContentType},
+		{"json suffix", "application/merge-patch+json", `{}`, jsonContentType},
+		{"form", "application/x-www-form-urlencoded", `message=hi`, formContentType},
+		{"text", "text/plain", `hi`, "text/plain"},
+		{"unparsable", "text/", `hi`, "text/"},
+		{"no header, json object", "", "  \n{\"message\": \"hi\"}", jsonContentType},
+		{"no header, empty body", "", ``, jsonContentType},
+		{"no header, text", "", `message=hi`, ""},
+		{"no header, json array", "", `[1]`, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/chat", strings.NewReader(test.body))
+			if test.header != "" {
+				r.Header.Set("Content-Type", test.header)
+			}
+			if got := chatContentType(r); got != test.want {
+				t.Errorf("chatContentType() = %q, want %q", got, test.want)
+			}
+			// Sniffing may only skip the leading blanks, the decoder gets the rest
+			if body, want := readAll(r.Body), strings.TrimLeft(test.body, " \t\r\n"); body != want && body != test.body {
+				t.Errorf("chatContentType() left the body %q, want %q", body, want)
+			}
+		})
+	}
+}
+
+// readAll Returns what is left of body
+func readAll(body io.Reader) string {
+	data, _ := io.ReadAll(body)
+	return string(data)
+}
+
+func TestFormChatBody(t *testing.T) {
+	tests := []struct {
+		form string
+		want map[string]interface{}
+	}{
+		{"message=gucci+belt", map[string]interface{}{"message": "gucci belt"}},
+		{"message=a&message=b", map[string]interface{}{"message": "a"}},
+		{"message=hi&meta=true", map[string]interface{}{"message": "hi", "meta": true}},
+		// Left a string so it answers the usual invalid_request
+		{"message=hi&meta=often", map[string]interface{}{"message": "hi", "meta": "often"}},
+		{"", map[string]interface{}{}},
+	}
+	for _, test := range tests {
+		body, err := formChatBody(strings.NewReader(test.form))
+		if err != nil {
+			t.Fatalf("formChatBody(%q) = %v", test.form, err)
+		}
+		var got map[string]interface{}
+		json.NewDecoder(body).Decode(&got)
+		if len(got) != len(test.want) {
+			t.Errorf("formChatBody(%q) = %v, want %v", test.form, got, test.want)
+			continue
+		}
+		for field, value := range test.want {
+			if got[field] != value {
+				t.Errorf("formChatBody(%q) = %v, want %v", test.form, got, test.want)
+			}
+		}
+	}
+	if _, err := formChatBody(strings.NewReader("message=%zz")); err == nil {
+		t.Error("formChatBody() of a broken form didn't fail")
+	}
+}
+
+func TestChatBodies(t *testing.T) {
+	s := newTestServer(t, Config{}, &fakeSearcher{})
+	c := startConversation(t, s)
+	tests := []struct {
+		name, contentType, body string
+		wantStatus              int
+		wantStep                string
+	}{
+		{"form", formContentType, "message=gucci+belt", http.StatusOK, "condition"},
+		{"json without content type", "", `{"message": "used"}`, http.StatusOK, "minPrice"},
+		{"text", "text/plain", "none", http.StatusUnsupportedMediaType, ""},
+		{"form without content type", "", "message=none", http.StatusUnsupportedMediaType, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s.clock.Advance(2 * duplicateGrace)
+			r := httptest.NewRequest(http.MethodPost, "/v1/chat", strings.NewReader(test.body))
+			r.Header.Set("Authorization", c.uuid)
+			if test.contentType != "" {
+				r.Header.Set("Content-Type", test.contentType)
+			}
+			w := httptest.NewRecorder()
+			c.handler.ServeHTTP(w, r)
+			reply := recordedReply(t, w)
+			if w.Code != test.wantStatus || test.wantStep != "" && reply["step"] != test.wantStep {
+				t.Errorf("the body answered %v %v, want %v and the step %q", w.Code, w.Body.String(), test.wantStatus, test.wantStep)
+			}
+			if w.Code == http.StatusUnsupportedMediaType && w.Header().Get("Accept-Post") != "application/json, application/x-www-form-urlencoded" {
+				t.Errorf("415 advertised Accept-Post %q", w.Header().Get("Accept-Post"))
+			}
+		})
+	}
+
+	options := do(t, c.handler, http.MethodOptions, "/v1/chat", "", nil)
+	if options.Header.Get("Accept-Post") == "" || !strings.Contains(options.Header.Get("Allow"), http.MethodPost) {
+		t.Errorf("OPTIONS /v1/chat answered %v %v", options.Status, options.Header)
+	}
+}
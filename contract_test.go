@@ -0,0 +1,111 @@
+package theluxuryshopper
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// contractCase Makes the request whose reply a golden file of testdata/contracts freezes, against the
+// routes under prefix, "/v1" or "" for the deprecated aliases
+type contractCase struct {
+	name       string
+	wantStatus int
+	request    func(t *testing.T, s *testServer, prefix string) (reply testReply, uuid string)
+}
+
+// contractSearcher Answers the searches of the contract tests: two belts, nothing for "nothing" and a failure for "broken"
+func contractSearcher() *fakeSearcher {
+	return &fakeSearcher{
+		results: map[string]SearchResult{"": {Items: testItems("belt", 1, 2), PageURL: "https://www.ebay.com/sch/i.html?_nkw=gucci+belt"}, "nothing": {}},
+		errs:    map[string]error{"broken": &searchFailure{message: "Invalid keyword", id: "5", category: failureInvalidInput}},
+	}
+}
+
+// contractChat Starts a session under prefix and sends it messages, returning the last reply
+func contractChat(t *testing.T, s *testServer, prefix string, messages ...string) (testReply, string) {
+	t.Helper()
+	handler := s.Routes()
+	welcome := do(t, handler, http.MethodGet, prefix+"/welcome", "", nil)
+	uuid, _ := welcome.Body["uuid"].(string)
+	if uuid == "" {
+		t.Fatalf("%v/welcome answered %v %v", prefix, welcome.Status, welcome.Raw)
+	}
+	reply := welcome
+	for _, message := range messages {
+		s.clock.Advance(2 * duplicateGrace)
+		body, _ := json.Marshal(JSON{"message": message})
+		reply = do(t, handler, http.MethodPost, prefix+"/chat", string(body), http.Header{"Authorization": {uuid}})
+	}
+	return reply, uuid
+}
+
+var contractCases = []contractCase{
+	{"welcome", http.StatusOK, func(t *testing.T, s *testServer, prefix string) (testReply, string) {
+		return contractChat(t, s, prefix)
+	}},
+	{"chat_question", http.StatusOK, func(t *testing.T, s *testServer, prefix string) (testReply, string) {
+		return contractChat(t, s, prefix, "gucci belt")
+	}},
+	{"chat_results", http.StatusOK, func(t *testing.T, s *testServer, prefix string) (testReply, string) {
+		return contractChat(t, s, prefix, "gucci belt", "new", "100", "500")
+	}},
+	{"chat_zero_results", http.StatusOK, func(t *testing.T, s *testServer, prefix string) (testReply, string) {
+		return contractChat(t, s, prefix, "nothing", "none", "none", "none")
+	}},
+	{"chat_search_failed", http.StatusBadRequest, func(t *testing.T, s *testServer, prefix string) (testReply, string) {
+		return contractChat(t, s, prefix, "broken", "none", "none", "none")
+	}},
+	{"chat_unknown_session", http.StatusUnauthorized, func(t *testing.T, s *testServer, prefix string) (testReply, string) {
+		return do(t, s.Routes(), http.MethodPost, prefix+"/chat", `{"message": "gucci belt"}`, http.Header{"Authorization": {"no-such-session"}}), ""
+	}},
+	{"search_results", http.StatusOK, func(t *testing.T, s *testServer, prefix string) (testReply, string) {
+		return do(t, s.Routes(), http.MethodGet, prefix+"/search?keyword=gucci+belt&condition=new&maxPrice=500", "", nil), ""
+	}},
+	{"search_invalid_query", http.StatusBadRequest, func(t *testing.T, s *testServer, prefix string) (testReply, string) {
+		return do(t, s.Routes(), http.MethodGet, prefix+"/search?keyword=gucci&condition=broken", "", nil), ""
+	}},
+	{"search_failed", http.StatusBadRequest, func(t *testing.T, s *testServer, prefix string) (testReply, string) {
+		return do(t, s.Routes(), http.MethodGet, prefix+"/search?keyword=broken", "", nil), ""
+	}},
+}
+
+// contractBody Returns the body of reply as it was sent, with the session uuid, which changes from run to run, replaced
+func contractBody(reply testReply, uuid string) []byte {
+	if uuid == "" {
+		return []byte(reply.Raw)
+	}
+	return []byte(strings.Replace(reply.Raw, uuid, "<uuid>", -1))
+}
+
+func TestContracts(t *testing.T) {
+	for _, test := range contractCases {
+		t.Run(test.name, func(t *testing.T) {
+			reply, uuid := test.request(t, newTestServer(t, Config{}, contractSearcher()), "/v1")
+			if reply.Status != test.wantStatus {
+				t.Errorf("answered %v, want %v", reply.Status, test.wantStatus)
+			}
+			if reply.Header.Get("Deprecation") != "" {
+				t.Errorf("the /v1 route answered Deprecation: %v", reply.Header.Get("Deprecation"))
+			}
+			checkGolden(t, filepath.Join("testdata", "contracts", test.name+".json"), contractBody(reply, uuid))
+		})
+	}
+}
+
+func TestLegacyAliasesKeepTheContracts(t *testing.T) {
+	for _, test := range contractCases {
+		t.Run(test.name, func(t *testing.T) {
+			v1, v1UUID := test.request(t, newTestServer(t, Config{}, contractSearcher()), "/v1")
+			legacy, legacyUUID := test.request(t, newTestServer(t, Config{}, contractSearcher()), "")
+			if legacy.Header.Get("Deprecation") != "true" {
+				t.Errorf("the unprefixed route answered Deprecation: %q, want true", legacy.Header.Get("Deprecation"))
+			}
+			if got, want := contractBody(legacy, legacyUUID), contractBody(v1, v1UUID); legacy.Status != v1.Status || string(got) != string(want) {
+				t.Errorf("the unprefixed route answered %v %s, the /v1 route %v %s", legacy.Status, got, v1.Status, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package theluxuryshopper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ebayStub Serves the fixtures of testdata/ebay as the Finding API and records the calls it got
+type ebayStub struct {
+	mu      sync.Mutex
+	fixture string // the file of testdata/ebay answering every call
+	calls   []string
+}
+
+// ServeHTTP Answers a Finding API call with the fixture
+func (e *ebayStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	fixture := e.fixture
+	e.calls = append(e.calls, findingParameters(r.URL.RawQuery))
+	e.mu.Unlock()
+	data, err := os.ReadFile(filepath.Join("testdata", "ebay", fixture+".json"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// setFixture Makes fixture answer the next calls
+func (e *ebayStub) setFixture(fixture string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fixture = fixture
+}
+
+// takeCalls Returns the calls recorded since the last take
+func (e *ebayStub) takeCalls() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	calls := e.calls
+	e.calls = nil
+	return calls
+}
+
+// findingParameters Keeps the parameters of a Finding API query that depend on the search, in the order they were sent
+func findingParameters(rawQuery string) string {
+	var kept []string
+	for _, parameter := range strings.Split(rawQuery, "&") {
+		switch name := strings.SplitN(parameter, "=", 2)[0]; {
+		case name == "OPERATION-NAME", name == "SERVICE-VERSION", name == "SECURITY-APPNAME", name == "RESPONSE-DATA-FORMAT",
+			name == "REST-PAYLOAD", strings.HasPrefix(name, "outputSelector"):
+		default:
+			kept = append(kept, parameter)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+// newStubbedServer Creates a test server whose eBay client calls stub
+func newStubbedServer(t testing.TB, config Config, stub *ebayStub) *testServer {
+	t.Helper()
+	finding := httptest.NewServer(stub)
+	t.Cleanup(finding.Close)
+	config.EbayEnv, config.EbayAppID, config.EbayEndpoint = "production", "test-app-id", finding.URL
+	return newTestServer(t, config, nil)
+}
+
+// conversationScript Is a script of testdata/conversations: one message per line, "@ebay <fixture>" picks
+// the fixture of testdata/ebay answering the searches that follow and "#" starts a comment
+type conversationScript struct {
+	lines []string
+}
+
+// readConversationScript Reads the script at path
+func readConversationScript(t *testing.T, path string) conversationScript {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var script conversationScript
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			script.lines = append(script.lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+// run Plays the script against a server searching stub and returns its transcript: every message
+// and the status and body of its reply, preceded by the eBay calls the message made
+func (script conversationScript) run(t *testing.T) []byte {
+	t.Helper()
+	stub := &ebayStub{fixture: "empty"}
+	c := startConversation(t, newStubbedServer(t, Config{}, stub))
+
+	var transcript bytes.Buffer
+	for _, line := range script.lines {
+		if fixture := strings.TrimPrefix(line, "@ebay "); fixture != line {
+			stub.setFixture(fixture)
+			continue
+		}
+		reply := c.say(line)
+		transcript.WriteString("> " + line + "\n")
+		for _, call := range stub.takeCalls() {
+			transcript.WriteString("= eBay " + call + "\n")
+		}
+		transcript.WriteString("< " + strconv.Itoa(reply.Status) + "\n")
+		transcript.Write(indentReply(t, strings.Replace(reply.Raw, c.uuid, "<uuid>", -1)))
+		transcript.WriteString("\n")
+	}
+	return transcript.Bytes()
+}
+
+// indentReply Indents a JSON reply for a readable golden, leaving everything else as it is
+func indentReply(t *testing.T, raw string) []byte {
+	t.Helper()
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte(raw), "", "  "); err != nil {
+		return []byte(raw)
+	}
+	return indented.Bytes()
+}
+
+func TestConversations(t *testing.T) {
+	scripts, err := filepath.Glob(filepath.Join("testdata", "conversations", "*.script"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scripts) < 5 {
+		t.Fatalf("found %v conversation scripts, want at least 5", len(scripts))
+	}
+	for _, path := range scripts {
+		name := strings.TrimSuffix(filepath.Base(path), ".script")
+		t.Run(name, func(t *testing.T) {
+			transcript := readConversationScript(t, path).run(t)
+			checkGolden(t, strings.TrimSuffix(path, ".script")+".golden", transcript)
+		})
+	}
+}
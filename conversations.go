@@ -0,0 +1,172 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxConversations Caps the named conversations of one session
+	maxConversations = 5
+	// defaultConversation Names the conversation every session starts with
+	defaultConversation = "main"
+)
+
+var (
+	switchConversationCommand = regexp.MustCompile(`(?i)^\s*switch\s+to\s+conversation\s+(.+?)\s*$`)
+	closeConversationCommand  = regexp.MustCompile(`(?i)^\s*close\s+conversation\s+(.+?)\s*$`)
+	listConversationsCommand  = regexp.MustCompile(`(?i)^\s*conversations\s*$`)
+)
+
+// conversations Returns the named conversations of session, creating the default one if needed.
+// Each conversation is a Session of its own holding the keys of one search.
+func conversations(session Session) map[string]Session {
+	slots, found := session["conversations"].(map[string]Session)
+	if !found {
+		slots = map[string]Session{defaultConversation: {}}
+		session["conversations"] = slots
+		session["activeConversation"] = defaultConversation
+	}
+	return slots
+}
+
+// activeConversation Returns the name and Session of the conversation the messages of session go to
+func activeConversation(session Session) (string, Session) {
+	slots := conversations(session)
+	name, _ := session["activeConversation"].(string)
+	slot, found := slots[name]
+	if !found {
+		name = defaultConversation
+		if slot, found = slots[name]; !found {
+			slot = Session{}
+			slots[name] = slot
+		}
+		session["activeConversation"] = name
+	}
+	return name, slot
+}
+
+// handleConversationCommand Answers the commands managing conversations, reporting whether message was one
+func handleConversationCommand(session Session, message string, now time.Time, w http.ResponseWriter) bool {
+	if match := switchConversationCommand.FindStringSubmatch(message); match != nil {
+		switchConversation(session, conversationName(match[1]), w)
+		return true
+	}
+	if match := closeConversationCommand.FindStringSubmatch(message); match != nil {
+		closeConversation(session, conversationName(match[1]), now, w)
+		return true
+	}
+	if listConversationsCommand.MatchString(message) {
+		WriteReply(w, ReplyInfo, JSON{
+			"message":      listConversations(session),
+			"conversation": session["activeConversation"],
+		})
+		return true
+	}
+	return false
+}
+
+// conversationName Normalizes a conversation name typed by the user
+func conversationName(name string) string {
+	name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	if len(name) > 30 {
+		name = name[:30]
+	}
+	return name
+}
+
+// switchConversation Activates the conversation called name, creating it if needed
+func switchConversation(session Session, name string, w http.ResponseWriter) {
+	slots := conversations(session)
+	slot, found := slots[name]
+	if !found {
+		if len(slots) >= maxConversations {
+			WriteReply(w, ReplyInfo, JSON{
+				"message":      "You already have " + strconv.Itoa(maxConversations) + " conversations, close one first.\n " + listConversations(session),
+				"conversation": session["activeConversation"],
+			})
+			return
+		}
+		slot = Session{}
+		slots[name] = slot
+	}
+	session["activeConversation"] = name
+
+	response := "Switched to conversation " + name + ".\n " + resumeSummary(slot)
+	if !found {
+		response = "Started conversation " + name + ".\n " + keywordQuestion
+	}
+	WriteReply(w, ReplyInfo, JSON{
+		"message":      response,
+		"conversation": name,
+	})
+}
+
+// closeConversation Removes the conversation called name, falling back to another one if it was active.
+// "undo" brings it back unless a search was started under its name meanwhile.
+func closeConversation(session Session, name string, now time.Time, w http.ResponseWriter) {
+	slots := conversations(session)
+	closed, found := slots[name]
+	if !found {
+		WriteReply(w, ReplyInfo, JSON{
+			"message":      "There is no conversation called " + name + ".\n " + listConversations(session),
+			"conversation": session["activeConversation"],
+		})
+		return
+	}
+	delete(slots, name)
+	stashUndo(session, now, func(session Session) string {
+		slots := conversations(session)
+		if _, searching := slots[name]["searchByKeyword"]; searching {
+			return "Conversation " + name + " was started again meanwhile, so the closed one can't be restored."
+		}
+		slots[name] = closed
+		session["activeConversation"] = name
+		return "Restored conversation " + name + "."
+	})
+
+	if session["activeConversation"] == name {
+		names := conversationNames(slots)
+		if len(names) == 0 {
+			slots[defaultConversation] = Session{}
+			names = []string{defaultConversation}
+		}
+		session["activeConversation"] = names[0]
+	}
+	active, slot := activeConversation(session)
+	WriteReply(w, ReplyInfo, JSON{
+		"message":      "Closed conversation " + name + ". You are in conversation " + active + ". " + undoHint + "\n " + resumeSummary(slot),
+		"conversation": active,
+	})
+}
+
+// listConversations Describes every conversation of session
+func listConversations(session Session) string {
+	slots := conversations(session)
+	active, _ := session["activeConversation"].(string)
+	response := "Your conversations:"
+	for _, name := range conversationNames(slots) {
+		response += "\n - " + name
+		if name == active {
+			response += " (active)"
+		}
+		if keyword, found := slots[name]["searchByKeyword"].(string); found {
+			response += ": searching for '" + keyword + "'"
+		}
+	}
+	return response
+}
+
+// conversationNames Returns the names of slots in alphabetical order
+func conversationNames(slots map[string]Session) []string {
+	names := make([]string, 0, len(slots))
+	for name := range slots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
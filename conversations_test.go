@@ -0,0 +1,91 @@
+package theluxuryshopper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConversationName(t *testing.T) {
+	tests := []struct {
+		typed, want string
+	}{
+		{"Work", "work"},
+		{"  Work   Bags ", "work bags"},
+		{strings.Repeat("a", 40), strings.Repeat("a", 30)},
+	}
+	for _, test := range tests {
+		if got := conversationName(test.typed); got != test.want {
+			t.Errorf("conversationName(%q) = %q, want %q", test.typed, got, test.want)
+		}
+	}
+}
+
+func TestActiveConversation(t *testing.T) {
+	tests := []struct {
+		name     string
+		session  Session
+		wantName string
+	}{
+		{"new session", Session{}, defaultConversation},
+		{"named", Session{"conversations": map[string]Session{"main": {}, "work": {}}, "activeConversation": "work"}, "work"},
+		{"active one gone", Session{"conversations": map[string]Session{"work": {}}, "activeConversation": "gone"}, defaultConversation},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name, slot := activeConversation(test.session)
+			if name != test.wantName || slot == nil || test.session["activeConversation"] != test.wantName {
+				t.Errorf("activeConversation() = %q, %v, want %q", name, slot, test.wantName)
+			}
+		})
+	}
+}
+
+func TestConversationCommands(t *testing.T) {
+	s := newTestServer(t, Config{}, &fakeSearcher{})
+	c := startConversation(t, s)
+	c.say("gucci belt")
+
+	steps := []struct {
+		message, wantPrefix, wantConversation string
+	}{
+		{"switch to conversation Work  Bags", "Started conversation work bags.", "work bags"},
+		{"prada", "Please specify the condition", ""},
+		{"conversations", "Your conversations:\n - main: searching for 'gucci belt'\n - work bags (active): searching for 'prada'", "work bags"},
+		{"switch to conversation main", "Switched to conversation main.\n You were searching for 'gucci belt'.", "main"},
+		{"close conversation nope", "There is no conversation called nope.", "main"},
+		{"close conversation main", "Closed conversation main. You are in conversation work bags. " + undoHint, "work bags"},
+		{"undo", "Restored conversation main.", ""},
+		{"conversations", "Your conversations:\n - main (active): searching for 'gucci belt'\n - work bags: searching for 'prada'", "main"},
+	}
+	for _, step := range steps {
+		reply := c.say(step.message)
+		if !strings.HasPrefix(reply.message(), step.wantPrefix) {
+			t.Errorf("%q answered %q, want it to start with %q", step.message, reply.message(), step.wantPrefix)
+		}
+		if step.wantConversation != "" && reply.Body["conversation"] != step.wantConversation {
+			t.Errorf("%q answered the conversation %v, want %v", step.message, reply.Body["conversation"], step.wantConversation)
+		}
+	}
+}
+
+func TestConversationLimit(t *testing.T) {
+	s := newTestServer(t, Config{}, &fakeSearcher{})
+	c := startConversation(t, s)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		c.say("switch to conversation " + name)
+	}
+	if reply := c.say("switch to conversation e"); !strings.HasPrefix(reply.message(), "You already have 5 conversations, close one first.") || reply.Body["conversation"] != "d" {
+		t.Errorf("a sixth conversation answered %v", reply.Raw)
+	}
+	c.say("close conversation d")
+	if reply := c.say("switch to conversation e"); !strings.HasPrefix(reply.message(), "Started conversation e.") {
+		t.Errorf("a fifth conversation after closing one answered %v", reply.Raw)
+	}
+	// Closing the last one starts the default over
+	for _, name := range []string{"main", "a", "b", "c", "e"} {
+		c.say("close conversation " + name)
+	}
+	if reply := c.say("conversations"); reply.message() != "Your conversations:\n - main (active)" {
+		t.Errorf("closing every conversation left %q", reply.message())
+	}
+}
@@ -0,0 +1,105 @@
+package theluxuryshopper
+
+import (
+	"regexp"
+	"strings"
+)
+
+// currencyNames Maps the symbols, codes and words a price answer may name its currency with to the ISO code
+var currencyNames = map[string]string{
+	"€":          "EUR",
+	"eur":        "EUR",
+	"euro":       "EUR",
+	"euros":      "EUR",
+	"£":          "GBP",
+	"gbp":        "GBP",
+	"pound":      "GBP",
+	"pounds":     "GBP",
+	"usd":        "USD",
+	"us dollars": "USD",
+	"cad":        "CAD",
+	"aud":        "AUD",
+}
+
+// priceCurrencyPattern Matches the currencies of currencyNames in a price answer like "500 eur" or "€500".
+// "$" is left to normalizePrice, it stays the currency of the site as it always was.
+var priceCurrencyPattern = regexp.MustCompile(`(?i)€|£|\b(?:eur|euros?|gbp|pounds?|usd|us\s+dollars|cad|aud)\b`)
+
+// takePriceCurrency Removes the currency from a price answer, remembering it in session as the currency of
+// both price filters. Answers naming two different currencies are returned as they are.
+func takePriceCurrency(session Session, answer string) string {
+	matches := priceCurrencyPattern.FindAllString(answer, -1)
+	if len(matches) == 0 {
+		return answer
+	}
+	currency := ""
+	for _, match := range matches {
+		code := currencyNames[strings.Join(strings.Fields(strings.ToLower(match)), " ")]
+		if currency != "" && code != currency {
+			return answer
+		}
+		currency = code
+	}
+	session["priceCurrency"] = currency
+	return strings.TrimSpace(priceCurrencyPattern.ReplaceAllString(answer, ""))
+}
+
+// priceCurrency Returns the currency the price filters of a search are in: the one the answers named,
+// else the currency of the site searched, "" for the default site whose currency eBay knows. The currency
+// the session prefers is only for showing prices, see displayCurrencyNote.
+func priceCurrency(session Session, site string) string {
+	if currency, found := session["priceCurrency"].(string); found {
+		return currency
+	}
+	if known, found := siteByGlobalID(site); found {
+		return known.Currency
+	}
+	return ""
+}
+
+// displayCurrencyNote Tells the user when items are priced in another currency than the one the session
+// prefers, or returns "". eBay prices them in the currency of the site searched, which isn't converted.
+func displayCurrencyNote(session Session, items []Item) string {
+	preferred := session.GetString("currency", "")
+	if preferred == "" {
+		return ""
+	}
+	var others []string
+	seen := map[string]bool{}
+	for _, item := range items {
+		if item.Currency != "" && item.Currency != preferred && !seen[item.Currency] {
+			seen[item.Currency] = true
+			others = append(others, item.Currency)
+		}
+	}
+	if len(others) == 0 {
+		return ""
+	}
+	return "Prices are in " + strings.Join(others, " and ") + " as listed on eBay, not in your " + preferred + "."
+}
+
+// validCurrency Reports whether code is the currency of one of the sites
+func validCurrency(code string) bool {
+	for _, site := range ebaySites {
+		if site.Currency == code {
+			return true
+		}
+	}
+	return false
+}
+
+// priceFilterSummary Describes the price filters of query with their currency, like "from 100 up to 500 EUR",
+// or returns "" when the query has none or doesn't know their currency
+func priceFilterSummary(query SearchQuery) string {
+	var limits []string
+	if query.MinPrice != "" && query.MinPrice != "none" {
+		limits = append(limits, "from "+query.MinPrice)
+	}
+	if query.MaxPrice != "" && query.MaxPrice != "none" {
+		limits = append(limits, "up to "+query.MaxPrice)
+	}
+	if len(limits) == 0 || query.Currency == "" {
+		return ""
+	}
+	return strings.Join(limits, " ") + " " + query.Currency
+}
@@ -0,0 +1,84 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPriceCurrency(t *testing.T) {
+	tests := []struct {
+		name    string
+		session Session
+		site    string
+		want    string
+	}{
+		{"default site", Session{}, "", ""},
+		{"site currency", Session{}, "EBAY-DE", "EUR"},
+		{"display preference", Session{"currency": "GBP"}, "EBAY-DE", "EUR"},
+		{"display preference on the default site", Session{"currency": "EUR"}, "", ""},
+		{"named in an answer", Session{"currency": "GBP", "priceCurrency": "USD"}, "EBAY-DE", "USD"},
+	}
+	for _, test := range tests {
+		if got := priceCurrency(test.session, test.site); got != test.want {
+			t.Errorf("%v: priceCurrency = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTakePriceCurrency(t *testing.T) {
+	tests := []struct {
+		answer, want, wantCurrency string
+	}{
+		{"500", "500", ""},
+		{"500 eur", "500", "EUR"},
+		{"€500", "500", "EUR"},
+		{"£20", "20", "GBP"},
+		{"30 US dollars", "30", "USD"},
+		{"$30", "$30", ""},
+		{"500 eur or 400 gbp", "500 eur or 400 gbp", ""},
+	}
+	for _, test := range tests {
+		session := Session{}
+		if got := takePriceCurrency(session, test.answer); got != test.want || session.GetString("priceCurrency", "") != test.wantCurrency {
+			t.Errorf("takePriceCurrency(%q) = %q with currency %q, want %q with %q", test.answer, got, session["priceCurrency"], test.want, test.wantCurrency)
+		}
+	}
+}
+
+func TestDisplayCurrencyNote(t *testing.T) {
+	usd, eur := Item{Currency: "USD"}, Item{Currency: "EUR"}
+	tests := []struct {
+		name    string
+		session Session
+		items   []Item
+		want    string
+	}{
+		{"no preference", Session{}, []Item{usd}, ""},
+		{"same currency", Session{"currency": "USD"}, []Item{usd}, ""},
+		{"another currency", Session{"currency": "GBP"}, []Item{usd, usd}, "Prices are in USD as listed on eBay, not in your GBP."},
+		{"several currencies", Session{"currency": "GBP"}, []Item{usd, eur}, "Prices are in USD and EUR as listed on eBay, not in your GBP."},
+	}
+	for _, test := range tests {
+		if got := displayCurrencyNote(test.session, test.items); got != test.want {
+			t.Errorf("%v: displayCurrencyNote = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestPreferredCurrencyOnlyShowsPrices(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 2)}}}
+	s := newTestServer(t, Config{}, searcher)
+	routes := s.Routes()
+	welcome := do(t, routes, http.MethodGet, "/v1/welcome?currency=GBP", "", nil)
+	c := &conversation{t: t, handler: routes, clock: s.clock, uuid: welcome.Body["uuid"].(string)}
+	reply := c.sayAll("gucci bag", "none", "100", "500")
+
+	queries := searcher.Queries()
+	if len(queries) != 1 || queries[0].Currency != "" {
+		t.Fatalf("searched %+v, want the price filters in the currency of the site", queries)
+	}
+	if !strings.Contains(reply.message(), "Prices are in USD as listed on eBay, not in your GBP.") {
+		t.Errorf("the results say %q, want the currency note", reply.message())
+	}
+}
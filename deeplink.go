@@ -0,0 +1,70 @@
+package theluxuryshopper
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// webHosts Maps every EBAY_ENV value to the host of its website for the default site
+var webHosts = map[string]string{
+	"production": "www.ebay.com",
+	"sandbox":    "www.sandbox.ebay.com",
+}
+
+// webConditions Maps the conditions of a query to the LH_ItemCondition ids of the website
+var webConditions = map[string]string{
+	"new":  "1000",
+	"used": "3000",
+}
+
+// webSortOrders Maps the Finding API sort orders to the _sop ids of the website
+var webSortOrders = map[string]string{
+	"BestMatch":                "12",
+	"PricePlusShippingLowest":  "15",
+	"PricePlusShippingHighest": "16",
+	"EndTimeSoonest":           "1",
+	"StartTimeNewest":          "10",
+}
+
+// SearchPageURL Builds the eBay website search for query on the site it searches.
+// Unlike the itemSearchURL of the Finding API, which only carries the keyword, it applies
+// the price, condition, free shipping and sort filters, so it lists what the bot listed.
+func (c *ebayClient) SearchPageURL(query SearchQuery) string {
+	host := webHosts[c.env]
+	// The sandbox has a single site
+	if site, found := siteByGlobalID(query.Site); found && c.env == "production" {
+		host = site.Domain
+	}
+	return searchPageURL(host, query)
+}
+
+// searchPageURL Builds the /sch/ search of host for query
+func searchPageURL(host string, query SearchQuery) string {
+	values := url.Values{"_nkw": {withNegativeTerms(query.Keyword, query.ExcludeTerms)}}
+	set := func(key, value string) {
+		if value != "" && !strings.EqualFold(value, "none") {
+			values.Set(key, value)
+		}
+	}
+	set("_udlo", query.MinPrice)
+	set("_udhi", query.MaxPrice)
+	set("LH_ItemCondition", webConditions[strings.ToLower(query.Condition)])
+	set("_sop", webSortOrders[query.SortOrder])
+	if query.FreeShipping {
+		values.Set("LH_FS", "1")
+	}
+	if query.Page > 1 {
+		values.Set("_pgn", strconv.Itoa(query.Page))
+	}
+	return "https://" + host + "/sch/i.html?" + values.Encode()
+}
+
+// resultsURL Returns the link to see the results of a search on eBay: the website search with
+// its filters when the searcher built one, the results page eBay returned otherwise
+func resultsURL(result SearchResult) string {
+	if result.SearchURL != "" {
+		return result.SearchURL
+	}
+	return result.PageURL
+}
@@ -0,0 +1,76 @@
+package theluxuryshopper
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSearchPageURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   string
+		query SearchQuery
+		want  string
+	}{
+		{
+			"keyword only",
+			"production",
+			SearchQuery{Keyword: "gucci belt", Condition: "none", MinPrice: "none", MaxPrice: "none"},
+			"https://www.ebay.com/sch/i.html?_nkw=gucci+belt",
+		},
+		{
+			"every filter",
+			"production",
+			SearchQuery{Keyword: "kelly", Condition: "Used", MinPrice: "100", MaxPrice: "2000", SortOrder: "PricePlusShippingLowest", FreeShipping: true, Page: 3},
+			"https://www.ebay.com/sch/i.html?LH_FS=1&LH_ItemCondition=3000&_nkw=kelly&_pgn=3&_sop=15&_udhi=2000&_udlo=100",
+		},
+		{
+			"excluded terms",
+			"production",
+			SearchQuery{Keyword: "gucci belt", ExcludeTerms: []string{"box", "dust bag"}},
+			"https://www.ebay.com/sch/i.html?_nkw=" + url.QueryEscape("gucci belt -(box,dust bag)"),
+		},
+		{
+			"site",
+			"production",
+			SearchQuery{Keyword: "prada", Site: "EBAY-GB", Page: 1},
+			"https://www.ebay.co.uk/sch/i.html?_nkw=prada",
+		},
+		{
+			"sandbox ignores the site",
+			"sandbox",
+			SearchQuery{Keyword: "prada", Site: "EBAY-DE", Condition: "new"},
+			"https://www.sandbox.ebay.com/sch/i.html?LH_ItemCondition=1000&_nkw=prada",
+		},
+		{
+			"unknown sort order",
+			"production",
+			SearchQuery{Keyword: "prada", SortOrder: "Random"},
+			"https://www.ebay.com/sch/i.html?_nkw=prada",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newEbayClient(Config{EbayEnv: test.env, EbayAppID: "test-app-id"}, 1, nil)
+			if got := client.SearchPageURL(test.query); got != test.want {
+				t.Errorf("SearchPageURL() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResultsURL(t *testing.T) {
+	tests := []struct {
+		result SearchResult
+		want   string
+	}{
+		{SearchResult{SearchURL: "https://www.ebay.com/sch/i.html?_nkw=a", PageURL: "https://shop.ebay.com/a"}, "https://www.ebay.com/sch/i.html?_nkw=a"},
+		{SearchResult{PageURL: "https://shop.ebay.com/a"}, "https://shop.ebay.com/a"},
+		{SearchResult{}, ""},
+	}
+	for _, test := range tests {
+		if got := resultsURL(test.result); got != test.want {
+			t.Errorf("resultsURL(%+v) = %q, want %q", test.result, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// DefaultFilters Holds the filters a deployment applies to every search unless the user says otherwise.
+// Empty strings and false leave a filter to the user.
+type DefaultFilters struct {
+	Condition    string // New, Used or none
+	MinPrice     string
+	MaxPrice     string
+	Site         string // an eBay GLOBAL-ID like EBAY-US
+	FreeShipping bool
+	TopRated     bool
+}
+
+// defaultFilterKeys Are the session keys the default filters fill, in the order they are applied
+var defaultFilterKeys = []string{"condition", "minPrice", "maxPrice", "site", "freeShipping", "topRated"}
+
+// values Returns the default of every key of defaultFilterKeys that is set
+func (d DefaultFilters) values() map[string]interface{} {
+	values := map[string]interface{}{}
+	for key, value := range map[string]string{"condition": d.Condition, "minPrice": d.MinPrice, "maxPrice": d.MaxPrice, "site": d.Site} {
+		if value != "" {
+			values[key] = value
+		}
+	}
+	if d.FreeShipping {
+		values["freeShipping"] = true
+	}
+	if d.TopRated {
+		values["topRated"] = true
+	}
+	return values
+}
+
+// filterOverride Is what one override command sets, whatever the deployment defaults
+type filterOverride struct {
+	key   string
+	value interface{}
+}
+
+// filterOverrideCommands Map the override commands to the filters they set, "actually" may precede any of them
+var filterOverrideCommands = map[string][]filterOverride{
+	"include used":          {{"condition", "none"}},
+	"any condition":         {{"condition", "none"}},
+	"only new":              {{"condition", "New"}},
+	"only used":             {{"condition", "Used"}},
+	"any price":             {{"minPrice", "none"}, {"maxPrice", "none"}},
+	"no price limit":        {{"minPrice", "none"}, {"maxPrice", "none"}},
+	"include paid shipping": {{"freeShipping", false}},
+	"only free shipping":    {{"freeShipping", true}},
+	"any seller":            {{"topRated", false}},
+	"only top rated":        {{"topRated", true}},
+}
+
+// filterOverrideCommand Matches an override command, optionally prefixed with "actually"
+var filterOverrideCommand = regexp.MustCompile(`(?i)^\s*(?:actually,?\s+)?(.+?)[.!]?\s*$`)
+
+// applyDefaultFilters Fills the filters of session nobody set yet. The precedence is
+//  1. what the user said during this search, an answer or an NLU entity
+//  2. the overrides the user set for the session with a command like "include used"
+//  3. the deployment defaults
+//
+// so the questions of the filters set by 2 or 3 are skipped.
+func (s *Server) applyDefaultFilters(session Session) {
+	overrides, _ := session["filterOverrides"].(map[string]interface{})
+	defaults := s.config.DefaultFilters.values()
+	for _, key := range defaultFilterKeys {
+		if _, set := session[key]; set {
+			continue
+		}
+		if value, found := overrides[key]; found {
+			session[key] = value
+		} else if value, found := defaults[key]; found {
+			session[key] = value
+		}
+	}
+}
+
+// handleFilterOverrideCommand Answers the override commands, reporting whether message was one.
+// The override outlives the search and replaces the filter of the search in progress too.
+func handleFilterOverrideCommand(session Session, message string, w http.ResponseWriter) bool {
+	match := filterOverrideCommand.FindStringSubmatch(message)
+	if match == nil {
+		return false
+	}
+	changes, found := filterOverrideCommands[strings.ToLower(strings.Join(strings.Fields(match[1]), " "))]
+	if !found {
+		return false
+	}
+	overrides, _ := session["filterOverrides"].(map[string]interface{})
+	if overrides == nil {
+		overrides = map[string]interface{}{}
+		session["filterOverrides"] = overrides
+	}
+	for _, change := range changes {
+		overrides[change.key] = change.value
+		session[change.key] = change.value
+	}
+	WriteReply(w, ReplyInfo, JSON{
+		"message": "OK, " + describeFilters(overrides) + " from now on.\n " + strings.TrimSpace(resumeSummary(session)),
+	})
+	return true
+}
+
+// describeFilters Describes filter values like "new items up to 500 on EBAY-US with free shipping"
+func describeFilters(values map[string]interface{}) string {
+	var description []string
+	switch condition, _ := values["condition"].(string); condition {
+	case "New", "Used":
+		description = append(description, strings.ToLower(condition)+" items")
+	case "none":
+		description = append(description, "items in any condition")
+	default:
+		description = append(description, "items")
+	}
+	minPrice, _ := values["minPrice"].(string)
+	maxPrice, _ := values["maxPrice"].(string)
+	if minPrice != "" && minPrice != "none" {
+		description = append(description, "from "+minPrice)
+	}
+	if maxPrice != "" && maxPrice != "none" {
+		description = append(description, "up to "+maxPrice)
+	}
+	if minPrice == "none" && maxPrice == "none" {
+		description = append(description, "at any price")
+	}
+	if site, _ := values["site"].(string); site != "" {
+		description = append(description, "on "+site)
+	}
+	if freeShipping, found := values["freeShipping"].(bool); found {
+		if freeShipping {
+			description = append(description, "with free shipping")
+		} else {
+			description = append(description, "with or without free shipping")
+		}
+	}
+	if topRated, found := values["topRated"].(bool); found {
+		if topRated {
+			description = append(description, "from top rated sellers")
+		} else {
+			description = append(description, "from any seller")
+		}
+	}
+	return strings.Join(description, " ")
+}
+
+// defaultFiltersNotice Tells new sessions which defaults apply, or "" if there are none
+func (s *Server) defaultFiltersNotice() string {
+	values := s.config.DefaultFilters.values()
+	if len(values) == 0 {
+		return ""
+	}
+	return "I'm showing " + describeFilters(values) + ", say something like 'include used' or 'any price' to change that."
+}
@@ -0,0 +1,103 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDescribeFilters(t *testing.T) {
+	tests := []struct {
+		values map[string]interface{}
+		want   string
+	}{
+		{map[string]interface{}{}, "items"},
+		{map[string]interface{}{"condition": "New", "maxPrice": "500", "site": "EBAY-US", "freeShipping": true}, "new items up to 500 on EBAY-US with free shipping"},
+		{map[string]interface{}{"condition": "none", "minPrice": "none", "maxPrice": "none"}, "items in any condition at any price"},
+		{map[string]interface{}{"condition": "Used", "minPrice": "100", "topRated": true}, "used items from 100 from top rated sellers"},
+		{map[string]interface{}{"freeShipping": false, "topRated": false}, "items with or without free shipping from any seller"},
+	}
+	for _, test := range tests {
+		if got := describeFilters(test.values); got != test.want {
+			t.Errorf("describeFilters(%v) = %q, want %q", test.values, got, test.want)
+		}
+	}
+}
+
+func TestApplyDefaultFilters(t *testing.T) {
+	defaults := DefaultFilters{Condition: "New", MaxPrice: "500", TopRated: true}
+	tests := []struct {
+		name    string
+		session Session
+		want    Session
+	}{
+		{"defaults", Session{}, Session{"condition": "New", "maxPrice": "500", "topRated": true}},
+		{
+			"answers win",
+			Session{"condition": "Used", "maxPrice": "none"},
+			Session{"condition": "Used", "maxPrice": "none", "topRated": true},
+		},
+		{
+			"overrides beat the defaults",
+			Session{"filterOverrides": map[string]interface{}{"condition": "none", "topRated": false, "freeShipping": true}},
+			Session{"filterOverrides": map[string]interface{}{"condition": "none", "topRated": false, "freeShipping": true}, "condition": "none", "maxPrice": "500", "topRated": false, "freeShipping": true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Server{config: Config{DefaultFilters: defaults}}
+			s.applyDefaultFilters(test.session)
+			if !reflect.DeepEqual(test.session, test.want) {
+				t.Errorf("applyDefaultFilters() = %v, want %v", test.session, test.want)
+			}
+		})
+	}
+}
+
+func TestHandleFilterOverrideCommand(t *testing.T) {
+	tests := []struct {
+		message     string
+		wantHandled bool
+		wantMessage string
+	}{
+		{"include used", true, "OK, items in any condition from now on."},
+		{"Actually, only  new!", true, "OK, new items from now on."},
+		{"any price.", true, "OK, items at any price from now on."},
+		{"only top rated", true, "OK, items from top rated sellers from now on."},
+		{"include used bags", false, ""},
+		{"gucci belt", false, ""},
+	}
+	for _, test := range tests {
+		session := Session{}
+		w := httptest.NewRecorder()
+		handled := handleFilterOverrideCommand(session, test.message, w)
+		if handled != test.wantHandled {
+			t.Errorf("handleFilterOverrideCommand(%q) = %v, want %v", test.message, handled, test.wantHandled)
+			continue
+		}
+		if handled && !strings.HasPrefix(recordedReply(t, w)["message"].(string), test.wantMessage) {
+			t.Errorf("%q answered %v, want %q", test.message, w.Body.String(), test.wantMessage)
+		}
+	}
+}
+
+func TestDefaultFiltersInAConversation(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 1)}}}
+	s := newTestServer(t, Config{DefaultFilters: DefaultFilters{Condition: "New", MaxPrice: "500"}}, searcher)
+	welcome := do(t, s.Routes(), http.MethodGet, "/v1/welcome", "", nil)
+	if notice := "I'm showing new items up to 500, say something like 'include used' or 'any price' to change that."; !strings.Contains(welcome.message(), notice) {
+		t.Errorf("/v1/welcome answered %q, want the notice %q", welcome.message(), notice)
+	}
+
+	c := startConversation(t, s)
+	c.sayAll("include used", "gucci bag", "none")
+	queries := searcher.Queries()
+	if len(queries) != 1 || queries[0].Condition != "none" || queries[0].MaxPrice != "500" || queries[0].MinPrice != "none" {
+		t.Errorf("the search ran with %+v, want the override, the default maxPrice and the answered minPrice", queries)
+	}
+	if (&Server{}).defaultFiltersNotice() != "" {
+		t.Error("defaultFiltersNotice() without defaults isn't empty")
+	}
+}
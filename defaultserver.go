@@ -0,0 +1,31 @@
+package theluxuryshopper
+
+import "sync"
+
+var (
+	// defaultServer Is the server ProcessFunc configures, created by DefaultServer on first use
+	defaultServer     *Server
+	defaultServerOnce sync.Once
+)
+
+// DefaultServer Returns the Server of LoadConfig that ProcessFunc configures, creating it on first use.
+// It panics when the environment doesn't make a valid Config.
+func DefaultServer() *Server {
+	defaultServerOnce.Do(func() {
+		config, err := LoadConfig()
+		if err != nil {
+			panic(err)
+		}
+		defaultServer = NewServer(config)
+	})
+	return defaultServer
+}
+
+// ProcessFunc Sets the processor of DefaultServer, panicking on a nil processor
+//
+// Deprecated: create a Server with NewServer and call its SetProcessor, which reports the error instead.
+func ProcessFunc(p Processor) {
+	if err := DefaultServer().SetProcessor(p); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,29 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestProcessFunc(t *testing.T) {
+	var got string
+	ProcessFunc(func(session Session, message string, w http.ResponseWriter) {
+		got = message
+		WriteReply(w, ReplyInfo, JSON{"message": "ok"})
+	})
+	if DefaultServer() != DefaultServer() {
+		t.Fatal("DefaultServer() returned a new server on the second call")
+	}
+	handler := DefaultServer().Routes()
+	uuid, _ := do(t, handler, http.MethodGet, "/v1/welcome", "", nil).Body["uuid"].(string)
+	if reply := do(t, handler, http.MethodPost, "/v1/chat", `{"message": "hello"}`, http.Header{"Authorization": {uuid}}); reply.message() != "ok" || got != "hello" {
+		t.Errorf("the processor set by ProcessFunc got %q and answered %v", got, reply.Raw)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("ProcessFunc(nil) didn't panic")
+		}
+	}()
+	ProcessFunc(nil)
+}
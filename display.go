@@ -0,0 +1,146 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Display modes, a session without a preference is shown everything
+const (
+	displayDetailed = "detailed"
+	displayCompact  = "compact"
+)
+
+// maxDisplayEntries Caps the items one page lists
+const maxDisplayEntries = 25
+
+// displayModes Are the values of the display command and of the display field of a chat request
+var displayModes = map[string]bool{displayDetailed: true, displayCompact: true}
+
+var (
+	displayModeCommand    = regexp.MustCompile(`(?i)^\s*(compact|detailed)(?:\s+mode)?\W*$`)
+	displayEntriesCommand = regexp.MustCompile(`(?i)^\s*show\s+(\d+)\s+(?:results|items)(?:\s+per\s+page)?\W*$`)
+)
+
+// itemFields Are the optional lines of an item, title and price are always shown
+type itemFields struct {
+	condition bool
+	image     bool
+	link      bool
+	endTime   bool
+	matched   bool
+	location  bool
+}
+
+// displayFields Maps every display mode to the item lines it shows
+var displayFields = map[string]itemFields{
+	displayDetailed: {condition: true, image: true, link: true, endTime: true, matched: true, location: true},
+	// Title and price only, for SMS and other small screens
+	displayCompact: {},
+}
+
+// displayPreference Holds how a session wants its items listed, it outlives every search
+type displayPreference struct {
+	Mode    string `json:"mode"`
+	Entries int    `json:"entries,omitempty"` // Items per page, the default when 0
+}
+
+// sessionDisplay Returns the display preference of session
+func sessionDisplay(session Session) displayPreference {
+	preference, _ := session["display"].(displayPreference)
+	if !displayModes[preference.Mode] {
+		preference.Mode = displayDetailed
+	}
+	return preference
+}
+
+// itemView Tells writeItems which lines to write and the location of the end times
+type itemView struct {
+	fields   itemFields
+	location *time.Location // nil shows UTC with a hint
+}
+
+// requestView Returns the itemView of the response w writes
+func requestView(w http.ResponseWriter) itemView {
+	view := itemView{fields: displayFields[requestDisplay(w).Mode], location: requestLocation(w)}
+	if plainReplies(w) {
+		// Plain text can't link the title, so the bare URLs stay in every mode
+		view.fields.link, view.fields.image = true, true
+	}
+	return view
+}
+
+// noteDisplay Sets the display preference the items of the response of w are listed with
+func noteDisplay(w http.ResponseWriter, preference displayPreference) {
+	if mw, ok := w.(*metaWriter); ok {
+		mw.display = preference
+	}
+}
+
+// requestDisplay Returns the display preference noted on w, the detailed mode if there is none
+func requestDisplay(w http.ResponseWriter) displayPreference {
+	if mw, ok := w.(*metaWriter); ok && displayModes[mw.display.Mode] {
+		return mw.display
+	}
+	return displayPreference{Mode: displayDetailed}
+}
+
+// withDisplay Echoes preference in query, which lists preference.Entries items when it is set
+func withDisplay(query SearchQuery, preference displayPreference) SearchQuery {
+	query.Display = preference.Mode
+	if preference.Entries > 0 {
+		query.Entries = preference.Entries
+	}
+	return query
+}
+
+// setDisplayMode Stores mode as the display preference of session
+func setDisplayMode(session Session, mode string) {
+	preference := sessionDisplay(session)
+	preference.Mode = mode
+	session["display"] = preference
+}
+
+// handleDisplayCommand Answers "compact mode", "detailed mode" and "show 10 results" for the whole session,
+// reporting whether message was one
+func handleDisplayCommand(session Session, message string, w http.ResponseWriter) bool {
+	_, conversation := activeConversation(session)
+	if match := displayModeCommand.FindStringSubmatch(message); match != nil {
+		mode := displayCompact
+		if match[1][0] == 'd' || match[1][0] == 'D' {
+			mode = displayDetailed
+		}
+		setDisplayMode(session, mode)
+		noteDisplay(w, sessionDisplay(session))
+		shown := "everything about each item"
+		if mode == displayCompact {
+			shown = "just the title and price of each item"
+		}
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "OK, " + mode + " mode: I'll show " + shown + ".\n " + resumeSummary(conversation),
+			"display": sessionDisplay(session),
+		})
+		return true
+	}
+	if match := displayEntriesCommand.FindStringSubmatch(message); match != nil {
+		entries, err := strconv.Atoi(match[1])
+		if err != nil || entries < 1 || entries > maxDisplayEntries {
+			WriteReply(w, ReplyInfo, JSON{
+				"message": "I can show 1 to " + strconv.Itoa(maxDisplayEntries) + " items at a time.",
+			})
+			return true
+		}
+		preference := sessionDisplay(session)
+		preference.Entries = entries
+		session["display"] = preference
+		noteDisplay(w, preference)
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "OK, I'll show " + match[1] + " items at a time.\n " + resumeSummary(conversation),
+			"display": preference,
+		})
+		return true
+	}
+	return false
+}
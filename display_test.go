@@ -0,0 +1,80 @@
+package theluxuryshopper
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleDisplayCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      interface{}
+		message     string
+		wantHandled bool
+		wantMessage string
+		want        displayPreference
+	}{
+		{"compact", nil, "compact mode", true, "OK, compact mode: I'll show just the title and price of each item.", displayPreference{Mode: displayCompact}},
+		{"detailed", displayPreference{Mode: displayCompact, Entries: 8}, "Detailed!", true, "OK, detailed mode: I'll show everything about each item.", displayPreference{Mode: displayDetailed, Entries: 8}},
+		{"entries", nil, "show 10 results per page", true, "OK, I'll show 10 items at a time.", displayPreference{Mode: displayDetailed, Entries: 10}},
+		{"entries keep the mode", displayPreference{Mode: displayCompact}, "show 3 items", true, "OK, I'll show 3 items at a time.", displayPreference{Mode: displayCompact, Entries: 3}},
+		{"too many entries", nil, "show 26 items", true, "I can show 1 to 25 items at a time.", displayPreference{Mode: displayDetailed}},
+		{"no entries", nil, "show 0 items", true, "I can show 1 to 25 items at a time.", displayPreference{Mode: displayDetailed}},
+		{"not a command", nil, "compact bag", false, "", displayPreference{Mode: displayDetailed}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			session := Session{}
+			if test.before != nil {
+				session["display"] = test.before
+			}
+			w := httptest.NewRecorder()
+			if handled := handleDisplayCommand(session, test.message, w); handled != test.wantHandled {
+				t.Fatalf("handleDisplayCommand() = %v, want %v", handled, test.wantHandled)
+			}
+			if test.wantHandled && !strings.HasPrefix(recordedReply(t, w)["message"].(string), test.wantMessage) {
+				t.Errorf("%q answered %v, want %q", test.message, w.Body.String(), test.wantMessage)
+			}
+			if got := sessionDisplay(session); got != test.want {
+				t.Errorf("the session displays %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWithDisplay(t *testing.T) {
+	tests := []struct {
+		preference  displayPreference
+		wantEntries int
+	}{
+		{displayPreference{Mode: displayCompact}, 5},
+		{displayPreference{Mode: displayDetailed, Entries: 12}, 12},
+	}
+	for _, test := range tests {
+		query := withDisplay(SearchQuery{Entries: 5}, test.preference)
+		if query.Display != test.preference.Mode || query.Entries != test.wantEntries {
+			t.Errorf("withDisplay(%+v) = %+v", test.preference, query)
+		}
+	}
+}
+
+func TestCompactResults(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 2)}}}
+	s := newTestServer(t, Config{}, searcher)
+	c := startConversation(t, s)
+	detailed := c.sayAll("gucci bag", "new", "none", "none")
+	c.say("compact mode")
+	c.say("show 2 items")
+	compact := c.sayAll("new search", "gucci bag", "new", "none", "none")
+
+	if !strings.Contains(detailed.message(), " Item 1 Condition : ") {
+		t.Errorf("the detailed results are %q", detailed.message())
+	}
+	if strings.Contains(compact.message(), " Item 1 Condition : ") || !strings.Contains(compact.message(), " Item 1 Title : ") {
+		t.Errorf("the compact results are %q", compact.message())
+	}
+	if queries := searcher.Queries(); len(queries) != 2 || queries[1].Entries != 2 || queries[1].Display != displayCompact {
+		t.Errorf("the searches ran with %+v", queries)
+	}
+}
@@ -0,0 +1,22 @@
+// Package theluxuryshopper Is a chatbot searching eBay for luxury items.
+//
+// A Server answers the chat over HTTP, asking for a keyword and the filters of
+// the registered steps before searching through its Searcher. Embedders mount
+// Server.Routes in their own mux and can replace the conversation with their
+// own Processor, which answers through WriteReply:
+//
+//	config, err := theluxuryshopper.LoadConfig()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	bot := theluxuryshopper.NewServer(config)
+//	bot.SetProcessor(func(session theluxuryshopper.Session, message string, w http.ResponseWriter) {
+//		theluxuryshopper.WriteReply(w, theluxuryshopper.ReplyInfo, theluxuryshopper.JSON{"message": "You said " + message})
+//	})
+//
+//	mux := http.NewServeMux()
+//	mux.Handle("/shopper/", http.StripPrefix("/shopper", bot.Handler()))
+//	log.Fatal(http.ListenAndServe(":8080", mux))
+//
+// The binary serving the chatbot on its own is cmd/theluxuryshopper.
+package theluxuryshopper
@@ -0,0 +1,114 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	showQueryCommand = regexp.MustCompile(`(?i)^\s*(?:show|explain)\s+(?:the\s+|me\s+the\s+)?(?:query|search\s+query|ebay\s+query)\W*$`)
+	goCommand        = regexp.MustCompile(`(?i)^\s*(?:go|run\s+it|search\s+now)\W*$`)
+)
+
+// ebay Returns the eBay client behind the searcher of the server, reporting whether it has one
+func (s *Server) ebay() (*ebayClient, bool) {
+	searcher := s.searcher
+	if chaos, ok := searcher.(*chaosSearcher); ok {
+		searcher = chaos.next
+	}
+	client, ok := searcher.(*ebayClient)
+	return client, ok
+}
+
+// dryRun Describes the Finding API call query would make, with the app id redacted, and its website
+// search, without calling eBay. A server searching something else than eBay has no call to describe.
+func (s *Server) dryRun(query SearchQuery) JSON {
+	client, ok := s.ebay()
+	if !ok {
+		return JSON{"query": query, "request": nil, "searchURL": ""}
+	}
+	sanitized := client.SanitizedURL(query)
+	parameters := map[string]string{}
+	if u, err := url.Parse(sanitized); err == nil {
+		for name, values := range u.Query() {
+			parameters[name] = values[0]
+		}
+	}
+	return JSON{
+		"query": query,
+		"request": JSON{
+			"operation":  parameters["OPERATION-NAME"],
+			"url":        sanitized,
+			"parameters": parameters,
+		},
+		"searchURL": client.SearchPageURL(query),
+	}
+}
+
+// handleDryRunCommand Answers "show query" with the search the answers so far would send to eBay,
+// or the last search when none is being collected, without touching the conversation. "go" then runs
+// the search being collected right away, "none" answering the questions left. Reports whether message
+// was one of them and the search should run.
+func (s *Server) handleDryRunCommand(session Session, message string, w http.ResponseWriter) (handled, search bool) {
+	_, searching := session["searchByKeyword"]
+	switch {
+	case goCommand.MatchString(message) && searching:
+		s.answerNone(session)
+		return true, true
+	case !showQueryCommand.MatchString(message):
+		return false, false
+	}
+
+	var query SearchQuery
+	switch list := listedResults(session); {
+	case searching:
+		// Unanswered questions are sent as "none", like "go" would
+		query = withDisplay(queryFromSession(session), requestDisplay(w))
+	case list != nil:
+		query = list.Query
+	default:
+		WriteReply(w, ReplyInfo, JSON{"message": "There is no search to show yet, tell me what you are looking for.\n " + keywordQuestion})
+		return true, false
+	}
+
+	reply := s.dryRun(query)
+	var response strings.Builder
+	response.WriteString("This is the search I would send to eBay for '" + query.Keyword + "' :")
+	if request, found := reply["request"].(JSON); found {
+		parameters := request["parameters"].(map[string]string)
+		names := make([]string, 0, len(parameters))
+		for name := range parameters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			response.WriteString("\n " + name + " : " + parameters[name])
+		}
+		response.WriteString("\n Website search : " + reply["searchURL"].(string))
+	} else {
+		response.WriteString("\n " + describeDryRun(query))
+	}
+	if searching {
+		response.WriteString("\n\n Say 'go' to run it now.\n " + strings.TrimSpace(resumeSummary(session)))
+	} else {
+		response.WriteString("\n\n What else would you like to search for?")
+	}
+	reply["message"] = response.String()
+	WriteReply(w, ReplyInfo, reply)
+	return true, false
+}
+
+// describeDryRun Lists the filters of query for servers searching something else than eBay
+func describeDryRun(query SearchQuery) string {
+	filters := []string{"condition " + query.Condition, "minPrice " + query.MinPrice, "maxPrice " + query.MaxPrice}
+	if query.SortOrder != "" {
+		filters = append(filters, "sort "+query.SortOrder)
+	}
+	if query.Site != "" {
+		filters = append(filters, "site "+query.Site)
+	}
+	return strings.Join(filters, ", ")
+}
@@ -0,0 +1,90 @@
+package theluxuryshopper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDryRunCommands(t *testing.T) {
+	tests := []struct {
+		message   string
+		show, run bool
+	}{
+		{"show query", true, false},
+		{"Explain the eBay query?", true, false},
+		{"show me the search query", true, false},
+		{"show queries", false, false},
+		{"go", false, true},
+		{"Run it!", false, true},
+		{"search now", false, true},
+		{"go shopping", false, false},
+	}
+	for _, test := range tests {
+		if show, run := showQueryCommand.MatchString(test.message), goCommand.MatchString(test.message); show != test.show || run != test.run {
+			t.Errorf("%q matched show %v and go %v, want %v and %v", test.message, show, run, test.show, test.run)
+		}
+	}
+}
+
+func TestDescribeDryRun(t *testing.T) {
+	tests := []struct {
+		query SearchQuery
+		want  string
+	}{
+		{SearchQuery{Condition: "New", MinPrice: "none", MaxPrice: "500"}, "condition New, minPrice none, maxPrice 500"},
+		{SearchQuery{Condition: "none", MinPrice: "none", MaxPrice: "none", SortOrder: "EndTimeSoonest", Site: "EBAY-GB"}, "condition none, minPrice none, maxPrice none, sort EndTimeSoonest, site EBAY-GB"},
+	}
+	for _, test := range tests {
+		if got := describeDryRun(test.query); got != test.want {
+			t.Errorf("describeDryRun() = %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestShowQueryAndGo(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 1)}}}
+	s := newTestServer(t, Config{}, searcher)
+	c := startConversation(t, s)
+	if reply := c.say("show query"); !strings.HasPrefix(reply.message(), "There is no search to show yet") {
+		t.Errorf("show query before a search answered %q", reply.message())
+	}
+
+	c.sayAll("gucci bag", "used")
+	shown := c.say("show query")
+	want := "This is the search I would send to eBay for 'gucci bag' :\n condition Used, minPrice none, maxPrice none\n\n Say 'go' to run it now."
+	if !strings.HasPrefix(shown.message(), want) {
+		t.Errorf("show query while searching answered %q, want it to start with %q", shown.message(), want)
+	}
+	if len(searcher.Queries()) != 0 {
+		t.Fatal("show query searched")
+	}
+
+	if results := c.say("go"); results.Body["type"] != string(ReplyResults) {
+		t.Errorf("go answered %v", results.Raw)
+	}
+	if queries := searcher.Queries(); len(queries) != 1 || queries[0].Condition != "Used" || queries[0].MinPrice != "none" || queries[0].MaxPrice != "none" {
+		t.Errorf("go searched %+v", queries)
+	}
+	if last := c.say("show query"); !strings.HasSuffix(last.message(), "What else would you like to search for?") {
+		t.Errorf("show query after the search answered %q", last.message())
+	}
+}
+
+func TestDryRunOfTheEbayCall(t *testing.T) {
+	stub := &ebayStub{fixture: "gucci_belt"}
+	s := newStubbedServer(t, Config{}, stub)
+	c := startConversation(t, s)
+	reply := c.sayAll("gucci belt", "new", "100", "show query")
+
+	request, _ := reply.Body["request"].(map[string]interface{})
+	parameters, _ := request["parameters"].(map[string]interface{})
+	if request["operation"] != "findItemsByKeywords" || parameters["keywords"] != "gucci belt" || parameters["SECURITY-APPNAME"] != "REDACTED" || strings.Contains(reply.Raw, "test-app-id") {
+		t.Errorf("show query answered %v", reply.Raw)
+	}
+	if searchURL, _ := reply.Body["searchURL"].(string); !strings.HasPrefix(searchURL, "https://www.ebay.com/sch/i.html?") {
+		t.Errorf("show query answered the website search %q", searchURL)
+	}
+	if len(stub.calls) != 0 {
+		t.Errorf("show query called eBay %v times", len(stub.calls))
+	}
+}
@@ -0,0 +1,596 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ebayHosts Maps every EBAY_ENV value to its Finding API host
+var ebayHosts = map[string]string{
+	"production": "svcs.ebay.com",
+	"sandbox":    "svcs.sandbox.ebay.com",
+}
+
+// defaultAppID The production app id used when EBAY_APP_ID isn't set
+const defaultAppID = "TheLuxur-TheLuxur-PRD-45d705b3d-83824180"
+
+// findingEndpoint Builds the Finding API base URL including the operation and app id
+func findingEndpoint(host, appID string) string {
+	return findingEndpointAt("https://"+host+"/services/search/FindingService/v1", appID)
+}
+
+// findingEndpointAt Builds the Finding API URL like findingEndpoint for the service at base
+func findingEndpointAt(base, appID string) string {
+	return base + "?OPERATION-NAME=findItemsByKeywords&SERVICE-VERSION=1.0.0&SECURITY-APPNAME=" + url.QueryEscape(appID) + "&RESPONSE-DATA-FORMAT=JSON&REST-PAYLOAD"
+}
+
+// appIDParam Matches the app id parameter of a Finding API URL, wherever the URL is quoted
+var appIDParam = regexp.MustCompile(`(SECURITY-APPNAME=)[^&\s"']*`)
+
+// redactedAppID Stands in for the app id in everything shown to users, logs and traces
+const redactedAppID = "REDACTED"
+
+// redactAppID Replaces appID, and any SECURITY-APPNAME value, in text
+func redactAppID(text, appID string) string {
+	text = appIDParam.ReplaceAllString(text, "${1}"+redactedAppID)
+	if appID == "" {
+		return text
+	}
+	return strings.NewReplacer(appID, redactedAppID, url.QueryEscape(appID), redactedAppID).Replace(text)
+}
+
+// SearchQuery Holds the parameters of one Finding API search
+type SearchQuery struct {
+	Keyword string `json:"keyword"`
+	// References are the model numbers and reference codes of Keyword, like "116610LN", for UIs to show
+	References []string `json:"references,omitempty"`
+	Condition  string   `json:"condition"`
+	MinPrice   string   `json:"minPrice"`
+	MaxPrice   string   `json:"maxPrice"`
+	// Currency is the ISO code MinPrice and MaxPrice are in, eBay assumes the currency of the site when empty
+	Currency  string `json:"currency,omitempty"`
+	SortOrder string `json:"sortOrder,omitempty"`
+	Entries   int    `json:"entries"`
+	// ExcludeLots asks eBay for single items and drops the lots it returns anyway
+	ExcludeLots bool `json:"excludeLots,omitempty"`
+	// ExcludeAccessories leaves out the straps, boxes, cases and the like of the accessory terms
+	ExcludeAccessories bool `json:"excludeAccessories,omitempty"`
+	// ExcludeTerms are the words eBay leaves out of the keyword search, set from the accessory terms
+	ExcludeTerms []string `json:"-"`
+	// ExcludeCategories are the eBay category ids the items may not be listed in
+	ExcludeCategories []string `json:"excludeCategories,omitempty"`
+	// ShipsTo is the country code the items must ship to, their landed cost is estimated for it
+	ShipsTo string `json:"shipsTo,omitempty"`
+	// LocatedIn is the country code the items must be located in, eBay filters it
+	LocatedIn string `json:"locatedIn,omitempty"`
+	// ExcludeCountries are the country codes items may not be located in. eBay can't exclude them,
+	// they are dropped after the search
+	ExcludeCountries []string `json:"excludeCountries,omitempty"`
+	// Site is the eBay GLOBAL-ID searched, the app id's own site when empty
+	Site         string `json:"site,omitempty"`
+	FreeShipping bool   `json:"freeShipping,omitempty"`
+	TopRated     bool   `json:"topRated,omitempty"`
+	// Page is the page of Entries items fetched, the first when 0
+	Page int `json:"page,omitempty"`
+	// Display is the display mode the items are listed in, it doesn't change the search
+	Display string `json:"display,omitempty"`
+}
+
+// page Returns the page number of the query, counting from 1
+func (q SearchQuery) page() int {
+	if q.Page < 1 {
+		return 1
+	}
+	return q.Page
+}
+
+// SearchResult Holds the parsed items of one Finding API search
+type SearchResult struct {
+	Items   []Item
+	Count   int
+	PageURL string
+	// SearchURL is the eBay website search with the filters of the query, "" when the searcher can't build one
+	SearchURL string
+	Stats     SearchStats
+	// PriceHistory holds the prices observed for the keyword so far, this search last
+	PriceHistory []PriceObservation
+	// Collapsed holds the listings left out of Items for sellers flooding the page, nil when none were
+	Collapsed []SellerGroup
+}
+
+// SearchStats Describes how a search was answered, for logs and the debug meta block
+type SearchStats struct {
+	Duration     time.Duration // Time spent waiting on eBay
+	Attempts     int           // Upstream calls made, 0 when answered from a cache
+	CacheHit     bool
+	URL          string // The Finding API URL called, with the app id redacted
+	Page         int
+	TotalPages   int
+	TotalEntries int
+	Slow         bool               // The search took longer than the soft deadline of the server
+	Scores       map[string]float64 // The ranking scores of the items by id, nil when ranking is off
+}
+
+// Searcher Runs Finding API searches
+type Searcher interface {
+	Search(ctx context.Context, query SearchQuery) (SearchResult, error)
+}
+
+// searchFailure Is returned when eBay answered a search with a failure ack. message is eBay's own, for
+// the logs; users get the catalog message of its category.
+type searchFailure struct {
+	message  string
+	id       string
+	domain   string
+	severity string
+	category string
+}
+
+func (e *searchFailure) Error() string {
+	return e.message
+}
+
+// ebayClient Searches the Finding API over HTTP, with at most cap(limiter) calls in flight
+type ebayClient struct {
+	client   *http.Client
+	env      string
+	host     string
+	appID    string
+	endpoint string
+	// shoppingEndpoint is the GetSingleItem URL the item id is appended to
+	shoppingEndpoint string
+	limiter          chan struct{}
+}
+
+// defaultMaxConcurrent The number of simultaneous eBay calls a Server allows
+const defaultMaxConcurrent = 4
+
+// newEbayClient Creates an ebayClient for the eBay environment of config allowing maxConcurrent simultaneous upstream calls.
+// A nil transport uses one honoring the HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables.
+func newEbayClient(config Config, maxConcurrent int, transport http.RoundTripper) *ebayClient {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	env, appID := config.EbayEnv, config.EbayAppID
+	if env == "" {
+		env = "production"
+	}
+	if appID == "" {
+		appID = defaultAppID
+	}
+	if transport == nil {
+		defaultTransport := http.DefaultTransport.(*http.Transport).Clone()
+		defaultTransport.Proxy = http.ProxyFromEnvironment
+		// Separate limits tell an unreachable eBay from a slow one, the context bounds the whole attempt
+		dialer := &net.Dialer{Timeout: orDefault(config.EbayConnectTimeout, defaultConnectTimeout), KeepAlive: 30 * time.Second}
+		defaultTransport.DialContext = dialer.DialContext
+		defaultTransport.TLSHandshakeTimeout = orDefault(config.EbayTLSTimeout, defaultTLSTimeout)
+		defaultTransport.ResponseHeaderTimeout = orDefault(config.EbayHeaderTimeout, defaultHeaderTimeout)
+		transport = defaultTransport
+	}
+	client := &ebayClient{
+		client:   &http.Client{Transport: transport},
+		env:      env,
+		host:     ebayHosts[env],
+		appID:    appID,
+		endpoint: findingEndpoint(ebayHosts[env], appID),
+		limiter:  make(chan struct{}, maxConcurrent),
+
+		shoppingEndpoint: shoppingEndpointAt("https://"+shoppingHosts[env]+"/shopping", appID),
+	}
+	if u, err := url.Parse(config.EbayEndpoint); err == nil && config.EbayEndpoint != "" {
+		client.host = u.Host
+		client.endpoint = findingEndpointAt(config.EbayEndpoint, appID)
+	}
+	if config.EbayShoppingEndpoint != "" {
+		client.shoppingEndpoint = shoppingEndpointAt(config.EbayShoppingEndpoint, appID)
+	}
+	return client
+}
+
+// queryFromSession Builds the SearchQuery from the answers collected in session
+func queryFromSession(session Session) SearchQuery {
+	query := SearchQuery{
+		Keyword:   session.GetString("searchByKeyword", ""),
+		Condition: session.GetString("condition", "none"),
+		MinPrice:  session.GetString("minPrice", "none"),
+		MaxPrice:  session.GetString("maxPrice", "none"),
+		Entries:   5,
+	}
+	// The sort order is only ever set by the NLU processor, there is no question for it
+	if sortOrder, found := session["sortOrder"].(string); found {
+		query.SortOrder = sortOrder
+	}
+	query.References = keywordReferences(query.Keyword)
+	query.ExcludeLots, _ = session["excludeLots"].(bool)
+	query.ExcludeAccessories, _ = session["excludeAccessories"].(bool)
+	query.ExcludeCategories, _ = session["excludedCategories"].([]string)
+	query.ShipsTo, _ = session["shipsTo"].(string)
+	query.LocatedIn, _ = session["locatedIn"].(string)
+	query.ExcludeCountries, _ = session["excludedCountries"].([]string)
+	query.Site, _ = session["site"].(string)
+	if query.MinPrice != "none" || query.MaxPrice != "none" {
+		query.Currency = priceCurrency(session, query.Site)
+	}
+	query.FreeShipping, _ = session["freeShipping"].(bool)
+	query.TopRated, _ = session["topRated"].(bool)
+	return query
+}
+
+// URL Builds the Finding API URL for query, it carries the app id so only requests may use it.
+// Anything logged, shown or traced uses SanitizedURL.
+func (c *ebayClient) URL(query SearchQuery) string {
+	keyword := strings.Replace(url.QueryEscape(withNegativeTerms(query.Keyword, query.ExcludeTerms)), "+", "%20", -1)
+
+	u := c.endpoint + "&paginationInput.entriesPerPage=" + strconv.Itoa(query.Entries) + "&keywords=" + keyword
+	if query.Page > 1 {
+		u += "&paginationInput.pageNumber=" + strconv.Itoa(query.Page)
+	}
+	u += "&outputSelector(0)=PictureURLLarge&outputSelector(1)=PictureURLSuperSize&outputSelector(2)=SellerInfo"
+
+	filterIndex := 0
+	addFilter := func(name, value string) {
+		if value == "" || strings.EqualFold(value, "none") {
+			return
+		}
+		u += "&itemFilter(" + strconv.Itoa(filterIndex) + ").name=" + name + "&itemFilter(" + strconv.Itoa(filterIndex) + ").value=" + url.QueryEscape(value)
+		filterIndex++
+	}
+	// The price filters name their currency, which eBay otherwise guesses differently from site to site
+	addPriceFilter := func(name, value string) {
+		index := filterIndex
+		addFilter(name, value)
+		if filterIndex > index && query.Currency != "" {
+			u += "&itemFilter(" + strconv.Itoa(index) + ").paramName=Currency&itemFilter(" + strconv.Itoa(index) + ").paramValue=" + url.QueryEscape(query.Currency)
+		}
+	}
+	addFilter("Condition", query.Condition)
+	addPriceFilter("MinPrice", query.MinPrice)
+	addPriceFilter("MaxPrice", query.MaxPrice)
+	addFilter("AvailableTo", query.ShipsTo)
+	addFilter("LocatedIn", query.LocatedIn)
+	if query.FreeShipping {
+		addFilter("FreeShippingOnly", "true")
+	}
+	if query.TopRated {
+		addFilter("TopRatedSellerOnly", "true")
+	}
+	// The Finding API counts the items of a lot as its quantity
+	if query.ExcludeLots {
+		addFilter("MaxQuantity", "1")
+	}
+	if len(query.ExcludeCategories) > 0 {
+		index := strconv.Itoa(filterIndex)
+		u += "&itemFilter(" + index + ").name=ExcludeCategory"
+		for i, id := range query.ExcludeCategories {
+			u += "&itemFilter(" + index + ").value(" + strconv.Itoa(i) + ")=" + url.QueryEscape(id)
+		}
+		filterIndex++
+	}
+
+	if query.SortOrder != "" {
+		u += "&sortOrder=" + query.SortOrder
+	}
+	if query.Site != "" {
+		u += "&GLOBAL-ID=" + url.QueryEscape(query.Site)
+	}
+	return u
+}
+
+// SanitizedURL Returns the URL of query with the app id redacted
+func (c *ebayClient) SanitizedURL(query SearchQuery) string {
+	return redactAppID(c.URL(query), c.appID)
+}
+
+// sanitize Redacts the app id from err, which may quote the request URL or echo its parameters
+func (c *ebayClient) sanitize(err error) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *url.Error:
+		e.URL = redactAppID(e.URL, c.appID)
+		if e.Err != nil && c.appID != "" && strings.Contains(e.Err.Error(), c.appID) {
+			e.Err = errors.New(redactAppID(e.Err.Error(), c.appID))
+		}
+		return e
+	case *searchFailure:
+		e.message = redactAppID(e.message, c.appID)
+		return e
+	case *throttled:
+		e.message = redactAppID(e.message, c.appID)
+		return e
+	case *upstreamError:
+		e.err = c.sanitize(e.err)
+		return e
+	}
+	if message := err.Error(); redactAppID(message, c.appID) != message {
+		return errors.New(redactAppID(message, c.appID))
+	}
+	return err
+}
+
+// Search Calls the Finding API for query and parses the response.
+// Connect failures are retried right away and header timeouts after a backoff,
+// as long as ctx leaves time for it; every other failure ends the search.
+func (c *ebayClient) Search(ctx context.Context, query SearchQuery) (SearchResult, error) {
+	select {
+	case c.limiter <- struct{}{}:
+	case <-ctx.Done():
+		return SearchResult{}, ctx.Err()
+	}
+	defer func() { <-c.limiter }()
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		result, err := c.call(ctx, query)
+		retry, backoff := false, time.Duration(0)
+		upstream, failed := err.(*upstreamError)
+		if failed {
+			retry, backoff = upstream.retryable()
+		}
+		if !retry || attempt >= maxSearchAttempts || !timeLeft(ctx, backoff) {
+			result.Stats.Duration = time.Since(start)
+			result.Stats.Attempts = attempt
+			result.Stats.URL = c.SanitizedURL(query)
+			if err == nil && result.PageURL != "" {
+				result.SearchURL = c.SearchPageURL(query)
+			}
+			// eBay sometimes echoes the request parameters in its error messages
+			return result, c.sanitize(err)
+		}
+		log.Printf("eBay call failed with %v, retrying in %v", upstream.category, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// call Makes one Finding API call for query
+func (c *ebayClient) call(ctx context.Context, query SearchQuery) (SearchResult, error) {
+	req, err := http.NewRequest(http.MethodGet, c.URL(query), nil)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	res, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return SearchResult{}, &upstreamError{category: classifyCallError(ctx, err), err: err}
+	}
+	defer res.Body.Close()
+
+	var response findingResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxFindingResponseSize)).Decode(&response); err != nil {
+		if unreadableBody(err) {
+			return SearchResult{}, &upstreamError{category: failureBodyRead, err: fmt.Errorf("couldn't read eBay response: %v", err)}
+		}
+		return SearchResult{}, fmt.Errorf("couldn't decode eBay response: %v", err)
+	}
+	result, err := parseSearchResponse(response)
+	if limited, ok := err.(*throttled); ok {
+		limited.retryAfter = parseRetryAfter(res.Header.Get("Retry-After"))
+	}
+	return result, err
+}
+
+// timeLeft Reports whether ctx still has more than wait left
+func timeLeft(ctx context.Context, wait time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	deadline, bounded := ctx.Deadline()
+	return !bounded || time.Until(deadline) > wait
+}
+
+// orDefault Returns limit, or fallback when limit isn't set
+func orDefault(limit, fallback time.Duration) time.Duration {
+	if limit <= 0 {
+		return fallback
+	}
+	return limit
+}
+
+// maxFindingResponseSize Caps how much of a Finding API response is read, 100 items stay well below it
+const maxFindingResponseSize = 8 << 20
+
+// findingResponse Mirrors the parts of a findItemsByKeywords response that are used.
+// eBay wraps every value, even single ones, in an array.
+type findingResponse struct {
+	FindItemsByKeywordsResponse []struct {
+		Ack          []string `json:"ack"`
+		ErrorMessage []struct {
+			Error []findingError `json:"error"`
+		} `json:"errorMessage"`
+		SearchResult []struct {
+			Count string        `json:"@count"`
+			Item  []findingItem `json:"item"`
+		} `json:"searchResult"`
+		ItemSearchURL    []string `json:"itemSearchURL"`
+		PaginationOutput []struct {
+			PageNumber   []string `json:"pageNumber"`
+			TotalPages   []string `json:"totalPages"`
+			TotalEntries []string `json:"totalEntries"`
+		} `json:"paginationOutput"`
+	} `json:"findItemsByKeywordsResponse"`
+	// ErrorMessage is set instead when eBay refused the call itself, like when it throttles the app id
+	ErrorMessage []struct {
+		Error []findingError `json:"error"`
+	} `json:"errorMessage"`
+}
+
+// findingItem Mirrors one element of the searchResult item array
+type findingItem struct {
+	ItemID     []string `json:"itemId"`
+	Title      []string `json:"title"`
+	GalleryURL []string `json:"galleryURL"`
+	// The picture URLs are only returned for the outputSelector values asking for them
+	PictureURLLarge     []string `json:"pictureURLLarge"`
+	PictureURLSuperSize []string `json:"pictureURLSuperSize"`
+	ViewItemURL         []string `json:"viewItemURL"`
+	Condition           []struct {
+		ConditionDisplayName []string `json:"conditionDisplayName"`
+	} `json:"condition"`
+	SellingStatus []struct {
+		CurrentPrice []findingAmount `json:"currentPrice"`
+	} `json:"sellingStatus"`
+	Country     []string `json:"country"`
+	Location    []string `json:"location"`
+	ListingInfo []struct {
+		EndTime     []string `json:"endTime"`
+		ListingType []string `json:"listingType"`
+	} `json:"listingInfo"`
+	ShippingInfo []struct {
+		ShippingServiceCost []findingAmount `json:"shippingServiceCost"`
+	} `json:"shippingInfo"`
+	// SellerInfo is only returned for the SellerInfo outputSelector
+	SellerInfo []struct {
+		PositiveFeedbackPercent []string `json:"positiveFeedbackPercent"`
+		SellerUserName          []string `json:"sellerUserName"`
+	} `json:"sellerInfo"`
+	PrimaryCategory []struct {
+		CategoryName []string `json:"categoryName"`
+	} `json:"primaryCategory"`
+	TopRatedListing []string `json:"topRatedListing"`
+	// UnitPrice is only set for listings priced by unit, its quantity is the size of the lot
+	UnitPrice []struct {
+		Quantity []string `json:"quantity"`
+	} `json:"unitPrice"`
+}
+
+// findingAmount Mirrors an amount with its currency
+type findingAmount struct {
+	Value      string `json:"__value__"`
+	CurrencyID string `json:"@currencyId"`
+}
+
+// parseSearchResponse Extracts the items of a findItemsByKeywords response
+func parseSearchResponse(decoded findingResponse) (SearchResult, error) {
+	for _, block := range decoded.ErrorMessage {
+		if err := throttleError(block.Error); err != nil {
+			return SearchResult{}, err
+		}
+	}
+	if len(decoded.FindItemsByKeywordsResponse) == 0 {
+		// eBay refusing the call itself, like for an invalid app id
+		if len(decoded.ErrorMessage) > 0 {
+			if failure := newSearchFailure(decoded.ErrorMessage[0].Error); failure != nil && failure.message != "" {
+				return SearchResult{}, failure
+			}
+		}
+		return SearchResult{}, errors.New("missing findItemsByKeywordsResponse in eBay response")
+	}
+	response := decoded.FindItemsByKeywordsResponse[0]
+
+	ack := first(response.Ack)
+	if ack == "" {
+		return SearchResult{}, errors.New("missing ack in eBay response")
+	}
+	if strings.EqualFold(ack, "failure") {
+		for _, block := range response.ErrorMessage {
+			if err := throttleError(block.Error); err != nil {
+				return SearchResult{}, err
+			}
+		}
+		var failure *searchFailure
+		if len(response.ErrorMessage) > 0 {
+			failure = newSearchFailure(response.ErrorMessage[0].Error)
+		}
+		if failure == nil || failure.message == "" {
+			return SearchResult{}, errors.New("missing error message in eBay failure")
+		}
+		return SearchResult{}, failure
+	}
+
+	if len(response.SearchResult) == 0 {
+		return SearchResult{}, errors.New("missing search result in eBay response")
+	}
+	searchResult := response.SearchResult[0]
+	count, err := strconv.Atoi(searchResult.Count)
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("invalid item count in eBay response: %v", err)
+	}
+
+	result := SearchResult{Count: count}
+	if len(response.PaginationOutput) > 0 {
+		pagination := response.PaginationOutput[0]
+		result.Stats.Page, _ = strconv.Atoi(first(pagination.PageNumber))
+		result.Stats.TotalPages, _ = strconv.Atoi(first(pagination.TotalPages))
+		result.Stats.TotalEntries, _ = strconv.Atoi(first(pagination.TotalEntries))
+	}
+	if count == 0 {
+		return result, nil
+	}
+
+	result.PageURL = first(response.ItemSearchURL) // ebay results page url
+	if result.PageURL == "" {
+		return SearchResult{}, errors.New("missing results page url in eBay response")
+	}
+
+	result.Items = make([]Item, 0, len(searchResult.Item))
+	for _, element := range searchResult.Item {
+		result.Items = append(result.Items, parseItem(element))
+	}
+	return result, nil
+}
+
+// parseItem Populates an Item from one element of the searchResult item array
+func parseItem(element findingItem) Item {
+	item := Item{
+		ID:         first(element.ItemID),
+		GalleryURL: first(element.GalleryURL),
+		ItemURL:    first(element.ViewItemURL),
+		Title:      first(element.Title),
+		Country:    first(element.Country),
+		Location:   first(element.Location),
+		ImageURL:   bestImage(element),
+	}
+	if len(element.ListingInfo) > 0 {
+		item.EndTime = first(element.ListingInfo[0].EndTime)
+		item.ListingType = first(element.ListingInfo[0].ListingType)
+	}
+	if len(element.ShippingInfo) > 0 && len(element.ShippingInfo[0].ShippingServiceCost) > 0 {
+		item.ShippingCost = element.ShippingInfo[0].ShippingServiceCost[0].Value
+	}
+	if len(element.SellerInfo) > 0 {
+		if percent := first(element.SellerInfo[0].PositiveFeedbackPercent); percent != "" {
+			item.SellerRating = percent + "%"
+		}
+		item.Seller = first(element.SellerInfo[0].SellerUserName)
+	}
+	if len(element.PrimaryCategory) > 0 {
+		item.Category = first(element.PrimaryCategory[0].CategoryName)
+	}
+	item.TopRated = first(element.TopRatedListing) == "true"
+	if len(element.Condition) > 0 {
+		item.Condition = first(element.Condition[0].ConditionDisplayName)
+	}
+	if len(element.SellingStatus) > 0 && len(element.SellingStatus[0].CurrentPrice) > 0 {
+		price := element.SellingStatus[0].CurrentPrice[0]
+		item.Price = price.Value
+		item.Currency = price.CurrencyID
+	}
+	quantity := 0
+	if len(element.UnitPrice) > 0 {
+		quantity, _ = strconv.Atoi(first(element.UnitPrice[0].Quantity))
+	}
+	item.Lot, item.LotSize = lotSize(quantity, item.Title)
+	return item
+}
+
+// first Returns the first value of an eBay array, or "" if it is empty
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,101 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readFixture Reads the Finding API response testdata/ebay/<name>.json
+func readFixture(tb testing.TB, name string) []byte {
+	tb.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "ebay", name+".json"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return data
+}
+
+// decodeStreamed Decodes a Finding API response the way ebayClient.call does, straight from the bounded body
+func decodeStreamed(body io.Reader) (SearchResult, error) {
+	var response findingResponse
+	if err := json.NewDecoder(io.LimitReader(body, maxFindingResponseSize)).Decode(&response); err != nil {
+		return SearchResult{}, err
+	}
+	return parseSearchResponse(response)
+}
+
+// decodeUntyped Decodes a Finding API response the way it was before the typed structs: the whole body
+// read first, then decoded into the generic tree go-simplejson kept
+func decodeUntyped(body io.Reader) (interface{}, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var tree interface{}
+	err = decoder.Decode(&tree)
+	return tree, err
+}
+
+func TestSearchGivesUpWaitingForTheLimiter(t *testing.T) {
+	client := newEbayClient(Config{EbayEndpoint: "http://127.0.0.1:1/finding"}, 1, nil)
+	// The only slot is taken by a call that never ends
+	client.limiter <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Search(ctx, SearchQuery{Keyword: "gucci"}); err != context.DeadlineExceeded {
+		t.Errorf("Search waiting for the limiter failed with %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, err := client.FetchItem(ctx, "123"); err != context.DeadlineExceeded {
+		t.Errorf("FetchItem waiting for the limiter failed with %v, want %v", err, context.DeadlineExceeded)
+	}
+	if len(client.limiter) != 1 {
+		t.Errorf("the limiter holds %v calls, want the 1 that never ended", len(client.limiter))
+	}
+}
+
+func TestDecodeFullPage(t *testing.T) {
+	data := readFixture(t, "page_100")
+	streamed, err := decodeStreamed(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(streamed.Items) != 100 || streamed.Count != 100 || streamed.Stats.TotalEntries != 2291 {
+		t.Fatalf("decoded %v items of count %v and %v entries, want 100, 100 and 2291", len(streamed.Items), streamed.Count, streamed.Stats.TotalEntries)
+	}
+	if first := streamed.Items[0]; first.ImageURL != "https://i.ebayimg.com/images/g/1000/s-l1600.jpg" || first.Seller != "seller0" || first.ShippingCost != "0.0" {
+		t.Errorf("the first item decoded as %+v", first)
+	}
+}
+
+// BenchmarkDecodeSearchResponse Compares decoding a 100 item page into the typed structs, straight from the body,
+// with reading it whole into the generic tree like before. Run with go test -bench DecodeSearchResponse -benchmem
+func BenchmarkDecodeSearchResponse(b *testing.B) {
+	data := readFixture(b, "page_100")
+	b.Run("streamed", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			if _, err := decodeStreamed(bytes.NewReader(data)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("untyped", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			if _, err := decodeUntyped(bytes.NewReader(data)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
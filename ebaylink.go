@@ -0,0 +1,253 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ebayHost Matches the hosts of the eBay sites on every TLD, like www.ebay.com, m.ebay.co.uk,
+	// cgi.ebay.de or ebay.com.au
+	ebayHost = regexp.MustCompile(`^(?:[a-z0-9-]+\.)*ebay\.(?:[a-z]{2,3}|com?\.[a-z]{2})$`)
+	// shortLinkHosts Are the hosts of eBay's shortened links, which only resolve by following them
+	shortLinkHosts = map[string]bool{"ebay.us": true, "ebay.to": true, "www.ebay.us": true}
+	// itemPath Matches the listing paths /itm/<id> and /itm/<title>/<id>
+	itemPath = regexp.MustCompile(`(?i)^/itm/(?:[^/]+/)?(\d{9,19})/?$`)
+	// legacyItemPath Matches the paths of the old item pages, whose id is in ?item=
+	legacyItemPath = regexp.MustCompile(`(?i)^/(?:ws/ebayisapi\.dll|itm/?)$`)
+	// searchPath Matches the search paths /sch/i.html, /sch/<category>/<id>/i.html and the old /i.html of shop.ebay.com
+	searchPath = regexp.MustCompile(`(?i)^(?:/sch(?:/[^/]+)*)?/i\.html$|^/sch/?$`)
+)
+
+// linkConditions Maps the LH_ItemCondition codes of search links to the conditions of the questions
+var linkConditions = map[string]string{
+	"1000": "New", "1500": "New", "1750": "New",
+	"2000": "Used", "2010": "Used", "2020": "Used", "2030": "Used", "2500": "Used", "3000": "Used",
+	"4000": "Used", "5000": "Used", "6000": "Used", "7000": "Used",
+}
+
+// linkSortOrders Maps the _sop values of search links to the sort orders of the Finding API
+var linkSortOrders = map[string]string{
+	"12": "BestMatch",
+	"15": "PricePlusShippingLowest",
+	"16": "PricePlusShippingHighest",
+	"1":  "EndTimeSoonest",
+	"10": "StartTimeNewest",
+}
+
+// sortLabels Names the sort orders in the confirmation of a search link
+var sortLabels = map[string]string{
+	"PricePlusShippingLowest":  "cheapest first",
+	"PricePlusShippingHighest": "most expensive first",
+	"EndTimeSoonest":           "ending soonest first",
+	"StartTimeNewest":          "newest first",
+}
+
+// ebayLink Is what a pasted eBay link points at: one listing, a search, or neither
+type ebayLink struct {
+	ItemID string
+	Search *linkSearch
+	Short  bool // a shortened link, which can't be read without following it
+}
+
+// linkSearch Is the search of a link, "" for what it doesn't filter
+type linkSearch struct {
+	Keyword   string
+	Condition string
+	MinPrice  string
+	MaxPrice  string
+	SortOrder string
+	Site      string // the GLOBAL-ID of the site the link is on, "" for the default one
+}
+
+// findEbayLink Returns the eBay link of message, reporting false when it holds none. Only the
+// parameters naming the search are read, the tracking ones like _trksid or mkevt are ignored.
+func findEbayLink(message string) (ebayLink, bool) {
+	for _, word := range strings.Fields(message) {
+		word = strings.Trim(word, `<>()[]"'`)
+		lower := strings.ToLower(word)
+		if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+			if !strings.Contains(lower, "ebay.") || !strings.Contains(lower, "/") {
+				continue
+			}
+			word = "https://" + word
+		}
+		u, err := url.Parse(word)
+		if err != nil {
+			continue
+		}
+		host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+		if shortLinkHosts[host] {
+			return ebayLink{Short: true}, true
+		}
+		if !ebayHost.MatchString(host) {
+			continue
+		}
+		return parseEbayLink(u, host), true
+	}
+	return ebayLink{}, false
+}
+
+// parseEbayLink Reads the listing or search u points at, on host
+func parseEbayLink(u *url.URL, host string) ebayLink {
+	query := u.Query()
+	if match := itemPath.FindStringSubmatch(u.Path); match != nil {
+		return ebayLink{ItemID: match[1]}
+	}
+	if id := query.Get("item"); legacyItemPath.MatchString(u.Path) && itemIDPattern.MatchString(id) {
+		return ebayLink{ItemID: id}
+	}
+	if !searchPath.MatchString(u.Path) {
+		return ebayLink{}
+	}
+	keyword, _ := normalizeKeyword(query.Get("_nkw"))
+	if keyword == "" {
+		return ebayLink{}
+	}
+	search := &linkSearch{
+		Keyword:   keyword,
+		MinPrice:  normalizePrice(query.Get("_udlo")),
+		MaxPrice:  normalizePrice(query.Get("_udhi")),
+		SortOrder: linkSortOrders[query.Get("_sop")],
+	}
+	// Several codes name one condition only when they all agree, like New and Open box
+	for _, code := range strings.Split(query.Get("LH_ItemCondition"), "|") {
+		condition, known := linkConditions[code]
+		if !known || search.Condition != "" && search.Condition != condition {
+			search.Condition = ""
+			break
+		}
+		search.Condition = condition
+	}
+	if site, found := siteByDomain(host); found && site.GlobalID != "EBAY-US" {
+		search.Site = site.GlobalID
+	}
+	return ebayLink{Search: search}
+}
+
+// siteByDomain Returns the site of the website host, with or without its subdomain
+func siteByDomain(host string) (ebaySite, bool) {
+	host = host[strings.LastIndex(host, "ebay."):]
+	for _, site := range ebaySites {
+		if strings.TrimPrefix(site.Domain, "www.") == host {
+			return site, true
+		}
+	}
+	return ebaySite{}, false
+}
+
+// describe Summarizes the search for the confirmation, like "'gucci belt', max 500"
+func (search *linkSearch) describe() string {
+	description := "'" + search.Keyword + "'"
+	if search.Condition != "" {
+		description += ", " + strings.ToLower(search.Condition)
+	}
+	if search.MinPrice != "" {
+		description += ", min " + search.MinPrice
+	}
+	if search.MaxPrice != "" {
+		description += ", max " + search.MaxPrice
+	}
+	if label, found := sortLabels[search.SortOrder]; found {
+		description += ", " + label
+	}
+	if search.Site != "" {
+		description += " on " + siteName(search.Site)
+	}
+	return description
+}
+
+// handleEbayLink Answers a message pasting an eBay link, reporting whether it did. A listing is looked up
+// fresh like GET /items/:itemId does; a search fills in the session, replacing the one in progress, and
+// is confirmed before it runs: yes searches it, no starts over and anything else drops it.
+func (s *Server) handleEbayLink(session Session, message string, w http.ResponseWriter) bool {
+	if pending, _ := session["linkConfirm"].(bool); pending {
+		delete(session, "linkConfirm")
+		switch {
+		case confirmCommandAnswer.MatchString(message):
+			// The scripted flow finds every filter answered and searches
+			return false
+		case rejectCommandAnswer.MatchString(message):
+			resetSession(session)
+			WriteReply(w, ReplyQuestion, JSON{
+				"message": "OK, forget that link.\n " + keywordQuestion,
+				"step":    "keyword",
+			})
+			return true
+		}
+		resetSession(session)
+	}
+
+	link, found := findEbayLink(message)
+	switch {
+	case !found:
+		return false
+	case link.Short:
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "I can't open shortened eBay links, please paste the full link of the listing or search.\n " + strings.TrimSpace(resumeSummary(session)),
+		})
+	case link.ItemID != "":
+		s.showLinkedItem(session, link.ItemID, w)
+	case link.Search != nil:
+		resetSession(session)
+		search := link.Search
+		session["searchByKeyword"] = search.Keyword
+		for key, value := range map[string]string{"condition": search.Condition, "minPrice": search.MinPrice, "maxPrice": search.MaxPrice} {
+			if value == "" {
+				value = "none"
+			}
+			session[key] = value
+		}
+		if search.SortOrder != "" {
+			session["sortOrder"] = search.SortOrder
+		}
+		if search.Site != "" {
+			session["site"] = search.Site
+		}
+		delete(session, "results")
+		delete(session, "originalSite")
+		session["linkConfirm"] = true
+		WriteReply(w, ReplyQuestion, JSON{
+			"message":     "From your link I'm searching for " + search.describe() + " — right? Say yes to search, or no to start over.",
+			"step":        "confirmLink",
+			"suggestions": []string{"yes", "no"},
+		})
+	default:
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "I can read links to eBay listings and searches, but not this one. Tell me what you are looking for instead.\n " + strings.TrimSpace(resumeSummary(session)),
+		})
+	}
+	return true
+}
+
+// showLinkedItem Answers the listing id of a pasted link with its fresh details
+func (s *Server) showLinkedItem(session Session, id string, w http.ResponseWriter) {
+	item, err := s.fetchItem(requestContext(w), id)
+	next := strings.TrimSpace(resumeSummary(session))
+	if _, searching := session["searchByKeyword"]; !searching {
+		next = "What else would you like to search for?"
+	}
+	switch {
+	case err == errItemNotFound:
+		WriteReply(w, ReplyInfo, JSON{"message": "eBay has no listing with the item id " + id + " of your link anymore.\n " + next})
+		return
+	case err == errItemsUnsupported:
+		WriteReply(w, ReplyInfo, JSON{"message": "I can't look up single listings here, tell me what you are looking for instead.\n " + next})
+		return
+	case handleError(err, session, w) == 1:
+		return
+	}
+	var response strings.Builder
+	response.WriteString("Here is the listing of your link, checked just now:\n")
+	writeItems(&response, []Item{item.Item}, itemView{fields: displayFields[displayDetailed], location: requestLocation(w)})
+	if item.Status == listingEnded {
+		response.WriteString("\n This listing has ended.")
+	}
+	response.WriteString("\n\n " + next)
+	WriteReply(w, ReplyInfo, JSON{
+		"message": response.String(),
+		"item":    item,
+	})
+}
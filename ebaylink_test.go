@@ -0,0 +1,115 @@
+package theluxuryshopper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindEbayLink(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   string
+		want      ebayLink
+		wantFound bool
+	}{
+		{"listing", "look at https://www.ebay.com/itm/123456789012", ebayLink{ItemID: "123456789012"}, true},
+		{"listing with a title", "<https://www.ebay.co.uk/itm/Gucci-Belt/123456789012?_trksid=p1>", ebayLink{ItemID: "123456789012"}, true},
+		{"legacy listing", "https://cgi.ebay.de/ws/eBayISAPI.dll?ViewItem&item=123456789012", ebayLink{ItemID: "123456789012"}, true},
+		{"without scheme", "ebay.com/itm/123456789012", ebayLink{ItemID: "123456789012"}, true},
+		{"short link", "https://ebay.us/abc123", ebayLink{Short: true}, true},
+		{
+			"search",
+			"https://www.ebay.com/sch/i.html?_nkw=gucci+belt&_udhi=500&LH_ItemCondition=1000|1500&_sop=15&mkevt=1",
+			ebayLink{Search: &linkSearch{Keyword: "gucci belt", Condition: "New", MaxPrice: "500", SortOrder: "PricePlusShippingLowest"}},
+			true,
+		},
+		{
+			"search on another site",
+			"https://www.ebay.de/sch/169291/i.html?_nkw=prada&_udlo=100&LH_ItemCondition=1000|3000",
+			ebayLink{Search: &linkSearch{Keyword: "prada", MinPrice: "100", Site: "EBAY-DE"}},
+			true,
+		},
+		{"search without keyword", "https://www.ebay.com/sch/i.html?_udhi=500", ebayLink{}, true},
+		{"other page", "https://www.ebay.com/help/home", ebayLink{}, true},
+		{"not ebay", "https://www.notebay.com/itm/123456789012", ebayLink{}, false},
+		{"lookalike", "https://ebay.com.evil.example/itm/123456789012", ebayLink{}, false},
+		{"no link", "gucci belt", ebayLink{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, found := findEbayLink(test.message)
+			if found != test.wantFound || !reflect.DeepEqual(got, test.want) {
+				t.Errorf("findEbayLink() = %+v, %v, want %+v, %v", got, found, test.want, test.wantFound)
+				if got.Search != nil {
+					t.Errorf("the search is %+v", *got.Search)
+				}
+			}
+		})
+	}
+}
+
+func TestLinkSearchDescribe(t *testing.T) {
+	tests := []struct {
+		search linkSearch
+		want   string
+	}{
+		{linkSearch{Keyword: "gucci belt"}, "'gucci belt'"},
+		{linkSearch{Keyword: "kelly", Condition: "Used", MinPrice: "100", MaxPrice: "500", SortOrder: "EndTimeSoonest"}, "'kelly', used, min 100, max 500, ending soonest first"},
+		{linkSearch{Keyword: "prada", SortOrder: "BestMatch", Site: "EBAY-GB"}, "'prada' on eBay UK"},
+	}
+	for _, test := range tests {
+		if got := test.search.describe(); got != test.want {
+			t.Errorf("describe() = %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestPastedSearchLink(t *testing.T) {
+	link := "https://www.ebay.com/sch/i.html?_nkw=gucci+belt&_udhi=500&LH_ItemCondition=3000"
+	tests := []struct {
+		name, answer string
+		wantSearch   bool
+		wantStep     string
+	}{
+		{"yes", "yes", true, ""},
+		{"no", "no", false, "keyword"},
+		{"something else", "prada bag", false, "condition"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("belt", 1, 1)}}}
+			s := newTestServer(t, Config{}, searcher)
+			c := startConversation(t, s)
+			confirm := c.say("I want this " + link)
+			if confirm.Body["step"] != "confirmLink" || confirm.message() != "From your link I'm searching for 'gucci belt', used, max 500 — right? Say yes to search, or no to start over." {
+				t.Fatalf("the link answered %v", confirm.Raw)
+			}
+			reply := c.say(test.answer)
+			queries := searcher.Queries()
+			if searched := len(queries) == 1; searched != test.wantSearch {
+				t.Fatalf("%q searched %v times", test.answer, len(queries))
+			}
+			if test.wantSearch && (queries[0].Keyword != "gucci belt" || queries[0].Condition != "Used" || queries[0].MaxPrice != "500" || queries[0].MinPrice != "none") {
+				t.Errorf("the link searched %+v", queries[0])
+			}
+			if test.wantStep != "" && reply.Body["step"] != test.wantStep {
+				t.Errorf("%q answered %v, want the step %v", test.answer, reply.Raw, test.wantStep)
+			}
+		})
+	}
+}
+
+func TestPastedShortAndUnknownLinks(t *testing.T) {
+	s := newTestServer(t, Config{}, &fakeSearcher{})
+	c := startConversation(t, s)
+	if reply := c.say("https://www.ebay.com/itm/123456789012"); !strings.HasPrefix(reply.message(), "I can't look up single listings here") {
+		t.Errorf("a listing link without the eBay client answered %q", reply.message())
+	}
+	if reply := c.say("https://ebay.us/abc"); !strings.HasPrefix(reply.message(), "I can't open shortened eBay links") {
+		t.Errorf("a short link answered %q", reply.message())
+	}
+	if reply := c.say("https://www.ebay.com/help/home"); !strings.HasPrefix(reply.message(), "I can read links to eBay listings and searches, but not this one.") {
+		t.Errorf("a help page link answered %q", reply.message())
+	}
+}
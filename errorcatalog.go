@@ -0,0 +1,96 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"encoding/hex"
+	"log"
+	"strings"
+)
+
+// Categories of the failures eBay answers searches with. Throttling never gets here, throttleError turns it into throttled.
+const (
+	failureInvalidInput = "invalid_input" // the search asked for something eBay doesn't take
+	failureSystem       = "system_error"  // eBay had a problem of its own
+	failureAppID        = "app_id"        // the app id is unknown, disabled or not allowed the call
+	failureUnknown      = "unknown"       // an error id the catalog doesn't know, logged in full
+)
+
+// failureCatalogEntry Is what users are told about one category of failures, and what they can try next
+type failureCatalogEntry struct {
+	message string
+	next    string
+}
+
+// failureCatalog Holds the user-safe message of every category, eBay's own wording never reaches users
+var failureCatalog = map[string]failureCatalogEntry{
+	failureInvalidInput: {"eBay couldn't search for that as asked.", "Try a shorter keyword, or answer None to some of the filters."},
+	failureSystem:       {"eBay had a problem running the search.", "Please try again in a moment."},
+	failureAppID:        {"Searches aren't set up right on our side, we are looking into it.", "Please try again later."},
+	failureUnknown:      {"eBay couldn't run the search.", "Try again, or search for something else."},
+}
+
+// failureErrorIDs Are the Finding API error ids known to belong to a category, the others are told apart by their domain and category
+var failureErrorIDs = map[string]string{
+	"11002": failureAppID, // Authentication failed : Invalid Application
+}
+
+// newSearchFailure Returns the searchFailure of the errors of a failed response, told by its first error of Error severity
+func newSearchFailure(errors []findingError) *searchFailure {
+	if len(errors) == 0 {
+		return nil
+	}
+	e := errors[0]
+	for _, candidate := range errors {
+		if strings.EqualFold(first(candidate.Severity), "Error") {
+			e = candidate
+			break
+		}
+	}
+	failure := &searchFailure{
+		message:  first(e.Message),
+		id:       first(e.ErrorID),
+		domain:   first(e.Domain),
+		severity: first(e.Severity),
+	}
+	failure.category = failureCategoryOf(e)
+	return failure
+}
+
+// failureCategoryOf Sorts one Finding API error into the categories of failureCatalog
+func failureCategoryOf(e findingError) string {
+	if category, known := failureErrorIDs[first(e.ErrorID)]; known {
+		return category
+	}
+	switch {
+	case strings.EqualFold(first(e.Domain), "Security"), strings.EqualFold(first(e.Subdomain), "Authentication"):
+		return failureAppID
+	case strings.EqualFold(first(e.Category), "Request"):
+		return failureInvalidInput
+	case strings.EqualFold(first(e.Category), "System"):
+		return failureSystem
+	}
+	return failureUnknown
+}
+
+// userMessage Returns the catalog message of the failure with what to try next
+func (e *searchFailure) userMessage() string {
+	entry, found := failureCatalog[e.category]
+	if !found {
+		entry = failureCatalog[failureUnknown]
+	}
+	return entry.message + " " + entry.next
+}
+
+// logFailure Logs what eBay answered a search of ctx with, for the operators, under the trace id of the request
+func logFailure(ctx context.Context, e *searchFailure) {
+	log.Printf("warning: eBay refused a search (request %v): category=%v errorId=%v domain=%v severity=%v: %v",
+		requestID(ctx), e.category, e.id, e.domain, e.severity, e.message)
+}
+
+// requestID Returns the trace id of the request ctx belongs to, "-" when it isn't traced
+func requestID(ctx context.Context) string {
+	if sp, ok := ctx.Value(spanContextKey{}).(*span); ok && sp != nil {
+		return hex.EncodeToString(sp.traceID[:])
+	}
+	return "-"
+}
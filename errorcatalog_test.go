@@ -0,0 +1,82 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// findingErr Builds a findingError of the fields a Finding API error carries
+func findingErr(id, domain, subdomain, severity, category, message string) findingError {
+	return findingError{
+		ErrorID: []string{id}, Domain: []string{domain}, Subdomain: []string{subdomain},
+		Severity: []string{severity}, Category: []string{category}, Message: []string{message},
+	}
+}
+
+func TestFailureCategoryOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  findingError
+		want string
+	}{
+		{"invalid application", findingErr("11002", "Security", "Authentication", "Error", "System", "Invalid Application"), failureAppID},
+		{"security domain", findingErr("99", "Security", "", "Error", "Request", ""), failureAppID},
+		{"authentication subdomain", findingErr("99", "Marketplace", "authentication", "Error", "Request", ""), failureAppID},
+		{"request", findingErr("3", "Marketplace", "Search", "Error", "Request", "Invalid keywords"), failureInvalidInput},
+		{"system", findingErr("10001", "Marketplace", "Search", "Error", "System", "Internal error"), failureSystem},
+		{"unknown", findingErr("42", "Marketplace", "Search", "Error", "Application", "?"), failureUnknown},
+		{"empty", findingError{}, failureUnknown},
+	}
+	for _, test := range tests {
+		if got := failureCategoryOf(test.err); got != test.want {
+			t.Errorf("failureCategoryOf(%v) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNewSearchFailure(t *testing.T) {
+	if newSearchFailure(nil) != nil {
+		t.Error("newSearchFailure() of no errors isn't nil")
+	}
+	warning := findingErr("1", "Marketplace", "Search", "Warning", "System", "Only a warning")
+	request := findingErr("3", "Marketplace", "Search", "Error", "Request", "Invalid keywords")
+	tests := []struct {
+		name     string
+		errors   []findingError
+		wantID   string
+		category string
+	}{
+		{"the error beats the warning", []findingError{warning, request}, "3", failureInvalidInput},
+		{"only warnings", []findingError{warning}, "1", failureSystem},
+	}
+	for _, test := range tests {
+		failure := newSearchFailure(test.errors)
+		if failure.id != test.wantID || failure.category != test.category {
+			t.Errorf("newSearchFailure(%v) = %+v, want id %v in %v", test.name, failure, test.wantID, test.category)
+		}
+	}
+}
+
+func TestUserMessage(t *testing.T) {
+	for category, entry := range failureCatalog {
+		failure := &searchFailure{category: category, message: "eBay's own wording"}
+		if got := failure.userMessage(); got != entry.message+" "+entry.next || strings.Contains(got, "eBay's own wording") {
+			t.Errorf("userMessage() of %v = %q", category, got)
+		}
+	}
+	if got, unknown := (&searchFailure{category: "new"}).userMessage(), failureCatalog[failureUnknown]; got != unknown.message+" "+unknown.next {
+		t.Errorf("userMessage() of a category outside the catalog = %q", got)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	if got := requestID(context.Background()); got != "-" {
+		t.Errorf("requestID() of an untraced request = %q", got)
+	}
+	sp := &span{traceID: [16]byte{0xab, 0xcd}}
+	ctx := context.WithValue(context.Background(), spanContextKey{}, sp)
+	if got := requestID(ctx); got != "abcd0000000000000000000000000000" {
+		t.Errorf("requestID() = %q", got)
+	}
+}
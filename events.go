@@ -0,0 +1,161 @@
+package theluxuryshopper
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventSink Receives the product analytics events of the chatbot.
+// Its methods are called from a single goroutine, never from a chat turn.
+type EventSink interface {
+	SessionStarted(uuid string)
+	QuestionAsked(step string)
+	AnswerRejected(step string)
+	SearchExecuted(query SearchQuery, resultCount int, durationMs float64)
+	SearchFailed(reason string)
+}
+
+// eventBufferSize Is how many events wait for a slow sink before new ones are dropped
+const eventBufferSize = 1024
+
+// noopEvents Is the EventSink of servers without one
+type noopEvents struct{}
+
+func (noopEvents) SessionStarted(string)                    {}
+func (noopEvents) QuestionAsked(string)                     {}
+func (noopEvents) AnswerRejected(string)                    {}
+func (noopEvents) SearchExecuted(SearchQuery, int, float64) {}
+func (noopEvents) SearchFailed(string)                      {}
+
+// eventDispatcher Hands events to a sink from its own goroutine.
+// When the buffer is full the event is dropped and counted, a chat turn never waits for the sink.
+type eventDispatcher struct {
+	sink    EventSink
+	events  chan func(EventSink)
+	dropped int64 // atomic
+}
+
+// newEventDispatcher Starts dispatching to sink with room for size pending events
+func newEventDispatcher(sink EventSink, size int) *eventDispatcher {
+	d := &eventDispatcher{sink: sink, events: make(chan func(EventSink), size)}
+	go func() {
+		for event := range d.events {
+			event(d.sink)
+		}
+	}()
+	return d
+}
+
+// dispatch Queues event, or drops it if the buffer is full
+func (d *eventDispatcher) dispatch(event func(EventSink)) {
+	select {
+	case d.events <- event:
+	default:
+		if dropped := atomic.AddInt64(&d.dropped, 1); dropped&(dropped-1) == 0 {
+			// Logs the 1st, 2nd, 4th, 8th... drop so a stuck sink doesn't flood the log
+			log.Printf("event sink can't keep up, %v events dropped so far", dropped)
+		}
+	}
+}
+
+// Dropped Returns how many events were dropped because the buffer was full
+func (d *eventDispatcher) Dropped() int64 {
+	return atomic.LoadInt64(&d.dropped)
+}
+
+func (d *eventDispatcher) SessionStarted(uuid string) {
+	d.dispatch(func(sink EventSink) { sink.SessionStarted(uuid) })
+}
+
+func (d *eventDispatcher) QuestionAsked(step string) {
+	d.dispatch(func(sink EventSink) { sink.QuestionAsked(step) })
+}
+
+func (d *eventDispatcher) AnswerRejected(step string) {
+	d.dispatch(func(sink EventSink) { sink.AnswerRejected(step) })
+}
+
+func (d *eventDispatcher) SearchExecuted(query SearchQuery, resultCount int, durationMs float64) {
+	d.dispatch(func(sink EventSink) { sink.SearchExecuted(query, resultCount, durationMs) })
+}
+
+func (d *eventDispatcher) SearchFailed(reason string) {
+	d.dispatch(func(sink EventSink) { sink.SearchFailed(reason) })
+}
+
+// WithEventSink Sends the analytics events of the server to sink, through a buffer so it can't slow chats down
+func WithEventSink(sink EventSink) ServerOption {
+	return func(s *Server) {
+		s.events = newEventDispatcher(sink, eventBufferSize)
+	}
+}
+
+// JSONLinesSink Is an EventSink appending one JSON object per event to a file
+type JSONLinesSink struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// NewJSONLinesSink Opens path for appending the events
+func NewJSONLinesSink(path string) (*JSONLinesSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLinesSink{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// write Appends one event with its name and time
+func (j *JSONLinesSink) write(name string, fields JSON) {
+	fields["event"] = name
+	fields["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.encoder.Encode(fields); err != nil {
+		log.Printf("couldn't write %v event: %v", name, err)
+	}
+}
+
+func (j *JSONLinesSink) SessionStarted(uuid string) {
+	j.write("session_started", JSON{"uuid": uuid})
+}
+
+func (j *JSONLinesSink) QuestionAsked(step string) {
+	j.write("question_asked", JSON{"step": step})
+}
+
+func (j *JSONLinesSink) AnswerRejected(step string) {
+	j.write("answer_rejected", JSON{"step": step})
+}
+
+func (j *JSONLinesSink) SearchExecuted(query SearchQuery, resultCount int, durationMs float64) {
+	j.write("search_executed", JSON{"query": query, "resultCount": resultCount, "durationMs": durationMs})
+}
+
+func (j *JSONLinesSink) SearchFailed(reason string) {
+	j.write("search_failed", JSON{"reason": reason})
+}
+
+func (j *JSONLinesSink) FeedbackGiven(query SearchQuery, resultCount int, feedback, replaced string, at time.Time) {
+	j.write("feedback_given", JSON{"query": query, "resultCount": resultCount, "feedback": feedback, "replaced": replaced, "at": at.UTC().Format(time.RFC3339)})
+}
+
+func (j *JSONLinesSink) SessionRestricted(failures int, until time.Time) {
+	j.write("session_restricted", JSON{"failures": failures, "until": until.UTC().Format(time.RFC3339)})
+}
+
+func (j *JSONLinesSink) FunnelCounted(counts map[string]int64, since time.Time) {
+	j.write("funnel_counted", JSON{"counts": counts, "since": since.UTC().Format(time.RFC3339)})
+}
+
+// Close Closes the file
+func (j *JSONLinesSink) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
@@ -0,0 +1,137 @@
+package theluxuryshopper
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink Is an EventSink remembering the events it got, as "name argument"
+type recordingSink struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recordingSink) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingSink) SessionStarted(string)      { r.record("started") }
+func (r *recordingSink) QuestionAsked(step string)  { r.record("asked " + step) }
+func (r *recordingSink) AnswerRejected(step string) { r.record("rejected " + step) }
+func (r *recordingSink) SearchExecuted(query SearchQuery, count int, _ float64) {
+	r.record("searched " + query.Keyword)
+}
+func (r *recordingSink) SearchFailed(reason string) { r.record("failed " + reason) }
+
+// waitFor Returns the events once there are n of them, failing t if they don't come
+func (r *recordingSink) waitFor(t *testing.T, n int) []string {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		r.mu.Lock()
+		events := append([]string(nil), r.events...)
+		r.mu.Unlock()
+		if len(events) >= n {
+			return events
+		}
+	}
+	t.Fatalf("the sink got %v events, want %v", len(r.events), n)
+	return nil
+}
+
+func TestEventsOfAConversation(t *testing.T) {
+	tests := []struct {
+		name     string
+		searcher *fakeSearcher
+		messages []string
+		want     []string
+	}{
+		{
+			"search",
+			&fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 1)}}},
+			[]string{"gucci bag", "mint", "new", "none", "none"},
+			[]string{"started", "asked condition", "rejected condition", "asked minPrice", "asked maxPrice", "searched gucci bag"},
+		},
+		{
+			"failed search",
+			&fakeSearcher{errs: map[string]error{"": errors.New("connection reset")}},
+			[]string{"gucci bag", "new", "none", "none"},
+			[]string{"started", "asked condition", "asked minPrice", "asked maxPrice", "failed Unavailable: connection reset"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sink := &recordingSink{}
+			s := newTestServer(t, Config{}, test.searcher, WithEventSink(sink))
+			c := startConversation(t, s)
+			c.sayAll(test.messages...)
+			if got := sink.waitFor(t, len(test.want)); strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+				t.Errorf("the sink got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// blockingSink Is an EventSink stuck on its first event until release is closed
+type blockingSink struct {
+	noopEvents
+	release chan struct{}
+}
+
+func (b blockingSink) SessionStarted(string) { <-b.release }
+
+func TestEventDispatcherDropsWhenFull(t *testing.T) {
+	sink := blockingSink{release: make(chan struct{})}
+	defer close(sink.release)
+	d := newEventDispatcher(sink, 2)
+	d.SessionStarted("stuck")
+	// Wait until the goroutine holds the first event, the buffer is empty again
+	for deadline := time.Now().Add(5 * time.Second); len(d.events) > 0 && time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+	}
+	for i := 0; i < 5; i++ {
+		d.QuestionAsked("condition")
+	}
+	if dropped := d.Dropped(); dropped != 3 {
+		t.Errorf("Dropped() = %v, want the 3 events past the 2 buffered", dropped)
+	}
+}
+
+func TestJSONLinesSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink, err := NewJSONLinesSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.SessionStarted("uuid-1")
+	sink.QuestionAsked("condition")
+	sink.AnswerRejected("condition")
+	sink.SearchExecuted(SearchQuery{Keyword: "gucci"}, 3, 12.5)
+	sink.SearchFailed("throttled")
+	sink.FeedbackGiven(SearchQuery{Keyword: "gucci"}, 3, "helpful", "", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	want := []string{
+		`"event":"session_started"`, `"event":"question_asked"`, `"event":"answer_rejected"`,
+		`"resultCount":3`, `"reason":"throttled"`, `"at":"2026-01-02T03:04:05Z"`,
+	}
+	scanner := bufio.NewScanner(file)
+	for i := 0; scanner.Scan(); i++ {
+		if i >= len(want) || !strings.Contains(scanner.Text(), want[i]) || !strings.Contains(scanner.Text(), `"time":"`) {
+			t.Errorf("line %v is %v", i+1, scanner.Text())
+		}
+	}
+}
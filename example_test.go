@@ -0,0 +1,73 @@
+package theluxuryshopper_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	theluxuryshopper "github.com/El-Etreby/theluxuryshopper"
+)
+
+// catalog Is a Searcher answering every search with the same items, standing in for eBay
+type catalog []theluxuryshopper.Item
+
+// Search Implements theluxuryshopper.Searcher
+func (c catalog) Search(ctx context.Context, query theluxuryshopper.SearchQuery) (theluxuryshopper.SearchResult, error) {
+	return theluxuryshopper.SearchResult{Items: c, Count: len(c)}, nil
+}
+
+// chat Sends message to /v1/chat as the session uuid and returns the message of the reply
+func chat(handler http.Handler, uuid, message string) string {
+	body, _ := json.Marshal(map[string]string{"message": message})
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat", strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authorization", uuid)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	var reply struct {
+		Message string `json:"message"`
+	}
+	json.NewDecoder(w.Body).Decode(&reply)
+	return reply.Message
+}
+
+// welcome Starts a session with /v1/welcome and returns its uuid
+func welcome(handler http.Handler) string {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/welcome", nil))
+	var reply struct {
+		UUID string `json:"uuid"`
+	}
+	json.NewDecoder(w.Body).Decode(&reply)
+	return reply.UUID
+}
+
+func ExampleServer_SetProcessor() {
+	bot := theluxuryshopper.NewServer(theluxuryshopper.Config{EbayEnv: "production"}, theluxuryshopper.WithSearcher(catalog{}))
+	defer bot.Shutdown(context.Background())
+	bot.SetProcessor(func(session theluxuryshopper.Session, message string, w http.ResponseWriter) {
+		theluxuryshopper.WriteReply(w, theluxuryshopper.ReplyInfo, theluxuryshopper.JSON{"message": "You said " + message})
+	})
+
+	handler := bot.Routes()
+	fmt.Println(chat(handler, welcome(handler), "a green Kelly bag"))
+	// Output: You said a green Kelly bag
+}
+
+func ExampleNewServer() {
+	bot := theluxuryshopper.NewServer(theluxuryshopper.Config{EbayEnv: "production"}, theluxuryshopper.WithSearcher(catalog{
+		{ID: "1", Title: "Hermes Kelly 28", Condition: "Used", Price: "9500.0", Currency: "USD"},
+	}))
+	defer bot.Shutdown(context.Background())
+
+	handler := bot.Routes()
+	uuid := welcome(handler)
+	fmt.Println(chat(handler, uuid, "hermes kelly"))
+	fmt.Println(chat(handler, uuid, "used"))
+	// Output:
+	// Please specify the condition of the required item. (New, Used or None)
+	// Please specify the minimum price of the required item. (None in case you dont want to filter with minimum price)
+}
@@ -0,0 +1,128 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// The feedback a user gives on the results of a search, as the feedback field and the events name it
+const (
+	feedbackHelpful    = "helpful"
+	feedbackNotHelpful = "notHelpful"
+)
+
+var (
+	notHelpfulCommand = regexp.MustCompile(`(?i)^\s*(?:👎|thumbs\s+down|(?:that\s+was\s+|those\s+were\s+)?(?:not\s+(?:very\s+)?helpful|unhelpful|useless))\W*$`)
+	helpfulCommand    = regexp.MustCompile(`(?i)^\s*(?:👍|thumbs\s+up|(?:that\s+was\s+|those\s+were\s+)?(?:very\s+)?(?:helpful|useful))\W*$`)
+)
+
+// feedbackCommand Returns the feedback message gives, or "" when it gives none
+func feedbackCommand(message string) string {
+	switch {
+	case notHelpfulCommand.MatchString(message):
+		return feedbackNotHelpful
+	case helpfulCommand.MatchString(message):
+		return feedbackHelpful
+	}
+	return ""
+}
+
+// FeedbackSink Is an EventSink that is also sent the feedback users give on their results
+type FeedbackSink interface {
+	EventSink
+	// FeedbackGiven is sent every time, replaced is the feedback it overwrites for the same search or ""
+	FeedbackGiven(query SearchQuery, resultCount int, feedback, replaced string, at time.Time)
+}
+
+// feedbackCounts Aggregates the feedback of every search, a search counts once whatever the user
+// said about it last
+type feedbackCounts struct {
+	mu     sync.Mutex
+	counts map[string]int64
+	since  time.Time
+}
+
+// newFeedbackCounts Creates empty feedback counts
+func newFeedbackCounts() *feedbackCounts {
+	return &feedbackCounts{counts: map[string]int64{}, since: time.Now()}
+}
+
+// replace Counts feedback in place of replaced
+func (f *feedbackCounts) replace(feedback, replaced string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if replaced != "" {
+		f.counts[replaced]--
+	}
+	f.counts[feedback]++
+}
+
+// Snapshot Returns a copy of the counts and when they started
+func (f *feedbackCounts) Snapshot() (map[string]int64, time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	counts := make(map[string]int64, len(f.counts))
+	for feedback, count := range f.counts {
+		counts[feedback] = count
+	}
+	return counts, f.since
+}
+
+// recordFeedback Records feedback on the last search of session, overwriting what the user said
+// about it before, and returns the acknowledgment. The results stay as they are.
+func (s *Server) recordFeedback(session Session, feedback string, now time.Time) string {
+	list := listedResults(session)
+	if list == nil {
+		return "Thanks, there are no results to rate yet though. Tell me what you are looking for first.\n " + keywordQuestion
+	}
+	replaced := list.Feedback
+	list.Feedback = feedback
+	if replaced != feedback {
+		s.feedback.replace(feedback, replaced)
+	}
+	count := list.TotalEntries
+	if count == 0 {
+		count = len(list.Items)
+	}
+	if dispatcher, ok := s.events.(*eventDispatcher); ok {
+		if _, ok := dispatcher.sink.(FeedbackSink); ok {
+			query := list.Query
+			dispatcher.dispatch(func(sink EventSink) { sink.(FeedbackSink).FeedbackGiven(query, count, feedback, replaced, now) })
+		}
+	}
+	if feedback == feedbackHelpful {
+		return "Thanks, glad that helped! Say 'more' for other items, or what else you would like to search for."
+	}
+	return "Thanks for telling me. Try other words or filters, say 'more' for other items, or what else you would like to search for."
+}
+
+// handleFeedbackCommand Answers "helpful", "not helpful" and their thumbs after a search, reporting whether message was one
+func (s *Server) handleFeedbackCommand(session Session, message string, w http.ResponseWriter) bool {
+	if _, searching := session["searchByKeyword"]; searching || listedResults(session) == nil {
+		return false
+	}
+	feedback := feedbackCommand(message)
+	if feedback == "" {
+		return false
+	}
+	WriteReply(w, ReplyInfo, JSON{"message": s.recordFeedback(session, feedback, s.sessions.now()), "feedback": feedback})
+	return true
+}
+
+// handleFeedback Handles GET /admin/feedback, answering how many searches were found helpful or not
+func (s *Server) handleFeedback(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if !s.authorizeAdmin(w, r, "Feedback counts") {
+		return
+	}
+	counts, since := s.feedback.Snapshot()
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, JSON{
+		"since":            since.UTC().Format(time.RFC3339),
+		feedbackHelpful:    counts[feedbackHelpful],
+		feedbackNotHelpful: counts[feedbackNotHelpful],
+	})
+}
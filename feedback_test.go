@@ -0,0 +1,94 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFeedbackCommand(t *testing.T) {
+	tests := []struct {
+		message, want string
+	}{
+		{"👍", feedbackHelpful},
+		{"thumbs up!", feedbackHelpful},
+		{"That was very helpful.", feedbackHelpful},
+		{"useful", feedbackHelpful},
+		{"👎", feedbackNotHelpful},
+		{"Thumbs down", feedbackNotHelpful},
+		{"those were not very helpful", feedbackNotHelpful},
+		{"useless!!", feedbackNotHelpful},
+		{"helpful bag", ""},
+		{"gucci", ""},
+	}
+	for _, test := range tests {
+		if got := feedbackCommand(test.message); got != test.want {
+			t.Errorf("feedbackCommand(%q) = %q, want %q", test.message, got, test.want)
+		}
+	}
+}
+
+// feedbackRecorder Is a FeedbackSink remembering the feedback it got, as "feedback replaced"
+type feedbackRecorder struct {
+	recordingSink
+}
+
+func (f *feedbackRecorder) FeedbackGiven(query SearchQuery, count int, feedback, replaced string, at time.Time) {
+	f.record(feedback + " " + replaced)
+}
+
+func TestFeedbackOnResults(t *testing.T) {
+	sink := &feedbackRecorder{}
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 2)}}}
+	s := newTestServer(t, Config{AdminToken: "secret"}, searcher, WithEventSink(sink))
+	c := startConversation(t, s)
+	if reply := c.say("👍"); reply.Body["feedback"] != nil {
+		t.Errorf("feedback before a search answered %v", reply.Raw)
+	}
+	c.sayAll("gucci bag", "new", "none", "none")
+
+	steps := []struct {
+		message, wantPrefix string
+	}{
+		{"👍", "Thanks, glad that helped!"},
+		{"not helpful", "Thanks for telling me."},
+		{"not helpful", "Thanks for telling me."},
+	}
+	for _, step := range steps {
+		if reply := c.say(step.message); !strings.HasPrefix(reply.message(), step.wantPrefix) {
+			t.Errorf("%q answered %q, want it to start with %q", step.message, reply.message(), step.wantPrefix)
+		}
+	}
+
+	counts := do(t, s.Routes(), http.MethodGet, "/admin/feedback", "", http.Header{"Authorization": {"Bearer secret"}})
+	if counts.Body[feedbackHelpful] != 0.0 || counts.Body[feedbackNotHelpful] != 1.0 {
+		t.Errorf("/admin/feedback answered %v, want the search counted once as not helpful", counts.Raw)
+	}
+
+	events := sink.waitFor(t, 9)
+	var given []string
+	for _, event := range events {
+		if strings.HasPrefix(event, feedbackHelpful) || strings.HasPrefix(event, feedbackNotHelpful) {
+			given = append(given, event)
+		}
+	}
+	if want := []string{"helpful ", "notHelpful helpful", "notHelpful notHelpful"}; strings.Join(given, "|") != strings.Join(want, "|") {
+		t.Errorf("the sink got the feedback %q, want %q", given, want)
+	}
+}
+
+func TestFeedbackCountsReplace(t *testing.T) {
+	counts := newFeedbackCounts()
+	counts.replace(feedbackHelpful, "")
+	counts.replace(feedbackHelpful, "")
+	counts.replace(feedbackNotHelpful, feedbackHelpful)
+	snapshot, since := counts.Snapshot()
+	if snapshot[feedbackHelpful] != 1 || snapshot[feedbackNotHelpful] != 1 || since.IsZero() {
+		t.Errorf("Snapshot() = %v, %v", snapshot, since)
+	}
+	snapshot[feedbackHelpful] = 100
+	if again, _ := counts.Snapshot(); again[feedbackHelpful] != 1 {
+		t.Error("Snapshot() shares its map with the counts")
+	}
+}
@@ -0,0 +1,62 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// capturedReply Keeps a reply in memory, for a handler answering with the reply of another
+type capturedReply struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (c *capturedReply) Header() http.Header         { return c.header }
+func (c *capturedReply) WriteHeader(status int)      { c.status = status }
+func (c *capturedReply) Write(b []byte) (int, error) { return c.body.Write(b) }
+
+// firstTurn Answers message as the first /chat message of the new session uuid, returning the status and
+// body of its reply. It runs through handleChat, so the message is validated and answered like any other.
+func (s *Server) firstTurn(r *http.Request, uuid, message string) (int, JSON) {
+	body, _ := json.Marshal(JSON{"message": message})
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, apiPrefix+"/chat", bytes.NewReader(body))
+	if err != nil {
+		return http.StatusInternalServerError, JSON{"type": ReplyError, "error": JSON{"code": "internal_error", "message": "Couldn't answer the first message."}}
+	}
+	req.Header.Set("Authorization", uuid)
+	req.Header.Set("Content-Type", "application/json")
+	for _, header := range []string{"X-Debug-Meta", responseSemanticsHeader} {
+		if value := r.Header.Get(header); value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+	reply := &capturedReply{header: http.Header{}, status: http.StatusOK}
+	s.handleChat(reply, req, nil)
+	var turn JSON
+	if err := json.Unmarshal(reply.body.Bytes(), &turn); err != nil {
+		return http.StatusInternalServerError, JSON{"type": ReplyError, "error": JSON{"code": "internal_error", "message": "Couldn't answer the first message."}}
+	}
+	return reply.status, turn
+}
+
+// writeFirstTurn Answers /welcome with the reply to its firstMessage, carrying the session of welcome
+// along with the greeting as welcome. Failures of the message are answered with their status, the
+// session being created all the same.
+func (s *Server) writeFirstTurn(w http.ResponseWriter, r *http.Request, welcome JSON, message string) {
+	status, turn := s.firstTurn(r, welcome["uuid"].(string), message)
+	for key, value := range welcome {
+		if _, taken := turn[key]; !taken && key != "message" && key != "step" {
+			turn[key] = value
+		}
+	}
+	greeting, _ := welcome["message"].(string)
+	if plainReplies(w) {
+		greeting = plainMessage(greeting)
+	}
+	turn["welcome"] = greeting
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(turn)
+}
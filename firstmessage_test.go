@@ -0,0 +1,57 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWelcomeWithFirstMessage(t *testing.T) {
+	tests := []struct {
+		name, firstMessage string
+		wantStatus         int
+		wantStep           string
+	}{
+		{"keyword", "gucci belt", http.StatusOK, "condition"},
+		{"blank", "   ", http.StatusOK, ""},
+		{"blocked keyword", "replica bag", http.StatusOK, "keyword"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestServer(t, Config{KeywordBlocklist: []string{"replica"}}, &fakeSearcher{})
+			reply := do(t, s.Routes(), http.MethodGet, "/v1/welcome?firstMessage="+url.QueryEscape(test.firstMessage), "", nil)
+			uuid, _ := reply.Body["uuid"].(string)
+			if reply.Status != test.wantStatus || uuid == "" {
+				t.Fatalf("/v1/welcome answered %v %v", reply.Status, reply.Raw)
+			}
+			if strings.TrimSpace(test.firstMessage) == "" {
+				// Without a first message it is the usual greeting
+				if reply.Body["welcome"] != nil || !strings.HasPrefix(reply.message(), "Welcome to") {
+					t.Errorf("/v1/welcome answered %v", reply.Raw)
+				}
+				return
+			}
+			if welcome, _ := reply.Body["welcome"].(string); !strings.HasPrefix(welcome, "Welcome to") {
+				t.Errorf("/v1/welcome answered the greeting %q", welcome)
+			}
+			if reply.Body["step"] != nilIfEmpty(test.wantStep) {
+				t.Errorf("/v1/welcome answered the step %v, want %q", reply.Body["step"], test.wantStep)
+			}
+
+			// The session goes on from the answered message
+			c := &conversation{t: t, handler: s.Routes(), clock: s.clock, uuid: uuid}
+			if next := c.say("new"); test.wantStep == "condition" && next.Body["step"] != "minPrice" {
+				t.Errorf("the next message answered %v", next.Raw)
+			}
+		})
+	}
+}
+
+// nilIfEmpty Returns value as a decoded JSON field would hold it, nil when it is empty
+func nilIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,177 @@
+package theluxuryshopper
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Funnel markers counted besides the asked and answered markers of every step
+const (
+	funnelStarted  = "started"
+	funnelSearched = "searched"
+	funnelResults  = "results"
+)
+
+// funnelCounts Aggregates how many conversations reached each point of the funnel. The markers a
+// conversation reached are kept in it so each counts once, the counts outlive the sessions.
+type funnelCounts struct {
+	mu     sync.Mutex
+	counts map[string]int64
+	since  time.Time
+}
+
+// newFunnelCounts Creates empty funnel counts
+func newFunnelCounts() *funnelCounts {
+	return &funnelCounts{counts: map[string]int64{}, since: time.Now()}
+}
+
+// add Counts marker once more
+func (f *funnelCounts) add(marker string) {
+	f.mu.Lock()
+	f.counts[marker]++
+	f.mu.Unlock()
+}
+
+// mark Counts marker for the conversation of session, unless it reached it already
+func (f *funnelCounts) mark(session Session, marker string) {
+	markers, _ := session["funnel"].(map[string]bool)
+	if markers == nil {
+		markers = map[string]bool{}
+		session["funnel"] = markers
+	}
+	if !markers[marker] {
+		markers[marker] = true
+		f.add(marker)
+	}
+}
+
+// marked Reports whether the conversation of session reached marker
+func (f *funnelCounts) marked(session Session, marker string) bool {
+	markers, _ := session["funnel"].(map[string]bool)
+	return markers[marker]
+}
+
+// markStep Counts what a step did: asking when it replied, answering when it let the conversation
+// go past a question it asked. Called by runSteps, so every step is counted.
+func (f *funnelCounts) markStep(session Session, step string, replied bool) {
+	switch {
+	case replied:
+		f.mark(session, step+".asked")
+	case f.marked(session, step+".asked"):
+		f.mark(session, step+".answered")
+	}
+}
+
+// Snapshot Returns a copy of the counts and when they started
+func (f *funnelCounts) Snapshot() (map[string]int64, time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	counts := make(map[string]int64, len(f.counts))
+	for marker, count := range f.counts {
+		counts[marker] = count
+	}
+	return counts, f.since
+}
+
+// Reset Clears the counts, the conversations still remember the markers they reached
+func (f *funnelCounts) Reset() {
+	f.mu.Lock()
+	f.counts = map[string]int64{}
+	f.since = time.Now()
+	f.mu.Unlock()
+}
+
+// FunnelSink Is an EventSink that is also sent the funnel counts every FUNNEL_FLUSH_INTERVAL
+type FunnelSink interface {
+	EventSink
+	FunnelCounted(counts map[string]int64, since time.Time)
+}
+
+// flushFunnel Sends the funnel counts to the sink of the server every interval until stop is closed,
+// and once more then
+func (s *Server) flushFunnel(interval time.Duration, stop <-chan struct{}) {
+	flush := func() {
+		counts, since := s.funnel.Snapshot()
+		s.events.(*eventDispatcher).dispatch(func(sink EventSink) { sink.(FunnelSink).FunnelCounted(counts, since) })
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			flush()
+		case <-stop:
+			flush()
+			return
+		}
+	}
+}
+
+// startFunnelFlush Starts flushFunnel when an interval is configured and the sink takes funnel counts
+func (s *Server) startFunnelFlush(interval time.Duration) {
+	dispatcher, ok := s.events.(*eventDispatcher)
+	if interval <= 0 || !ok {
+		return
+	}
+	if _, ok := dispatcher.sink.(FunnelSink); !ok {
+		return
+	}
+	s.funnelStop = make(chan struct{})
+	go s.flushFunnel(interval, s.funnelStop)
+}
+
+// authorizeAdmin Answers 404 when the admin routes are disabled and 401 when the request lacks the
+// admin token, reporting whether an admin made it. feature names what the route serves in the errors.
+func (s *Server) authorizeAdmin(w http.ResponseWriter, r *http.Request, feature string) bool {
+	if s.adminToken == nil {
+		writeError(w, http.StatusNotFound, "not_found", feature+" aren't enabled.")
+		return false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), s.adminToken) != 1 {
+		writeError(w, http.StatusUnauthorized, "invalid_admin_token", feature+" need the admin token as a bearer token.")
+		return false
+	}
+	return true
+}
+
+// handleFunnel Handles GET /admin/funnel, answering how many conversations were asked and answered
+// each question, searched and saw results
+func (s *Server) handleFunnel(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if !s.authorizeAdmin(w, r, "Funnel counts") {
+		return
+	}
+	counts, since := s.funnel.Snapshot()
+	steps := make([]JSON, 0, len(s.steps))
+	for _, step := range s.steps {
+		asked, answered := counts[step+".asked"], counts[step+".answered"]
+		steps = append(steps, JSON{
+			"step":      step,
+			"asked":     asked,
+			"answered":  answered,
+			"abandoned": asked - answered,
+		})
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, JSON{
+		"since":         since.UTC().Format(time.RFC3339),
+		"sessions":      counts[funnelStarted],
+		"steps":         steps,
+		"searched":      counts[funnelSearched],
+		"viewedResults": counts[funnelResults],
+	})
+}
+
+// handleFunnelReset Handles DELETE /admin/funnel, starting the counts over
+func (s *Server) handleFunnelReset(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if !s.authorizeAdmin(w, r, "Funnel counts") {
+		return
+	}
+	s.funnel.Reset()
+	w.WriteHeader(http.StatusNoContent)
+}
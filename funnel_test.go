@@ -0,0 +1,108 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFunnelMarkStep(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls []bool // the replied of every markStep call of the condition step
+		want  map[string]int64
+	}{
+		{"asked", []bool{true}, map[string]int64{"condition.asked": 1}},
+		{"asked again", []bool{true, true}, map[string]int64{"condition.asked": 1}},
+		{"answered", []bool{true, false}, map[string]int64{"condition.asked": 1, "condition.answered": 1}},
+		{"skipped without asking", []bool{false}, map[string]int64{}},
+		{"answered once", []bool{true, false, false}, map[string]int64{"condition.asked": 1, "condition.answered": 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			funnel := newFunnelCounts()
+			session := Session{}
+			for _, replied := range test.calls {
+				funnel.markStep(session, "condition", replied)
+			}
+			counts, _ := funnel.Snapshot()
+			if len(counts) != len(test.want) {
+				t.Fatalf("the funnel counted %v, want %v", counts, test.want)
+			}
+			for marker, count := range test.want {
+				if counts[marker] != count {
+					t.Errorf("the funnel counted %v, want %v", counts, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFunnelRoutes(t *testing.T) {
+	s := newTestServer(t, Config{AdminToken: "secret"}, &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 1)}}})
+	startConversation(t, s).sayAll("gucci bag", "new", "none", "none")
+	startConversation(t, s).sayAll("prada", "used")
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+
+	reply := do(t, s.Routes(), http.MethodGet, "/admin/funnel", "", admin)
+	var funnel struct {
+		Sessions int64 `json:"sessions"`
+		Steps    []struct {
+			Step                       string
+			Asked, Answered, Abandoned int64
+		} `json:"steps"`
+		Searched      int64 `json:"searched"`
+		ViewedResults int64 `json:"viewedResults"`
+	}
+	json.Unmarshal([]byte(reply.Raw), &funnel)
+	if funnel.Sessions != 2 || funnel.Searched != 1 || funnel.ViewedResults != 1 {
+		t.Errorf("/admin/funnel answered %v", reply.Raw)
+	}
+	want := map[string][3]int64{"keyword": {2, 2, 0}, "condition": {2, 2, 0}, "minPrice": {2, 1, 1}, "maxPrice": {1, 1, 0}}
+	for _, step := range funnel.Steps {
+		if got := [3]int64{step.Asked, step.Answered, step.Abandoned}; got != want[step.Step] {
+			t.Errorf("/admin/funnel counted %v for %v, want %v", got, step.Step, want[step.Step])
+		}
+	}
+
+	if reset := do(t, s.Routes(), http.MethodDelete, "/admin/funnel", "", admin); reset.Status != http.StatusNoContent {
+		t.Errorf("DELETE /admin/funnel answered %v", reset.Status)
+	}
+	if after := do(t, s.Routes(), http.MethodGet, "/admin/funnel", "", admin); after.Body["sessions"] != 0.0 {
+		t.Errorf("/admin/funnel after the reset answered %v", after.Raw)
+	}
+	if unauthorized := do(t, s.Routes(), http.MethodGet, "/admin/funnel", "", nil); unauthorized.Status != http.StatusUnauthorized {
+		t.Errorf("/admin/funnel without the token answered %v", unauthorized.Status)
+	}
+	disabled := newTestServer(t, Config{}, &fakeSearcher{})
+	if reply := do(t, disabled.Routes(), http.MethodGet, "/admin/funnel", "", admin); reply.Status != http.StatusNotFound {
+		t.Errorf("/admin/funnel without ADMIN_TOKEN answered %v", reply.Status)
+	}
+}
+
+// funnelRecorder Is a FunnelSink remembering the counts it got
+type funnelRecorder struct {
+	recordingSink
+	counted chan map[string]int64
+}
+
+func (f *funnelRecorder) FunnelCounted(counts map[string]int64, since time.Time) {
+	f.counted <- counts
+}
+
+func TestFunnelFlush(t *testing.T) {
+	sink := &funnelRecorder{counted: make(chan map[string]int64, 10)}
+	s := newTestServer(t, Config{FunnelFlushInterval: time.Hour}, &fakeSearcher{}, WithEventSink(sink))
+	startConversation(t, s)
+	s.Shutdown(context.Background())
+	select {
+	case counts := <-sink.counted:
+		if counts[funnelStarted] != 1 {
+			t.Errorf("the last flush sent %v", counts)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown didn't flush the funnel")
+	}
+}
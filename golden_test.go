@@ -0,0 +1,33 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update Rewrites the golden files with what the tests got: go test -run TestConversations -update
+var update = flag.Bool("update", false, "rewrite the golden files under testdata with the replies the tests got")
+
+// checkGolden Compares got with the golden file at path byte for byte, or rewrites the file under -update
+func checkGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("couldn't read the golden file, run go test -update to create it: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%v doesn't match, run go test -update and review the diff if the change is intended.\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,169 @@
+package theluxuryshopper
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// gzipMinSize Responses smaller than this are sent uncompressed
+const gzipMinSize = 1024
+
+// gzipWriters Pools gzip writers between responses
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// compressibleTypes The content types worth compressing
+var compressibleTypes = []string{"application/json", "text/html", "text/plain"}
+
+// gzipHandler Compresses the JSON and HTML responses of next for clients accepting gzip.
+// It must wrap the router directly, inside the CORS middleware, so preflight
+// requests never reach it.
+func gzipHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r) || r.Header.Get("Upgrade") != "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.Close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip Reports whether the Accept-Encoding header of r allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		encoding = strings.TrimSpace(encoding)
+		if encoding == "gzip" || (strings.HasPrefix(encoding, "gzip;") && !strings.HasSuffix(encoding, "q=0")) {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter Buffers the start of a response until it knows whether compressing it pays off
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	buffer  []byte
+	gz      *gzip.Writer
+	decided bool
+}
+
+// WriteHeader Holds the status back until the compression decision is made
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buffer = append(w.buffer, p...)
+	if len(w.buffer) >= gzipMinSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide Sends the headers, compressed when large is true and the response qualifies, and flushes the buffer
+func (w *gzipResponseWriter) decide(large bool) error {
+	w.decided = true
+	header := w.Header()
+	if header.Get("Content-Type") == "" && len(w.buffer) > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buffer))
+	}
+	if large && w.compressible() {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	buffer := w.buffer
+	w.buffer = nil
+	if len(buffer) == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(buffer)
+	} else {
+		_, err = w.ResponseWriter.Write(buffer)
+	}
+	return err
+}
+
+// compressible Reports whether the response has a compressible type and isn't encoded already
+func (w *gzipResponseWriter) compressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	for _, t := range compressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush Sends whatever was written so far, streaming responses like SSE are never compressed
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack Lets WebSocket handlers take over the connection
+func (w *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	w.decided = true
+	return hijacker.Hijack()
+}
+
+// Close Finishes the response, sending small responses uncompressed
+func (w *gzipResponseWriter) Close() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.gz != nil {
+		w.gz.Close()
+		gzipWriters.Put(w.gz)
+		w.gz = nil
+	}
+}
@@ -0,0 +1,115 @@
+package theluxuryshopper
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"gzip", true},
+		{"deflate, gzip", true},
+		{"gzip;q=0.8, br", true},
+		{"gzip;q=0", false},
+		{"br", false},
+		{"", false},
+		{"x-gzip", false},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", test.header)
+		if got := acceptsGzip(r); got != test.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", test.header, got, test.want)
+		}
+	}
+}
+
+func TestGzipHandler(t *testing.T) {
+	large := strings.Repeat(`{"title":"Gucci belt"}`, 100)
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		contentType    string
+		status         int
+		body           string
+		wantGzip       bool
+	}{
+		{"large json", http.MethodGet, "gzip", "application/json", http.StatusOK, large, true},
+		{"large json with a status", http.MethodGet, "gzip", "application/json", http.StatusBadRequest, large, true},
+		{"small json", http.MethodGet, "gzip", "application/json", http.StatusOK, `{"ok":true}`, false},
+		{"sniffed html", http.MethodGet, "gzip", "", http.StatusOK, "<html>" + strings.Repeat("a", gzipMinSize) + "</html>", true},
+		{"image", http.MethodGet, "gzip", "image/jpeg", http.StatusOK, strings.Repeat("\xff", 2*gzipMinSize), false},
+		{"not accepted", http.MethodGet, "", "application/json", http.StatusOK, large, false},
+		{"head", http.MethodHead, "gzip", "application/json", http.StatusOK, "", false},
+		{"no content", http.MethodDelete, "gzip", "", http.StatusNoContent, "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := gzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.contentType != "" {
+					w.Header().Set("Content-Type", test.contentType)
+				}
+				w.WriteHeader(test.status)
+				// Written in pieces, the decision waits for gzipMinSize bytes
+				for body := test.body; body != ""; {
+					n := len(body)
+					if n > 100 {
+						n = 100
+					}
+					w.Write([]byte(body[:n]))
+					body = body[n:]
+				}
+			}))
+			r := httptest.NewRequest(test.method, "/", nil)
+			if test.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", test.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.status || w.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("answered %v with Vary %q, want %v", w.Code, w.Header().Get("Vary"), test.status)
+			}
+			gzipped := w.Header().Get("Content-Encoding") == "gzip"
+			if gzipped != test.wantGzip {
+				t.Fatalf("gzipped %v, want %v", gzipped, test.wantGzip)
+			}
+			body := w.Body.String()
+			if gzipped {
+				reader, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, _ := io.ReadAll(reader)
+				body = string(data)
+			}
+			if body != test.body {
+				t.Errorf("answered %v bytes, want %v", len(body), len(test.body))
+			}
+		})
+	}
+}
+
+func TestGzipFlushSendsUncompressed(t *testing.T) {
+	handler := gzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(strings.Repeat("x", 2*gzipMinSize)))
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Header().Get("Content-Encoding") != "" || !w.Flushed || !strings.HasPrefix(w.Body.String(), "data: first\n\n") {
+		t.Errorf("a flushed response answered %v %q", w.Header(), w.Body.String()[:20])
+	}
+}
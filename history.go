@@ -0,0 +1,129 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchHistory Caps the completed searches a conversation remembers, the oldest are forgotten first
+const maxSearchHistory = 10
+
+var (
+	historyCommand = regexp.MustCompile(`(?i)^\s*(?:(?:show\s+)?(?:my\s+)?(?:search\s+)?history|(?:my\s+)?past\s+searches)\W*$`)
+	repeatCommand  = regexp.MustCompile(`(?i)^\s*(?:repeat|rerun|re-run)\s+(?:search\s+)?#?(\d+)\W*$`)
+)
+
+// pastSearch Is one completed search of the history: its query, how many items matched and when it ran
+type pastSearch struct {
+	Query SearchQuery
+	Count int
+	At    time.Time
+}
+
+// searchHistory Returns the completed searches of session, oldest first
+func searchHistory(session Session) []pastSearch {
+	history, _ := session["searchHistory"].([]pastSearch)
+	return history
+}
+
+// recordSearch Adds the completed search query, which matched count items, to the history of session.
+// It outlives the search like the saved searches, so "history" and "repeat <n>" work in between.
+func recordSearch(session Session, query SearchQuery, count int) {
+	query.Page = 0
+	history := append(searchHistory(session), pastSearch{Query: query, Count: count, At: time.Now()})
+	if len(history) > maxSearchHistory {
+		history = history[len(history)-maxSearchHistory:]
+	}
+	// A new slice, a copy of the session may still share the old one
+	session["searchHistory"] = append([]pastSearch{}, history...)
+}
+
+// matchCount Returns how many items of result matched its search, across its pages when eBay said
+func matchCount(result SearchResult) int {
+	if result.Stats.TotalEntries > result.Count {
+		return result.Stats.TotalEntries
+	}
+	return result.Count
+}
+
+// describeQuery Summarizes the keyword and filters of query, like "'gucci belt', used, up to 500"
+func describeQuery(query SearchQuery) string {
+	description := "'" + query.Keyword + "'"
+	if query.Condition != "" && query.Condition != "none" {
+		description += ", " + strings.ToLower(query.Condition)
+	}
+	if query.MinPrice != "" && query.MinPrice != "none" {
+		description += ", from " + query.MinPrice
+	}
+	if query.MaxPrice != "" && query.MaxPrice != "none" {
+		description += ", up to " + query.MaxPrice
+	}
+	if query.Currency != "" && (query.MinPrice != "none" || query.MaxPrice != "none") {
+		description += " " + query.Currency
+	}
+	if label, found := sortLabels[query.SortOrder]; found {
+		description += ", " + label
+	}
+	if query.Site != "" {
+		description += ", on " + siteName(query.Site)
+	}
+	return description
+}
+
+// handleHistoryCommand Answers "history" with the completed searches of the conversation, newest first
+// and numbered, and "repeat <n>" by searching number n again. Reports whether message was one of them.
+func (s *Server) handleHistoryCommand(session Session, message string, w http.ResponseWriter) bool {
+	history := searchHistory(session)
+	if historyCommand.MatchString(message) {
+		var response strings.Builder
+		entries := make([]JSON, 0, len(history))
+		if len(history) == 0 {
+			response.WriteString("You haven't completed a search yet.")
+		} else {
+			response.WriteString("Your last searches :")
+		}
+		location := requestLocation(w)
+		for number := 1; number <= len(history); number++ {
+			past := history[len(history)-number]
+			response.WriteString("\n " + strconv.Itoa(number) + ". " + describeQuery(past.Query) + " : " + strconv.Itoa(past.Count) + " results, " + formatRunTime(past.At, location))
+			entries = append(entries, JSON{"number": number, "query": past.Query, "count": past.Count, "at": past.At.UTC().Format(time.RFC3339)})
+		}
+		if len(history) > 0 {
+			response.WriteString("\n\n Say 'repeat <number>' to search one of them again.")
+		}
+		WriteReply(w, ReplyInfo, JSON{
+			"message": response.String() + "\n " + strings.TrimSpace(resumeSummary(session)),
+			"history": entries,
+		})
+		return true
+	}
+
+	match := repeatCommand.FindStringSubmatch(message)
+	if match == nil {
+		return false
+	}
+	number, _ := strconv.Atoi(match[1])
+	if number < 1 || number > len(history) {
+		problem := "There is no search " + match[1] + " in your history, say 'history' to list them."
+		if len(history) == 0 {
+			problem = "You haven't completed a search yet, so there is nothing to repeat."
+		}
+		WriteReply(w, ReplyInfo, JSON{"message": problem + "\n " + strings.TrimSpace(resumeSummary(session))})
+		return true
+	}
+	query := history[len(history)-number].Query
+	if allowed, reason := s.allowKeyword(query.Keyword); !allowed {
+		s.answerRejected(w, "keyword")
+		refuseKeyword(reason, session, w)
+		return true
+	}
+	query = withDisplay(query, requestDisplay(w))
+	session["lastQuery"] = query
+	result, err := s.search(requestContext(w), query)
+	s.funnel.mark(session, funnelSearched)
+	RenderSearch(query, result, err, session, w)
+	return true
+}
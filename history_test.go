@@ -0,0 +1,89 @@
+package theluxuryshopper
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDescribeQuery(t *testing.T) {
+	tests := []struct {
+		query SearchQuery
+		want  string
+	}{
+		{SearchQuery{Keyword: "gucci belt", Condition: "none", MinPrice: "none", MaxPrice: "none"}, "'gucci belt'"},
+		{SearchQuery{Keyword: "kelly", Condition: "Used", MinPrice: "100", MaxPrice: "500", Currency: "EUR"}, "'kelly', used, from 100, up to 500 EUR"},
+		{SearchQuery{Keyword: "prada", Condition: "none", MinPrice: "none", MaxPrice: "none", Currency: "GBP", SortOrder: "StartTimeNewest", Site: "EBAY-GB"}, "'prada', newest first, on eBay UK"},
+	}
+	for _, test := range tests {
+		if got := describeQuery(test.query); got != test.want {
+			t.Errorf("describeQuery(%+v) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestMatchCount(t *testing.T) {
+	tests := []struct {
+		result SearchResult
+		want   int
+	}{
+		{SearchResult{Count: 5}, 5},
+		{SearchResult{Count: 5, Stats: SearchStats{TotalEntries: 240}}, 240},
+		{SearchResult{Count: 5, Stats: SearchStats{TotalEntries: 3}}, 5},
+	}
+	for _, test := range tests {
+		if got := matchCount(test.result); got != test.want {
+			t.Errorf("matchCount(%+v) = %v, want %v", test.result, got, test.want)
+		}
+	}
+}
+
+func TestRecordSearchKeepsTheLastOnes(t *testing.T) {
+	session := Session{}
+	for i := 1; i <= maxSearchHistory+2; i++ {
+		recordSearch(session, SearchQuery{Keyword: "bag " + strconv.Itoa(i), Page: 3}, i)
+	}
+	history := searchHistory(session)
+	if len(history) != maxSearchHistory || history[0].Query.Keyword != "bag 3" || history[len(history)-1].Count != maxSearchHistory+2 || history[0].Query.Page != 0 {
+		t.Errorf("the history is %+v", history)
+	}
+}
+
+func TestHistoryAndRepeat(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 2)}}}
+	s := newTestServer(t, Config{}, searcher)
+	c := startConversation(t, s)
+	if reply := c.say("history"); !strings.HasPrefix(reply.message(), "You haven't completed a search yet.") {
+		t.Errorf("history before a search answered %q", reply.message())
+	}
+	if reply := c.say("repeat 1"); !strings.HasPrefix(reply.message(), "You haven't completed a search yet, so there is nothing to repeat.") {
+		t.Errorf("repeat before a search answered %q", reply.message())
+	}
+	c.sayAll("gucci belt", "used", "none", "500")
+	c.sayAll("new search", "prada bag", "none", "none", "none")
+
+	tests := []struct {
+		message, wantPrefix string
+	}{
+		{"show my search history", "Your last searches :\n 1. 'prada bag' : 2 results, "},
+		{"my past searches", "Your last searches :\n 1. 'prada bag'"},
+		{"repeat 3", "There is no search 3 in your history, say 'history' to list them."},
+	}
+	for _, test := range tests {
+		if reply := c.say(test.message); !strings.HasPrefix(reply.message(), test.wantPrefix) {
+			t.Errorf("%q answered %q, want it to start with %q", test.message, reply.message(), test.wantPrefix)
+		}
+	}
+	history := c.say("history")
+	if entries, _ := history.Body["history"].([]interface{}); len(entries) != 2 || !strings.Contains(history.message(), "\n 2. 'gucci belt', used, up to 500 : 2 results, ") {
+		t.Errorf("history answered %v", history.Raw)
+	}
+
+	if reply := c.say("rerun #2"); reply.Body["type"] != string(ReplyResults) {
+		t.Errorf("rerun #2 answered %v", reply.Raw)
+	}
+	queries := searcher.Queries()
+	if last := queries[len(queries)-1]; len(queries) != 3 || last.Keyword != "gucci belt" || last.Condition != "Used" || last.MaxPrice != "500" {
+		t.Errorf("rerun #2 searched %+v", queries)
+	}
+}
@@ -0,0 +1,171 @@
+package theluxuryshopper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// maxImageSize Caps the images the proxy serves
+	maxImageSize = 5 << 20
+	// imageMaxAge Is how long browsers may cache a proxied image, listing images don't change
+	imageMaxAge = 24 * time.Hour
+	// imageFetchTimeout Bounds one fetch of the proxy
+	imageFetchTimeout = 10 * time.Second
+)
+
+// imageContentTypes Are the content types the proxy passes on
+var imageContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// bestImage Returns the largest picture eBay returned for item, falling back to its gallery thumbnail
+func bestImage(element findingItem) string {
+	for _, candidate := range [][]string{element.PictureURLSuperSize, element.PictureURLLarge, element.GalleryURL} {
+		if u := first(candidate); u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+// imageSigner Signs the image URLs the proxy may fetch
+type imageSigner struct {
+	secret []byte
+	base   string // the /img URLs start with, "" for bare paths
+}
+
+// publicBaseURL Returns where clients reach the server per config, without a trailing slash
+func publicBaseURL(config Config) string {
+	if config.PublicBaseURL != "" {
+		return strings.TrimSuffix(config.PublicBaseURL, "/")
+	}
+	if len(config.AutocertDomains) > 0 {
+		return "https://" + config.AutocertDomains[0]
+	}
+	return ""
+}
+
+// sign Returns the signature of u
+func (is *imageSigner) sign(u string) string {
+	mac := hmac.New(sha256.New, is.secret)
+	mac.Write([]byte(u))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// valid Reports whether signature was made by sign for u
+func (is *imageSigner) valid(u, signature string) bool {
+	expected, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, is.secret)
+	mac.Write([]byte(u))
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// proxied Returns the /img URL serving u under the public base URL, or "" for an empty u
+func (is *imageSigner) proxied(u string) string {
+	if u == "" {
+		return ""
+	}
+	return is.base + "/img?u=" + url.QueryEscape(u) + "&s=" + is.sign(u)
+}
+
+// proxyImages Points the images of items at the proxy when it is enabled
+func (s *Server) proxyImages(items []Item) {
+	if s.images == nil {
+		return
+	}
+	for i := range items {
+		items[i].GalleryURL = s.images.proxied(items[i].GalleryURL)
+		items[i].ImageURL = s.images.proxied(items[i].ImageURL)
+	}
+}
+
+// handleImage Handles /img, streaming an image whose URL the server signed
+func (s *Server) handleImage(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if s.images == nil {
+		writeError(w, http.StatusNotFound, "not_found", "The image proxy isn't enabled.")
+		return
+	}
+	u := r.URL.Query().Get("u")
+	if !s.images.valid(u, r.URL.Query().Get("s")) {
+		writeError(w, http.StatusForbidden, "invalid_signature", "The image URL isn't signed by this server.")
+		return
+	}
+	target, err := url.Parse(u)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		writeError(w, http.StatusBadRequest, "invalid_image_url", "Only http and https images can be proxied.")
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_image_url", "Only http and https images can be proxied.")
+		return
+	}
+	req = req.WithContext(r.Context())
+	for _, header := range []string{"If-None-Match", "If-Modified-Since"} {
+		if value := r.Header.Get(header); value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+	res, err := s.imageClient.Do(req)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "image_unavailable", "Couldn't fetch the image.")
+		return
+	}
+	defer res.Body.Close()
+
+	cacheControl := "public, max-age=" + strconv.Itoa(int(imageMaxAge/time.Second))
+	if res.StatusCode == http.StatusNotModified {
+		w.Header().Set("Cache-Control", cacheControl)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		writeError(w, http.StatusBadGateway, "image_unavailable", "Couldn't fetch the image.")
+		return
+	}
+	contentType := strings.TrimSpace(strings.Split(res.Header.Get("Content-Type"), ";")[0])
+	if !imageContentTypes[strings.ToLower(contentType)] {
+		writeError(w, http.StatusBadGateway, "invalid_image", "The URL isn't a supported image.")
+		return
+	}
+	if res.ContentLength > maxImageSize {
+		writeError(w, http.StatusBadGateway, "image_too_large", "The image is too large.")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", cacheControl)
+	for _, header := range []string{"ETag", "Last-Modified", "Content-Length"} {
+		if value := res.Header.Get(header); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+	copied, err := io.Copy(w, io.LimitReader(res.Body, maxImageSize+1))
+	if copied > maxImageSize {
+		// The headers are gone already, breaking the connection keeps the truncated image out of caches
+		log.Printf("image %v is larger than %v bytes, aborting", u, maxImageSize)
+		panic(http.ErrAbortHandler)
+	}
+	if err != nil {
+		log.Printf("couldn't stream image %v: %v", u, err)
+	}
+}
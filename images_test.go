@@ -0,0 +1,94 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPublicBaseURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   string
+	}{
+		{"unset", Config{}, ""},
+		{"absolute", Config{PublicBaseURL: "https://shop.example.com"}, "https://shop.example.com"},
+		{"trailing slash", Config{PublicBaseURL: "https://shop.example.com/"}, "https://shop.example.com"},
+		{"path prefix", Config{PublicBaseURL: "/shopper"}, "/shopper"},
+		{"autocert", Config{AutocertDomains: []string{"shop.example.com", "www.shop.example.com"}}, "https://shop.example.com"},
+		{"configured beats autocert", Config{PublicBaseURL: "/shopper", AutocertDomains: []string{"shop.example.com"}}, "/shopper"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := publicBaseURL(test.config); got != test.want {
+				t.Errorf("publicBaseURL() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidatePublicBaseURL(t *testing.T) {
+	tests := []struct {
+		base    string
+		wantErr bool
+	}{
+		{"", false},
+		{"https://shop.example.com", false},
+		{"http://localhost:8080", false},
+		{"/shopper", false},
+		{"shop.example.com", true},
+		{"ftp://shop.example.com", true},
+		{"https://", true},
+	}
+	for _, test := range tests {
+		config := Config{EbayEnv: "production", PublicBaseURL: test.base}
+		if err := config.validate(); (err != nil) != test.wantErr {
+			t.Errorf("validate() with PUBLIC_BASE_URL %q = %v, want an error: %v", test.base, err, test.wantErr)
+		}
+	}
+}
+
+func TestProxied(t *testing.T) {
+	const image = "https://i.ebayimg.com/images/g/abc/s-l1600.jpg"
+	tests := []struct {
+		base, wantPrefix string
+	}{
+		{"", "/img?u="},
+		{"/shopper", "/shopper/img?u="},
+		{"https://shop.example.com", "https://shop.example.com/img?u="},
+	}
+	for _, test := range tests {
+		signer := &imageSigner{secret: []byte("secret"), base: test.base}
+		got := signer.proxied(image)
+		if !strings.HasPrefix(got, test.wantPrefix) {
+			t.Errorf("proxied() under %q = %q, want it to start with %q", test.base, got, test.wantPrefix)
+			continue
+		}
+		parsed, err := url.Parse(got)
+		if err != nil {
+			t.Fatalf("proxied() = %q isn't a URL: %v", got, err)
+		}
+		if u, signature := parsed.Query().Get("u"), parsed.Query().Get("s"); u != image || !signer.valid(u, signature) {
+			t.Errorf("proxied() = %q doesn't carry a valid signature of the image", got)
+		}
+	}
+	if got := (&imageSigner{base: "/shopper"}).proxied(""); got != "" {
+		t.Errorf("proxied(\"\") = %q, want \"\"", got)
+	}
+}
+
+func TestHandleImageRefusesUnsignedURLs(t *testing.T) {
+	s := newTestServer(t, Config{ImageProxySecret: "secret", PublicBaseURL: "https://shop.example.com"}, &fakeSearcher{})
+	signed, _ := url.Parse(s.images.proxied("https://i.ebayimg.com/a.jpg"))
+	forged := "/img?u=" + url.QueryEscape("https://evil.example.com/a.jpg") + "&s=" + signed.Query().Get("s")
+	if reply := do(t, s.Routes(), http.MethodGet, forged, "", nil); reply.Status != http.StatusForbidden || reply.errorCode() != "invalid_signature" {
+		t.Errorf("/img with a forged signature answered %v %v, want 403 invalid_signature", reply.Status, reply.Raw)
+	}
+
+	disabled := newTestServer(t, Config{}, &fakeSearcher{})
+	if reply := do(t, disabled.Routes(), http.MethodGet, signed.RequestURI(), "", nil); reply.Status != http.StatusNotFound {
+		t.Errorf("/img without IMAGE_PROXY_SECRET answered %v, want 404", reply.Status)
+	}
+}
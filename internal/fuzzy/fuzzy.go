@@ -0,0 +1,49 @@
+// Package fuzzy Measures how many edits apart two words are, for the typo tolerant matching of the chatbot
+package fuzzy
+
+// Distance Returns the Levenshtein distance between a and b, counted in runes
+func Distance(a, b string) int {
+	return distance([]rune(a), []rune(b), false)
+}
+
+// TypoDistance Returns the edits turning a into b like Distance, but a swap of two neighbouring
+// letters counts as one edit, so "mroe" is as close to "more" as "mor"
+func TypoDistance(a, b string) int {
+	return distance([]rune(a), []rune(b), true)
+}
+
+// distance Fills the edit table of x and y, counting swaps as one edit when asked to
+func distance(x, y []rune, swaps bool) int {
+	rows := make([][]int, len(x)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(y)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min3(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if swaps && i > 1 && j > 1 && x[i-1] == y[j-2] && x[i-2] == y[j-1] && rows[i-2][j-2]+1 < rows[i][j] {
+				rows[i][j] = rows[i-2][j-2] + 1
+			}
+		}
+	}
+	return rows[len(x)][len(y)]
+}
+
+// min3 Returns the smallest of three ints
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
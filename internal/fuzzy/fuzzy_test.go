@@ -0,0 +1,41 @@
+package fuzzy
+
+import "testing"
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"message", "message", 0},
+		{"mesage", "message", 1},
+		{"mesasge", "message", 2},
+		{"idempotencykey", "idempotencyKey", 1},
+		{"", "meta", 4},
+		{"café", "cafe", 1},
+	}
+	for _, test := range tests {
+		if got := Distance(test.a, test.b); got != test.want {
+			t.Errorf("Distance(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestTypoDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"more", "more", 0},
+		{"mroe", "more", 1},
+		{"mor", "more", 1},
+		{"moore", "more", 1},
+		{"nxet pgae", "next page", 2},
+		{"", "more", 4},
+	}
+	for _, test := range tests {
+		if got := TypoDistance(test.a, test.b); got != test.want {
+			t.Errorf("TypoDistance(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// shoppingHosts Maps every EBAY_ENV value to its Shopping API host
+var shoppingHosts = map[string]string{
+	"production": "open.api.ebay.com",
+	"sandbox":    "open.api.sandbox.ebay.com",
+}
+
+// shoppingEndpointAt Builds the GetSingleItem URL of the Shopping API at base, the item id is appended to it
+func shoppingEndpointAt(base, appID string) string {
+	return base + "?callname=GetSingleItem&responseencoding=JSON&siteid=0&version=967&IncludeSelector=Details&appid=" + url.QueryEscape(appID) + "&ItemID="
+}
+
+// itemIDPattern Matches the ids of eBay listings
+var itemIDPattern = regexp.MustCompile(`^\d{9,19}$`)
+
+// maxShoppingResponseSize Caps how much of a GetSingleItem response is read
+const maxShoppingResponseSize = 1 << 20
+
+// shoppingItemNotFound Is the Shopping API error code of item ids that don't exist, or not anymore
+const shoppingItemNotFound = "10.12"
+
+// Listing statuses of ItemStatus
+const (
+	listingActive = "active"
+	listingEnded  = "ended"
+)
+
+// ItemStatus Is the fresh state of one listing, as GET /items/:itemId answers it
+type ItemStatus struct {
+	Item
+	Status       string `json:"status"`             // active or ended
+	TimeLeft     string `json:"timeLeft,omitempty"` // An ISO 8601 duration like P2DT3H, while the listing is active
+	QuantitySold int    `json:"quantitySold"`
+}
+
+// ItemFetcher Is a Searcher that can also fetch one listing fresh by its id.
+// GET /items/:itemId and "refresh all" need the searcher of the server to be one.
+type ItemFetcher interface {
+	Searcher
+	FetchItem(ctx context.Context, id string) (ItemStatus, error)
+}
+
+var (
+	// errItemNotFound Is returned for item ids eBay doesn't know, or doesn't anymore
+	errItemNotFound = errors.New("eBay has no listing with this item id")
+	// errItemsUnsupported Is returned when the searcher of the server can't fetch single items
+	errItemsUnsupported = errors.New("the searcher of the server can't fetch single items")
+)
+
+// shoppingResponse Mirrors the parts of a GetSingleItem response that are used
+type shoppingResponse struct {
+	Ack    string `json:"Ack"`
+	Errors []struct {
+		ShortMessage        string `json:"ShortMessage"`
+		LongMessage         string `json:"LongMessage"`
+		ErrorCode           string `json:"ErrorCode"`
+		SeverityCode        string `json:"SeverityCode"`
+		ErrorClassification string `json:"ErrorClassification"`
+	} `json:"Errors"`
+	Item *struct {
+		ItemID                      string         `json:"ItemID"`
+		Title                       string         `json:"Title"`
+		ViewItemURLForNaturalSearch string         `json:"ViewItemURLForNaturalSearch"`
+		GalleryURL                  string         `json:"GalleryURL"`
+		PictureURL                  []string       `json:"PictureURL"`
+		ConditionDisplayName        string         `json:"ConditionDisplayName"`
+		CurrentPrice                shoppingAmount `json:"CurrentPrice"`
+		Country                     string         `json:"Country"`
+		Location                    string         `json:"Location"`
+		PrimaryCategoryName         string         `json:"PrimaryCategoryName"`
+		EndTime                     string         `json:"EndTime"`
+		ListingType                 string         `json:"ListingType"`
+		ListingStatus               string         `json:"ListingStatus"`
+		TimeLeft                    string         `json:"TimeLeft"`
+		QuantitySold                int            `json:"QuantitySold"`
+	} `json:"Item"`
+}
+
+// shoppingAmount Mirrors an amount of the Shopping API, whose values are numbers
+type shoppingAmount struct {
+	Value      float64 `json:"Value"`
+	CurrencyID string  `json:"CurrencyID"`
+}
+
+// FetchItem Calls GetSingleItem for id once, without the retries of searches
+func (c *ebayClient) FetchItem(ctx context.Context, id string) (ItemStatus, error) {
+	select {
+	case c.limiter <- struct{}{}:
+	case <-ctx.Done():
+		return ItemStatus{}, ctx.Err()
+	}
+	defer func() { <-c.limiter }()
+
+	req, err := http.NewRequest(http.MethodGet, c.shoppingEndpoint+url.QueryEscape(id), nil)
+	if err != nil {
+		return ItemStatus{}, err
+	}
+	res, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return ItemStatus{}, c.sanitize(&upstreamError{category: classifyCallError(ctx, err), err: err})
+	}
+	defer res.Body.Close()
+
+	var response shoppingResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxShoppingResponseSize)).Decode(&response); err != nil {
+		if unreadableBody(err) {
+			return ItemStatus{}, &upstreamError{category: failureBodyRead, err: fmt.Errorf("couldn't read eBay response: %v", err)}
+		}
+		return ItemStatus{}, fmt.Errorf("couldn't decode eBay response: %v", err)
+	}
+	status, err := parseSingleItem(response)
+	return status, c.sanitize(err)
+}
+
+// parseSingleItem Extracts the listing of a GetSingleItem response
+func parseSingleItem(response shoppingResponse) (ItemStatus, error) {
+	if response.Item == nil || (response.Ack != "Success" && response.Ack != "Warning") {
+		for _, e := range response.Errors {
+			if e.ErrorCode == shoppingItemNotFound {
+				return ItemStatus{}, errItemNotFound
+			}
+		}
+		failure := &searchFailure{message: "eBay couldn't fetch the item", category: failureUnknown}
+		if len(response.Errors) > 0 {
+			e := response.Errors[0]
+			failure.message, failure.id, failure.severity = e.LongMessage, e.ErrorCode, e.SeverityCode
+			switch e.ErrorClassification {
+			case "RequestError":
+				failure.category = failureInvalidInput
+			case "SystemError":
+				failure.category = failureSystem
+			}
+		}
+		return ItemStatus{}, failure
+	}
+	item := response.Item
+	status := ItemStatus{
+		Item: Item{
+			ID:          item.ItemID,
+			Title:       item.Title,
+			ItemURL:     item.ViewItemURLForNaturalSearch,
+			GalleryURL:  item.GalleryURL,
+			ImageURL:    item.GalleryURL,
+			Condition:   item.ConditionDisplayName,
+			Price:       strconv.FormatFloat(item.CurrentPrice.Value, 'f', -1, 64),
+			Currency:    item.CurrentPrice.CurrencyID,
+			Country:     item.Country,
+			Location:    item.Location,
+			Category:    item.PrimaryCategoryName,
+			EndTime:     item.EndTime,
+			ListingType: item.ListingType,
+		},
+		Status:       listingEnded,
+		QuantitySold: item.QuantitySold,
+	}
+	if len(item.PictureURL) > 0 {
+		status.ImageURL = item.PictureURL[0]
+	}
+	if item.ListingStatus == "Active" {
+		status.Status, status.TimeLeft = listingActive, item.TimeLeft
+	}
+	return status, nil
+}
+
+// itemFetcher Returns the searcher of the server as an ItemFetcher, reporting whether it is one
+func (s *Server) itemFetcher() (ItemFetcher, bool) {
+	searcher := s.searcher
+	if chaos, ok := searcher.(*chaosSearcher); ok {
+		searcher = chaos.next
+	}
+	fetcher, ok := searcher.(ItemFetcher)
+	return fetcher, ok
+}
+
+// fetchItem Fetches the listing id fresh, counting the call against the quota like a search
+func (s *Server) fetchItem(ctx context.Context, id string) (ItemStatus, error) {
+	ctx, sp := s.tracer.Start(ctx, "ebay GetSingleItem", spanClient)
+	defer sp.End()
+	sp.SetAttribute("item.id", id)
+
+	fetcher, ok := s.itemFetcher()
+	if !ok {
+		return ItemStatus{}, errItemsUnsupported
+	}
+	if err := s.quota.Check(); err != nil {
+		return ItemStatus{}, err
+	}
+	if err := s.throttle.Check(); err != nil {
+		return ItemStatus{}, err
+	}
+	if s.config.SearchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.SearchTimeout)
+		defer cancel()
+	}
+	status, err := fetcher.FetchItem(ctx, id)
+	s.quota.Record(1)
+	if failure, refused := err.(*searchFailure); refused {
+		logFailure(ctx, failure)
+	}
+	if err == nil {
+		items := []Item{status.Item}
+		s.proxyImages(items)
+		status.Item = items[0]
+	}
+	sp.SetAttribute("item.status", status.Status)
+	return status, err
+}
+
+// handleItem Handles GET /items/:itemId, answering the fresh price and availability of one listing.
+// Ended listings answer 410 with the item, unknown ones 404.
+func (s *Server) handleItem(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w = s.withPlainText(withMeta(w, r), r)
+	w.Header().Set("Cache-Control", "no-store")
+	id := ps.ByName("itemId")
+	if !itemIDPattern.MatchString(id) {
+		writeError(w, http.StatusBadRequest, "invalid_item_id", "The item id must be the 9 to 19 digits of an eBay listing.")
+		return
+	}
+	item, err := s.fetchItem(r.Context(), id)
+	switch {
+	case err == errItemNotFound:
+		writeError(w, http.StatusNotFound, "item_not_found", "eBay has no listing with the item id "+id+".")
+		return
+	case err == errItemsUnsupported:
+		writeError(w, http.StatusNotImplemented, "items_unsupported", "This server can't look up single items.")
+		return
+	case err != nil:
+		status, code, message, retryAt := describeSearchError(err)
+		if !retryAt.IsZero() {
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(retryAt).Seconds())+1))
+		}
+		writeError(w, status, code, message)
+		return
+	}
+	if item.Status == listingEnded {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(JSON{
+			"type":  ReplyError,
+			"error": JSON{"code": "item_ended", "message": "The listing " + id + " has ended."},
+			"item":  item,
+		})
+		return
+	}
+	writeJSON(w, JSON{"item": item})
+}
+
+// itemChanges Describes how the listing changed since stored was shown, like "price changed from 450 to 420 USD"
+func itemChanges(stored Item, fresh ItemStatus) []string {
+	var changes []string
+	if fresh.Status == listingEnded {
+		changes = append(changes, "listing ended")
+	}
+	before, errBefore := strconv.ParseFloat(stored.Price, 64)
+	after, errAfter := strconv.ParseFloat(fresh.Price, 64)
+	if errBefore == nil && errAfter == nil && before != after {
+		changes = append(changes, "price changed from "+formatAmount(before)+" to "+formatAmount(after)+" "+fresh.Currency)
+	}
+	return changes
+}
+
+// formatAmount Writes a price without the decimals of whole amounts
+func formatAmount(amount float64) string {
+	return strings.TrimSuffix(strconv.FormatFloat(amount, 'f', 2, 64), ".00")
+}
+
+// maxRefreshedItems Caps the listings one "refresh all" fetches, each one is an eBay call
+const maxRefreshedItems = 10
+
+// refreshAllCommand Matches "refresh all", what "show all" becomes when the listings are fetched fresh
+var refreshAllCommand = regexp.MustCompile(`(?i)^\s*(?:refresh\s+all|show\s+all\s+refresh(?:ed)?)\s*$`)
+
+// refreshAll Answers "refresh all" like "show all", but with the listings of the last saved search run
+// fetched fresh: their new prices are kept and what changed since the run is listed after them
+func (s *Server) refreshAll(session Session, w http.ResponseWriter) {
+	run, found := session["lastRun"].(savedRun)
+	if !found {
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "Run a saved search first, then say 'refresh all' to check its items again.",
+		})
+		return
+	}
+	ctx := requestContext(w)
+	changes := []JSON{}
+	var notes []string
+	refreshed := 0
+	var failure error
+	refresh := func(items []Item) []Item {
+		kept := make([]Item, 0, len(items))
+		for _, item := range items {
+			if failure != nil || refreshed >= maxRefreshedItems {
+				kept = append(kept, item)
+				continue
+			}
+			refreshed++
+			fresh, err := s.fetchItem(ctx, item.ID)
+			switch {
+			case err == errItemNotFound:
+				// Listings removed from eBay are gone for good, like ended ones
+				fresh = ItemStatus{Item: item, Status: listingEnded}
+			case err != nil:
+				failure = err
+				kept = append(kept, item)
+				continue
+			}
+			described := itemChanges(item, fresh)
+			if len(described) > 0 {
+				changes = append(changes, JSON{"id": item.ID, "changes": described})
+				notes = append(notes, item.Title+" : "+strings.Join(described, ", "))
+			}
+			if fresh.Status == listingActive {
+				item.Price, item.EndTime = fresh.Price, fresh.EndTime
+			}
+			kept = append(kept, item)
+		}
+		return kept
+	}
+	run.New, run.Repeats = refresh(run.New), refresh(run.Repeats)
+	session["lastRun"] = run
+
+	items := append(append([]Item{}, run.New...), run.Repeats...)
+	var response strings.Builder
+	response.Grow(estimateRenderSize(items) + 256)
+	response.WriteString("Everything " + run.Name + " found, checked again : \n")
+	writeItems(&response, items, requestView(w))
+	if len(notes) == 0 {
+		response.WriteString("\n Nothing changed since the search ran.")
+	} else {
+		response.WriteString("\n Changes since the search ran :\n " + strings.Join(notes, "\n ") + "\n")
+	}
+	if failure != nil {
+		_, _, message, _ := describeSearchError(failure)
+		if failure == errItemsUnsupported {
+			message = "Items can't be checked again on this server."
+		}
+		response.WriteString("\n The other items weren't checked. " + message)
+	} else if refreshed < len(items) {
+		response.WriteString("\n Only the first " + strconv.Itoa(maxRefreshedItems) + " items were checked.")
+	}
+	response.WriteString("\n Results Page URL : " + run.PageURL + "\n\n What else would you like to search for?")
+	WriteReply(w, ReplyResults, JSON{
+		"message": response.String(),
+		"items":   nonNilItems(items),
+		"changes": changes,
+		"query":   run.Query,
+	})
+}
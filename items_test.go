@@ -0,0 +1,141 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// fakeFetcher Is a fakeSearcher that also fetches single items, answering errItemNotFound for the unknown ones
+type fakeFetcher struct {
+	fakeSearcher
+	items map[string]ItemStatus
+}
+
+// FetchItem Implements ItemFetcher
+func (f *fakeFetcher) FetchItem(ctx context.Context, id string) (ItemStatus, error) {
+	if status, found := f.items[id]; found {
+		return status, nil
+	}
+	return ItemStatus{}, errItemNotFound
+}
+
+func TestParseSingleItem(t *testing.T) {
+	tests := []struct {
+		name         string
+		response     string
+		want         ItemStatus
+		wantErr      error
+		wantCategory string
+	}{
+		{
+			name:     "active",
+			response: `{"Ack":"Success","Item":{"ItemID":"123456789","Title":"Kelly 28","GalleryURL":"g.jpg","PictureURL":["p.jpg"],"ConditionDisplayName":"Used","CurrentPrice":{"Value":9500.5,"CurrencyID":"USD"},"ListingStatus":"Active","TimeLeft":"P2DT3H","QuantitySold":1}}`,
+			want: ItemStatus{
+				Item:   Item{ID: "123456789", Title: "Kelly 28", GalleryURL: "g.jpg", ImageURL: "p.jpg", Condition: "Used", Price: "9500.5", Currency: "USD"},
+				Status: listingActive, TimeLeft: "P2DT3H", QuantitySold: 1,
+			},
+		},
+		{
+			name:     "ended",
+			response: `{"Ack":"Warning","Item":{"ItemID":"123456789","GalleryURL":"g.jpg","CurrentPrice":{"Value":420,"CurrencyID":"EUR"},"ListingStatus":"Completed","TimeLeft":"PT0S"}}`,
+			want:     ItemStatus{Item: Item{ID: "123456789", GalleryURL: "g.jpg", ImageURL: "g.jpg", Price: "420", Currency: "EUR"}, Status: listingEnded},
+		},
+		{
+			name:     "unknown item",
+			response: `{"Ack":"Failure","Errors":[{"ErrorCode":"10.12","LongMessage":"Invalid item ID."}]}`,
+			wantErr:  errItemNotFound,
+		},
+		{
+			name:         "request error",
+			response:     `{"Ack":"Failure","Errors":[{"ErrorCode":"1.20","LongMessage":"Invalid application ID.","ErrorClassification":"RequestError"}]}`,
+			wantCategory: failureInvalidInput,
+		},
+		{
+			name:         "system error",
+			response:     `{"Ack":"Failure","Errors":[{"ErrorCode":"10.1","ErrorClassification":"SystemError"}]}`,
+			wantCategory: failureSystem,
+		},
+		{
+			name:         "no item nor errors",
+			response:     `{"Ack":"Success"}`,
+			wantCategory: failureUnknown,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var response shoppingResponse
+			if err := json.Unmarshal([]byte(test.response), &response); err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseSingleItem(response)
+			if test.wantCategory != "" {
+				if failure, ok := err.(*searchFailure); !ok || failure.category != test.wantCategory {
+					t.Errorf("parseSingleItem() failed with %#v, want a %v searchFailure", err, test.wantCategory)
+				}
+				return
+			}
+			if err != test.wantErr {
+				t.Fatalf("parseSingleItem() failed with %v, want %v", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseSingleItem() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestItemChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored Item
+		fresh  ItemStatus
+		want   []string
+	}{
+		{"unchanged", Item{Price: "450.0"}, ItemStatus{Item: Item{Price: "450"}, Status: listingActive}, nil},
+		{"cheaper", Item{Price: "450.0"}, ItemStatus{Item: Item{Price: "420.5", Currency: "USD"}, Status: listingActive}, []string{"price changed from 450 to 420.50 USD"}},
+		{"ended", Item{Price: "450.0"}, ItemStatus{Item: Item{Price: "450"}, Status: listingEnded}, []string{"listing ended"}},
+		{"unknown price", Item{Price: ""}, ItemStatus{Item: Item{Price: "450"}, Status: listingActive}, nil},
+	}
+	for _, test := range tests {
+		if got := itemChanges(test.stored, test.fresh); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: itemChanges() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestHandleItem(t *testing.T) {
+	fetcher := &fakeFetcher{items: map[string]ItemStatus{
+		"111111111": {Item: Item{ID: "111111111", Price: "950", Currency: "USD"}, Status: listingActive, TimeLeft: "P1D"},
+		"222222222": {Item: Item{ID: "222222222", Price: "420", Currency: "USD"}, Status: listingEnded},
+	}}
+	handler := newTestServer(t, Config{}, fetcher).Routes()
+	tests := []struct {
+		id         string
+		wantStatus int
+		wantCode   string
+		wantItem   bool
+	}{
+		{"111111111", http.StatusOK, "", true},
+		{"222222222", http.StatusGone, "item_ended", true},
+		{"333333333", http.StatusNotFound, "item_not_found", false},
+		{"12345", http.StatusBadRequest, "invalid_item_id", false},
+		{"abcdefghij", http.StatusBadRequest, "invalid_item_id", false},
+	}
+	for _, test := range tests {
+		reply := do(t, handler, http.MethodGet, "/v1/items/"+test.id, "", nil)
+		if reply.Status != test.wantStatus || reply.errorCode() != test.wantCode {
+			t.Errorf("/v1/items/%v answered %v %v, want %v %q", test.id, reply.Status, reply.Raw, test.wantStatus, test.wantCode)
+		}
+		if item, _ := reply.Body["item"].(map[string]interface{}); test.wantItem && item["id"] != test.id {
+			t.Errorf("/v1/items/%v answered the item %v", test.id, item)
+		}
+	}
+
+	unsupported := newTestServer(t, Config{}, &fakeSearcher{}).Routes()
+	if reply := do(t, unsupported, http.MethodGet, "/v1/items/111111111", "", nil); reply.Status != http.StatusNotImplemented || reply.errorCode() != "items_unsupported" {
+		t.Errorf("/v1/items without an ItemFetcher answered %v %v, want 501 items_unsupported", reply.Status, reply.Raw)
+	}
+}
@@ -0,0 +1,157 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Kinds of the work a session can stop, in the order the replies name them
+const (
+	jobTurn    = "turn"    // a chat message still being answered, with its search
+	jobWebhook = "webhook" // a search waiting for its delivery to SEARCH_WEBHOOK_URL
+)
+
+// jobKinds Names every kind of job for the replies, in the singular and the plural
+var jobKinds = []struct{ kind, one, many string }{
+	{jobTurn, "message still being answered", "messages still being answered"},
+	{jobWebhook, "search waiting for the webhook", "searches waiting for the webhook"},
+}
+
+// stopCommand Matches asking to stop what still runs for the session, like "stop" or "cancel everything"
+var stopCommand = regexp.MustCompile(`(?i)^\s*(?:stop|cancel)(?:\s+(?:it|that|all|everything|(?:the\s+)?(?:background\s+)?(?:jobs?|work)))?\W*$`)
+
+// sessionJob Is one piece of work running on behalf of a session
+type sessionJob struct {
+	kind   string
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// jobRegistry Tracks the work running on behalf of each session, by the hash withSessionHash notes, so the
+// session can stop it. Jobs leave it when they end or are stopped, it only ever holds what still runs.
+type jobRegistry struct {
+	mu   sync.Mutex
+	next int
+	jobs map[string]map[int]*sessionJob
+}
+
+// newJobRegistry Creates an empty registry
+func newJobRegistry() *jobRegistry {
+	return &jobRegistry{jobs: map[string]map[int]*sessionJob{}}
+}
+
+// sessionOf Returns the hashed session noted on ctx by withSessionHash, "" for work of no session
+func sessionOf(ctx context.Context) string {
+	session, _ := ctx.Value(sessionHashKey{}).(string)
+	return session
+}
+
+// start Registers a job of kind for session, returning the context it runs under, canceled when the
+// session stops it, and the func to call once it ended. Work of no session isn't registered.
+func (j *jobRegistry) start(parent context.Context, session, kind string) (context.Context, func()) {
+	if session == "" {
+		return parent, func() {}
+	}
+	ctx, cancel := context.WithCancel(parent)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.next++
+	id := j.next
+	if j.jobs[session] == nil {
+		j.jobs[session] = map[int]*sessionJob{}
+	}
+	j.jobs[session][id] = &sessionJob{kind: kind, ctx: ctx, cancel: cancel}
+	return ctx, func() {
+		j.mu.Lock()
+		j.remove(session, id)
+		j.mu.Unlock()
+		cancel()
+	}
+}
+
+// remove Forgets job id of session, and the session once it has none left. j.mu must be held.
+func (j *jobRegistry) remove(session string, id int) {
+	delete(j.jobs[session], id)
+	if len(j.jobs[session]) == 0 {
+		delete(j.jobs, session)
+	}
+}
+
+// stop Cancels every job of session but the one running under keep, returning how many of each kind it stopped
+func (j *jobRegistry) stop(session string, keep context.Context) map[string]int {
+	stopped := map[string]int{}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for id, job := range j.jobs[session] {
+		if job.ctx == keep {
+			continue
+		}
+		job.cancel()
+		j.remove(session, id)
+		stopped[job.kind]++
+	}
+	return stopped
+}
+
+// stoppedSummary Tells the user what stop canceled
+func stoppedSummary(stopped map[string]int) string {
+	var parts []string
+	for _, kind := range jobKinds {
+		switch n := stopped[kind.kind]; {
+		case n == 1:
+			parts = append(parts, "1 "+kind.one)
+		case n > 1:
+			parts = append(parts, strconv.Itoa(n)+" "+kind.many)
+		}
+	}
+	if len(parts) == 0 {
+		return "Nothing was running for this session."
+	}
+	return "Stopped " + strings.Join(parts, " and ") + "."
+}
+
+// handleStopCommand Answers "stop" by canceling the background work of the session, this message aside.
+// Reports whether message was the command.
+func (s *Server) handleStopCommand(session Session, message string, w http.ResponseWriter) bool {
+	if !stopCommand.MatchString(message) {
+		return false
+	}
+	ctx := requestContext(w)
+	stopped := s.jobs.stop(sessionOf(ctx), ctx)
+	WriteReply(w, ReplyInfo, JSON{
+		"message": stoppedSummary(stopped) + "\n " + strings.TrimSpace(resumeSummary(session)),
+		"stopped": stopped,
+	})
+	return true
+}
+
+// handleStopJobs Handles DELETE /jobs, canceling the work running for the session of the Authorization
+// header, the message it is still being answered included
+func (s *Server) handleStopJobs(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	uuid := r.Header.Get("Authorization")
+	if uuid == "" {
+		writeError(w, http.StatusUnauthorized, "missing_authorization", "Missing or empty Authorization header.")
+		return
+	}
+	switch s.sessions.State(uuid) {
+	case sessionExpired:
+		writeError(w, http.StatusUnauthorized, "session_expired", "The session "+uuid+" expired.")
+		return
+	case sessionUnknown:
+		writeError(w, http.StatusUnauthorized, "unknown_session", "No session found for: "+uuid+".")
+		return
+	}
+	// The session isn't locked, stopping the message holding the lock is the point
+	stopped := s.jobs.stop(sessionOf(withSessionHash(r.Context(), uuid)), nil)
+	total := 0
+	for _, n := range stopped {
+		total += n
+	}
+	writeJSON(w, JSON{"message": stoppedSummary(stopped), "stopped": stopped, "total": total})
+}
@@ -0,0 +1,121 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStopCommand(t *testing.T) {
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{"stop", true},
+		{"Cancel!", true},
+		{"stop everything", true},
+		{"cancel the background jobs", true},
+		{"stop the work", true},
+		{"stop loss bag", false},
+		{"cancelled order", false},
+	}
+	for _, test := range tests {
+		if got := stopCommand.MatchString(test.message); got != test.want {
+			t.Errorf("stopCommand matches %q: %v, want %v", test.message, got, test.want)
+		}
+	}
+}
+
+func TestStoppedSummary(t *testing.T) {
+	tests := []struct {
+		stopped map[string]int
+		want    string
+	}{
+		{map[string]int{}, "Nothing was running for this session."},
+		{map[string]int{jobTurn: 1}, "Stopped 1 message still being answered."},
+		{map[string]int{jobWebhook: 3}, "Stopped 3 searches waiting for the webhook."},
+		{map[string]int{jobWebhook: 1, jobTurn: 2}, "Stopped 2 messages still being answered and 1 search waiting for the webhook."},
+	}
+	for _, test := range tests {
+		if got := stoppedSummary(test.stopped); got != test.want {
+			t.Errorf("stoppedSummary(%v) = %q, want %q", test.stopped, got, test.want)
+		}
+	}
+}
+
+func TestJobRegistry(t *testing.T) {
+	jobs := newJobRegistry()
+	if ctx, done := jobs.start(context.Background(), "", jobTurn); ctx != context.Background() || len(jobs.jobs) != 0 {
+		t.Error("start() registered work of no session")
+	} else {
+		done()
+	}
+
+	current, doneCurrent := jobs.start(context.Background(), "a", jobTurn)
+	defer doneCurrent()
+	webhook, _ := jobs.start(context.Background(), "a", jobWebhook)
+	ended, doneEnded := jobs.start(context.Background(), "a", jobWebhook)
+	other, _ := jobs.start(context.Background(), "b", jobTurn)
+	doneEnded()
+	if ended.Err() == nil {
+		t.Error("the context of an ended job isn't canceled")
+	}
+
+	if stopped := jobs.stop("a", current); stopped[jobWebhook] != 1 || stopped[jobTurn] != 0 {
+		t.Errorf("stop() stopped %v, want 1 webhook", stopped)
+	}
+	if webhook.Err() == nil || current.Err() != nil || other.Err() != nil {
+		t.Errorf("after stop(), the jobs are canceled: webhook %v, kept %v, other session %v", webhook.Err(), current.Err(), other.Err())
+	}
+	if stopped := jobs.stop("a", nil); stopped[jobTurn] != 1 || current.Err() == nil {
+		t.Errorf("stop() without a job to keep stopped %v", stopped)
+	}
+	if _, found := jobs.jobs["a"]; found {
+		t.Error("a session without jobs is still in the registry")
+	}
+}
+
+func TestHandleStopJobs(t *testing.T) {
+	s := newTestServer(t, Config{}, &fakeSearcher{delay: 10 * time.Second})
+	c := startConversation(t, s)
+	c.sayAll("gucci belt", "none", "none")
+
+	answered := make(chan testReply)
+	go func() { answered <- c.say("none") }()
+	session := sessionOf(withSessionHash(context.Background(), c.uuid))
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		s.jobs.mu.Lock()
+		running := len(s.jobs.jobs[session])
+		s.jobs.mu.Unlock()
+		if running > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the search never started")
+		}
+	}
+
+	reply := do(t, s.Routes(), http.MethodDelete, "/v1/jobs", "", http.Header{"Authorization": {c.uuid}})
+	if reply.Status != http.StatusOK || reply.Body["total"] != float64(1) || reply.message() != "Stopped 1 message still being answered." {
+		t.Errorf("DELETE /v1/jobs answered %v %v", reply.Status, reply.Raw)
+	}
+	select {
+	case <-answered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stopped message is still being answered")
+	}
+
+	tests := []struct {
+		uuid     string
+		wantCode string
+	}{
+		{"", "missing_authorization"},
+		{"unknown", "unknown_session"},
+	}
+	for _, test := range tests {
+		if reply := do(t, s.Routes(), http.MethodDelete, "/v1/jobs", "", http.Header{"Authorization": {test.uuid}}); reply.Status != http.StatusUnauthorized || reply.errorCode() != test.wantCode {
+			t.Errorf("DELETE /v1/jobs as %q answered %v %v, want 401 %v", test.uuid, reply.Status, reply.Raw, test.wantCode)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package theluxuryshopper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The limits the Finding API documents for the keywords of a search
+const (
+	maxKeywordLength     = 350
+	maxKeywordWords      = 98
+	maxKeywordPhraseSize = 12
+)
+
+// normalizeKeyword Cleans up a keyword typed by the user and checks it against eBay's limits.
+// Control and invisible formatting characters are dropped and whitespace is collapsed,
+// the error explains to the user what to change.
+func normalizeKeyword(raw string) (string, error) {
+	cleaned := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r):
+			return ' '
+		case unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, raw)
+	keyword := strings.Join(strings.Fields(cleaned), " ")
+
+	if keyword == "" || strings.Trim(keyword, `"`) == "" {
+		return "", errors.New("I didn't catch what you are looking for.")
+	}
+	if utf8.RuneCountInString(keyword) > maxKeywordLength {
+		return "", fmt.Errorf("That's a bit long, eBay searches at most %v characters.", maxKeywordLength)
+	}
+	if words := len(strings.Fields(strings.Replace(keyword, `"`, " ", -1))); words > maxKeywordWords {
+		return "", fmt.Errorf("That's a lot of words, eBay searches at most %v.", maxKeywordWords)
+	}
+	// The odd parts of a split on quotes are the quoted phrases
+	for i, part := range strings.Split(keyword, `"`) {
+		if i%2 == 1 && len(strings.Fields(part)) > maxKeywordPhraseSize {
+			return "", fmt.Errorf("A quoted phrase can hold at most %v words.", maxKeywordPhraseSize)
+		}
+	}
+	return keyword, nil
+}
@@ -0,0 +1,43 @@
+package theluxuryshopper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeKeyword(t *testing.T) {
+	tests := []struct {
+		name       string
+		raw        string
+		want       string
+		wantPrefix string // of the error
+	}{
+		{"plain", "gucci belt", "gucci belt", ""},
+		{"whitespace", "  gucci \t\n belt  ", "gucci belt", ""},
+		{"control characters", "gucci\x00belt", "gucci belt", ""},
+		{"invisible formatting", "gu\u200bcci\u200e belt", "gucci belt", ""},
+		{"quoted phrase", `"kelly 28" hermes`, `"kelly 28" hermes`, ""},
+		{"empty", " \t ", "", "I didn't catch"},
+		{"only quotes", `""`, "", "I didn't catch"},
+		{"longest", strings.Repeat("a", maxKeywordLength), strings.Repeat("a", maxKeywordLength), ""},
+		{"too long", strings.Repeat("a", maxKeywordLength+1), "", "That's a bit long"},
+		{"runes not bytes", strings.Repeat("é", maxKeywordLength), strings.Repeat("é", maxKeywordLength), ""},
+		{"too many words", strings.Repeat("a ", maxKeywordWords+1), "", "That's a lot of words"},
+		{"long phrase", `"` + strings.Repeat("a ", maxKeywordPhraseSize+1) + `"`, "", "A quoted phrase"},
+		{"long words unquoted", strings.Repeat("a ", maxKeywordPhraseSize+1) + `"b"`, strings.TrimSpace(strings.Repeat("a ", maxKeywordPhraseSize+1)) + ` "b"`, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := normalizeKeyword(test.raw)
+			if test.wantPrefix != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), test.wantPrefix) {
+					t.Errorf("normalizeKeyword() failed with %v, want %q", err, test.wantPrefix)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Errorf("normalizeKeyword() = %q, %v, want %q", got, err, test.want)
+			}
+		})
+	}
+}
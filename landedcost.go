@@ -0,0 +1,202 @@
+package theluxuryshopper
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LandedCostRate Holds the VAT and import duty percentages paid on an item shipped to a country
+type LandedCostRate struct {
+	VAT  float64
+	Duty float64
+}
+
+// LandedCostRates Maps a country code and a duty category to its rate, the "*" category applies to every other category
+type LandedCostRates map[string]map[string]LandedCostRate
+
+// standardVAT The standard VAT percentage of the countries with a default estimate
+var standardVAT = map[string]float64{
+	"AT": 20, "BE": 21, "DE": 19, "DK": 25, "ES": 21, "FI": 25.5, "FR": 20, "GB": 20,
+	"GR": 24, "IE": 23, "IT": 22, "NL": 21, "PL": 23, "PT": 23, "SE": 25,
+}
+
+// importDuty The import duty percentage of each duty category in the countries of standardVAT
+var importDuty = map[string]float64{
+	"*":        4,
+	"clothing": 12,
+	"shoes":    8,
+	"bags":     3,
+	"watches":  4.5,
+	"jewelry":  2.5,
+}
+
+// dutyCategories Sorts eBay category names into the duty categories, the first matching word wins
+var dutyCategories = []struct {
+	category string
+	words    []string
+}{
+	{"watches", []string{"watch"}},
+	{"jewelry", []string{"jewelry", "jewellery", "necklace", "bracelet", "ring", "earring"}},
+	{"shoes", []string{"shoe", "sneaker", "boot", "loafer", "heel", "sandal"}},
+	{"bags", []string{"bag", "wallet", "backpack", "clutch", "purse", "luggage"}},
+	{"clothing", []string{"clothing", "shirt", "coat", "jacket", "dress", "sweater", "scarf", "scarves"}},
+}
+
+// countryNames The country names understood by "ship to", codes are always understood
+var countryNames = map[string]string{
+	"austria": "AT", "belgium": "BE", "germany": "DE", "denmark": "DK", "spain": "ES",
+	"finland": "FI", "france": "FR", "united kingdom": "GB", "uk": "GB", "greece": "GR",
+	"ireland": "IE", "italy": "IT", "netherlands": "NL", "poland": "PL", "portugal": "PT",
+	"sweden": "SE", "united states": "US", "usa": "US",
+}
+
+// shipToCommand Matches "ship to germany" or "shipping to DE"
+var shipToCommand = regexp.MustCompile(`(?i)^\s*(?:ship|shipping|deliver)\s+to\s+(.+?)\s*$`)
+
+// defaultLandedCostRates Builds the rates of every country of standardVAT from importDuty
+func defaultLandedCostRates() LandedCostRates {
+	rates := LandedCostRates{}
+	for country, vat := range standardVAT {
+		rates[country] = map[string]LandedCostRate{}
+		for category, duty := range importDuty {
+			rates[country][category] = LandedCostRate{VAT: vat, Duty: duty}
+		}
+	}
+	return rates
+}
+
+// readLandedCostFile Adds the rates of path to rates, one "country,category,vat,duty" line each.
+// Blank lines and # comments are skipped, lines replace the rate they name.
+func readLandedCostFile(path string, rates LandedCostRates) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) != 4 {
+			return fmt.Errorf("line %v: want country,category,vat,duty", number)
+		}
+		country := strings.ToUpper(strings.TrimSpace(fields[0]))
+		category := strings.ToLower(strings.TrimSpace(fields[1]))
+		vat, vatErr := strconv.ParseFloat(strings.TrimSpace(fields[2]), 64)
+		duty, dutyErr := strconv.ParseFloat(strings.TrimSpace(fields[3]), 64)
+		if len(country) != 2 || category == "" || vatErr != nil || dutyErr != nil || vat < 0 || duty < 0 {
+			return fmt.Errorf("line %v: invalid rate %q", number, line)
+		}
+		if rates[country] == nil {
+			rates[country] = map[string]LandedCostRate{}
+		}
+		rates[country][category] = LandedCostRate{VAT: vat, Duty: duty}
+	}
+	return scanner.Err()
+}
+
+// dutyCategory Returns the duty category of an eBay category name
+func dutyCategory(categoryName string) string {
+	name := strings.ToLower(categoryName)
+	for _, candidate := range dutyCategories {
+		for _, word := range candidate.words {
+			if strings.Contains(name, word) {
+				return candidate.category
+			}
+		}
+	}
+	return "*"
+}
+
+// Lookup Returns the rate of the duty category of categoryName in country
+func (rates LandedCostRates) Lookup(country, categoryName string) (LandedCostRate, bool) {
+	byCategory, found := rates[country]
+	if !found {
+		return LandedCostRate{}, false
+	}
+	if rate, found := byCategory[dutyCategory(categoryName)]; found {
+		return rate, true
+	}
+	rate, found := byCategory["*"]
+	return rate, found
+}
+
+// estimateLandedCosts Sets the estimated landed cost of the items shipped into country from abroad.
+// Duty is charged on the price and VAT on the price plus duty. Items located in country,
+// priced in an unreadable way or headed to a country missing from rates get no estimate.
+func estimateLandedCosts(items []Item, country string, rates LandedCostRates) {
+	if country == "" {
+		return
+	}
+	for i := range items {
+		item := &items[i]
+		if item.Country == "" || strings.EqualFold(item.Country, country) {
+			continue
+		}
+		rate, found := rates.Lookup(country, item.Category)
+		if !found {
+			continue
+		}
+		price, err := strconv.ParseFloat(item.Price, 64)
+		if err != nil {
+			continue
+		}
+		landed := price * (1 + rate.Duty/100) * (1 + rate.VAT/100)
+		item.LandedCost = strconv.FormatFloat(landed, 'f', 2, 64)
+	}
+}
+
+// landedCostNote Describes the landed cost estimate of item for its price line
+func landedCostNote(item Item) string {
+	if item.LandedCost == "" {
+		return ""
+	}
+	return " (estimated " + item.LandedCost + " " + item.Currency + " with VAT and import duty)"
+}
+
+// countryCode Returns the code of a country typed by the user, or "" if it isn't understood
+func countryCode(country string) string {
+	country = strings.ToLower(strings.Join(strings.Fields(country), " "))
+	if code, found := countryNames[country]; found {
+		return code
+	}
+	if len(country) == 2 && country[0] >= 'a' && country[0] <= 'z' && country[1] >= 'a' && country[1] <= 'z' {
+		return strings.ToUpper(country)
+	}
+	return ""
+}
+
+// handleShipToCommand Answers "ship to <country>", reporting whether message was one.
+// The country outlives the search so every later search of the conversation follows it.
+func (s *Server) handleShipToCommand(session Session, message string, w http.ResponseWriter) bool {
+	match := shipToCommand.FindStringSubmatch(message)
+	if match == nil {
+		return false
+	}
+	code := countryCode(match[1])
+	if code == "" {
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "Sorry, I don't know the country " + match[1] + ", try its two letter code like DE.\n " + strings.TrimSpace(resumeSummary(session)),
+		})
+		return true
+	}
+	session["shipsTo"] = code
+
+	response := "OK, I'll only show items that ship to " + code + "."
+	if _, found := s.landedCosts[code]; found {
+		response = "OK, I'll only show items that ship to " + code + " and estimate their VAT and import duty."
+	}
+	WriteReply(w, ReplyInfo, JSON{
+		"message": response + "\n " + strings.TrimSpace(resumeSummary(session)),
+	})
+	return true
+}
@@ -0,0 +1,173 @@
+package theluxuryshopper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDutyCategory(t *testing.T) {
+	tests := []struct {
+		categoryName, want string
+	}{
+		{"Wristwatches", "watches"},
+		{"Fine Necklaces & Pendants", "jewelry"},
+		{"Women's Heels", "shoes"},
+		{"Women's Bags & Handbags", "bags"},
+		{"Men's Coats & Jackets", "clothing"},
+		{"Watch Bags", "watches"},
+		{"Sunglasses", "*"},
+		{"", "*"},
+	}
+	for _, test := range tests {
+		if got := dutyCategory(test.categoryName); got != test.want {
+			t.Errorf("dutyCategory(%q) = %q, want %q", test.categoryName, got, test.want)
+		}
+	}
+}
+
+func TestLandedCostRatesLookup(t *testing.T) {
+	rates := LandedCostRates{
+		"DE": {"*": {VAT: 19, Duty: 4}, "bags": {VAT: 19, Duty: 3}},
+		"CH": {"bags": {VAT: 8.1}},
+	}
+	tests := []struct {
+		country, categoryName string
+		want                  LandedCostRate
+		wantFound             bool
+	}{
+		{"DE", "Handbags", LandedCostRate{VAT: 19, Duty: 3}, true},
+		{"DE", "Sunglasses", LandedCostRate{VAT: 19, Duty: 4}, true},
+		{"CH", "Handbags", LandedCostRate{VAT: 8.1}, true},
+		{"CH", "Sunglasses", LandedCostRate{}, false},
+		{"US", "Handbags", LandedCostRate{}, false},
+	}
+	for _, test := range tests {
+		if got, found := rates.Lookup(test.country, test.categoryName); got != test.want || found != test.wantFound {
+			t.Errorf("Lookup(%q, %q) = %v, %v, want %v, %v", test.country, test.categoryName, got, found, test.want, test.wantFound)
+		}
+	}
+}
+
+func TestEstimateLandedCosts(t *testing.T) {
+	rates := LandedCostRates{"DE": {"*": {VAT: 20, Duty: 10}}}
+	tests := []struct {
+		name    string
+		item    Item
+		country string
+		want    string
+	}{
+		{"from abroad", Item{Price: "100", Country: "US"}, "DE", "132.00"},
+		{"located there", Item{Price: "100", Country: "de"}, "DE", ""},
+		{"unknown location", Item{Price: "100"}, "DE", ""},
+		{"unreadable price", Item{Price: "n/a", Country: "US"}, "DE", ""},
+		{"country without rates", Item{Price: "100", Country: "US"}, "CH", ""},
+		{"no country", Item{Price: "100", Country: "US"}, "", ""},
+	}
+	for _, test := range tests {
+		items := []Item{test.item}
+		estimateLandedCosts(items, test.country, rates)
+		if items[0].LandedCost != test.want {
+			t.Errorf("%v: the landed cost is %q, want %q", test.name, items[0].LandedCost, test.want)
+		}
+	}
+}
+
+func TestCountryCode(t *testing.T) {
+	tests := []struct {
+		country, want string
+	}{
+		{"Germany", "DE"},
+		{" united   Kingdom ", "GB"},
+		{"uk", "GB"},
+		{"ch", "CH"},
+		{"CH", "CH"},
+		{"narnia", ""},
+		{"c1", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := countryCode(test.country); got != test.want {
+			t.Errorf("countryCode(%q) = %q, want %q", test.country, got, test.want)
+		}
+	}
+}
+
+func TestReadLandedCostFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		wantErr  string
+		want     map[string]LandedCostRate // by country and category, "DE/bags"
+	}{
+		{
+			name:     "rates",
+			contents: "# country,category,vat,duty\n\nch, Bags ,8.1,0\nDE,bags,19,5\n",
+			want:     map[string]LandedCostRate{"CH/bags": {VAT: 8.1}, "DE/bags": {VAT: 19, Duty: 5}, "DE/shoes": {VAT: 19, Duty: 8}},
+		},
+		{name: "missing field", contents: "DE,bags,19\n", wantErr: "line 1: want country,category,vat,duty"},
+		{name: "negative", contents: "# ok\nDE,bags,19,-1\n", wantErr: "line 2: invalid rate"},
+		{name: "long country", contents: "DEU,bags,19,3\n", wantErr: "line 1: invalid rate"},
+		{name: "unreadable vat", contents: "DE,bags,19%,3\n", wantErr: "line 1: invalid rate"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rates.csv")
+			if err := os.WriteFile(path, []byte(test.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			rates := defaultLandedCostRates()
+			err := readLandedCostFile(path, rates)
+			if test.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), test.wantErr) {
+					t.Errorf("readLandedCostFile() failed with %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, want := range test.want {
+				country, category, _ := strings.Cut(key, "/")
+				if got := rates[country][category]; got != want {
+					t.Errorf("the rate of %v is %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+	if err := readLandedCostFile(filepath.Join(t.TempDir(), "missing.csv"), LandedCostRates{}); !os.IsNotExist(err) {
+		t.Errorf("readLandedCostFile() of a missing file failed with %v", err)
+	}
+}
+
+func TestShipTo(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: []Item{
+		{ID: "1", Title: "Kelly", Price: "100", Currency: "EUR", Country: "FR", Category: "Handbags"},
+		{ID: "2", Title: "Birkin", Price: "100", Currency: "EUR", Country: "DE", Category: "Handbags"},
+	}}}}
+	s := newTestServer(t, Config{}, searcher)
+	c := startConversation(t, s)
+
+	tests := []struct {
+		message, wantPrefix string
+	}{
+		{"ship to atlantis", "Sorry, I don't know the country atlantis, try its two letter code like DE."},
+		{"ship to CH", "OK, I'll only show items that ship to CH.\n"},
+		{"deliver to germany", "OK, I'll only show items that ship to DE and estimate their VAT and import duty.\n"},
+	}
+	for _, test := range tests {
+		if reply := c.say(test.message); !strings.HasPrefix(reply.message(), test.wantPrefix) {
+			t.Errorf("%q answered %q, want it to start with %q", test.message, reply.message(), test.wantPrefix)
+		}
+	}
+
+	reply := c.sayAll("kelly bag", "none", "none", "none")
+	if queries := searcher.Queries(); queries[len(queries)-1].ShipsTo != "DE" {
+		t.Errorf("the search ships to %q, want DE", queries[len(queries)-1].ShipsTo)
+	}
+	// A bag from France pays the 3% duty and the 19% German VAT, the German one nothing
+	if !strings.Contains(reply.message(), "(estimated 122.57 EUR with VAT and import duty)") || strings.Count(reply.message(), "estimated") != 1 {
+		t.Errorf("the results are %q", reply.message())
+	}
+}
@@ -0,0 +1,188 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// countryLabels Names the countries items are commonly located in, by their ISO code.
+// Their lower case names are understood by "ship to", "only from" and "exclude" too.
+var countryLabels = map[string]string{
+	"AE": "United Arab Emirates", "AT": "Austria", "AU": "Australia", "BE": "Belgium", "BR": "Brazil",
+	"CA": "Canada", "CH": "Switzerland", "CN": "China", "CZ": "Czech Republic", "DE": "Germany",
+	"DK": "Denmark", "ES": "Spain", "FI": "Finland", "FR": "France", "GB": "United Kingdom",
+	"GR": "Greece", "HK": "Hong Kong", "HU": "Hungary", "IE": "Ireland", "IL": "Israel",
+	"IN": "India", "IT": "Italy", "JP": "Japan", "KR": "South Korea", "LU": "Luxembourg",
+	"MX": "Mexico", "MY": "Malaysia", "NL": "Netherlands", "NO": "Norway", "NZ": "New Zealand",
+	"PL": "Poland", "PT": "Portugal", "RO": "Romania", "SA": "Saudi Arabia", "SE": "Sweden",
+	"SG": "Singapore", "TH": "Thailand", "TR": "Turkey", "TW": "Taiwan", "US": "United States",
+}
+
+func init() {
+	for code, label := range countryLabels {
+		if _, found := countryNames[strings.ToLower(label)]; !found {
+			countryNames[strings.ToLower(label)] = code
+		}
+	}
+	countryNames["korea"] = "KR"
+	countryNames["uae"] = "AE"
+}
+
+var (
+	onlyFromCommand    = regexp.MustCompile(`(?i)^\s*(?:only|just)\s+(?:items\s+|ones\s+)?(?:from|located\s+in)\s+(.+?)\W*$`)
+	excludeFromCommand = regexp.MustCompile(`(?i)^\s*(?:exclude|(?:nothing|no\s+items|not)\s+from)\s+(?:items\s+from\s+)?(.+?)\W*$`)
+	anyLocationCommand = regexp.MustCompile(`(?i)^\s*(?:from\s+)?any\s*(?:where|location|country)\W*$`)
+)
+
+// countryLabel Returns the name of the country code, or the code itself when it has none
+func countryLabel(code string) string {
+	if label, found := countryLabels[code]; found {
+		return label
+	}
+	return code
+}
+
+// countryFlag Returns the flag emoji of a two letter country code, or "" for anything else
+func countryFlag(code string) string {
+	if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return ""
+	}
+	return string([]rune{0x1F1E6 + rune(code[0]-'A'), 0x1F1E6 + rune(code[1]-'A')})
+}
+
+// shipsFrom Describes where item is located, like "🇮🇹 Italy (Milano)", or "" when eBay didn't say
+func shipsFrom(item Item) string {
+	if item.Country == "" {
+		return ""
+	}
+	label := countryLabel(item.Country)
+	described := label
+	if flag := countryFlag(item.Country); flag != "" {
+		described = flag + " " + label
+	}
+	// The location is "City,Country" or "City,Region,Country", only the city is new
+	if parts := strings.Split(item.Location, ","); len(parts) > 1 {
+		if city := strings.TrimSpace(parts[0]); city != "" && !strings.EqualFold(city, label) {
+			described += " (" + city + ")"
+		}
+	}
+	return described
+}
+
+// locatedAllowed Reports whether item is located where query allows.
+// Items without a country are kept by exclusions but dropped by "only from", which they can't prove.
+func locatedAllowed(item Item, query SearchQuery) bool {
+	if query.LocatedIn != "" && item.Country != query.LocatedIn {
+		return false
+	}
+	for _, excluded := range query.ExcludeCountries {
+		if item.Country == excluded {
+			return false
+		}
+	}
+	return true
+}
+
+// withLocations Drops the items of result located where query doesn't allow.
+// eBay filters LocatedIn itself, but the Finding API has no filter excluding a country, so
+// ExcludeCountries is only filtered here: the upstream query is left as it is, and a page can
+// come back short, or empty, while later pages still hold items from elsewhere.
+func withLocations(result SearchResult, query SearchQuery) SearchResult {
+	if query.LocatedIn == "" && len(query.ExcludeCountries) == 0 {
+		return result
+	}
+	kept := result.Items[:0:0]
+	for _, item := range result.Items {
+		if locatedAllowed(item, query) {
+			kept = append(kept, item)
+		}
+	}
+	result.Items = kept
+	result.Count = len(kept)
+	return result
+}
+
+// handleLocationCommand Answers "only from <country>", "exclude <country>" and "from anywhere", reporting whether
+// message was one. The choice outlives the search; the items listed last are filtered right away and the
+// pages fetched later are searched with it, exclusions being filtered locally, see withLocations.
+func handleLocationCommand(session Session, message string, w http.ResponseWriter) bool {
+	var response string
+	switch only, exclude := onlyFromCommand.FindStringSubmatch(message), excludeFromCommand.FindStringSubmatch(message); {
+	case anyLocationCommand.MatchString(message):
+		delete(session, "locatedIn")
+		delete(session, "excludedCountries")
+		// Dropped items aren't fetched again, only the next pages are searched everywhere
+		if list := listedResults(session); list != nil {
+			list.Query.LocatedIn, list.Query.ExcludeCountries = "", nil
+		}
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "OK, items from any country are back in.\n " + strings.TrimSpace(resumeSummary(session)),
+		})
+		return true
+	case only != nil:
+		code := countryCode(only[1])
+		if code == "" {
+			WriteReply(w, ReplyInfo, JSON{"message": "Sorry, I don't know the country " + only[1] + ", try its two letter code like JP."})
+			return true
+		}
+		session["locatedIn"] = code
+		response = "OK, I'll only show items located in " + countryLabel(code) + "."
+	case exclude != nil:
+		code := countryCode(exclude[1])
+		if code == "" {
+			WriteReply(w, ReplyInfo, JSON{"message": "Sorry, I don't know the country " + exclude[1] + ", try its two letter code like CN."})
+			return true
+		}
+		excluded, _ := session["excludedCountries"].([]string)
+		if !locatedAllowed(Item{Country: code}, SearchQuery{ExcludeCountries: excluded}) {
+			WriteReply(w, ReplyInfo, JSON{"message": "Items located in " + countryLabel(code) + " are already left out."})
+			return true
+		}
+		// A new slice, the query of the listed results may still share the old one
+		session["excludedCountries"] = append(append([]string{}, excluded...), code)
+		response = "OK, I'll leave out items located in " + countryLabel(code) + "."
+	default:
+		return false
+	}
+
+	list := listedResults(session)
+	if _, searching := session["searchByKeyword"]; searching || list == nil || len(list.Items) == 0 {
+		WriteReply(w, ReplyInfo, JSON{
+			"message": response + "\n " + strings.TrimSpace(resumeSummary(session)),
+		})
+		return true
+	}
+
+	// Filter the items shown last, keeping their numbers, and search the next pages with the new locations
+	list.Query.LocatedIn, _ = session["locatedIn"].(string)
+	list.Query.ExcludeCountries, _ = session["excludedCountries"].([]string)
+	numbers := make([]int, 0, len(list.Items))
+	for number, item := range list.Items {
+		if locatedAllowed(item, list.Query) {
+			numbers = append(numbers, number)
+		} else {
+			delete(list.Items, number)
+		}
+	}
+	sort.Ints(numbers)
+	var listing strings.Builder
+	kept := make([]Item, 0, len(numbers))
+	for _, number := range numbers {
+		kept = append(kept, list.Items[number])
+		writeNumberedItems(&listing, []Item{list.Items[number]}, number, requestView(w))
+	}
+	if len(kept) == 0 {
+		response += " None of the items listed last are left, say 'more' to search the next page this way."
+	} else {
+		response += " Of the items listed last these are left:\n" + listing.String()
+	}
+	WriteReply(w, resultsType(kept), JSON{
+		"message": response + "\n What else would you like to search for?",
+		"items":   kept,
+		"numbers": numbers,
+		"query":   list.Query,
+	})
+	return true
+}
@@ -0,0 +1,135 @@
+package theluxuryshopper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCountryFlag(t *testing.T) {
+	tests := []struct {
+		code, want string
+	}{
+		{"IT", "🇮🇹"},
+		{"US", "🇺🇸"},
+		{"it", ""},
+		{"ITA", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := countryFlag(test.code); got != test.want {
+			t.Errorf("countryFlag(%q) = %q, want %q", test.code, got, test.want)
+		}
+	}
+}
+
+func TestShipsFrom(t *testing.T) {
+	tests := []struct {
+		item Item
+		want string
+	}{
+		{Item{Country: "IT", Location: "Milano,Italy"}, "🇮🇹 Italy (Milano)"},
+		{Item{Country: "US", Location: "Austin,TX,USA"}, "🇺🇸 United States (Austin)"},
+		{Item{Country: "HK", Location: "Hong Kong,Hong Kong"}, "🇭🇰 Hong Kong"},
+		{Item{Country: "IT", Location: "Italy"}, "🇮🇹 Italy"},
+		{Item{Country: "XK"}, "🇽🇰 XK"},
+		{Item{Location: "Milano,Italy"}, ""},
+	}
+	for _, test := range tests {
+		if got := shipsFrom(test.item); got != test.want {
+			t.Errorf("shipsFrom(%+v) = %q, want %q", test.item, got, test.want)
+		}
+	}
+}
+
+func TestWithLocations(t *testing.T) {
+	items := []Item{{ID: "it", Country: "IT"}, {ID: "cn", Country: "CN"}, {ID: "unknown"}}
+	tests := []struct {
+		name  string
+		query SearchQuery
+		want  []string
+	}{
+		{"everywhere", SearchQuery{}, []string{"it", "cn", "unknown"}},
+		{"only from", SearchQuery{LocatedIn: "IT"}, []string{"it"}},
+		{"exclude", SearchQuery{ExcludeCountries: []string{"CN"}}, []string{"it", "unknown"}},
+		{"both", SearchQuery{LocatedIn: "CN", ExcludeCountries: []string{"CN"}}, []string{}},
+	}
+	for _, test := range tests {
+		result := withLocations(SearchResult{Items: append([]Item{}, items...), Count: len(items)}, test.query)
+		got := []string{}
+		for _, item := range result.Items {
+			got = append(got, item.ID)
+		}
+		if !reflect.DeepEqual(got, test.want) || result.Count != len(test.want) {
+			t.Errorf("%v: withLocations() kept %v (count %v), want %v", test.name, got, result.Count, test.want)
+		}
+	}
+}
+
+func TestLocationCommands(t *testing.T) {
+	tests := []struct {
+		message               string
+		wantOnly, wantExclude string
+		wantAny               bool
+	}{
+		{"only from italy", "italy", "", false},
+		{"just items located in Japan!", "Japan", "", false},
+		{"exclude china", "", "china", false},
+		{"nothing from CN", "", "CN", false},
+		{"not from items from hong kong", "", "hong kong", false},
+		{"from anywhere", "", "", true},
+		{"any country", "", "", true},
+		{"italy", "", "", false},
+	}
+	for _, test := range tests {
+		var only, exclude string
+		if match := onlyFromCommand.FindStringSubmatch(test.message); match != nil {
+			only = match[1]
+		}
+		if match := excludeFromCommand.FindStringSubmatch(test.message); match != nil {
+			exclude = match[1]
+		}
+		if only != test.wantOnly || exclude != test.wantExclude || anyLocationCommand.MatchString(test.message) != test.wantAny {
+			t.Errorf("%q matches only %q, exclude %q, any %v", test.message, only, exclude, anyLocationCommand.MatchString(test.message))
+		}
+	}
+}
+
+func TestHandleLocationCommand(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: []Item{
+		{ID: "1", Title: "Kelly", Price: "100", Currency: "USD", Country: "IT", Location: "Milano,Italy"},
+		{ID: "2", Title: "Birkin", Price: "200", Currency: "USD", Country: "CN"},
+		{ID: "3", Title: "Evelyne", Price: "300", Currency: "USD", Country: "US"},
+	}}}}
+	s := newTestServer(t, Config{}, searcher)
+	c := startConversation(t, s)
+	c.sayAll("hermes bag", "none", "none", "none")
+
+	tests := []struct {
+		message, wantPrefix string
+		wantItems           []string
+	}{
+		{"only from narnia", "Sorry, I don't know the country narnia, try its two letter code like JP.", nil},
+		{"exclude china", "OK, I'll leave out items located in China. Of the items listed last these are left:\n", []string{"1", "3"}},
+		{"not from CN", "Items located in China are already left out.", nil},
+		{"only from italy", "OK, I'll only show items located in Italy. Of the items listed last these are left:\n", []string{"1"}},
+		{"only from japan", "OK, I'll only show items located in Japan. None of the items listed last are left", []string{}},
+		{"from anywhere", "OK, items from any country are back in.", nil},
+	}
+	for _, test := range tests {
+		reply := c.say(test.message)
+		if !strings.HasPrefix(reply.message(), test.wantPrefix) {
+			t.Errorf("%q answered %q, want it to start with %q", test.message, reply.message(), test.wantPrefix)
+		}
+		if got := reply.itemIDs(); test.wantItems != nil && !reflect.DeepEqual(got, test.wantItems) {
+			t.Errorf("%q answered the items %v, want %v", test.message, got, test.wantItems)
+		}
+	}
+
+	c.say("exclude china")
+	c.sayAll("new search", "kelly bag", "none", "none", "none")
+	queries := searcher.Queries()
+	if last := queries[len(queries)-1]; last.LocatedIn != "" || !reflect.DeepEqual(last.ExcludeCountries, []string{"CN"}) {
+		t.Errorf("the next search is located in %q excluding %v, want everywhere but CN", last.LocatedIn, last.ExcludeCountries)
+	}
+}
@@ -0,0 +1,96 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxLotSize Bounds the counts read from titles, bigger numbers are model numbers or years
+const maxLotSize = 500
+
+var (
+	// lotCountPatterns Capture the count of a lot in a title, like "lot of 10", "10-pack" or "12 pcs"
+	lotCountPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(?:lot|pack|set|bundle|box|case)\s+of\s+(\d+)\b`),
+		regexp.MustCompile(`(?i)\b(\d+)\s*[- ]?\s*(?:pack|pk|pcs|pieces|piece lot|units|count|ct)\b`),
+		regexp.MustCompile(`(?i)\b(\d+)\s*[- ]?\s*(?:x\s+)?lot\b`),
+		regexp.MustCompile(`(?i)\bx\s?(\d+)\s*(?:pcs|pieces|units)?$`),
+	}
+	// lotPattern Tells that a title is a lot even without a readable count
+	lotPattern = regexp.MustCompile(`(?i)\b(?:wholesale|bulk|job\s+lot|lot\s+of|reseller\s+lot)\b`)
+)
+
+// excludeLotsCommand and includeLotsCommand Toggle leaving lots out of the results
+var (
+	excludeLotsCommand = regexp.MustCompile(`(?i)^\s*(?:exclude|no|hide|without)\s+(?:lots|bulk|wholesale)\s*$`)
+	includeLotsCommand = regexp.MustCompile(`(?i)^\s*(?:include|show)\s+(?:lots|bulk|wholesale)\s*$`)
+)
+
+// lotSize Guesses from the eBay lot quantity and the title whether an item is a lot, and of how many.
+// size is 0 when the count isn't known.
+func lotSize(quantity int, title string) (lot bool, size int) {
+	if quantity > 1 {
+		return true, quantity
+	}
+	for _, pattern := range lotCountPatterns {
+		match := pattern.FindStringSubmatch(title)
+		if match == nil {
+			continue
+		}
+		if n, err := strconv.Atoi(match[1]); err == nil && n > 1 && n <= maxLotSize {
+			return true, n
+		}
+	}
+	return lotPattern.MatchString(title), 0
+}
+
+// lotNote Describes the lot of item for its price line, like " (lot of 10 — approx. 45 USD each)"
+func lotNote(item Item) string {
+	if !item.Lot {
+		return ""
+	}
+	if item.LotSize == 0 {
+		return " (sold as a lot)"
+	}
+	note := " (lot of " + strconv.Itoa(item.LotSize)
+	if price, err := strconv.ParseFloat(item.Price, 64); err == nil {
+		each := strings.TrimSuffix(strconv.FormatFloat(price/float64(item.LotSize), 'f', 2, 64), ".00")
+		note += " — approx. " + each + " " + item.Currency + " each"
+	}
+	return note + ")"
+}
+
+// withoutLots Returns the items of result that aren't lots
+func withoutLots(result SearchResult) SearchResult {
+	kept := result.Items[:0:0]
+	for _, item := range result.Items {
+		if !item.Lot {
+			kept = append(kept, item)
+		}
+	}
+	result.Items = kept
+	result.Count = len(kept)
+	return result
+}
+
+// handleLotsCommand Answers "exclude lots" and "include lots", reporting whether message was one.
+// The choice outlives the search so every later search of the conversation follows it.
+func handleLotsCommand(session Session, message string, w http.ResponseWriter) bool {
+	var response string
+	switch {
+	case excludeLotsCommand.MatchString(message):
+		session["excludeLots"] = true
+		response = "OK, I'll leave out lots and bulk listings."
+	case includeLotsCommand.MatchString(message):
+		delete(session, "excludeLots")
+		response = "OK, lots and bulk listings are back in."
+	default:
+		return false
+	}
+	WriteReply(w, ReplyInfo, JSON{
+		"message": response + "\n " + strings.TrimSpace(resumeSummary(session)),
+	})
+	return true
+}
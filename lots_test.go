@@ -0,0 +1,84 @@
+package theluxuryshopper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLotSize(t *testing.T) {
+	tests := []struct {
+		quantity int
+		title    string
+		wantLot  bool
+		wantSize int
+	}{
+		{1, "Hermes Kelly 28 Gold", false, 0},
+		{12, "Hermes Twilly", true, 12},
+		{1, "Lot of 10 Gucci dust bags", true, 10},
+		{1, "Chanel ribbon 5-pack", true, 5},
+		{1, "Dior pins 12 pcs", true, 12},
+		{1, "Vintage scarves 3 x lot", true, 3},
+		{1, "Louis Vuitton charms x4", true, 4},
+		{1, "Wholesale designer sunglasses", true, 0},
+		{1, "Job lot of 1000 watch boxes", true, 0},
+		{1, "Rolex Submariner 16610 set of 2000", false, 0},
+		{1, "Rolex 1 pcs", false, 0},
+		{1, "Cartier Love bracelet size 17", false, 0},
+	}
+	for _, test := range tests {
+		if lot, size := lotSize(test.quantity, test.title); lot != test.wantLot || size != test.wantSize {
+			t.Errorf("lotSize(%v, %q) = %v, %v, want %v, %v", test.quantity, test.title, lot, size, test.wantLot, test.wantSize)
+		}
+	}
+}
+
+func TestLotNote(t *testing.T) {
+	tests := []struct {
+		item Item
+		want string
+	}{
+		{Item{Price: "450", Currency: "USD"}, ""},
+		{Item{Lot: true, Price: "450", Currency: "USD"}, " (sold as a lot)"},
+		{Item{Lot: true, LotSize: 10, Price: "450", Currency: "USD"}, " (lot of 10 — approx. 45 USD each)"},
+		{Item{Lot: true, LotSize: 3, Price: "100", Currency: "EUR"}, " (lot of 3 — approx. 33.33 EUR each)"},
+		{Item{Lot: true, LotSize: 3, Price: "n/a", Currency: "EUR"}, " (lot of 3)"},
+	}
+	for _, test := range tests {
+		if got := lotNote(test.item); got != test.want {
+			t.Errorf("lotNote(%+v) = %q, want %q", test.item, got, test.want)
+		}
+	}
+}
+
+func TestHandleLotsCommand(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: []Item{
+		{ID: "1", Title: "Twilly", Price: "100", Currency: "USD"},
+		{ID: "2", Title: "Twilly lot of 5", Price: "400", Currency: "USD", Lot: true, LotSize: 5},
+	}, Count: 2}}}
+	s := newTestServer(t, Config{}, searcher)
+	c := startConversation(t, s)
+
+	tests := []struct {
+		message, wantPrefix string
+		wantExcluded        bool
+		wantItems           []string
+	}{
+		{"no lots", "OK, I'll leave out lots and bulk listings.\n", true, []string{"1"}},
+		{"show wholesale", "OK, lots and bulk listings are back in.\n", false, []string{"1", "2"}},
+		{"hide bulk", "OK, I'll leave out lots and bulk listings.\n", true, []string{"1"}},
+	}
+	for _, test := range tests {
+		if reply := c.say(test.message); !strings.HasPrefix(reply.message(), test.wantPrefix) {
+			t.Errorf("%q answered %q, want it to start with %q", test.message, reply.message(), test.wantPrefix)
+		}
+		reply := c.sayAll("twilly", "none", "none", "none")
+		queries := searcher.Queries()
+		if last := queries[len(queries)-1]; last.ExcludeLots != test.wantExcluded {
+			t.Errorf("after %q the search excludes lots: %v, want %v", test.message, last.ExcludeLots, test.wantExcluded)
+		}
+		if got := reply.itemIDs(); !reflect.DeepEqual(got, test.wantItems) {
+			t.Errorf("after %q the results are %v, want %v", test.message, got, test.wantItems)
+		}
+	}
+}
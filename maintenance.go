@@ -0,0 +1,122 @@
+package theluxuryshopper
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// defaultMaintenanceMessage Is what /welcome and /chat answer during maintenance unless MAINTENANCE_MESSAGE is set
+	defaultMaintenanceMessage = "We are doing some maintenance right now, please come back in a few minutes. Your conversation will be waiting."
+	// defaultMaintenanceRetryAfter Is the Retry-After of the maintenance answers unless MAINTENANCE_RETRY_AFTER is set
+	defaultMaintenanceRetryAfter = 5 * time.Minute
+)
+
+// maintenanceMode Is the switch POST /admin/maintenance flips, shared by every bot. While it is on,
+// /welcome and /chat answer 503 and leave the sessions as they are.
+type maintenanceMode struct {
+	mu         sync.Mutex
+	enabled    bool
+	message    string
+	retryAfter time.Duration
+	since      time.Time
+	toggles    int64 // the times the mode changed since the start
+	refused    int64 // the requests answered with the maintenance message
+}
+
+// newMaintenanceMode Creates the switch, on from the start when enabled. An empty message and a
+// retryAfter of 0 take the defaults.
+func newMaintenanceMode(enabled bool, message string, retryAfter time.Duration) *maintenanceMode {
+	if message == "" {
+		message = defaultMaintenanceMessage
+	}
+	if retryAfter <= 0 {
+		retryAfter = defaultMaintenanceRetryAfter
+	}
+	m := &maintenanceMode{enabled: enabled, message: message, retryAfter: retryAfter}
+	if enabled {
+		m.since = time.Now()
+	}
+	return m
+}
+
+// Set Turns the mode on or off, an empty message keeps the current one
+func (m *maintenanceMode) Set(enabled bool, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if message != "" {
+		m.message = message
+	}
+	if m.enabled == enabled {
+		return
+	}
+	m.enabled, m.since = enabled, time.Now()
+	m.toggles++
+}
+
+// refuse Reports whether the mode is on, counting the request refused and returning the message
+// with how long clients should wait
+func (m *maintenanceMode) refuse() (string, time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.enabled {
+		return "", 0, false
+	}
+	m.refused++
+	return m.message, m.retryAfter, true
+}
+
+// Snapshot Describes the mode for /healthz and the admin endpoint
+func (m *maintenanceMode) Snapshot() JSON {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := JSON{"enabled": m.enabled, "toggles": m.toggles, "refused": m.refused}
+	if !m.since.IsZero() {
+		snapshot["since"] = m.since.UTC().Format(time.RFC3339)
+	}
+	return snapshot
+}
+
+// refuseInMaintenance Answers 503 with the maintenance message while the mode is on, reporting whether it did
+func (s *Server) refuseInMaintenance(w http.ResponseWriter) bool {
+	message, retryAfter, refused := s.maintenance.refuse()
+	if !refused {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	writeError(w, http.StatusServiceUnavailable, "maintenance", message)
+	return true
+}
+
+// maintenanceRequest Is the body of POST /admin/maintenance
+type maintenanceRequest struct {
+	Enabled *bool  `json:"enabled"`
+	Message string `json:"message"`
+}
+
+// handleMaintenance Handles POST /admin/maintenance, turning the maintenance mode on or off for an admin
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if !s.authorizeAdmin(w, r, "Maintenance controls") {
+		return
+	}
+	var request maintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Enabled == nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", `The body must be like {"enabled": true, "message": "Back at 10:00."}, the message being optional.`)
+		return
+	}
+	s.maintenance.Set(*request.Enabled, strings.TrimSpace(request.Message))
+	if *request.Enabled {
+		log.Printf("maintenance mode on, /welcome and /chat answer 503")
+	} else {
+		log.Printf("maintenance mode off")
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, s.maintenance.Snapshot())
+}
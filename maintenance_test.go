@@ -0,0 +1,92 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewMaintenanceMode(t *testing.T) {
+	tests := []struct {
+		name           string
+		enabled        bool
+		message        string
+		retryAfter     time.Duration
+		wantMessage    string
+		wantRetryAfter time.Duration
+	}{
+		{"defaults", true, "", 0, defaultMaintenanceMessage, defaultMaintenanceRetryAfter},
+		{"configured", true, "Back at 10:00.", time.Minute, "Back at 10:00.", time.Minute},
+		{"negative retry", true, "", -time.Second, defaultMaintenanceMessage, defaultMaintenanceRetryAfter},
+		{"off", false, "Back at 10:00.", time.Minute, "", 0},
+	}
+	for _, test := range tests {
+		m := newMaintenanceMode(test.enabled, test.message, test.retryAfter)
+		message, retryAfter, refused := m.refuse()
+		if message != test.wantMessage || retryAfter != test.wantRetryAfter || refused != test.enabled {
+			t.Errorf("%v: refuse() = %q, %v, %v, want %q, %v, %v", test.name, message, retryAfter, refused, test.wantMessage, test.wantRetryAfter, test.enabled)
+		}
+		if _, found := m.Snapshot()["since"]; found != test.enabled {
+			t.Errorf("%v: the snapshot has a since: %v, want %v", test.name, found, test.enabled)
+		}
+	}
+}
+
+func TestMaintenanceModeSet(t *testing.T) {
+	m := newMaintenanceMode(false, "", 0)
+	m.Set(true, "")
+	m.Set(true, "Back at 10:00.")
+	if message, _, refused := m.refuse(); !refused || message != "Back at 10:00." {
+		t.Errorf("refuse() = %q, %v after turning the mode on", message, refused)
+	}
+	m.Set(false, "")
+	m.refuse()
+	if snapshot := m.Snapshot(); snapshot["enabled"] != false || snapshot["toggles"] != int64(2) || snapshot["refused"] != int64(1) {
+		t.Errorf("Snapshot() = %v, want disabled after 2 toggles and 1 refusal", snapshot)
+	}
+}
+
+func TestHandleMaintenance(t *testing.T) {
+	s := newTestServer(t, Config{AdminToken: "secret", Maintenance: true, MaintenanceRetryAfter: 90 * time.Second}, &fakeSearcher{})
+	handler := s.Routes()
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+
+	reply := do(t, handler, http.MethodGet, "/v1/welcome", "", nil)
+	if reply.Status != http.StatusServiceUnavailable || reply.errorCode() != "maintenance" || reply.Header.Get("Retry-After") != "90" {
+		t.Errorf("/v1/welcome in maintenance answered %v %v, Retry-After %q", reply.Status, reply.Raw, reply.Header.Get("Retry-After"))
+	}
+
+	refusals := []struct {
+		body   string
+		header http.Header
+		status int
+	}{
+		{`{"enabled": false}`, nil, http.StatusUnauthorized},
+		{`{"enabled": false}`, http.Header{"Authorization": {"Bearer wrong"}}, http.StatusUnauthorized},
+		{`{"message": "Back soon."}`, admin, http.StatusBadRequest},
+		{`{"enabled": "no"}`, admin, http.StatusBadRequest},
+	}
+	for _, test := range refusals {
+		if reply := do(t, handler, http.MethodPost, "/admin/maintenance", test.body, test.header); reply.Status != test.status {
+			t.Errorf("POST /admin/maintenance %v answered %v %v, want %v", test.body, reply.Status, reply.Raw, test.status)
+		}
+	}
+
+	if reply := do(t, handler, http.MethodPost, "/admin/maintenance", `{"enabled": false}`, admin); reply.Status != http.StatusOK || reply.Body["enabled"] != false {
+		t.Fatalf("turning the maintenance off answered %v %v", reply.Status, reply.Raw)
+	}
+	c := startConversation(t, s)
+	c.say("gucci belt")
+
+	if reply := do(t, handler, http.MethodPost, "/admin/maintenance", `{"enabled": true, "message": " Back at 10:00. "}`, admin); reply.Body["enabled"] != true || reply.Body["toggles"] != float64(2) {
+		t.Errorf("turning the maintenance on answered %v", reply.Raw)
+	}
+	if reply := c.say("used"); reply.Status != http.StatusServiceUnavailable || !strings.Contains(reply.Raw, "Back at 10:00.") {
+		t.Errorf("/v1/chat in maintenance answered %v %v", reply.Status, reply.Raw)
+	}
+	do(t, handler, http.MethodPost, "/admin/maintenance", `{"enabled": false}`, admin)
+	if reply := c.say("used"); !strings.HasPrefix(reply.message(), "Please specify the minimum price") {
+		t.Errorf("the conversation didn't survive the maintenance, it answered %q", reply.message())
+	}
+}
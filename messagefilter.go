@@ -0,0 +1,48 @@
+package theluxuryshopper
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// MessageFilter Reviews a chat message before any processing, returning the message to process
+// and whether it may be processed at all
+type MessageFilter func(message string) (string, bool)
+
+// htmlTag Matches an HTML tag or comment
+var htmlTag = regexp.MustCompile(`<!--[\s\S]*?-->|</?[a-zA-Z][^>]*>`)
+
+// offTopicMessage Answers the messages a filter refused
+const offTopicMessage = "Let's keep it about shopping."
+
+// sanitizeMessage Strips HTML tags and control characters from message so they are never echoed back
+func sanitizeMessage(message string) string {
+	message = htmlTag.ReplaceAllString(message, " ")
+	message = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, message)
+	return strings.TrimSpace(message)
+}
+
+// WithMessageFilter Consults filter for every chat message after the sanitation and the profanity list
+func WithMessageFilter(filter MessageFilter) ServerOption {
+	return func(s *Server) {
+		s.messageFilter = filter
+	}
+}
+
+// filterMessage Sanitizes message and checks it against the profanity list and the MessageFilter hook
+func (s *Server) filterMessage(message string) (string, bool) {
+	message = sanitizeMessage(message)
+	if _, found := s.profanity.Match(message); found {
+		return "", false
+	}
+	if s.messageFilter != nil {
+		return s.messageFilter(message)
+	}
+	return message, true
+}
@@ -0,0 +1,63 @@
+package theluxuryshopper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeMessage(t *testing.T) {
+	tests := []struct {
+		message, want string
+	}{
+		{"gucci belt", "gucci belt"},
+		{"<b>gucci</b> belt", "gucci  belt"},
+		{"gucci<script>alert(1)</script>", "gucci alert(1)"},
+		{"gucci <!-- a\ncomment --> belt", "gucci   belt"},
+		{"gucci\x00belt\r\n", "gucci belt"},
+		{"price < 500 and > 100", "price < 500 and > 100"},
+		{"  <br/>  ", ""},
+	}
+	for _, test := range tests {
+		if got := sanitizeMessage(test.message); got != test.want {
+			t.Errorf("sanitizeMessage(%q) = %q, want %q", test.message, got, test.want)
+		}
+	}
+}
+
+func TestFilterMessage(t *testing.T) {
+	var reviewed []string
+	filter := func(message string) (string, bool) {
+		reviewed = append(reviewed, message)
+		if strings.Contains(message, "weather") {
+			return "", false
+		}
+		return strings.Replace(message, "LV", "louis vuitton", 1), true
+	}
+	s := newTestServer(t, Config{ProfanityList: []string{"darn"}}, &fakeSearcher{}, WithMessageFilter(filter))
+
+	tests := []struct {
+		message, want string
+		wantAllowed   bool
+	}{
+		{"<i>LV</i> bag", "louis vuitton  bag", true},
+		{"darn bag", "", false},
+		{"what's the weather", "", false},
+	}
+	for _, test := range tests {
+		if got, allowed := s.filterMessage(test.message); got != test.want || allowed != test.wantAllowed {
+			t.Errorf("filterMessage(%q) = %q, %v, want %q, %v", test.message, got, allowed, test.want, test.wantAllowed)
+		}
+	}
+	// The profanity list answers before the hook is consulted
+	if len(reviewed) != 2 {
+		t.Errorf("the filter reviewed %q, want the messages past the profanity list", reviewed)
+	}
+
+	c := startConversation(t, s)
+	if reply := c.say("what's the weather"); !strings.HasPrefix(reply.message(), offTopicMessage+"\n") {
+		t.Errorf("a refused message answered %q", reply.message())
+	}
+	if reply := c.say("gucci belt"); !strings.HasPrefix(reply.message(), "Please specify the condition") {
+		t.Errorf("the refused message moved the conversation, the next one answered %q", reply.message())
+	}
+}
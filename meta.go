@@ -0,0 +1,200 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// metaWriter Collects what writeJSON adds to the keys of one response: the debug meta block,
+// the expiry of the session and the recap of an idle session
+type metaWriter struct {
+	http.ResponseWriter
+	ctx     context.Context
+	start   time.Time
+	enabled bool
+	stats   SearchStats
+	noted   bool
+
+	expiresAt time.Time
+	recap     string
+	location  *time.Location // nil until the session sets a timezone
+	display   displayPreference
+	plainText bool                   // set by withPlainText
+	locale    string                 // of the session, "" until /welcome is sent one
+	progress  func(step string) JSON // set by noteProgress
+	rejected  bool                   // set by answerRejected
+}
+
+// withMeta Wraps w to collect the meta block, which is only written when r asks for it
+// with ?meta=1 or an X-Debug-Meta header
+func withMeta(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+	enabled := r.URL.Query().Get("meta") == "1"
+	if header := r.Header.Get("X-Debug-Meta"); header != "" && header != "0" && header != "false" {
+		enabled = true
+	}
+	return &metaWriter{ResponseWriter: w, ctx: r.Context(), start: time.Now(), enabled: enabled}
+}
+
+// requestContext Returns the context of the request w answers.
+// Processors only get the ResponseWriter, so the context rides along with it.
+func requestContext(w http.ResponseWriter) context.Context {
+	if mw, ok := w.(*metaWriter); ok {
+		return mw.ctx
+	}
+	return context.Background()
+}
+
+// enableMeta Writes the meta block of w whatever the request asked for
+func enableMeta(w http.ResponseWriter) {
+	if mw, ok := w.(*metaWriter); ok {
+		mw.enabled = true
+	}
+}
+
+// Flush Lets streaming responses flush through the wrapper
+func (w *metaWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// noteSession Adds the expiry of the session to the response of w,
+// with recap in front of its message when it isn't empty
+func noteSession(w http.ResponseWriter, expiresAt time.Time, recap string) {
+	if mw, ok := w.(*metaWriter); ok {
+		mw.expiresAt = expiresAt
+		mw.recap = recap
+	}
+}
+
+// addRecap Adds text to the recap in front of the message of the response of w
+func addRecap(w http.ResponseWriter, text string) {
+	if mw, ok := w.(*metaWriter); ok {
+		if mw.recap != "" {
+			text = mw.recap + "\n " + text
+		}
+		mw.recap = text
+	}
+}
+
+// noteLocation Sets the location the times of the response of w are shown in
+func noteLocation(w http.ResponseWriter, location *time.Location) {
+	if mw, ok := w.(*metaWriter); ok {
+		mw.location = location
+	}
+}
+
+// noteLocale Sets the locale the answers of the request of w are read in
+func noteLocale(w http.ResponseWriter, locale string) {
+	if mw, ok := w.(*metaWriter); ok {
+		mw.locale = locale
+	}
+}
+
+// requestLocale Returns the locale noted on w, or "" if the session has none
+func requestLocale(w http.ResponseWriter) string {
+	if mw, ok := w.(*metaWriter); ok {
+		return mw.locale
+	}
+	return ""
+}
+
+// requestLocation Returns the location noted on w, or nil if the session has no timezone
+func requestLocation(w http.ResponseWriter) *time.Location {
+	if mw, ok := w.(*metaWriter); ok {
+		return mw.location
+	}
+	return nil
+}
+
+// noteSearch Adds the stats of one search to the meta block of w.
+// Several searches in one response add up their attempts and entries and
+// report the slowest eBay call.
+func noteSearch(w http.ResponseWriter, stats SearchStats) {
+	mw, ok := w.(*metaWriter)
+	if !ok {
+		return
+	}
+	if !mw.noted {
+		mw.stats = stats
+		mw.noted = true
+		return
+	}
+	if stats.Duration > mw.stats.Duration {
+		mw.stats.Duration = stats.Duration
+	}
+	mw.stats.Attempts += stats.Attempts
+	mw.stats.CacheHit = mw.stats.CacheHit && stats.CacheHit
+	mw.stats.Slow = mw.stats.Slow || stats.Slow
+	if stats.Scores != nil {
+		merged := make(map[string]float64, len(mw.stats.Scores)+len(stats.Scores))
+		for _, scores := range []map[string]float64{mw.stats.Scores, stats.Scores} {
+			for id, score := range scores {
+				merged[id] = score
+			}
+		}
+		mw.stats.Scores = merged
+	}
+	mw.stats.TotalEntries += stats.TotalEntries
+	if stats.TotalPages > mw.stats.TotalPages {
+		mw.stats.TotalPages = stats.TotalPages
+	}
+}
+
+// addSession Adds the session expiry and the recap noted on w to data
+func addSession(w http.ResponseWriter, data JSON) {
+	mw, ok := w.(*metaWriter)
+	if !ok || mw.expiresAt.IsZero() {
+		return
+	}
+	data["sessionExpiresAt"] = mw.expiresAt.UTC().Format(time.RFC3339)
+	if message, found := data["message"].(string); found && mw.recap != "" {
+		data["message"] = mw.recap + "\n " + message
+		// A single recap per message, even if the processor writes twice
+		mw.recap = ""
+	}
+}
+
+// addSlow Marks data slow when a search noted on w outlived the soft deadline,
+// with how long the reply took so clients can tune their "still searching" UX
+func addSlow(w http.ResponseWriter, data JSON) {
+	mw, ok := w.(*metaWriter)
+	if !ok || !mw.noted || !mw.stats.Slow {
+		return
+	}
+	data["slow"] = true
+	data["searchDurationMs"] = milliseconds(mw.stats.Duration)
+	data["durationMs"] = milliseconds(time.Since(mw.start))
+}
+
+// responseMeta Returns the meta block for w, or nil if it wasn't requested
+func responseMeta(w http.ResponseWriter) JSON {
+	mw, ok := w.(*metaWriter)
+	if !ok || !mw.enabled {
+		return nil
+	}
+	meta := JSON{
+		"durationMs": milliseconds(time.Since(mw.start)),
+	}
+	if mw.noted {
+		meta["ebayDurationMs"] = milliseconds(mw.stats.Duration)
+		meta["attempts"] = mw.stats.Attempts
+		meta["cacheHit"] = mw.stats.CacheHit
+		meta["page"] = mw.stats.Page
+		if mw.stats.URL != "" {
+			meta["ebayURL"] = mw.stats.URL
+		}
+		meta["totalPages"] = mw.stats.TotalPages
+		meta["totalEntries"] = mw.stats.TotalEntries
+		if mw.stats.Scores != nil {
+			meta["scores"] = mw.stats.Scores
+		}
+	}
+	return meta
+}
+
+// milliseconds Converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,110 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithMetaEnabled(t *testing.T) {
+	tests := []struct {
+		target, header string
+		want           bool
+	}{
+		{"/v1/chat", "", false},
+		{"/v1/chat?meta=1", "", true},
+		{"/v1/chat?meta=true", "", false},
+		{"/v1/chat", "1", true},
+		{"/v1/chat", "yes", true},
+		{"/v1/chat", "0", false},
+		{"/v1/chat", "false", false},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodPost, test.target, nil)
+		if test.header != "" {
+			r.Header.Set("X-Debug-Meta", test.header)
+		}
+		w := withMeta(httptest.NewRecorder(), r)
+		if got := responseMeta(w) != nil; got != test.want {
+			t.Errorf("%v with X-Debug-Meta %q writes the meta block: %v, want %v", test.target, test.header, got, test.want)
+		}
+	}
+	if meta := responseMeta(httptest.NewRecorder()); meta != nil {
+		t.Errorf("responseMeta() of a plain ResponseWriter = %v", meta)
+	}
+}
+
+func TestNoteSearch(t *testing.T) {
+	w := withMeta(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/chat?meta=1", nil))
+	noteSearch(w, SearchStats{Duration: time.Second, Attempts: 1, CacheHit: true, Page: 1, TotalPages: 3, TotalEntries: 250, Scores: map[string]float64{"a": 1}})
+	noteSearch(w, SearchStats{Duration: 2 * time.Second, Attempts: 2, TotalPages: 1, TotalEntries: 10, Slow: true, Scores: map[string]float64{"b": 2}})
+	noteSearch(w, SearchStats{Duration: time.Millisecond, CacheHit: true})
+
+	meta := responseMeta(w)
+	want := JSON{
+		"ebayDurationMs": 2000.0, "attempts": 3, "cacheHit": false, "page": 1,
+		"totalPages": 3, "totalEntries": 260, "scores": map[string]float64{"a": 1, "b": 2},
+	}
+	delete(meta, "durationMs")
+	if !reflect.DeepEqual(meta, want) {
+		t.Errorf("responseMeta() = %v, want %v", meta, want)
+	}
+
+	data := JSON{}
+	addSlow(w, data)
+	if data["slow"] != true || data["searchDurationMs"] != 2000.0 {
+		t.Errorf("addSlow() added %v", data)
+	}
+}
+
+func TestAddSession(t *testing.T) {
+	expiresAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	tests := []struct {
+		name        string
+		recaps      []string
+		data        JSON
+		wantMessage interface{}
+	}{
+		{"no recap", nil, JSON{"message": "Hi"}, "Hi"},
+		{"recap", []string{"Welcome back."}, JSON{"message": "Hi"}, "Welcome back.\n Hi"},
+		{"added recaps", []string{"Welcome back.", "Your search ran."}, JSON{"message": "Hi"}, "Welcome back.\n Your search ran.\n Hi"},
+		{"no message", []string{"Welcome back."}, JSON{}, nil},
+	}
+	for _, test := range tests {
+		w := withMeta(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/chat", nil))
+		noteSession(w, expiresAt, "")
+		for _, recap := range test.recaps {
+			addRecap(w, recap)
+		}
+		addSession(w, test.data)
+		if test.data["message"] != test.wantMessage || test.data["sessionExpiresAt"] != "2024-05-01T10:00:00Z" {
+			t.Errorf("%v: addSession() made %v", test.name, test.data)
+		}
+		if test.wantMessage == nil {
+			continue
+		}
+		// The recap goes in front of the first message only
+		again := JSON{"message": "Bye"}
+		if addSession(w, again); again["message"] != "Bye" {
+			t.Errorf("%v: the recap was added twice, to %q", test.name, again["message"])
+		}
+	}
+}
+
+func TestChatMeta(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 2), Stats: SearchStats{Attempts: 1, TotalEntries: 42, TotalPages: 1}}}}
+	s := newTestServer(t, Config{}, searcher)
+	c := startConversation(t, s)
+	c.sayAll("gucci belt", "none", "none")
+	c.clock.Advance(2 * duplicateGrace)
+	reply := do(t, c.handler, http.MethodPost, "/v1/chat?meta=1", `{"message": "none"}`, http.Header{"Authorization": {c.uuid}})
+	meta, _ := reply.Body["meta"].(map[string]interface{})
+	if meta["attempts"] != float64(1) || meta["totalEntries"] != float64(42) {
+		t.Errorf("the meta block of a search is %v", reply.Body["meta"])
+	}
+	if reply := c.say("gucci belt"); reply.Body["meta"] != nil {
+		t.Errorf("a reply without ?meta=1 has the meta block %v", reply.Body["meta"])
+	}
+}
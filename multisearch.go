@@ -0,0 +1,187 @@
+package theluxuryshopper
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// maxSubQueries Caps how many keywords of one message are searched
+const maxSubQueries = 3
+
+var (
+	// quotedKeywords Matches a message made only of quoted keywords, like `"gucci loafers" or "prada loafers"`
+	quotedKeywords = regexp.MustCompile(`(?i)^\s*"[^"]+"(?:\s*(?:,|\bor\b|\bvs\.?|\bversus\b)?\s*"[^"]+")+\s*$`)
+	quotedKeyword  = regexp.MustCompile(`"([^"]+)"`)
+	// compareKeywords Matches "compare gucci loafers vs prada loafers"
+	compareKeywords = regexp.MustCompile(`(?i)^\s*compare\s+(.+?)\s*$`)
+	versusSeparator = regexp.MustCompile(`(?i)\s+(?:vs\.?|versus)\s+`)
+)
+
+// splitKeywords Splits a message searching several keywords at once into its keywords. Only the explicit
+// forms are split, quoted keywords like `"gucci loafers" or "prada loafers"` and "compare gucci loafers vs
+// prada loafers", so commas and "or"s of an ordinary keyword like "black or gold chanel" are searched as typed.
+func splitKeywords(keyword string) []string {
+	var parts []string
+	if quotedKeywords.MatchString(keyword) {
+		for _, match := range quotedKeyword.FindAllStringSubmatch(keyword, -1) {
+			parts = append(parts, match[1])
+		}
+	} else if compare := compareKeywords.FindStringSubmatch(keyword); compare != nil && !compareCommand.MatchString(keyword) {
+		parts = versusSeparator.Split(compare[1], -1)
+	}
+	if len(parts) < 2 {
+		return []string{keyword}
+	}
+
+	var keywords []string
+	seen := map[string]bool{}
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" || seen[strings.ToLower(part)] {
+			continue
+		}
+		seen[strings.ToLower(part)] = true
+		keywords = append(keywords, part)
+	}
+	return keywords
+}
+
+// subSearch Holds the outcome of searching one of several keywords
+type subSearch struct {
+	keyword string
+	result  SearchResult
+	err     error
+}
+
+// multiSearch Searches every keyword concurrently with the filters of query and replies with the merged items
+func (s *Server) multiSearch(query SearchQuery, keywords []string, session Session, w http.ResponseWriter) {
+	var dropped []string
+	if len(keywords) > maxSubQueries {
+		dropped = keywords[maxSubQueries:]
+		keywords = keywords[:maxSubQueries]
+	}
+
+	// The searches share a context, canceled when the request ends or one of them fails for all of them
+	group, ctx := errgroup.WithContext(requestContext(w))
+	searches := make([]subSearch, len(keywords))
+	for i, keyword := range keywords {
+		i, keyword := i, keyword
+		group.Go(func() error {
+			subQuery := query
+			subQuery.Keyword = keyword
+			result, err := s.search(ctx, subQuery)
+			searches[i] = subSearch{keyword: keyword, result: result, err: err}
+			switch err.(type) {
+			case *quotaExhausted, *throttled, *sessionRestricted:
+				return err
+			}
+			// A keyword eBay can't search leaves the others to finish
+			return nil
+		})
+	}
+	group.Wait()
+
+	var failed []string
+	var succeeded []subSearch
+	for _, search := range searches {
+		noteSearch(w, search.result.Stats)
+		if search.err != nil {
+			log.Printf("eBay search for %q failed: %v", search.keyword, search.err)
+			failed = append(failed, search.keyword)
+			continue
+		}
+		succeeded = append(succeeded, search)
+	}
+
+	// Only fail the whole reply if no keyword could be searched
+	if len(succeeded) == 0 {
+		handleError(searches[0].err, session, w)
+		return
+	}
+
+	items := mergeItems(succeeded, query.SortOrder)
+	if len(items) == 0 && len(failed) == 0 {
+		handleCaseZero(query, SearchResult{}, session, w)
+		return
+	}
+
+	response := "There are " + strconv.Itoa(len(items)) + " items matching your criteria : \n"
+	response += renderItems(items, requestView(w))
+	for _, search := range succeeded {
+		if link := resultsURL(search.result); link != "" {
+			response += "\n Results Page URL for " + search.keyword + " : " + link
+		}
+	}
+	for _, keyword := range failed {
+		response += "\n Couldn't search for " + keyword + " right now."
+	}
+	if len(dropped) > 0 {
+		response += "\n Only " + strconv.Itoa(maxSubQueries) + " keywords are searched at once, skipped: " + strings.Join(dropped, ", ")
+	}
+	response += "\n\n What else would you like to search for?"
+	WriteReply(w, resultsType(items), JSON{
+		"message":     response,
+		"items":       items,
+		"query":       query,
+		"suggestions": resultSuggestions(1, len(items), false),
+	})
+	resetSession(session)
+	rememberResults(session, query, SearchResult{Items: items}, false)
+	recordSearch(session, query, len(items))
+}
+
+// mergeItems Labels, dedupes and orders the items of several searches.
+// Price sort orders sort the merged items by price, any other order
+// interleaves the best matches of every keyword.
+func mergeItems(searches []subSearch, sortOrder string) []Item {
+	var items []Item
+	seen := map[string]bool{}
+	for rank := 0; ; rank++ {
+		added := false
+		for _, search := range searches {
+			if rank >= len(search.result.Items) {
+				continue
+			}
+			added = true
+			item := search.result.Items[rank]
+			if seen[item.ID] {
+				continue
+			}
+			seen[item.ID] = true
+			item.Keyword = search.keyword
+			items = append(items, item)
+		}
+		if !added {
+			break
+		}
+	}
+
+	switch sortOrder {
+	case "PricePlusShippingLowest":
+		sort.SliceStable(items, func(i, j int) bool { return itemPrice(items[i]) < itemPrice(items[j]) })
+	case "PricePlusShippingHighest":
+		sort.SliceStable(items, func(i, j int) bool {
+			priceI, priceJ := itemPrice(items[i]), itemPrice(items[j])
+			return priceI != unknownPrice && (priceJ == unknownPrice || priceI > priceJ)
+		})
+	}
+	return items
+}
+
+// unknownPrice Stands in for prices that couldn't be parsed so they sort last
+const unknownPrice = 1e18
+
+// itemPrice Returns the numeric price of item, or unknownPrice
+func itemPrice(item Item) float64 {
+	price, err := strconv.ParseFloat(item.Price, 64)
+	if err != nil {
+		return unknownPrice
+	}
+	return price
+}
@@ -0,0 +1,185 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitKeywords(t *testing.T) {
+	tests := []struct {
+		keyword string
+		want    []string
+	}{
+		{"gucci loafers", []string{"gucci loafers"}},
+		{"black or gold chanel", []string{"black or gold chanel"}},
+		{"rolex 116610LN, black dial", []string{"rolex 116610LN, black dial"}},
+		{`"gucci loafers" bag`, []string{`"gucci loafers" bag`}},
+		{`"gucci loafers" or "prada loafers"`, []string{"gucci loafers", "prada loafers"}},
+		{`"gucci loafers", "prada loafers", "tods loafers"`, []string{"gucci loafers", "prada loafers", "tods loafers"}},
+		{`"gucci" "Gucci"`, []string{"gucci"}},
+		{"compare gucci loafers vs prada loafers", []string{"gucci loafers", "prada loafers"}},
+		{"Compare hermes versus chanel vs. dior", []string{"hermes", "chanel", "dior"}},
+		{"compare gucci loafers", []string{"compare gucci loafers"}},
+		{"compare 1 vs 2", []string{"compare 1 vs 2"}},
+	}
+	for _, test := range tests {
+		if got := splitKeywords(test.keyword); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitKeywords(%q) = %q, want %q", test.keyword, got, test.want)
+		}
+	}
+}
+
+func TestMergeItems(t *testing.T) {
+	gucci := subSearch{keyword: "gucci", result: SearchResult{Items: []Item{{ID: "1", Price: "300"}, {ID: "2", Price: "100"}}}}
+	prada := subSearch{keyword: "prada", result: SearchResult{Items: []Item{{ID: "2", Price: "100"}, {ID: "3", Price: "200"}, {ID: "4", Price: "n/a"}}}}
+	tests := []struct {
+		sortOrder string
+		want      []string
+	}{
+		{"", []string{"1", "2", "3", "4"}},
+		{"BestMatch", []string{"1", "2", "3", "4"}},
+		{"PricePlusShippingLowest", []string{"2", "3", "1", "4"}},
+		{"PricePlusShippingHighest", []string{"1", "3", "2", "4"}},
+	}
+	for _, test := range tests {
+		items := mergeItems([]subSearch{gucci, prada}, test.sortOrder)
+		var ids []string
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		if !reflect.DeepEqual(ids, test.want) {
+			t.Errorf("mergeItems sorted by %q = %v, want %v", test.sortOrder, ids, test.want)
+		}
+		// The duplicate is labeled with the keyword that ranked it highest
+		for _, item := range items {
+			if item.ID == "2" && item.Keyword != "prada" {
+				t.Errorf("item 2 is labeled %q, want prada", item.Keyword)
+			}
+		}
+	}
+}
+
+func TestMultiSearch(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     string
+		errs        map[string]error
+		wantQueries []string
+		wantIDs     []string
+		wantNotes   []string
+		wantCode    string
+	}{
+		{
+			name:        "merged and deduped",
+			message:     `"gucci loafers" or "prada loafers"`,
+			wantQueries: []string{"gucci loafers", "prada loafers"},
+			wantIDs:     []string{"g1", "p1", "shared", "p2"},
+		},
+		{
+			name:        "one keyword failing",
+			message:     "compare gucci loafers vs prada loafers",
+			errs:        map[string]error{"prada loafers": &upstreamError{category: "timeout", err: errors.New("timeout")}},
+			wantQueries: []string{"gucci loafers", "prada loafers"},
+			wantIDs:     []string{"g1", "shared"},
+			wantNotes:   []string{"Couldn't search for prada loafers right now."},
+		},
+		{
+			name:        "capped",
+			message:     `"a" "b" "c" "d"`,
+			wantQueries: []string{"a", "b", "c"},
+			wantNotes:   []string{"skipped: d"},
+		},
+		{
+			name:        "every keyword failing",
+			message:     `"gucci loafers" or "prada loafers"`,
+			errs:        map[string]error{"": &upstreamError{category: "timeout", err: errors.New("timeout")}},
+			wantQueries: []string{"gucci loafers", "prada loafers"},
+			wantCode:    "upstream_unavailable",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			searcher := &fakeSearcher{
+				delay: 20 * time.Millisecond,
+				errs:  test.errs,
+				results: map[string]SearchResult{
+					"gucci loafers": {Items: []Item{{ID: "g1", Price: "100"}, {ID: "shared", Price: "150"}}},
+					"prada loafers": {Items: []Item{{ID: "p1", Price: "200"}, {ID: "shared", Price: "150"}, {ID: "p2", Price: "250"}}},
+					"":              {Items: testItems("other", 1, 1)},
+				},
+			}
+			c := startConversation(t, newTestServer(t, Config{}, searcher))
+			reply := c.sayAll(test.message, "none", "none", "none")
+
+			var keywords []string
+			for _, query := range searcher.Queries() {
+				keywords = append(keywords, query.Keyword)
+			}
+			if strings.Join(sorted(keywords), "|") != strings.Join(test.wantQueries, "|") {
+				t.Errorf("searched %q, want %q", keywords, test.wantQueries)
+			}
+			if len(test.wantQueries) > 1 && searcher.maxInFlight < 2 {
+				t.Errorf("the keywords were searched one at a time")
+			}
+			if test.wantCode != "" {
+				if reply.errorCode() != test.wantCode {
+					t.Fatalf("replied %v, want error %v", reply.Raw, test.wantCode)
+				}
+				return
+			}
+			if test.wantIDs != nil && !reflect.DeepEqual(reply.itemIDs(), test.wantIDs) {
+				t.Errorf("listed %v, want %v", reply.itemIDs(), test.wantIDs)
+			}
+			for _, note := range test.wantNotes {
+				if !strings.Contains(reply.message(), note) {
+					t.Errorf("message %q doesn't say %q", reply.message(), note)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiSearchStopsOnQuotaExhausted(t *testing.T) {
+	// The quota error comes back right away, the other search is canceled rather than waited for
+	searcher := &fakeSearcher{errs: map[string]error{"a": &quotaExhausted{resetAt: time.Now().Add(time.Hour)}}}
+	slow := &slowUnlessCanceled{fakeSearcher: searcher, slow: "b"}
+	s := newTestServer(t, Config{}, slow)
+	c := startConversation(t, s)
+	start := time.Now()
+	reply := c.sayAll(`"a" or "b"`, "none", "none", "none")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("the search took %v, the failing keyword didn't cancel the other", elapsed)
+	}
+	if reply.errorCode() != "quota_exhausted" {
+		t.Errorf("replied %v, want quota_exhausted", reply.Raw)
+	}
+}
+
+// slowUnlessCanceled Makes the searches of one keyword hang until their context ends
+type slowUnlessCanceled struct {
+	*fakeSearcher
+	slow string
+}
+
+func (s *slowUnlessCanceled) Search(ctx context.Context, query SearchQuery) (SearchResult, error) {
+	if query.Keyword == s.slow {
+		select {
+		case <-ctx.Done():
+			return SearchResult{}, ctx.Err()
+		case <-time.After(time.Minute):
+		}
+	}
+	return s.fakeSearcher.Search(ctx, query)
+}
+
+// sorted Returns a sorted copy of values
+func sorted(values []string) []string {
+	values = append([]string(nil), values...)
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,267 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IntentParser Extracts a shopping intent from a user message.
+// The summary holds the filters the session already has so the parser can
+// resolve follow-ups like "cheaper ones" against them.
+type IntentParser interface {
+	Parse(ctx context.Context, message string, summary JSON) (Intent, error)
+}
+
+// Intent Holds the intent name and the entities an IntentParser extracted.
+// Empty fields mean the entity wasn't found in the message.
+type Intent struct {
+	Name      string
+	Keyword   string
+	Condition string
+	MinPrice  string
+	MaxPrice  string
+	Sort      string
+}
+
+// DialogflowParser Talks to an HTTP endpoint speaking Dialogflow's detectIntent shapes
+type DialogflowParser struct {
+	Endpoint string
+	Token    string
+	Language string
+	Client   *http.Client
+}
+
+type dialogflowRequest struct {
+	QueryInput struct {
+		Text struct {
+			Text         string `json:"text"`
+			LanguageCode string `json:"languageCode"`
+		} `json:"text"`
+	} `json:"queryInput"`
+	QueryParams struct {
+		Payload JSON `json:"payload"`
+	} `json:"queryParams"`
+}
+
+type dialogflowResponse struct {
+	QueryResult struct {
+		Intent struct {
+			DisplayName      string `json:"displayName"`
+			IsFallbackIntent bool   `json:"isFallbackIntent"`
+		} `json:"intent"`
+		Parameters map[string]interface{} `json:"parameters"`
+	} `json:"queryResult"`
+}
+
+// maxNLUResponseSize Caps how much of the NLU response body is read
+const maxNLUResponseSize = 1 << 20
+
+// Parse Sends the message to the Dialogflow-compatible endpoint and reads back the intent
+func (p *DialogflowParser) Parse(ctx context.Context, message string, summary JSON) (Intent, error) {
+	var payload dialogflowRequest
+	payload.QueryInput.Text.Text = message
+	payload.QueryInput.Text.LanguageCode = p.Language
+	if payload.QueryInput.Text.LanguageCode == "" {
+		payload.QueryInput.Text.LanguageCode = "en"
+	}
+	payload.QueryParams.Payload = JSON{"session": summary}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Intent{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return Intent{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return Intent{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return Intent{}, fmt.Errorf("nlu endpoint returned %v", res.Status)
+	}
+
+	var decoded dialogflowResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxNLUResponseSize)).Decode(&decoded); err != nil {
+		return Intent{}, fmt.Errorf("couldn't decode nlu response: %v", err)
+	}
+	result := decoded.QueryResult
+	if result.Intent.IsFallbackIntent {
+		return Intent{Name: result.Intent.DisplayName}, nil
+	}
+	params := result.Parameters
+	return Intent{
+		Name:      result.Intent.DisplayName,
+		Keyword:   paramString(params["keyword"]),
+		Condition: paramString(params["condition"]),
+		MinPrice:  paramString(params["minPrice"]),
+		MaxPrice:  paramString(params["maxPrice"]),
+		Sort:      paramString(params["sort"]),
+	}, nil
+}
+
+// paramString Flattens a Dialogflow parameter value (string, number or unit-currency object) into a string
+func paramString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]interface{}:
+		if amount, found := v["amount"]; found {
+			return paramString(amount)
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			return paramString(v[0])
+		}
+	}
+	return ""
+}
+
+// sortOrders Maps the sort values an NLU service may return to eBay sortOrder values
+var sortOrders = map[string]string{
+	"bestmatch":                "BestMatch",
+	"best match":               "BestMatch",
+	"priceplusshippinglowest":  "PricePlusShippingLowest",
+	"cheapest":                 "PricePlusShippingLowest",
+	"lowest price":             "PricePlusShippingLowest",
+	"priceplusshippinghighest": "PricePlusShippingHighest",
+	"most expensive":           "PricePlusShippingHighest",
+	"highest price":            "PricePlusShippingHighest",
+	"endtimesoonest":           "EndTimeSoonest",
+	"ending soonest":           "EndTimeSoonest",
+	"starttimenewest":          "StartTimeNewest",
+	"newest":                   "StartTimeNewest",
+}
+
+// NLUProcessor Wraps next with an IntentParser that pre-fills the session.
+// Anything the parser couldn't extract, and any parser failure or timeout,
+// is left to the scripted questions in next.
+func NLUProcessor(parser IntentParser, timeout time.Duration, next Processor) Processor {
+	return func(session Session, message string, w http.ResponseWriter) {
+		ctx, cancel := context.WithTimeout(requestContext(w), timeout)
+		defer cancel()
+
+		intent, err := parser.Parse(ctx, message, summarizeSession(session))
+		if err != nil {
+			log.Printf("nlu: %v, falling back to the scripted flow", err)
+		} else {
+			applyIntent(session, intent, message)
+		}
+		next(session, message, w)
+	}
+}
+
+// summarizeSession Builds the compact view of the session sent along with every message
+func summarizeSession(session Session) JSON {
+	summary := JSON{}
+	for _, key := range []string{"searchByKeyword", "condition", "minPrice", "maxPrice", "sortOrder"} {
+		if value, found := session[key]; found {
+			summary[key] = value
+		}
+	}
+	if pending := pendingQuestion(session); pending != "" {
+		summary["pending"] = pending
+	}
+	return summary
+}
+
+// pendingQuestion Returns the session key of the question the user is currently answering
+func pendingQuestion(session Session) string {
+	for _, key := range []string{"condition", "minPrice", "maxPrice"} {
+		if _, answered := session[key]; answered {
+			continue
+		}
+		if asked, _ := session[key+"Bool"].(bool); asked {
+			return key
+		}
+	}
+	return ""
+}
+
+// applyIntent Copies the valid entities of intent into the session without overwriting answers already given.
+// The reference codes of message stay in the keyword as typed, and aren't taken for a price unless a price
+// question is pending.
+func applyIntent(session Session, intent Intent, message string) {
+	pending := pendingQuestion(session)
+	filled := false
+	fill := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if _, found := session[key]; found {
+			return
+		}
+		session[key] = value
+		filled = true
+	}
+
+	// An invalid keyword is left for the scripted flow, which re-prompts for it
+	keyword, _ := normalizeKeyword(keepReferences(intent.Keyword, message))
+	fill("searchByKeyword", keyword)
+	fill("condition", normalizeCondition(intent.Condition))
+	references := keywordReferences(message)
+	price := func(price string) string {
+		if pending != "minPrice" && pending != "maxPrice" && containsStep(references, strings.TrimSpace(price)) {
+			return ""
+		}
+		return normalizePrice(price)
+	}
+	fill("minPrice", price(intent.MinPrice))
+	fill("maxPrice", price(intent.MaxPrice))
+	fill("sortOrder", sortOrders[strings.ToLower(intent.Sort)])
+
+	// The message answered something other than the pending question, so ask it again
+	// instead of letting the scripted flow consume the message as its answer
+	if _, answered := session[pending]; pending != "" && filled && !answered {
+		session[pending+"Bool"] = false
+	}
+}
+
+// normalizeCondition Returns the eBay condition value for an extracted condition, or "" if it isn't one
+func normalizeCondition(condition string) string {
+	if isNone(condition) {
+		return "none"
+	}
+	switch strings.ToLower(strings.TrimSpace(condition)) {
+	case "new":
+		return "New"
+	case "used":
+		return "Used"
+	}
+	return ""
+}
+
+// normalizePrice Returns the extracted price if it is a usable amount, or "" otherwise
+func normalizePrice(price string) string {
+	return localizedPrice(price, "")
+}
+
+// localizedPrice Returns price like normalizePrice, reading its separators the way locale writes numbers
+func localizedPrice(price, locale string) string {
+	if isNone(price) {
+		return "none"
+	}
+	return parsePrice(price, locale)
+}
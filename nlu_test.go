@@ -0,0 +1,215 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParamString(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{" gucci belt ", "gucci belt"},
+		{500.0, "500"},
+		{99.5, "99.5"},
+		{map[string]interface{}{"amount": 250.0, "currency": "USD"}, "250"},
+		{map[string]interface{}{"currency": "USD"}, ""},
+		{[]interface{}{"used", "new"}, "used"},
+		{[]interface{}{}, ""},
+		{true, ""},
+		{nil, ""},
+	}
+	for _, test := range tests {
+		if got := paramString(test.value); got != test.want {
+			t.Errorf("paramString(%#v) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestNormalizeCondition(t *testing.T) {
+	tests := []struct {
+		condition, want string
+	}{
+		{"new", "New"},
+		{" Used ", "Used"},
+		{"none", "none"},
+		{"refurbished", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := normalizeCondition(test.condition); got != test.want {
+			t.Errorf("normalizeCondition(%q) = %q, want %q", test.condition, got, test.want)
+		}
+	}
+}
+
+func TestApplyIntent(t *testing.T) {
+	tests := []struct {
+		name    string
+		session Session
+		intent  Intent
+		message string
+		want    Session
+	}{
+		{
+			name:    "every entity",
+			session: Session{},
+			intent:  Intent{Keyword: "gucci belt", Condition: "used", MinPrice: "100", MaxPrice: "500", Sort: "Cheapest"},
+			message: "a used gucci belt between 100 and 500, cheapest first",
+			want:    Session{"searchByKeyword": "gucci belt", "condition": "Used", "minPrice": "100", "maxPrice": "500", "sortOrder": "PricePlusShippingLowest"},
+		},
+		{
+			name:    "answers kept",
+			session: Session{"searchByKeyword": "gucci belt", "condition": "New"},
+			intent:  Intent{Keyword: "prada bag", Condition: "used", Sort: "random"},
+			message: "prada bag used",
+			want:    Session{"searchByKeyword": "gucci belt", "condition": "New"},
+		},
+		{
+			name:    "invalid entities",
+			session: Session{},
+			intent:  Intent{Keyword: `""`, Condition: "mint", MaxPrice: "lots"},
+			message: "mint, lots",
+			want:    Session{},
+		},
+		{
+			name:    "pending question asked again",
+			session: Session{"searchByKeyword": "gucci belt", "conditionBool": true},
+			intent:  Intent{MaxPrice: "500"},
+			message: "up to 500",
+			want:    Session{"searchByKeyword": "gucci belt", "conditionBool": false, "maxPrice": "500"},
+		},
+		{
+			name:    "pending question answered",
+			session: Session{"searchByKeyword": "gucci belt", "conditionBool": true},
+			intent:  Intent{Condition: "new"},
+			message: "new",
+			want:    Session{"searchByKeyword": "gucci belt", "conditionBool": true, "condition": "New"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			applyIntent(test.session, test.intent, test.message)
+			if !reflect.DeepEqual(test.session, test.want) {
+				t.Errorf("applyIntent() made %v, want %v", test.session, test.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeSession(t *testing.T) {
+	session := Session{"searchByKeyword": "gucci belt", "condition": "Used", "conditionBool": true, "minPriceBool": true, "uuid": "x"}
+	want := JSON{"searchByKeyword": "gucci belt", "condition": "Used", "pending": "minPrice"}
+	if got := summarizeSession(session); !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeSession() = %v, want %v", got, want)
+	}
+}
+
+func TestDialogflowParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		want     Intent
+		wantErr  bool
+	}{
+		{
+			name:     "entities",
+			status:   http.StatusOK,
+			response: `{"queryResult":{"intent":{"displayName":"search"},"parameters":{"keyword":"kelly bag","condition":["used"],"maxPrice":{"amount":9000,"currency":"USD"},"sort":"newest"}}}`,
+			want:     Intent{Name: "search", Keyword: "kelly bag", Condition: "used", MaxPrice: "9000", Sort: "newest"},
+		},
+		{
+			name:     "fallback",
+			status:   http.StatusOK,
+			response: `{"queryResult":{"intent":{"displayName":"Default Fallback Intent","isFallbackIntent":true},"parameters":{"keyword":"hello"}}}`,
+			want:     Intent{Name: "Default Fallback Intent"},
+		},
+		{name: "failure", status: http.StatusInternalServerError, response: `{}`, wantErr: true},
+		{name: "garbage", status: http.StatusOK, response: `<html>`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var request dialogflowRequest
+			var authorization string
+			endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+				json.NewDecoder(r.Body).Decode(&request)
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.response))
+			}))
+			defer endpoint.Close()
+
+			parser := &DialogflowParser{Endpoint: endpoint.URL, Token: "token"}
+			got, err := parser.Parse(context.Background(), "a used kelly bag", JSON{"condition": "Used"})
+			if (err != nil) != test.wantErr || got != test.want {
+				t.Errorf("Parse() = %+v, %v, want %+v, an error: %v", got, err, test.want, test.wantErr)
+			}
+			if authorization != "Bearer token" || request.QueryInput.Text.Text != "a used kelly bag" || request.QueryInput.Text.LanguageCode != "en" {
+				t.Errorf("the endpoint was sent %+v with Authorization %q", request, authorization)
+			}
+			if session, _ := request.QueryParams.Payload["session"].(map[string]interface{}); session["condition"] != "Used" {
+				t.Errorf("the endpoint was sent the session %v", request.QueryParams.Payload)
+			}
+		})
+	}
+}
+
+// intentFunc Is an IntentParser calling itself
+type intentFunc func(ctx context.Context, message string) (Intent, error)
+
+// Parse Implements IntentParser
+func (f intentFunc) Parse(ctx context.Context, message string, summary JSON) (Intent, error) {
+	return f(ctx, message)
+}
+
+func TestNLUProcessor(t *testing.T) {
+	tests := []struct {
+		name       string
+		parser     intentFunc
+		wantPrefix string
+	}{
+		{
+			name: "prefilled",
+			parser: func(ctx context.Context, message string) (Intent, error) {
+				return Intent{Keyword: "kelly bag", Condition: "used"}, nil
+			},
+			wantPrefix: "Please specify the minimum price",
+		},
+		{
+			name: "failure",
+			parser: func(ctx context.Context, message string) (Intent, error) {
+				return Intent{}, errors.New("unavailable")
+			},
+			wantPrefix: "Please specify the condition",
+		},
+		{
+			name: "timeout",
+			parser: func(ctx context.Context, message string) (Intent, error) {
+				<-ctx.Done()
+				return Intent{}, ctx.Err()
+			},
+			wantPrefix: "Please specify the condition",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestServer(t, Config{}, &fakeSearcher{})
+			if err := s.SetProcessor(NLUProcessor(test.parser, 10*time.Millisecond, s.sampleProcessor)); err != nil {
+				t.Fatal(err)
+			}
+			c := startConversation(t, s)
+			if reply := c.say("a used kelly bag"); !strings.HasPrefix(reply.message(), test.wantPrefix) {
+				t.Errorf("the first message answered %q, want it to start with %q", reply.message(), test.wantPrefix)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package theluxuryshopper
+
+import (
+	"strings"
+	"unicode"
+)
+
+// defaultNoneSynonyms Are the answers every filter question takes as "none"
+var defaultNoneSynonyms = []string{
+	"none", "no", "nope", "nah", "skip", "pass", "any", "anything", "whatever", "all",
+	"n/a", "na", "nothing", "no preference", "no filter", "no limit", "not really",
+	"doesn't matter", "does not matter", "it doesn't matter", "don't care", "i don't care",
+	"do not care", "either", "either is fine", "any is fine", "no thanks",
+}
+
+// builtinNone Holds the default synonyms, for the answers not given to a Server
+var builtinNone = newNoneSynonyms(nil)
+
+// noneSynonyms Holds the normalized answers that mean "don't filter"
+type noneSynonyms map[string]bool
+
+// newNoneSynonyms Creates the set of the default synonyms and extra ones
+func newNoneSynonyms(extra []string) noneSynonyms {
+	synonyms := noneSynonyms{}
+	for _, answer := range append(append([]string{}, defaultNoneSynonyms...), extra...) {
+		if answer = noneKey(answer); answer != "" {
+			synonyms[answer] = true
+		}
+	}
+	return synonyms
+}
+
+// isNone Reports whether answer is one of the synonyms, or a bare "-"
+func (n noneSynonyms) isNone(answer string) bool {
+	key := noneKey(answer)
+	if key == "" {
+		return strings.TrimSpace(answer) == "-"
+	}
+	return n[key]
+}
+
+// isNone Reports whether answer means "none" with the default synonyms, ignoring case,
+// the surrounding punctuation and apostrophes, so "Doesnt matter!" and "N/A." count
+func isNone(answer string) bool {
+	return builtinNone.isNone(answer)
+}
+
+// normalizeAnswer Returns "none" for the synonyms of the server, or answer normalized by normalize.
+// Every filter step stores its answer through it, so "skip" means the same to all of them.
+func (s *Server) normalizeAnswer(answer string, normalize func(string) string) string {
+	if s.none.isNone(answer) {
+		return "none"
+	}
+	return normalize(answer)
+}
+
+// noneKey Normalizes answer for the lookup in a noneSynonyms
+func noneKey(answer string) string {
+	answer = strings.ToLower(answer)
+	answer = strings.NewReplacer("’", "", "'", "", "`", "").Replace(answer)
+	answer = strings.TrimFunc(answer, func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSpace(r) || unicode.IsSymbol(r) })
+	return strings.Join(strings.Fields(answer), " ")
+}
@@ -0,0 +1,64 @@
+package theluxuryshopper
+
+import "testing"
+
+func TestIsNone(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{"none", true},
+		{"NONE", true},
+		{"  skip ", true},
+		{"N/A.", true},
+		{"Doesnt matter!", true},
+		{"doesn’t matter", true},
+		{"i don't   care", true},
+		{"-", true},
+		{"--", false},
+		{"", false},
+		{"new", false},
+		{"no 500", false},
+		{"500", false},
+	}
+	for _, test := range tests {
+		if got := isNone(test.answer); got != test.want {
+			t.Errorf("isNone(%q) = %v, want %v", test.answer, got, test.want)
+		}
+	}
+}
+
+func TestNoneSynonymsExtra(t *testing.T) {
+	synonyms := newNoneSynonyms([]string{" Egal ", "", "peu importe!"})
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{"egal", true},
+		{"Peu importe", true},
+		{"skip", true},
+		{"importe", false},
+	}
+	for _, test := range tests {
+		if got := synonyms.isNone(test.answer); got != test.want {
+			t.Errorf("isNone(%q) with the extra synonyms = %v, want %v", test.answer, got, test.want)
+		}
+	}
+	if isNone("egal") {
+		t.Error("the extra synonyms of a server leaked into the defaults")
+	}
+}
+
+func TestNoneSynonymsInTheFlow(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("belt", 1, 1)}}}
+	s := newTestServer(t, Config{NoneSynonyms: []string{"egal"}}, searcher)
+	c := startConversation(t, s)
+	c.sayAll("gucci belt", "Egal", "whatever", "-")
+	queries := searcher.Queries()
+	if len(queries) != 1 {
+		t.Fatalf("the flow searched %v times, want once", len(queries))
+	}
+	if query := queries[0]; query.Condition != "none" || query.MinPrice != "none" || query.MaxPrice != "none" {
+		t.Errorf("the search filters %q, %q, %q, want none of them", query.Condition, query.MinPrice, query.MaxPrice)
+	}
+}
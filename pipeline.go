@@ -0,0 +1,141 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// The default processor runs four stages, each usable on its own by a custom Processor:
+// RouteMessage answers commands and questions, BuildQuery turns the answers into a search,
+// ExecuteSearch runs it and RenderSearch replies with its outcome.
+
+// errNoKeyword Is returned by BuildQuery for sessions that weren't told what to search for yet
+var errNoKeyword = errors.New("the session has no keyword to search for")
+
+// RouteMessage Answers message when it is a command or the answer to a question and replies, reporting whether it did.
+// When it didn't, session holds every answer the search needs.
+func (s *Server) RouteMessage(session Session, message string, w http.ResponseWriter) bool {
+	// Pasted eBay links are read rather than searched for as they are
+	if s.handleEbayLink(session, message, w) {
+		return true
+	}
+	// "surprise me" skips the questions and searches a random category
+	if isSurpriseCommand(message) {
+		s.surprise(session, w)
+		return true
+	}
+	if s.handleStopCommand(session, message, w) {
+		return true
+	}
+	if handleLotsCommand(session, message, w) {
+		return true
+	}
+	// Before the locations, which would take "exclude category ..." for a country
+	if handleAccessoriesCommand(session, message, w) {
+		return true
+	}
+	if handleLocationCommand(session, message, w) {
+		return true
+	}
+	if s.handleRefineCommand(session, message, w) {
+		return true
+	}
+	if s.handleShipToCommand(session, message, w) {
+		return true
+	}
+	if s.handleSavedSearchCommand(session, message, w) {
+		return true
+	}
+	if s.handleHistoryCommand(session, message, w) {
+		return true
+	}
+	if handleFilterOverrideCommand(session, message, w) {
+		return true
+	}
+	if s.handleResultsCommand(session, message, w) {
+		return true
+	}
+	if s.handleFeedbackCommand(session, message, w) {
+		return true
+	}
+	if s.handleSiteCommand(session, message, w) {
+		return true
+	}
+	if handled, search := s.handleSkipCommand(session, message, w); handled && !search {
+		return true
+	}
+	if handled, search := s.handleDryRunCommand(session, message, w); handled {
+		return !search
+	}
+
+	// Questions answered over and over end in a search, or in a new start without a keyword
+	lastTurn := s.countTurn(session)
+
+	//Check if there is already an existing value assigned to searchByKeyword in this session
+	_, found := session["searchByKeyword"]
+	if !found {
+		//Id we didnt find searchByKeyword in this session, that means that this message is the answer of the first question
+		keyword, err := normalizeKeyword(message)
+		if err != nil {
+			s.answerRejected(w, "keyword")
+			if lastTurn {
+				s.endLongConversation(session, w)
+				return true
+			}
+			// Ask again right away rather than after the other questions
+			WriteReply(w, ReplyQuestion, JSON{
+				"message": err.Error() + "\n " + keywordQuestion,
+				"step":    "keyword",
+			})
+			return true
+		}
+		session["searchByKeyword"] = keyword
+		// A new search numbers its items from 1 again, on the site it chose
+		delete(session, "results")
+		delete(session, "originalSite")
+	}
+
+	// Refuse disallowed keywords before asking anything else
+	if allowed, reason := s.allowKeyword(session.GetString("searchByKeyword", "")); !allowed {
+		s.answerRejected(w, "keyword")
+		refuseKeyword(reason, session, w)
+		return true
+	}
+
+	// Defaults and overrides skip the questions of the filters they set
+	s.applyDefaultFilters(session)
+
+	if lastTurn {
+		addRecap(w, s.turnCeilingNote())
+		s.answerNone(session)
+		return false
+	}
+
+	//Filter results, asking the enabled questions in the configured order
+	return s.runSteps(session, message, w)
+}
+
+// BuildQuery Builds the search the answers collected in session ask for
+func BuildQuery(session Session) (SearchQuery, error) {
+	if _, found := session["searchByKeyword"].(string); !found {
+		return SearchQuery{}, errNoKeyword
+	}
+	return queryFromSession(session), nil
+}
+
+// ExecuteSearch Runs query against eBay, within the quota and the throttling cool-down of the server
+func (s *Server) ExecuteSearch(ctx context.Context, query SearchQuery) (SearchResult, error) {
+	return s.search(ctx, query)
+}
+
+// RenderSearch Replies with the outcome of searching query: the error, the zero results message or the items
+func RenderSearch(query SearchQuery, result SearchResult, err error, session Session, w http.ResponseWriter) {
+	if handleError(err, session, w) == 1 {
+		return
+	}
+	if handleCaseZero(query, result, session, w) == 1 {
+		return
+	}
+	generateResponse(query, result, session, w)
+}
@@ -0,0 +1,144 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+// recordedReply Decodes the reply written to w
+func recordedReply(t *testing.T, w *httptest.ResponseRecorder) JSON {
+	t.Helper()
+	var reply JSON
+	if err := json.Unmarshal(w.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("the reply %q isn't JSON: %v", w.Body.String(), err)
+	}
+	return reply
+}
+
+func TestRouteMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		session     Session
+		message     string
+		wantHandled bool
+		wantStep    string
+		wantKey     string
+		wantValue   interface{}
+	}{
+		{"keyword", Session{}, "gucci belt", true, "condition", "searchByKeyword", "gucci belt"},
+		{"empty keyword", Session{}, `""`, true, "keyword", "searchByKeyword", nil},
+		{"condition", Session{"searchByKeyword": "gucci belt", "conditionBool": true}, "used", true, "minPrice", "condition", "Used"},
+		{"invalid condition", Session{"searchByKeyword": "gucci belt", "conditionBool": true}, "brand new-ish", true, "condition", "condition", nil},
+		{"last answer", Session{"searchByKeyword": "gucci belt", "conditionBool": true, "condition": "New", "minPriceBool": true, "minPrice": "100", "maxPriceBool": true}, "500", false, "", "maxPrice", "500"},
+		{"command", Session{}, "show lots", true, "", "excludeLots", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestServer(t, Config{}, &fakeSearcher{})
+			w := httptest.NewRecorder()
+			handled := s.RouteMessage(test.session, test.message, w)
+			if handled != test.wantHandled {
+				t.Fatalf("RouteMessage(%q) = %v, want %v, reply %v", test.message, handled, test.wantHandled, w.Body.String())
+			}
+			if !handled && w.Body.Len() > 0 {
+				t.Errorf("RouteMessage(%q) wasn't handled but replied %v", test.message, w.Body.String())
+			}
+			if handled && test.wantStep != "" {
+				if step := recordedReply(t, w)["step"]; step != test.wantStep {
+					t.Errorf("RouteMessage(%q) asked for %v, want %v", test.message, step, test.wantStep)
+				}
+			}
+			if got := test.session[test.wantKey]; got != test.wantValue {
+				t.Errorf("RouteMessage(%q) left %v = %v, want %v", test.message, test.wantKey, got, test.wantValue)
+			}
+		})
+	}
+}
+
+func TestBuildQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		session Session
+		want    SearchQuery
+		wantErr error
+	}{
+		{"no keyword", Session{"condition": "New"}, SearchQuery{}, errNoKeyword},
+		{"keyword only", Session{"searchByKeyword": "gucci belt"}, SearchQuery{Keyword: "gucci belt", Condition: "none", MinPrice: "none", MaxPrice: "none", Entries: 5}, nil},
+		{"every answer", Session{"searchByKeyword": "gucci belt", "condition": "Used", "minPrice": "100", "maxPrice": "500"},
+			SearchQuery{Keyword: "gucci belt", Condition: "Used", MinPrice: "100", MaxPrice: "500", Entries: 5}, nil},
+		{"site currency", Session{"searchByKeyword": "gucci belt", "minPrice": "100", "site": "EBAY-DE"},
+			SearchQuery{Keyword: "gucci belt", Condition: "none", MinPrice: "100", MaxPrice: "none", Currency: "EUR", Site: "EBAY-DE", Entries: 5}, nil},
+		{"sort order", Session{"searchByKeyword": "gucci belt", "sortOrder": "PricePlusShippingLowest"},
+			SearchQuery{Keyword: "gucci belt", Condition: "none", MinPrice: "none", MaxPrice: "none", SortOrder: "PricePlusShippingLowest", Entries: 5}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := BuildQuery(test.session)
+			if err != test.wantErr {
+				t.Fatalf("BuildQuery() failed with %v, want %v", err, test.wantErr)
+			}
+			if query.Keyword != test.want.Keyword || query.Condition != test.want.Condition || query.MinPrice != test.want.MinPrice ||
+				query.MaxPrice != test.want.MaxPrice || query.Currency != test.want.Currency || query.Site != test.want.Site ||
+				query.SortOrder != test.want.SortOrder || query.Entries != test.want.Entries {
+				t.Errorf("BuildQuery() = %+v, want %+v", query, test.want)
+			}
+		})
+	}
+}
+
+func TestExecuteSearch(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"gucci belt": {Items: testItems("belt", 1, 2)}}, errs: map[string]error{"broken": errors.New("eBay is down")}}
+	s := newTestServer(t, Config{DailyCallLimit: 2}, searcher)
+
+	result, err := s.ExecuteSearch(context.Background(), SearchQuery{Keyword: "gucci belt", Entries: 5})
+	if err != nil || len(result.Items) != 2 {
+		t.Fatalf("ExecuteSearch(gucci belt) = %v items, %v, want 2 items", len(result.Items), err)
+	}
+	if _, err := s.ExecuteSearch(context.Background(), SearchQuery{Keyword: "broken", Entries: 5}); err == nil {
+		t.Error("ExecuteSearch(broken) didn't fail")
+	}
+	// Both calls counted against the quota, the third is refused without calling eBay
+	if _, err := s.ExecuteSearch(context.Background(), SearchQuery{Keyword: "gucci belt", Entries: 5}); !isQuotaExhausted(err) {
+		t.Errorf("ExecuteSearch() past DAILY_CALL_LIMIT failed with %v, want quotaExhausted", err)
+	}
+	if queries := searcher.Queries(); len(queries) != 2 {
+		t.Errorf("ExecuteSearch() called the searcher %v times, want 2", len(queries))
+	}
+}
+
+// isQuotaExhausted Reports whether err refused a search for the daily call limit
+func isQuotaExhausted(err error) bool {
+	_, exhausted := err.(*quotaExhausted)
+	return exhausted
+}
+
+func TestRenderSearch(t *testing.T) {
+	query := SearchQuery{Keyword: "gucci belt", Condition: "none", MinPrice: "none", MaxPrice: "none", Entries: 5}
+	tests := []struct {
+		name       string
+		result     SearchResult
+		err        error
+		wantStatus int
+		wantType   string
+		wantItems  int
+	}{
+		{"items", SearchResult{Items: testItems("belt", 1, 3), Count: 3, PageURL: "https://www.ebay.com/sch/i.html?_nkw=gucci+belt"}, nil, 200, "results", 3},
+		{"zero results", SearchResult{}, nil, 200, "zeroResults", 0},
+		{"failure", SearchResult{}, &searchFailure{message: "Invalid keyword", id: "5", category: failureInvalidInput}, 400, "error", 0},
+		{"quota", SearchResult{}, &quotaExhausted{}, 429, "error", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			RenderSearch(query, test.result, test.err, Session{"searchByKeyword": "gucci belt"}, w)
+			reply := recordedReply(t, w)
+			items, _ := reply["items"].([]interface{})
+			if w.Code != test.wantStatus || reply["type"] != test.wantType || len(items) != test.wantItems {
+				t.Errorf("RenderSearch() answered %v %v with %v items, want %v %v with %v", w.Code, reply["type"], len(items), test.wantStatus, test.wantType, test.wantItems)
+			}
+		})
+	}
+}
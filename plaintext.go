@@ -0,0 +1,50 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// plainTextPolicy Is the Content-Security-Policy of the built-in HTML page when Config.DisableHTMLMessages is set
+const plainTextPolicy = "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'"
+
+// messagePlaceholder Matches the placeholders of the commands the messages teach, like "details <number>"
+var messagePlaceholder = regexp.MustCompile(`<(number|name|country|n|m)>`)
+
+// angleBrackets Swaps the angle brackets left in a message for look-alikes no DOM parses as markup
+var angleBrackets = strings.NewReplacer("<", "‹", ">", "›")
+
+// withPlainText Makes every message written to w plain text when Config.DisableHTMLMessages is set,
+// whatever format the request asked for. w is wrapped to carry it unless it already collects the meta block.
+func (s *Server) withPlainText(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+	if !s.config.DisableHTMLMessages {
+		return w
+	}
+	return plainTextWriter(w, r)
+}
+
+// plainTextWriter Wraps w so the messages written to it are plain text, for DISABLE_HTML_MESSAGES or a
+// /chat request asking for "format": "text"
+func plainTextWriter(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+	mw, ok := w.(*metaWriter)
+	if !ok {
+		mw = &metaWriter{ResponseWriter: w, ctx: r.Context(), start: time.Now()}
+	}
+	mw.plainText = true
+	return mw
+}
+
+// plainReplies Reports whether the messages written to w must be plain text
+func plainReplies(w http.ResponseWriter) bool {
+	mw, ok := w.(*metaWriter)
+	return ok && mw.plainText
+}
+
+// plainMessage Strips the tags from message, titles from eBay included, and swaps the angle brackets
+// left and the ones of the placeholders
+func plainMessage(message string) string {
+	message = messagePlaceholder.ReplaceAllString(message, "‹$1›")
+	return angleBrackets.Replace(htmlTag.ReplaceAllString(message, ""))
+}
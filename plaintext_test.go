@@ -0,0 +1,67 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPlainMessage(t *testing.T) {
+	tests := []struct {
+		message, want string
+	}{
+		{"Say 'details <number>' to see more.", "Say 'details ‹number›' to see more."},
+		{"Say 'ship to <country>' or 'rename <n> <name>'.", "Say 'ship to ‹country›' or 'rename ‹n› ‹name›'."},
+		{"<b>Kelly</b> 28 <script>alert(1)</script>", "Kelly 28 alert(1)"},
+		{"price < 500 and <unknown>", "price ‹ 500 and "},
+		{"1 < 2 > 0", "1 ‹ 2 › 0"},
+		{"<!-- hidden -->shown", "shown"},
+		{"nothing to strip", "nothing to strip"},
+	}
+	for _, test := range tests {
+		if got := plainMessage(test.message); got != test.want {
+			t.Errorf("plainMessage(%q) = %q, want %q", test.message, got, test.want)
+		}
+	}
+}
+
+func TestPlainTextReplies(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: []Item{
+		{ID: "1", Title: "<b>Kelly</b> <img src=x onerror=alert(1)>28", Price: "9500", Currency: "USD"},
+	}, Count: 1}}}
+	tests := []struct {
+		name    string
+		config  Config
+		format  string
+		wantCSP bool
+		wantTag bool
+	}{
+		{"html", Config{}, "", false, true},
+		{"text format", Config{}, "text", false, false},
+		{"disabled html", Config{DisableHTMLMessages: true}, "", true, false},
+		{"disabled html asking json", Config{DisableHTMLMessages: true}, "json", true, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestServer(t, test.config, searcher)
+			c := startConversation(t, s)
+			c.sayAll("kelly bag", "none", "none")
+			c.clock.Advance(2 * duplicateGrace)
+			body := `{"message": "none"}`
+			if test.format != "" {
+				body = `{"message": "none", "format": "` + test.format + `"}`
+			}
+			reply := do(t, c.handler, http.MethodPost, "/v1/chat", body, http.Header{"Authorization": {c.uuid}})
+			if got := strings.Contains(reply.message(), "<b>"); got != test.wantTag {
+				t.Errorf("the results %q have tags: %v, want %v", reply.message(), got, test.wantTag)
+			}
+			if !test.wantTag && (strings.ContainsAny(reply.message(), "<>") || !strings.Contains(reply.message(), "Kelly 28")) {
+				t.Errorf("the plain results are %q", reply.message())
+			}
+			index := do(t, c.handler, http.MethodGet, "/", "", nil)
+			if got := index.Header.Get("Content-Security-Policy") == plainTextPolicy; got != test.wantCSP {
+				t.Errorf("/ has the plain text policy: %v, want %v", got, test.wantCSP)
+			}
+		})
+	}
+}
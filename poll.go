@@ -0,0 +1,221 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// maxQueuedReplies Bounds the replies waiting for a poll of one session, the oldest make room for new ones
+	maxQueuedReplies = 20
+	// defaultPollTimeout And maxPollTimeout Bound how long GET /chat/poll waits for a reply
+	defaultPollTimeout, maxPollTimeout = 25 * time.Second, 60 * time.Second
+	// asyncTurnTimeout Bounds a message answered for a poll, no client waits on it
+	asyncTurnTimeout = 2 * time.Minute
+	// deliverPoll Is the deliver value of a /chat body asking for its reply through GET /chat/poll
+	deliverPoll = "poll"
+)
+
+// asyncTurnKey Marks the context of a message answered for a poll, it was counted when it arrived
+type asyncTurnKey struct{}
+
+// queuedReply Is one reply waiting in the queue of a session, until a poll acknowledges its id
+type queuedReply struct {
+	ID       int64           `json:"id"`
+	Status   int             `json:"status"`
+	QueuedAt string          `json:"queuedAt"`
+	Reply    json.RawMessage `json:"reply"`
+}
+
+// replyQueue Holds the replies of one session generated without a request waiting for them.
+// Replies stay until a poll acknowledges them, so each is delivered at least once.
+type replyQueue struct {
+	mu      sync.Mutex
+	replies []queuedReply
+	lastID  int64
+	ready   chan struct{} // closed and replaced whenever a reply is queued
+	closed  bool          // the session expired
+}
+
+// newReplyQueue Creates the empty queue of a session
+func newReplyQueue() *replyQueue {
+	return &replyQueue{ready: make(chan struct{})}
+}
+
+// Push Queues the reply body answered with status, returning its id, or 0 once the session expired
+func (q *replyQueue) Push(status int, body []byte, now time.Time) int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return 0
+	}
+	q.lastID++
+	if len(q.replies) >= maxQueuedReplies {
+		q.replies = q.replies[1:]
+	}
+	q.replies = append(q.replies, queuedReply{
+		ID:       q.lastID,
+		Status:   status,
+		QueuedAt: now.UTC().Format(time.RFC3339),
+		Reply:    json.RawMessage(bytes.TrimSpace(body)),
+	})
+	close(q.ready)
+	q.ready = make(chan struct{})
+	return q.lastID
+}
+
+// Wait Drops the replies up to ack and returns the others, waiting until one is queued or ctx is done.
+// closed reports that the session expired meanwhile.
+func (q *replyQueue) Wait(ctx context.Context, ack int64) (replies []queuedReply, closed bool) {
+	for {
+		q.mu.Lock()
+		kept := q.replies[:0]
+		for _, reply := range q.replies {
+			if reply.ID > ack {
+				kept = append(kept, reply)
+			}
+		}
+		q.replies = kept
+		if q.closed || len(q.replies) > 0 {
+			replies, closed = append([]queuedReply{}, q.replies...), q.closed
+			q.mu.Unlock()
+			return replies, closed
+		}
+		ready := q.ready
+		q.mu.Unlock()
+
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// close Drops the replies of an expired session and wakes up its polls
+func (q *replyQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed, q.replies = true, nil
+		close(q.ready)
+	}
+}
+
+// replyQueue Returns the queue of uuid and the bot that started it without recording activity, so polling
+// alone can't keep a session alive. Neither waits for the turn of the session.
+func (st *SessionStore) replyQueue(uuid string) (*replyQueue, string, sessionState) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	stored, found := st.sessions[uuid]
+	if !found {
+		if _, expired := st.expired[uuid]; expired {
+			return nil, "", sessionExpired
+		}
+		return nil, "", sessionUnknown
+	}
+	if st.now().Sub(stored.lastActivity) > st.ttl {
+		st.expire(uuid, stored)
+		return nil, "", sessionExpired
+	}
+	return stored.replies, stored.bot, sessionActive
+}
+
+// DeliverReply Queues reply for the next GET /chat/poll of the session uuid, for replies generated outside
+// of a /chat request like notifications. It fails when the session is unknown or expired.
+func (s *Server) DeliverReply(uuid string, reply JSON) error {
+	body, err := json.Marshal(reply)
+	if err != nil {
+		return err
+	}
+	queue, _, state := s.sessions.replyQueue(uuid)
+	if state != sessionActive || queue.Push(http.StatusOK, body, s.sessions.now()) == 0 {
+		return errSessionGone
+	}
+	return nil
+}
+
+// answerForPoll Answers request of the session uuid in the background, like a /chat request without the
+// deliver field would be, and queues the reply for GET /chat/poll
+func (s *Server) answerForPoll(r *http.Request, uuid string, request chatRequest) {
+	request.Deliver = ""
+	body, _ := json.Marshal(request)
+	header := r.Header.Clone()
+	header.Del("Content-Length")
+	header.Set("Content-Type", jsonContentType)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), asyncTurnKey{}, true), asyncTurnTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL.Path, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		req.Header = header
+		reply := &capturedReply{header: http.Header{}, status: http.StatusOK}
+		s.handleChat(reply, req, nil)
+		if queue, _, state := s.sessions.replyQueue(uuid); state == sessionActive {
+			queue.Push(reply.status, reply.body.Bytes(), s.sessions.now())
+		}
+	}()
+}
+
+// handlePoll Handles GET /chat/poll, answering the replies queued for the session of the Authorization
+// header, or waiting up to ?timeout= seconds for one and answering 204 when none came. ?ack= acknowledges
+// the replies up to that id, the others are answered again by every poll.
+func (s *Server) handlePoll(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Cache-Control", "no-store")
+	negotiateSemantics(w, r)
+	uuid := r.Header.Get("Authorization")
+	if uuid == "" {
+		writeError(w, http.StatusUnauthorized, "missing_authorization", "Missing or empty Authorization header.")
+		return
+	}
+	timeout := defaultPollTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 || time.Duration(seconds)*time.Second > maxPollTimeout {
+			writeError(w, http.StatusBadRequest, "invalid_timeout", fmt.Sprintf("timeout must be a number of seconds from 0 to %v.", int(maxPollTimeout.Seconds())))
+			return
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+	var ack int64
+	if value := r.URL.Query().Get("ack"); value != "" {
+		var err error
+		if ack, err = strconv.ParseInt(value, 10, 64); err != nil || ack < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_ack", "ack must be the id of the last reply received.")
+			return
+		}
+	}
+
+	// Polls don't wait for the turn of the session, a message answered meanwhile is what they wait for
+	queue, bot, state := s.sessions.replyQueue(uuid)
+	switch {
+	case state == sessionExpired:
+		writeError(w, http.StatusUnauthorized, "session_expired", fmt.Sprintf("The session %v expired.", uuid))
+		return
+	case state != sessionActive || !s.ownBotName(bot):
+		writeError(w, http.StatusUnauthorized, "unknown_session", fmt.Sprintf("No session found for: %v.", uuid))
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	replies, closed := queue.Wait(ctx, ack)
+	if closed {
+		writeError(w, http.StatusUnauthorized, "session_expired", fmt.Sprintf("The session %v expired.", uuid))
+		return
+	}
+	if len(replies) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, JSON{"replies": replies})
+}
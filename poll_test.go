@@ -0,0 +1,80 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPollDoesntWaitForTheTurn(t *testing.T) {
+	s := newTestServer(t, Config{Bots: map[string]BotProfile{"acme": {BrandName: "Acme"}}}, &fakeSearcher{})
+	c := startConversation(t, s)
+
+	// A message of the session is being answered meanwhile
+	unlock, err := s.sessions.Lock(context.Background(), c.uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantCode   string
+	}{
+		{"own bot", "/v1/chat/poll?timeout=0", http.StatusNoContent, ""},
+		{"other bot", "/v1/bots/acme/chat/poll?timeout=0", http.StatusUnauthorized, "unknown_session"},
+		{"unknown session", "/v1/chat/poll?timeout=0", http.StatusUnauthorized, "unknown_session"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			uuid := c.uuid
+			if test.name == "unknown session" {
+				uuid = exampleClientSessionID
+			}
+			done := make(chan testReply, 1)
+			go func() {
+				done <- do(t, c.handler, http.MethodGet, test.target, "", http.Header{"Authorization": {uuid}})
+			}()
+			select {
+			case reply := <-done:
+				if reply.Status != test.wantStatus || reply.errorCode() != test.wantCode {
+					t.Errorf("GET %v answered %v %v, want %v %v", test.target, reply.Status, reply.errorCode(), test.wantStatus, test.wantCode)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("GET %v waited for the turn of the session", test.target)
+			}
+		})
+	}
+}
+
+func TestReplyQueue(t *testing.T) {
+	queue := newReplyQueue()
+	now := time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < maxQueuedReplies+2; i++ {
+		queue.Push(http.StatusOK, []byte(`{"message":"hi"}`), now)
+	}
+	replies, closed := queue.Wait(context.Background(), 0)
+	if closed || len(replies) != maxQueuedReplies || replies[0].ID != 3 {
+		t.Fatalf("Wait returned %v replies starting at %v, closed %v, want %v starting at 3", len(replies), replies[0].ID, closed, maxQueuedReplies)
+	}
+	if replies, _ := queue.Wait(context.Background(), int64(maxQueuedReplies)); len(replies) != 2 {
+		t.Errorf("Wait after acknowledging %v returned %v replies, want 2", maxQueuedReplies, len(replies))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if replies, closed := queue.Wait(ctx, int64(maxQueuedReplies+2)); replies != nil || closed {
+		t.Errorf("Wait with every reply acknowledged returned %v, closed %v", replies, closed)
+	}
+
+	queue.close()
+	if _, closed := queue.Wait(context.Background(), 0); !closed {
+		t.Errorf("Wait on an expired session didn't report it closed")
+	}
+	if id := queue.Push(http.StatusOK, []byte(`{}`), now); id != 0 {
+		t.Errorf("Push on an expired session returned id %v, want 0", id)
+	}
+}
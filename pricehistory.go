@@ -0,0 +1,178 @@
+package theluxuryshopper
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxPriceObservations Caps the history of one keyword, the oldest observations go first
+	maxPriceObservations = 30
+	// maxPriceKeywords Caps the keywords with a history, the least recently searched go first
+	maxPriceKeywords = 1000
+	// priceObservationGap Merges the searches of a keyword within this gap into one observation
+	priceObservationGap = time.Hour
+	// priceHistoryAge Is how long an observation is kept
+	priceHistoryAge = 90 * 24 * time.Hour
+)
+
+// PriceObservation Holds the prices seen by one search of a keyword
+type PriceObservation struct {
+	At     time.Time `json:"at"`
+	Min    float64   `json:"min"`
+	Median float64   `json:"median"`
+	Count  int       `json:"count"`
+	// Currency is the currency of the items observed, "" when they didn't say
+	Currency string `json:"currency,omitempty"`
+}
+
+// priceHistoryStore Keeps the price observations of every keyword searched, across sessions
+type priceHistoryStore struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	histories map[string][]PriceObservation
+}
+
+// newPriceHistoryStore Creates an empty priceHistoryStore
+func newPriceHistoryStore() *priceHistoryStore {
+	return &priceHistoryStore{now: time.Now, histories: map[string][]PriceObservation{}}
+}
+
+// priceHistoryKey Normalizes keyword so "Gucci  Belt" and "gucci belt" share a history
+func priceHistoryKey(keyword string) string {
+	return strings.ToLower(strings.Join(strings.Fields(keyword), " "))
+}
+
+// observePrices Computes the observation of items, reporting false when none has a readable price
+func observePrices(items []Item, at time.Time) (PriceObservation, bool) {
+	var prices []float64
+	for _, item := range items {
+		if price, err := strconv.ParseFloat(item.Price, 64); err == nil && price > 0 {
+			prices = append(prices, price)
+		}
+	}
+	if len(prices) == 0 {
+		return PriceObservation{}, false
+	}
+	sort.Float64s(prices)
+	median := prices[len(prices)/2]
+	if len(prices)%2 == 0 {
+		median = (prices[len(prices)/2-1] + prices[len(prices)/2]) / 2
+	}
+	observation := PriceObservation{At: at, Min: prices[0], Median: median, Count: len(prices)}
+	if len(items) > 0 {
+		observation.Currency = items[0].Currency
+	}
+	return observation, true
+}
+
+// Record Adds the prices of items to the history of keyword and returns the updated history, oldest first.
+// Searches without readable prices record nothing.
+func (st *priceHistoryStore) Record(keyword string, items []Item) []PriceObservation {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := st.now()
+	key := priceHistoryKey(keyword)
+	history := st.histories[key]
+	observation, found := observePrices(items, now)
+	if !found {
+		return append([]PriceObservation(nil), history...)
+	}
+
+	if n := len(history); n > 0 && now.Sub(history[n-1].At) < priceObservationGap {
+		history[n-1] = observation
+	} else {
+		history = append(history, observation)
+	}
+	history = prunePriceHistory(history, now)
+	if _, known := st.histories[key]; !known && len(st.histories) >= maxPriceKeywords {
+		st.evictOldest()
+	}
+	st.histories[key] = history
+	return append([]PriceObservation(nil), history...)
+}
+
+// prunePriceHistory Drops the observations that are too old or beyond the cap
+func prunePriceHistory(history []PriceObservation, now time.Time) []PriceObservation {
+	start := 0
+	for start < len(history) && now.Sub(history[start].At) > priceHistoryAge {
+		start++
+	}
+	if len(history)-start > maxPriceObservations {
+		start = len(history) - maxPriceObservations
+	}
+	return append(history[:0:0], history[start:]...)
+}
+
+// evictOldest Forgets the keyword searched least recently
+func (st *priceHistoryStore) evictOldest() {
+	oldestKey := ""
+	var oldest time.Time
+	for key, history := range st.histories {
+		if last := history[len(history)-1].At; oldestKey == "" || last.Before(oldest) {
+			oldestKey, oldest = key, last
+		}
+	}
+	delete(st.histories, oldestKey)
+}
+
+// priceTrend Compares the latest median of history with an earlier one, like "median price is down 8% vs two weeks ago".
+// It compares with the most recent observation at least a day older, or the one before the latest.
+// It returns "" for a first observation or a zero median.
+func priceTrend(history []PriceObservation) string {
+	if len(history) < 2 {
+		return ""
+	}
+	latest := history[len(history)-1]
+	reference := history[len(history)-2]
+	for i := len(history) - 2; i >= 0; i-- {
+		if latest.At.Sub(history[i].At) >= 24*time.Hour {
+			reference = history[i]
+			break
+		}
+	}
+	if reference.Median == 0 {
+		return ""
+	}
+
+	change := math.Round((latest.Median - reference.Median) / reference.Median * 100)
+	ago := humanizeAgo(latest.At.Sub(reference.At))
+	switch {
+	case change >= 1:
+		return "median price is up " + strconv.FormatFloat(change, 'f', 0, 64) + "% vs " + ago
+	case change <= -1:
+		return "median price is down " + strconv.FormatFloat(-change, 'f', 0, 64) + "% vs " + ago
+	}
+	return "median price is about the same as " + ago
+}
+
+// humanizeAgo Describes how long ago d was, like "yesterday" or "two weeks ago"
+func humanizeAgo(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	words := []string{"", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten", "eleven", "twelve"}
+	count := func(n int, unit string) string {
+		number := strconv.Itoa(n)
+		if n < len(words) {
+			number = words[n]
+		}
+		return number + " " + unit + "s ago"
+	}
+	switch {
+	case days == 0:
+		return "earlier today"
+	case days == 1:
+		return "yesterday"
+	case days < 7:
+		return count(days, "day")
+	case days < 14:
+		return "a week ago"
+	case days < 60:
+		return count(days/7, "week")
+	}
+	return count(days/30, "month")
+}
@@ -0,0 +1,145 @@
+package theluxuryshopper
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObservePrices(t *testing.T) {
+	at := time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		prices    []string
+		want      PriceObservation
+		wantFound bool
+	}{
+		{"odd", []string{"300", "100", "200"}, PriceObservation{At: at, Min: 100, Median: 200, Count: 3, Currency: "USD"}, true},
+		{"even", []string{"400", "100", "200", "300"}, PriceObservation{At: at, Min: 100, Median: 250, Count: 4, Currency: "USD"}, true},
+		{"unreadable skipped", []string{"n/a", "0", "150"}, PriceObservation{At: at, Min: 150, Median: 150, Count: 1, Currency: "USD"}, true},
+		{"none readable", []string{"n/a", ""}, PriceObservation{}, false},
+		{"no items", nil, PriceObservation{}, false},
+	}
+	for _, test := range tests {
+		var items []Item
+		for _, price := range test.prices {
+			items = append(items, Item{Price: price, Currency: "USD"})
+		}
+		if got, found := observePrices(items, at); got != test.want || found != test.wantFound {
+			t.Errorf("%v: observePrices() = %+v, %v, want %+v, %v", test.name, got, found, test.want, test.wantFound)
+		}
+	}
+}
+
+func TestHumanizeAgo(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{3 * time.Hour, "earlier today"},
+		{day + time.Hour, "yesterday"},
+		{3 * day, "three days ago"},
+		{8 * day, "a week ago"},
+		{15 * day, "two weeks ago"},
+		{59 * day, "eight weeks ago"},
+		{90 * day, "three months ago"},
+		{400 * day, "13 months ago"},
+	}
+	for _, test := range tests {
+		if got := humanizeAgo(test.d); got != test.want {
+			t.Errorf("humanizeAgo(%v) = %q, want %q", test.d, got, test.want)
+		}
+	}
+}
+
+func TestPriceTrend(t *testing.T) {
+	start := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+	observed := func(days float64, median float64) PriceObservation {
+		return PriceObservation{At: start.Add(time.Duration(days * float64(24*time.Hour))), Median: median}
+	}
+	tests := []struct {
+		name    string
+		history []PriceObservation
+		want    string
+	}{
+		{"first", []PriceObservation{observed(0, 100)}, ""},
+		{"down", []PriceObservation{observed(0, 100), observed(14, 92)}, "median price is down 8% vs two weeks ago"},
+		{"up", []PriceObservation{observed(0, 100), observed(1, 125)}, "median price is up 25% vs yesterday"},
+		{"same", []PriceObservation{observed(0, 100), observed(3, 100.4)}, "median price is about the same as three days ago"},
+		{"day old reference", []PriceObservation{observed(0, 100), observed(7, 200), observed(7.5, 110)}, "median price is up 10% vs a week ago"},
+		{"previous reference", []PriceObservation{observed(0, 100), observed(0.25, 200)}, "median price is up 100% vs earlier today"},
+		{"zero median", []PriceObservation{observed(0, 0), observed(1, 100)}, ""},
+	}
+	for _, test := range tests {
+		if got := priceTrend(test.history); got != test.want {
+			t.Errorf("%v: priceTrend() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestPriceHistoryStoreRecord(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)}
+	store := newPriceHistoryStore()
+	store.now = clock.Now
+	items := func(price string) []Item { return []Item{{Price: price}} }
+
+	store.Record("Gucci  Belt", items("100"))
+	clock.Advance(time.Minute)
+	if history := store.Record("gucci belt", items("120")); len(history) != 1 || history[0].Median != 120 {
+		t.Errorf("a search within the gap made the history %+v, want one merged observation", history)
+	}
+	if history := store.Record("gucci belt", items("n/a")); len(history) != 1 {
+		t.Errorf("a search without prices made the history %+v", history)
+	}
+
+	for i := 0; i < maxPriceObservations+5; i++ {
+		clock.Advance(priceObservationGap)
+		store.Record("gucci belt", items(strconv.Itoa(200+i)))
+	}
+	history := store.Record("gucci belt", items("n/a"))
+	if len(history) != maxPriceObservations || history[len(history)-1].Median != float64(200+maxPriceObservations+4) {
+		t.Errorf("the history kept %v observations up to %v", len(history), history[len(history)-1].Median)
+	}
+
+	clock.Advance(priceHistoryAge + priceObservationGap)
+	if history := store.Record("gucci belt", items("300")); len(history) != 1 {
+		t.Errorf("the observations older than %v were kept, the history has %v", priceHistoryAge, len(history))
+	}
+}
+
+func TestPriceHistoryStoreEvictsTheOldestKeyword(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)}
+	store := newPriceHistoryStore()
+	store.now = clock.Now
+	for i := 0; i < maxPriceKeywords; i++ {
+		clock.Advance(time.Second)
+		store.Record("bag "+strconv.Itoa(i), []Item{{Price: "100"}})
+	}
+	store.Record("bag 0", []Item{{Price: "100"}})
+	store.Record("new bag", []Item{{Price: "100"}})
+	if _, found := store.histories["bag 1"]; found || len(store.histories) != maxPriceKeywords {
+		t.Errorf("the store kept %v keywords, bag 1 among them: %v", len(store.histories), found)
+	}
+	if _, found := store.histories["bag 0"]; !found {
+		t.Error("the store evicted a keyword searched again")
+	}
+}
+
+func TestPriceTrendInTheResults(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("belt", 1, 3), Count: 3}}}
+	s := newTestServer(t, Config{}, searcher)
+	if reply := startConversation(t, s).sayAll("gucci belt", "none", "none", "none"); strings.Contains(reply.message(), "median price") {
+		t.Errorf("the first search has a trend: %q", reply.message())
+	}
+	s.clock.Advance(14 * 24 * time.Hour)
+	searcher.mu.Lock()
+	searcher.results[""] = SearchResult{Items: testItems("belt", 2, 3), Count: 3}
+	searcher.mu.Unlock()
+	// The history is shared across sessions, the first one expired meanwhile
+	reply := startConversation(t, s).sayAll("Gucci belt", "none", "none", "none")
+	if !strings.Contains(reply.message(), "\n The median price is up 50% vs two weeks ago.") {
+		t.Errorf("the second search answered %q", reply.message())
+	}
+}
@@ -0,0 +1,93 @@
+package theluxuryshopper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// priceNumber Matches an amount the way people type it, with thousands separators like "1.500",
+// "1 500" or "1'500" and a decimal comma or dot like "1 500,50"
+const priceNumber = `\d{1,3}(?:[ .,'’\x{a0}\x{202f}]\d{3})+(?:[.,]\d+)?|\d+(?:[.,]\d+)?`
+
+var (
+	// spacedThousands Matches amounts grouping their thousands with spaces or apostrophes, never decimal separators
+	spacedThousands = regexp.MustCompile(`^\d{1,3}(?:[ '’\x{a0}\x{202f}]\d{3})+(?:[.,]\d+)?$`)
+	// groupedThousands Matches digits grouped by three after the first group
+	groupedThousands = regexp.MustCompile(`^\d{1,3}(?:\x00\d{3})*$`)
+)
+
+// decimalCommaLanguages Are the languages writing 1.500,50 for what English writes 1,500.50
+var decimalCommaLanguages = map[string]bool{
+	"de": true, "fr": true, "es": true, "it": true, "pt": true, "nl": true, "ru": true, "pl": true,
+	"tr": true, "sv": true, "da": true, "fi": true, "nb": true, "no": true, "cs": true, "sk": true,
+	"hu": true, "ro": true, "el": true, "id": true, "vi": true, "uk": true,
+}
+
+// parsePrice Reads a price answer typed the way locale writes numbers, like "1.500" or "1 500,50 €"
+// in de, returning it as a plain decimal like "1500" or "1500.5", or "" when it isn't an amount.
+// A single separator followed by three digits is a thousands separator without a locale, as
+// prices are rarely given to the tenth of a cent.
+func parsePrice(price, locale string) string {
+	number := strings.TrimSpace(price)
+	number = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(number, "$"), "$"))
+	if spacedThousands.MatchString(number) {
+		number = strings.NewReplacer(" ", "", "'", "", "’", "", "\u00a0", "", "\u202f", "").Replace(number)
+	}
+
+	decimal := decimalSeparator(number, locale)
+	integer, fraction := number, ""
+	if decimal != "" {
+		cut := strings.LastIndex(number, decimal)
+		integer, fraction = number[:cut], number[cut+1:]
+	}
+	// What is left of the separators groups the thousands
+	if grouped := strings.NewReplacer(".", "\x00", ",", "\x00").Replace(integer); grouped != integer {
+		if !groupedThousands.MatchString(grouped) || strings.ContainsAny(integer, ".") && strings.ContainsAny(integer, ",") {
+			return ""
+		}
+		integer = strings.Replace(grouped, "\x00", "", -1)
+	}
+	if fraction != "" {
+		integer += "." + fraction
+	}
+	value, err := strconv.ParseFloat(integer, 64)
+	if err != nil || value < 0 || strings.ContainsAny(fraction, ".,") {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// decimalSeparator Returns the separator of number introducing its decimals, "" when it has none
+func decimalSeparator(number, locale string) string {
+	dot, comma := strings.LastIndex(number, "."), strings.LastIndex(number, ",")
+	switch {
+	case dot < 0 && comma < 0:
+		return ""
+	case dot >= 0 && comma >= 0:
+		// Both are used, the last one comes before the decimals
+		if dot > comma {
+			return "."
+		}
+		return ","
+	}
+	separator, last := ".", dot
+	if comma >= 0 {
+		separator, last = ",", comma
+	}
+	switch {
+	case strings.Count(number, separator) > 1:
+		return ""
+	case len(number)-last-1 != 3 || strings.HasPrefix(number, "0"+separator):
+		return separator
+	}
+	// "1.500" and "1,500" only tell their meaning through the locale
+	language := strings.ToLower(strings.SplitN(locale, "-", 2)[0])
+	if language == "" {
+		return ""
+	}
+	if decimalCommaLanguages[language] == (separator == ",") {
+		return separator
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		price, locale, want string
+	}{
+		{"500", "", "500"},
+		{" $500 ", "", "500"},
+		{"500$", "", "500"},
+		{"99.99", "", "99.99"},
+		{"99,99", "", "99.99"},
+		{"0.500", "", "0.5"},
+		{"1,500.50", "", "1500.5"},
+		{"1.500,50", "", "1500.5"},
+		{"1 500,50", "", "1500.5"},
+		{"1'500", "", "1500"},
+		{"1 500", "", "1500"},
+		{"1,500,000", "", "1500000"},
+		{"1.500", "", "1500"},
+		{"1.500", "de", "1500"},
+		{"1.500", "en-US", "1.5"},
+		{"1,500", "en", "1500"},
+		{"1,500", "fr-FR", "1.5"},
+		{"1,50", "de", "1.5"},
+		{"12,34,56", "", ""},
+		{"1.500.00,5", "", ""},
+		{"1.5.0", "", ""},
+		{"cheap", "", ""},
+		{"-500", "", ""},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		if got := parsePrice(test.price, test.locale); got != test.want {
+			t.Errorf("parsePrice(%q, %q) = %q, want %q", test.price, test.locale, got, test.want)
+		}
+	}
+}
+
+func TestPriceAnswersFollowTheLocale(t *testing.T) {
+	tests := []struct {
+		locale, answer, want string
+	}{
+		{"de", "1.500", "1500"},
+		{"en", "1.500", "1.5"},
+		{"de", "2.500,50", "2500.5"},
+	}
+	for _, test := range tests {
+		searcher := &fakeSearcher{}
+		s := newTestServer(t, Config{}, searcher)
+		handler := s.Routes()
+		welcome := do(t, handler, http.MethodGet, "/v1/welcome?locale="+test.locale, "", nil)
+		uuid, _ := welcome.Body["uuid"].(string)
+		c := &conversation{t: t, handler: handler, clock: s.clock, uuid: uuid}
+		c.sayAll("kelly bag", "none", "none", test.answer)
+		if queries := searcher.Queries(); len(queries) != 1 || queries[0].MaxPrice != test.want {
+			t.Errorf("%q in %v searched %+v, want the max price %v", test.answer, test.locale, queries, test.want)
+		}
+	}
+}
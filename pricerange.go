@@ -0,0 +1,66 @@
+package theluxuryshopper
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var (
+	underPriceAnswer   = regexp.MustCompile(`(?i)^\s*(?:under|below|less\s+than|up\s+to)\s+\$?(` + priceNumber + `)\s*\$?\W*$`)
+	betweenPriceAnswer = regexp.MustCompile(`(?i)^\s*(?:between\s+)?\$?(` + priceNumber + `)\s*\$?\s*(?:-|–|to|and)\s*\$?(` + priceNumber + `)\s*\$?\W*$`)
+	overPriceAnswer    = regexp.MustCompile(`(?i)^\s*(?:(?:over|above|more\s+than|at\s+least)\s+\$?(` + priceNumber + `)\s*\$?|\$?(` + priceNumber + `)\s*\$?\s*\+)\W*$`)
+)
+
+// parsePriceRange Reads a price answer giving both bounds at once, like "under 100", "100-500" or
+// "500+", returning them as localizedPrice would for locale with "none" for the missing one
+func parsePriceRange(answer, locale string) (minPrice, maxPrice string, found bool) {
+	if match := underPriceAnswer.FindStringSubmatch(answer); match != nil {
+		if maxPrice = parsePrice(match[1], locale); maxPrice != "" {
+			return "none", maxPrice, true
+		}
+	}
+	if match := betweenPriceAnswer.FindStringSubmatch(answer); match != nil {
+		minPrice, maxPrice = parsePrice(match[1], locale), parsePrice(match[2], locale)
+		low, lowErr := strconv.ParseFloat(minPrice, 64)
+		high, highErr := strconv.ParseFloat(maxPrice, 64)
+		if lowErr != nil || highErr != nil || low > high {
+			return "", "", false
+		}
+		return minPrice, maxPrice, true
+	}
+	if match := overPriceAnswer.FindStringSubmatch(answer); match != nil {
+		price := match[1]
+		if price == "" {
+			price = match[2]
+		}
+		if minPrice = parsePrice(price, locale); minPrice != "" {
+			return minPrice, "none", true
+		}
+	}
+	return "", "", false
+}
+
+// minPriceRange Answers the minimum price question with a range, which answers the maximum price question too.
+// It reports whether message was a range.
+func minPriceRange(session Session, message, locale string) bool {
+	minPrice, maxPrice, found := parsePriceRange(message, locale)
+	if !found {
+		return false
+	}
+	session["minPrice"], session["maxPrice"] = minPrice, maxPrice
+	return true
+}
+
+// maxPriceRange Answers the maximum price question with a range, whose lower bound fills in a minimum price
+// left at none. It reports whether message was a range.
+func maxPriceRange(session Session, message, locale string) bool {
+	minPrice, maxPrice, found := parsePriceRange(message, locale)
+	if !found {
+		return false
+	}
+	session["maxPrice"] = maxPrice
+	if minPrice != "none" && session.GetString("minPrice", "none") == "none" {
+		session["minPrice"] = minPrice
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package theluxuryshopper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePriceRange(t *testing.T) {
+	tests := []struct {
+		answer, locale   string
+		wantMin, wantMax string
+		wantFound        bool
+	}{
+		{"Under 100", "", "none", "100", true},
+		{"less than $1,500", "", "none", "1500", true},
+		{"up to 2.500,50", "de", "none", "2500.5", true},
+		{"100-500", "", "100", "500", true},
+		{"between $100 and $500!", "", "100", "500", true},
+		{"1.000 to 2.000", "de", "1000", "2000", true},
+		{"100 – 100", "", "100", "100", true},
+		{"500-100", "", "", "", false},
+		{"500+", "", "500", "none", true},
+		{"at least 1 500", "", "1500", "none", true},
+		{"over 99.99", "", "99.99", "none", true},
+		{"500", "", "", "", false},
+		{"under the sea", "", "", "", false},
+		{"none", "", "", "", false},
+	}
+	for _, test := range tests {
+		minPrice, maxPrice, found := parsePriceRange(test.answer, test.locale)
+		if minPrice != test.wantMin || maxPrice != test.wantMax || found != test.wantFound {
+			t.Errorf("parsePriceRange(%q, %q) = %q, %q, %v, want %q, %q, %v", test.answer, test.locale, minPrice, maxPrice, found, test.wantMin, test.wantMax, test.wantFound)
+		}
+	}
+}
+
+func TestMaxPriceRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		session Session
+		message string
+		want    Session
+	}{
+		{"fills a minimum left at none", Session{"minPrice": "none"}, "100-500", Session{"minPrice": "100", "maxPrice": "500"}},
+		{"keeps a minimum given", Session{"minPrice": "50"}, "100-500", Session{"minPrice": "50", "maxPrice": "500"}},
+		{"under", Session{"minPrice": "none"}, "under 300", Session{"minPrice": "none", "maxPrice": "300"}},
+		{"over", Session{"minPrice": "none"}, "800+", Session{"minPrice": "800", "maxPrice": "none"}},
+		{"not a range", Session{"minPrice": "none"}, "300", Session{"minPrice": "none"}},
+	}
+	for _, test := range tests {
+		maxPriceRange(test.session, test.message, "")
+		if !reflect.DeepEqual(test.session, test.want) {
+			t.Errorf("%v: maxPriceRange() made %v, want %v", test.name, test.session, test.want)
+		}
+	}
+}
+
+func TestPriceRangeAnswers(t *testing.T) {
+	tests := []struct {
+		name             string
+		answers          []string
+		wantMin, wantMax string
+	}{
+		{"range for the minimum", []string{"kelly bag", "none", "100-500"}, "100", "500"},
+		{"under for the minimum", []string{"kelly bag", "none", "Under 100"}, "none", "100"},
+		{"range for the maximum", []string{"kelly bag", "none", "none", "500+"}, "500", "none"},
+	}
+	for _, test := range tests {
+		searcher := &fakeSearcher{}
+		c := startConversation(t, newTestServer(t, Config{}, searcher))
+		c.sayAll(test.answers...)
+		if queries := searcher.Queries(); len(queries) != 1 || queries[0].MinPrice != test.wantMin || queries[0].MaxPrice != test.wantMax {
+			t.Errorf("%v: searched %+v, want the prices %v to %v", test.name, queries, test.wantMin, test.wantMax)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package theluxuryshopper
+
+import (
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// priceSanityShare Is how far below the typical starting price of a keyword a maximum has to be to get a warning
+const priceSanityShare = 0.25
+
+var (
+	searchAnywayAnswer = regexp.MustCompile(`(?i)^\s*(?:y|yes|yeah|yep|ok|okay|sure|go\s+ahead|keep\s+it|(?:yes\W*)?search\s+anyway)\W*$`)
+	raiseMaxAnswer     = regexp.MustCompile(`(?i)^\s*(?:n|no|nope|change\s+it|raise(?:\s+(?:it|the\s+max(?:imum)?(?:\s+price)?))?)\W*$`)
+)
+
+// TypicalMin Returns the price items of keyword usually start at, the median of the lowest prices its searches saw,
+// along with their currency. It reports false for keywords without a history.
+func (st *priceHistoryStore) TypicalMin(keyword string) (float64, string, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	history := prunePriceHistory(st.histories[priceHistoryKey(keyword)], st.now())
+	if len(history) == 0 {
+		return 0, "", false
+	}
+	mins := make([]float64, len(history))
+	for i, observation := range history {
+		mins[i] = observation.Min
+	}
+	sort.Float64s(mins)
+	return mins[len(mins)/2], history[len(history)-1].Currency, true
+}
+
+// warnMaxPrice Asks the user to confirm a maximum price far below what the keyword usually starts at,
+// reporting whether it did. Each search warns at most once.
+func (s *Server) warnMaxPrice(session Session, w http.ResponseWriter) bool {
+	if _, checked := session["maxPriceChecked"]; checked {
+		return false
+	}
+	session["maxPriceChecked"] = true
+	maxPrice, err := strconv.ParseFloat(session.GetString("maxPrice", ""), 64)
+	keyword, _ := session["searchByKeyword"].(string)
+	if err != nil || keyword == "" {
+		return false
+	}
+	typical, currency, found := s.prices.TypicalMin(keyword)
+	if !found || maxPrice >= typical*priceSanityShare {
+		return false
+	}
+
+	session["maxPriceConfirm"] = true
+	s.events.QuestionAsked("maxPriceConfirm")
+	WriteReply(w, ReplyQuestion, JSON{
+		"message":         maxPriceWarning(keyword, maxPrice, typical, currency),
+		"step":            "maxPriceConfirm",
+		"typicalMinPrice": typical,
+	})
+	return true
+}
+
+// maxPriceWarning Words the warning about a maximum price far below the typical one
+func maxPriceWarning(keyword string, maxPrice, typical float64, currency string) string {
+	return "Heads up: " + keyword + " usually starts around " + roundedPrice(typical, currency) +
+		", so a maximum of " + roundedPrice(maxPrice, currency) + " may find little or nothing. " +
+		"Search anyway, or raise the maximum? (Say 'search anyway' or a new maximum price)"
+}
+
+// roundedPrice Formats price with two significant digits and thousands separators, like "4,000 USD"
+func roundedPrice(price float64, currency string) string {
+	if price >= 100 {
+		magnitude := math.Pow(10, math.Floor(math.Log10(price))-1)
+		price = math.Round(price/magnitude) * magnitude
+	}
+	digits := strconv.FormatFloat(price, 'f', 0, 64)
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return strings.TrimSpace(digits + " " + currency)
+}
+
+// confirmMaxPrice Handles the answer to the warning of warnMaxPrice: "search anyway" keeps the maximum,
+// a price replaces it and "raise it" asks for it again. It returns 1 when it replied to the message.
+func (s *Server) confirmMaxPrice(session Session, message string, w http.ResponseWriter) int {
+	switch {
+	case searchAnywayAnswer.MatchString(message):
+		delete(session, "maxPriceConfirm")
+		return 0
+	case raiseMaxAnswer.MatchString(message):
+		delete(session, "maxPriceConfirm")
+		delete(session, "maxPrice")
+		WriteReply(w, ReplyQuestion, JSON{
+			"message": maxPriceQuestion,
+			"step":    "maxPrice",
+		})
+		return 1
+	}
+	if price := s.normalizeAnswer(takePriceCurrency(session, message), func(price string) string { return localizedPrice(price, requestLocale(w)) }); price != "" {
+		delete(session, "maxPriceConfirm")
+		session["maxPrice"] = price
+		return 0
+	}
+	s.answerRejected(w, "maxPriceConfirm")
+	maxPrice, _ := strconv.ParseFloat(session.GetString("maxPrice", ""), 64)
+	keyword, _ := session["searchByKeyword"].(string)
+	typical, currency, _ := s.prices.TypicalMin(keyword)
+	WriteReply(w, ReplyQuestion, JSON{
+		"message":         maxPriceWarning(keyword, maxPrice, typical, currency),
+		"step":            "maxPriceConfirm",
+		"typicalMinPrice": typical,
+	})
+	return 1
+}
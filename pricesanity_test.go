@@ -0,0 +1,107 @@
+package theluxuryshopper
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundedPrice(t *testing.T) {
+	tests := []struct {
+		price    float64
+		currency string
+		want     string
+	}{
+		{4123, "USD", "4,100 USD"},
+		{87.4, "EUR", "87 EUR"},
+		{155, "", "160"},
+		{1234567, "USD", "1,200,000 USD"},
+		{999, "GBP", "1,000 GBP"},
+	}
+	for _, test := range tests {
+		if got := roundedPrice(test.price, test.currency); got != test.want {
+			t.Errorf("roundedPrice(%v, %q) = %q, want %q", test.price, test.currency, got, test.want)
+		}
+	}
+}
+
+func TestTypicalMin(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)}
+	store := newPriceHistoryStore()
+	store.now = clock.Now
+	for _, price := range []string{"4000", "3000", "5000"} {
+		store.Record("Kelly Bag", []Item{{Price: price, Currency: "USD"}})
+		clock.Advance(priceObservationGap)
+	}
+	if typical, currency, found := store.TypicalMin("kelly  bag"); typical != 4000 || currency != "USD" || !found {
+		t.Errorf("TypicalMin() = %v, %q, %v, want 4000 USD", typical, currency, found)
+	}
+	if _, _, found := store.TypicalMin("birkin"); found {
+		t.Error("TypicalMin() found a keyword never searched")
+	}
+	clock.Advance(priceHistoryAge + time.Hour)
+	if _, _, found := store.TypicalMin("kelly bag"); found {
+		t.Error("TypicalMin() found a history older than its age")
+	}
+}
+
+func TestMaxPriceAnswers(t *testing.T) {
+	tests := []struct {
+		answer             string
+		wantAnyway, wantNo bool
+	}{
+		{"search anyway", true, false},
+		{"Yes, search anyway!", true, false},
+		{"ok", true, false},
+		{"raise it", false, true},
+		{"raise the maximum price", false, true},
+		{"no", false, true},
+		{"600", false, false},
+	}
+	for _, test := range tests {
+		if anyway, no := searchAnywayAnswer.MatchString(test.answer), raiseMaxAnswer.MatchString(test.answer); anyway != test.wantAnyway || no != test.wantNo {
+			t.Errorf("%q is search anyway: %v, raise: %v, want %v, %v", test.answer, anyway, no, test.wantAnyway, test.wantNo)
+		}
+	}
+}
+
+func TestMaxPriceWarning(t *testing.T) {
+	tests := []struct {
+		name       string
+		answers    []string
+		wantStep   string
+		wantSearch string // the max price searched, "" for no search
+	}{
+		{"warned", []string{"300"}, "maxPriceConfirm", ""},
+		{"search anyway", []string{"300", "search anyway"}, "", "300"},
+		{"new maximum", []string{"300", "5000"}, "", "5000"},
+		{"raise it", []string{"300", "raise it"}, "maxPrice", ""},
+		{"raised once", []string{"300", "raise it", "200"}, "", "200"},
+		{"not an answer", []string{"300", "maybe"}, "maxPriceConfirm", ""},
+		{"plausible", []string{"2000"}, "", "2000"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: []Item{{ID: "1", Price: "4000", Currency: "USD"}}, Count: 1}}}
+			s := newTestServer(t, Config{}, searcher)
+			startConversation(t, s).sayAll("kelly bag", "none", "none", "none")
+
+			c := startConversation(t, s)
+			reply := c.sayAll(append([]string{"kelly bag", "none", "none"}, test.answers...)...)
+			if test.wantStep != "" && reply.Body["step"] != test.wantStep {
+				t.Errorf("the conversation asked %v: %q, want %v", reply.Body["step"], reply.message(), test.wantStep)
+			}
+			if test.wantStep == "maxPriceConfirm" && !strings.HasPrefix(reply.message(), "Heads up: kelly bag usually starts around 4,000 USD, so a maximum of 300 USD") {
+				t.Errorf("the warning is %q", reply.message())
+			}
+			queries := searcher.Queries()
+			searched := ""
+			if len(queries) == 2 {
+				searched = queries[1].MaxPrice
+			}
+			if searched != test.wantSearch {
+				t.Errorf("the second conversation searched up to %q, want %q", searched, test.wantSearch)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package theluxuryshopper
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// defaultProfileTTL How long the durable data of a userId survives without any session using it
+	defaultProfileTTL = 90 * 24 * time.Hour
+	// maxUserIDLength Caps the userIds clients may send
+	maxUserIDLength = 128
+)
+
+// errUserToken Is returned when a userId that has a profile comes without the userToken it was given
+var errUserToken = errors.New("the userToken doesn't match the userId")
+
+// storedProfile Holds the durable data of one userId, along with the hash of the userToken that owns it
+type storedProfile struct {
+	Backup    sessionBackup `json:"backup"`
+	TokenHash string        `json:"tokenHash"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+}
+
+// profileStore Keeps the preferences and saved searches of durable users, keyed by userId, so they outlive
+// the sessions of those users. The first session of a userId gets a userToken every later one must present.
+// The profiles survive restarts when path isn't empty.
+type profileStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	now      func() time.Time
+	path     string
+	profiles map[string]*storedProfile
+}
+
+// newProfileStore Creates a profileStore forgetting the profiles unused for longer than ttl,
+// continuing the profiles saved at path
+func newProfileStore(ttl time.Duration, path string) *profileStore {
+	if ttl <= 0 {
+		ttl = defaultProfileTTL
+	}
+	p := &profileStore{ttl: ttl, now: time.Now, path: path, profiles: map[string]*storedProfile{}}
+	if path == "" {
+		return p
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &p.profiles)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("couldn't read the user profiles from %v, starting without any: %v", path, err)
+		p.profiles = map[string]*storedProfile{}
+	}
+	return p
+}
+
+// Claim Returns the durable data of userID when token is the userToken it was given, reporting whether
+// there was any. A userID without a profile is claimed for a new userToken, returned as issued.
+// A userID with one fails with errUserToken for any other token.
+func (p *profileStore) Claim(userID, token string) (backup sessionBackup, found bool, issued string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	p.prune(now)
+	if stored, found := p.profiles[userID]; found {
+		if subtle.ConstantTimeCompare([]byte(stored.TokenHash), []byte(hashUserToken(token))) != 1 {
+			return sessionBackup{}, false, "", errUserToken
+		}
+		stored.UpdatedAt = now
+		p.save()
+		return stored.Backup, true, "", nil
+	}
+	issued = newUserToken()
+	p.profiles[userID] = &storedProfile{Backup: sessionBackup{Version: backupVersion, ExportedAt: now}, TokenHash: hashUserToken(issued), UpdatedAt: now}
+	p.save()
+	return sessionBackup{}, false, issued, nil
+}
+
+// Save Replaces the durable data of the claimed userID, a profile that expired meanwhile isn't claimed again
+func (p *profileStore) Save(userID string, backup sessionBackup) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	p.prune(now)
+	stored, found := p.profiles[userID]
+	if !found {
+		return
+	}
+	stored.Backup, stored.UpdatedAt = backup, now
+	p.save()
+}
+
+// prune Forgets the profiles unused for longer than the TTL as of now, p.mu must be held
+func (p *profileStore) prune(now time.Time) {
+	for id, stored := range p.profiles {
+		if now.Sub(stored.UpdatedAt) > p.ttl {
+			delete(p.profiles, id)
+		}
+	}
+}
+
+// save Writes the profiles to p.path, p.mu must be held
+func (p *profileStore) save() {
+	if p.path == "" {
+		return
+	}
+	data, err := json.Marshal(p.profiles)
+	if err == nil {
+		// Write aside and rename so a crash never leaves half a file, the file holds user data
+		if err = os.WriteFile(p.path+".tmp", data, 0o600); err == nil {
+			err = os.Rename(p.path+".tmp", p.path)
+		}
+	}
+	if err != nil {
+		log.Printf("couldn't save the user profiles to %v: %v", p.path, err)
+	}
+}
+
+// newUserToken Generates the random userToken owning a new profile
+func newUserToken() string {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(random)
+}
+
+// hashUserToken Returns what is stored of token, so the profile file can't be used to take over a userId
+func hashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// validUserID Reports whether a client supplied userId can key a profile
+func validUserID(userID string) bool {
+	if userID == "" || len(userID) > maxUserIDLength {
+		return false
+	}
+	for _, r := range userID {
+		if !unicode.IsPrint(r) || unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// attachProfile Ties session to the userID it claimed and restores the durable data found for it, returning what was restored
+func attachProfile(session Session, userID string, backup sessionBackup, found bool) []string {
+	session["userId"] = userID
+	if !found {
+		return nil
+	}
+	restored, _ := importSession(session, backup)
+	return restored
+}
+
+// saveProfile Saves the durable data of session under its userId, sessions without one stay ephemeral
+func (s *Server) saveProfile(session Session) {
+	if userID, found := session["userId"].(string); found {
+		s.profiles.Save(userID, exportSession(session, time.Now()))
+	}
+}
@@ -0,0 +1,100 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	now := time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)
+	store := newProfileStore(time.Hour, path)
+	store.now = func() time.Time { return now }
+
+	_, found, token, err := store.Claim("alice", "")
+	if err != nil || found || token == "" {
+		t.Fatalf("claiming a new userId = %v, %q, %v, want a token", found, token, err)
+	}
+	store.Save("alice", sessionBackup{Version: backupVersion, Preferences: backupPreferences{Timezone: "Europe/Paris"}})
+	store.Save("bob", sessionBackup{Version: backupVersion})
+
+	// The profiles and the hashes of their tokens survive a restart
+	restarted := newProfileStore(time.Hour, path)
+	restarted.now = store.now
+	tests := []struct {
+		name      string
+		userID    string
+		token     string
+		wantFound bool
+		wantErr   error
+	}{
+		{"owner", "alice", token, true, nil},
+		{"no token", "alice", "", false, errUserToken},
+		{"another token", "alice", newUserToken(), false, errUserToken},
+		{"unclaimed userId, never saved", "bob", "", false, nil},
+	}
+	for _, test := range tests {
+		backup, found, _, err := restarted.Claim(test.userID, test.token)
+		if found != test.wantFound || err != test.wantErr {
+			t.Errorf("%v: Claim = %v, %v, want %v, %v", test.name, found, err, test.wantFound, test.wantErr)
+		}
+		if found && backup.Preferences.Timezone != "Europe/Paris" {
+			t.Errorf("%v: Claim restored %+v", test.name, backup.Preferences)
+		}
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), token) {
+		t.Errorf("the profile file holds the userToken itself")
+	}
+
+	// An unused profile expires and its userId can be claimed again
+	now = now.Add(2 * time.Hour)
+	if _, found, issued, err := restarted.Claim("alice", ""); found || issued == "" || err != nil {
+		t.Errorf("claiming an expired userId = %v, %q, %v, want a new token", found, issued, err)
+	}
+}
+
+func TestProfileStoreUnreadableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	os.WriteFile(path, []byte("{not json"), 0o600)
+	store := newProfileStore(time.Hour, path)
+	if _, found, token, err := store.Claim("alice", ""); found || token == "" || err != nil {
+		t.Errorf("a store with an unreadable file = %v, %q, %v, want an empty store", found, token, err)
+	}
+}
+
+func TestWelcomeUserToken(t *testing.T) {
+	s := newTestServer(t, Config{ProfileFile: filepath.Join(t.TempDir(), "profiles.json")}, &fakeSearcher{})
+	routes := s.Routes()
+	welcome := func(token string) testReply {
+		t.Helper()
+		header := http.Header{}
+		if token != "" {
+			header.Set("X-User-Token", token)
+		}
+		return do(t, routes, http.MethodGet, "/v1/welcome?userId=alice", "", header)
+	}
+
+	first := welcome("")
+	token, _ := first.Body["userToken"].(string)
+	if first.Status != http.StatusOK || token == "" {
+		t.Fatalf("the first welcome of alice answered %v %v, want a userToken", first.Status, first.Raw)
+	}
+	c := &conversation{t: t, handler: routes, clock: s.clock, uuid: first.Body["uuid"].(string)}
+	c.say("timezone Europe/Paris")
+
+	if reply := welcome(""); reply.Status != http.StatusForbidden || reply.errorCode() != "invalid_user_token" {
+		t.Errorf("a welcome of alice without her token answered %v %v, want 403 invalid_user_token", reply.Status, reply.Raw)
+	}
+	reply := welcome(token)
+	if reply.Status != http.StatusOK || reply.Body["userToken"] != nil {
+		t.Fatalf("a welcome of alice with her token answered %v %v", reply.Status, reply.Raw)
+	}
+	if restored, _ := reply.Body["restored"].([]interface{}); len(restored) == 0 {
+		t.Errorf("a welcome of alice with her token restored nothing: %v", reply.Raw)
+	}
+}
@@ -0,0 +1,96 @@
+package theluxuryshopper
+
+import "net/http"
+
+// progressStepAliases Maps the follow-up questions of a step to it, they don't count as steps of their own
+var progressStepAliases = map[string]string{"maxPriceConfirm": "maxPrice"}
+
+// questionProgress Returns where the question of step stands among the questions of the search of session,
+// for UIs showing "step 2 of 4". Steps answered without being asked, by a default, an override or one
+// message giving several filters, aren't counted. Questions outside of the steps, like confirming a
+// command, stand for the next step to ask. The step is noted as asked in session, nil is returned
+// when no step is left.
+func (s *Server) questionProgress(session Session, step string) JSON {
+	flow := []string{"keyword"}
+	if skip, _ := session["skipQuestions"].(bool); !skip {
+		flow = append(flow, s.steps...)
+	}
+	if alias, found := progressStepAliases[step]; found {
+		step = alias
+	}
+	if !containsStep(flow, step) {
+		step = ""
+		for _, name := range flow {
+			if !s.stepSkipped(session, name) {
+				step = name
+				break
+			}
+		}
+		if step == "" {
+			return nil
+		}
+	}
+	asked, _ := session["askedSteps"].([]string)
+	if !containsStep(asked, step) {
+		asked = append(append([]string{}, asked...), step)
+		session["askedSteps"] = asked
+	}
+
+	done, remaining := 0, []string{}
+	for _, name := range flow {
+		switch {
+		case name == step:
+		case !s.stepSkipped(session, name):
+			remaining = append(remaining, name)
+		case name == "keyword" || containsStep(asked, name):
+			done++
+		}
+	}
+	return JSON{"current": done + 1, "total": done + 1 + len(remaining), "remainingSteps": remaining}
+}
+
+// stepSkipped Reports whether the step name won't be asked: session holds its answer, or an override or
+// a default of the deployment fills it once the keyword is known
+func (s *Server) stepSkipped(session Session, name string) bool {
+	if name == "keyword" {
+		name = "searchByKeyword"
+	}
+	if _, answered := session[name]; answered {
+		return true
+	}
+	overrides, _ := session["filterOverrides"].(map[string]interface{})
+	if _, found := overrides[name]; found {
+		return true
+	}
+	_, found := s.config.DefaultFilters.values()[name]
+	return found
+}
+
+// containsStep Reports whether steps lists name
+func containsStep(steps []string, name string) bool {
+	for _, step := range steps {
+		if step == name {
+			return true
+		}
+	}
+	return false
+}
+
+// noteProgress Sets how the question replies written to w describe their progress
+func noteProgress(w http.ResponseWriter, progress func(step string) JSON) {
+	if mw, ok := w.(*metaWriter); ok {
+		mw.progress = progress
+	}
+}
+
+// addProgress Adds the progress of the question data asks to it, unless it already has one
+func addProgress(w http.ResponseWriter, data JSON) {
+	mw, ok := w.(*metaWriter)
+	if _, set := data["progress"]; set || !ok || mw.progress == nil {
+		return
+	}
+	step, _ := data["step"].(string)
+	if progress := mw.progress(step); progress != nil {
+		data["progress"] = progress
+	}
+}
@@ -0,0 +1,86 @@
+package theluxuryshopper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuestionProgress(t *testing.T) {
+	s := &Server{steps: []string{"condition", "minPrice", "maxPrice"}, config: Config{DefaultFilters: DefaultFilters{MinPrice: "100"}}}
+	tests := []struct {
+		name    string
+		session Session
+		step    string
+		want    JSON
+	}{
+		{
+			name: "keyword", session: Session{}, step: "keyword",
+			want: JSON{"current": 1, "total": 3, "remainingSteps": []string{"condition", "maxPrice"}},
+		},
+		{
+			name: "condition", session: Session{"searchByKeyword": "kelly", "askedSteps": []string{"keyword"}}, step: "condition",
+			want: JSON{"current": 2, "total": 3, "remainingSteps": []string{"maxPrice"}},
+		},
+		{
+			name: "follow-up of the maximum", session: Session{"searchByKeyword": "kelly", "condition": "New", "askedSteps": []string{"keyword", "condition"}}, step: "maxPriceConfirm",
+			want: JSON{"current": 3, "total": 3, "remainingSteps": []string{}},
+		},
+		{
+			name: "answered unasked", session: Session{"searchByKeyword": "kelly", "condition": "New", "askedSteps": []string{"keyword"}}, step: "maxPrice",
+			want: JSON{"current": 2, "total": 2, "remainingSteps": []string{}},
+		},
+		{
+			name: "overridden", session: Session{"searchByKeyword": "kelly", "filterOverrides": map[string]interface{}{"condition": "Used"}}, step: "maxPrice",
+			want: JSON{"current": 2, "total": 2, "remainingSteps": []string{}},
+		},
+		{
+			name: "outside of the steps", session: Session{"searchByKeyword": "kelly"}, step: "confirmReset",
+			want: JSON{"current": 2, "total": 3, "remainingSteps": []string{"maxPrice"}},
+		},
+		{
+			name: "skipped questions", session: Session{"skipQuestions": true}, step: "keyword",
+			want: JSON{"current": 1, "total": 1, "remainingSteps": []string{}},
+		},
+		{
+			name: "nothing left", session: Session{"searchByKeyword": "kelly", "condition": "New", "maxPrice": "500"}, step: "confirmReset",
+			want: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := s.questionProgress(test.session, test.step)
+			if test.want == nil {
+				if got != nil {
+					t.Errorf("questionProgress() = %v, want nil", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("questionProgress() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestProgressOfTheQuestions(t *testing.T) {
+	c := startConversation(t, newTestServer(t, Config{}, &fakeSearcher{}))
+	tests := []struct {
+		message     string
+		wantCurrent float64
+		wantTotal   float64
+	}{
+		{"kelly bag", 2, 4},
+		{"used", 3, 4},
+		{"none", 4, 4},
+	}
+	for _, test := range tests {
+		reply := c.say(test.message)
+		progress, _ := reply.Body["progress"].(map[string]interface{})
+		if progress["current"] != test.wantCurrent || progress["total"] != test.wantTotal {
+			t.Errorf("%q answered the progress %v, want %v of %v", test.message, reply.Body["progress"], test.wantCurrent, test.wantTotal)
+		}
+	}
+	if reply := c.say("none"); reply.Body["progress"] != nil {
+		t.Errorf("the results have the progress %v", reply.Body["progress"])
+	}
+}
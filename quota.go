@@ -0,0 +1,155 @@
+package theluxuryshopper
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDailyCallLimit Is the Finding API allowance of an app id when EBAY_DAILY_CALL_LIMIT isn't set
+	defaultDailyCallLimit = 5000
+	// quotaWindow Is the rolling window the calls are counted over
+	quotaWindow = 24 * time.Hour
+	// quotaResolution Groups the calls of one minute into one count
+	quotaResolution = time.Minute
+	// quotaWarnShare Logs a warning once this share of the limit is used
+	quotaWarnShare = 0.8
+)
+
+// quotaExhausted Is returned instead of searching once the daily call limit is used up
+type quotaExhausted struct {
+	resetAt time.Time
+}
+
+func (e *quotaExhausted) Error() string {
+	return "Daily search limit reached, try again after " + e.resetAt.UTC().Format("15:04 MST") + "."
+}
+
+// quotaCount Holds the calls made during one minute
+type quotaCount struct {
+	Minute time.Time `json:"minute"`
+	Calls  int       `json:"calls"`
+}
+
+// callQuota Counts the eBay calls of the last 24 hours, saving the counts to path if it isn't empty
+// so a restart doesn't reset them. A limit of 0 counts without ever refusing a search.
+type callQuota struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	limit  int
+	path   string
+	counts []quotaCount // oldest first
+	warned bool
+}
+
+// newCallQuota Creates a callQuota, continuing the counts saved at path
+func newCallQuota(limit int, path string) *callQuota {
+	q := &callQuota{now: time.Now, limit: limit, path: path}
+	if path == "" {
+		return q
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &q.counts)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("couldn't read the eBay call counts from %v, starting from zero: %v", path, err)
+		q.counts = nil
+	}
+	return q
+}
+
+// prune Drops the counts that left the window as of now
+func (q *callQuota) prune(now time.Time) {
+	cutoff := now.Add(-quotaWindow)
+	drop := 0
+	for drop < len(q.counts) && !q.counts[drop].Minute.After(cutoff) {
+		drop++
+	}
+	q.counts = q.counts[drop:]
+}
+
+// used Returns the calls made in the window, q.mu must be held
+func (q *callQuota) used() int {
+	used := 0
+	for _, count := range q.counts {
+		used += count.Calls
+	}
+	return used
+}
+
+// resetAt Returns when calls leave the window again, q.mu must be held
+func (q *callQuota) resetAt(now time.Time) time.Time {
+	if len(q.counts) == 0 {
+		return now
+	}
+	return q.counts[0].Minute.Add(quotaWindow)
+}
+
+// Check Returns a *quotaExhausted if no call is left in the window
+func (q *callQuota) Check() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	q.prune(now)
+	if q.limit > 0 && q.used() >= q.limit {
+		return &quotaExhausted{resetAt: q.resetAt(now)}
+	}
+	return nil
+}
+
+// Record Counts calls made now, warning once per window when most of the limit is used
+func (q *callQuota) Record(calls int) {
+	if calls <= 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	q.prune(now)
+	minute := now.UTC().Truncate(quotaResolution)
+	if last := len(q.counts) - 1; last >= 0 && q.counts[last].Minute.Equal(minute) {
+		q.counts[last].Calls += calls
+	} else {
+		q.counts = append(q.counts, quotaCount{Minute: minute, Calls: calls})
+	}
+
+	used := q.used()
+	switch warn := q.limit > 0 && float64(used) >= quotaWarnShare*float64(q.limit); {
+	case warn && !q.warned:
+		log.Printf("eBay calls of the last 24 hours are at %v of the daily limit of %v", used, q.limit)
+		q.warned = true
+	case !warn:
+		q.warned = false
+	}
+	q.save()
+}
+
+// Usage Returns the calls made in the window, the limit and when the oldest counted call leaves the window
+func (q *callQuota) Usage() (used, limit int, resetAt time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	q.prune(now)
+	return q.used(), q.limit, q.resetAt(now)
+}
+
+// save Writes the counts to q.path, q.mu must be held
+func (q *callQuota) save() {
+	if q.path == "" {
+		return
+	}
+	data, err := json.Marshal(q.counts)
+	if err == nil {
+		// Write aside and rename so a crash never leaves half a file
+		if err = os.WriteFile(q.path+".tmp", data, 0o644); err == nil {
+			err = os.Rename(q.path+".tmp", q.path)
+		}
+	}
+	if err != nil {
+		log.Printf("couldn't save the eBay call counts to %v: %v", q.path, err)
+	}
+}
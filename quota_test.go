@@ -0,0 +1,96 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCallQuota(t *testing.T) {
+	start := time.Date(2026, time.October, 15, 12, 0, 30, 0, time.UTC)
+	tests := []struct {
+		name      string
+		limit     int
+		calls     []int         // recorded a minute apart
+		after     time.Duration // from the last call
+		wantUsed  int
+		wantError bool
+	}{
+		{"under the limit", 10, []int{3, 4}, 0, 7, false},
+		{"limit reached", 10, []int{6, 4}, 0, 10, true},
+		{"no limit", 0, []int{6000}, 0, 6000, false},
+		{"left the window", 10, []int{6, 4}, quotaWindow - time.Minute, 4, false},
+		{"all left the window", 10, []int{10}, quotaWindow, 0, false},
+		{"nothing counted", 10, []int{0, -2}, 0, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := &testClock{now: start}
+			q := newCallQuota(test.limit, "")
+			q.now = clock.Now
+			for _, calls := range test.calls {
+				q.Record(calls)
+				clock.Advance(time.Minute)
+			}
+			clock.Advance(test.after - time.Minute)
+			used, limit, _ := q.Usage()
+			err := q.Check()
+			if used != test.wantUsed || limit != test.limit || (err != nil) != test.wantError {
+				t.Errorf("Usage() = %v, %v and Check() = %v, want %v used, an error: %v", used, limit, err, test.wantUsed, test.wantError)
+			}
+			if exhausted, ok := err.(*quotaExhausted); ok && !exhausted.resetAt.Equal(start.Truncate(time.Minute).Add(quotaWindow)) {
+				t.Errorf("the quota resets at %v", exhausted.resetAt)
+			}
+		})
+	}
+}
+
+func TestCallQuotaMergesTheCallsOfAMinute(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)}
+	q := newCallQuota(0, "")
+	q.now = clock.Now
+	q.Record(1)
+	clock.Advance(30 * time.Second)
+	q.Record(2)
+	clock.Advance(30 * time.Second)
+	q.Record(1)
+	if len(q.counts) != 2 || q.counts[0].Calls != 3 || q.counts[1].Calls != 1 {
+		t.Errorf("the counts are %+v, want 3 then 1", q.counts)
+	}
+}
+
+func TestCallQuotaSurvivesRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	q := newCallQuota(5, path)
+	q.Record(3)
+	if used, _, _ := newCallQuota(5, path).Usage(); used != 3 {
+		t.Errorf("a restarted quota used %v calls, want 3", used)
+	}
+
+	if err := os.WriteFile(path, []byte("{torn"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if used, _, _ := newCallQuota(5, path).Usage(); used != 0 {
+		t.Errorf("a quota restarted from a corrupt file used %v calls, want 0", used)
+	}
+}
+
+func TestQuotaExhaustedSearches(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 1), Count: 1, Stats: SearchStats{Attempts: 1}}}}
+	s := newTestServer(t, Config{DailyCallLimit: 1}, searcher)
+	startConversation(t, s).sayAll("kelly bag", "none", "none", "none")
+
+	reply := startConversation(t, s).sayAll("birkin", "none", "none", "none")
+	if reply.Status != http.StatusTooManyRequests || reply.errorCode() != "quota_exhausted" {
+		t.Errorf("a search past the limit answered %v %v, want 429 quota_exhausted", reply.Status, reply.Raw)
+	}
+	if len(searcher.Queries()) != 1 {
+		t.Errorf("eBay was searched %v times, want once", len(searcher.Queries()))
+	}
+	health := do(t, s.Routes(), http.MethodGet, "/healthz", "", nil)
+	if health.Body["ebayCalls24h"] != float64(1) || health.Body["ebayDailyCallLimit"] != float64(1) || health.Body["ebayQuotaResetsAt"] == nil {
+		t.Errorf("/healthz answered %v", health.Raw)
+	}
+}
@@ -0,0 +1,110 @@
+package theluxuryshopper
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// RankingWeights Weighs the signals the items of a search are re-ranked by, ranking is off while all are 0.
+// Boosts add their weight to the score of the items having the signal, penalties take it off.
+type RankingWeights struct {
+	FreeShipping   float64 // RANK_FREE_SHIPPING, boosts items shipping for free
+	TopRated       float64 // RANK_TOP_RATED, boosts the listings of top rated sellers
+	Lot            float64 // RANK_LOT_PENALTY, penalizes lots and bulk listings
+	MissingImage   float64 // RANK_MISSING_IMAGE_PENALTY, penalizes items without a picture
+	PriceProximity float64 // RANK_PRICE_PROXIMITY, boosts items priced close to the price range asked for
+}
+
+// Enabled Reports whether any signal is weighed
+func (r RankingWeights) Enabled() bool {
+	return r.FreeShipping != 0 || r.TopRated != 0 || r.Lot != 0 || r.MissingImage != 0 || r.PriceProximity != 0
+}
+
+// rankingFromEnv Reads the RANK_ variables
+func rankingFromEnv() (RankingWeights, error) {
+	var weights RankingWeights
+	for name, weight := range map[string]*float64{
+		"RANK_FREE_SHIPPING":         &weights.FreeShipping,
+		"RANK_TOP_RATED":             &weights.TopRated,
+		"RANK_LOT_PENALTY":           &weights.Lot,
+		"RANK_MISSING_IMAGE_PENALTY": &weights.MissingImage,
+		"RANK_PRICE_PROXIMITY":       &weights.PriceProximity,
+	} {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed < 0 || math.IsInf(parsed, 0) {
+				return weights, fmt.Errorf("%v must be a weight of 0 or more, not %q", name, value)
+			}
+			*weight = parsed
+		}
+	}
+	return weights, nil
+}
+
+// scoreItem Scores item for query under weights, the higher the better. It only reads its arguments.
+func scoreItem(item Item, query SearchQuery, weights RankingWeights) float64 {
+	score := 0.0
+	if shipping, err := strconv.ParseFloat(item.ShippingCost, 64); err == nil && shipping == 0 {
+		score += weights.FreeShipping
+	}
+	if item.TopRated {
+		score += weights.TopRated
+	}
+	if item.Lot {
+		score -= weights.Lot
+	}
+	if item.GalleryURL == "" && item.ImageURL == "" {
+		score -= weights.MissingImage
+	}
+	return score + weights.PriceProximity*priceProximity(item.Price, query)
+}
+
+// priceProximity Returns from 0 to 1 how close price is to the price the query aims at: the middle of
+// its range, or its only bound. Queries without a price range aim at nothing and get 0.
+func priceProximity(price string, query SearchQuery) float64 {
+	value, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		return 0
+	}
+	minPrice, minErr := strconv.ParseFloat(query.MinPrice, 64)
+	maxPrice, maxErr := strconv.ParseFloat(query.MaxPrice, 64)
+	var target float64
+	switch {
+	case minErr == nil && maxErr == nil:
+		target = (minPrice + maxPrice) / 2
+	case maxErr == nil:
+		target = maxPrice
+	case minErr == nil:
+		target = minPrice
+	default:
+		return 0
+	}
+	if target <= 0 {
+		return 0
+	}
+	return math.Max(0, 1-math.Abs(value-target)/target)
+}
+
+// rankItems Orders items by their score, best first, keeping eBay's order between equal scores.
+// It returns the score of every item by id for the debug meta block.
+func rankItems(items []Item, query SearchQuery, weights RankingWeights) ([]Item, map[string]float64) {
+	scores := make([]float64, len(items))
+	for i, item := range items {
+		scores[i] = scoreItem(item, query, weights)
+	}
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	ranked := make([]Item, len(items))
+	byID := make(map[string]float64, len(items))
+	for i, index := range order {
+		ranked[i] = items[index]
+		byID[items[index].ID] = scores[index]
+	}
+	return ranked, byID
+}
@@ -0,0 +1,118 @@
+package theluxuryshopper
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPriceProximity(t *testing.T) {
+	tests := []struct {
+		price              string
+		minPrice, maxPrice string
+		want               float64
+	}{
+		{"300", "100", "500", 1},
+		{"150", "100", "500", 0.5},
+		{"450", "none", "500", 0.9},
+		{"150", "100", "none", 0.5},
+		{"1000", "none", "300", 0},
+		{"300", "none", "none", 0},
+		{"n/a", "100", "500", 0},
+		{"10", "0", "0", 0},
+	}
+	for _, test := range tests {
+		got := priceProximity(test.price, SearchQuery{MinPrice: test.minPrice, MaxPrice: test.maxPrice})
+		if math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("priceProximity(%q) between %v and %v = %v, want %v", test.price, test.minPrice, test.maxPrice, got, test.want)
+		}
+	}
+}
+
+func TestScoreItem(t *testing.T) {
+	weights := RankingWeights{FreeShipping: 1, TopRated: 2, Lot: 4, MissingImage: 8, PriceProximity: 16}
+	query := SearchQuery{MinPrice: "none", MaxPrice: "100"}
+	tests := []struct {
+		name string
+		item Item
+		want float64
+	}{
+		{"plain", Item{GalleryURL: "g.jpg", ShippingCost: "9.99", Price: "n/a"}, 0},
+		{"free shipping", Item{GalleryURL: "g.jpg", ShippingCost: "0.0", Price: "n/a"}, 1},
+		{"top rated", Item{GalleryURL: "g.jpg", TopRated: true, Price: "n/a"}, 2},
+		{"lot", Item{GalleryURL: "g.jpg", Lot: true, Price: "n/a"}, -4},
+		{"no image", Item{Price: "n/a"}, -8},
+		{"image only", Item{ImageURL: "i.jpg", Price: "n/a"}, 0},
+		{"on target", Item{GalleryURL: "g.jpg", Price: "100"}, 16},
+		{"everything", Item{ShippingCost: "0", TopRated: true, Lot: true, Price: "50"}, 1 + 2 - 4 - 8 + 8},
+	}
+	for _, test := range tests {
+		if got := scoreItem(test.item, query, weights); got != test.want {
+			t.Errorf("%v: scoreItem() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRankItems(t *testing.T) {
+	items := []Item{
+		{ID: "lot", GalleryURL: "g.jpg", Lot: true},
+		{ID: "plain", GalleryURL: "g.jpg"},
+		{ID: "free", GalleryURL: "g.jpg", ShippingCost: "0"},
+		{ID: "plain again", GalleryURL: "g.jpg"},
+	}
+	ranked, scores := rankItems(items, SearchQuery{}, RankingWeights{FreeShipping: 1, Lot: 1})
+	var order []string
+	for _, item := range ranked {
+		order = append(order, item.ID)
+	}
+	if want := []string{"free", "plain", "plain again", "lot"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("rankItems() ordered %v, want %v", order, want)
+	}
+	if want := map[string]float64{"lot": -1, "plain": 0, "free": 1, "plain again": 0}; !reflect.DeepEqual(scores, want) {
+		t.Errorf("rankItems() scored %v, want %v", scores, want)
+	}
+	if items[0].ID != "lot" {
+		t.Error("rankItems() reordered the items it was given")
+	}
+}
+
+func TestRankingFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    RankingWeights
+		wantErr bool
+	}{
+		{"unset", nil, RankingWeights{}, false},
+		{"weights", map[string]string{"RANK_FREE_SHIPPING": "1.5", "RANK_LOT_PENALTY": "3", "RANK_PRICE_PROXIMITY": "0"}, RankingWeights{FreeShipping: 1.5, Lot: 3}, false},
+		{"negative", map[string]string{"RANK_TOP_RATED": "-1"}, RankingWeights{}, true},
+		{"infinite", map[string]string{"RANK_MISSING_IMAGE_PENALTY": "Inf"}, RankingWeights{}, true},
+		{"not a number", map[string]string{"RANK_TOP_RATED": "high"}, RankingWeights{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setEnv(t, test.env, "RANK_FREE_SHIPPING", "RANK_TOP_RATED", "RANK_LOT_PENALTY", "RANK_MISSING_IMAGE_PENALTY", "RANK_PRICE_PROXIMITY")
+			got, err := rankingFromEnv()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("rankingFromEnv() failed with %v, want an error: %v", err, test.wantErr)
+			}
+			if err == nil && got != test.want {
+				t.Errorf("rankingFromEnv() = %+v, want %+v", got, test.want)
+			}
+			if got.Enabled() != (got != RankingWeights{}) {
+				t.Errorf("Enabled() of %+v = %v", got, got.Enabled())
+			}
+		})
+	}
+}
+
+func TestRankedResults(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: []Item{
+		{ID: "1", Title: "Kelly lot", Price: "100", Currency: "USD", GalleryURL: "g.jpg", Lot: true},
+		{ID: "2", Title: "Kelly", Price: "100", Currency: "USD", GalleryURL: "g.jpg"},
+	}, Count: 2}}}
+	c := startConversation(t, newTestServer(t, Config{Ranking: RankingWeights{Lot: 1}}, searcher))
+	if reply := c.sayAll("kelly bag", "none", "none", "none"); !reflect.DeepEqual(reply.itemIDs(), []string{"2", "1"}) {
+		t.Errorf("the ranked results are %v, want the lot last", reply.itemIDs())
+	}
+}
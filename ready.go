@@ -0,0 +1,56 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// sessionPingTimeout Bounds the session store health check of /readyz
+const sessionPingTimeout = time.Second
+
+// handleReady Handles /readyz, answering 503 while the session store fails its ping or
+// until the self-test passed when one is configured
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ready := JSON{"status": "ready"}
+	status := http.StatusOK
+
+	ctx, cancel := context.WithTimeout(r.Context(), sessionPingTimeout)
+	sessions := JSON{"backend": "memory", "status": "ok", "active": s.sessions.Len()}
+	if err := s.sessions.Ping(ctx); err != nil {
+		sessions["status"], sessions["error"] = "down", err.Error()
+		status = http.StatusServiceUnavailable
+	}
+	cancel()
+	ready["sessions"] = sessions
+
+	if s.config.SelfTest != "" {
+		s.selfTest.mu.Lock()
+		ran, err, duration, at := s.selfTest.ran, s.selfTest.err, s.selfTest.duration, s.selfTest.at
+		s.selfTest.mu.Unlock()
+		selfTest := JSON{"mode": s.config.SelfTest, "status": "pending"}
+		switch {
+		case !ran:
+			status = http.StatusServiceUnavailable
+		case err != nil:
+			selfTest["status"], selfTest["error"] = "failed", err.Error()
+			status = http.StatusServiceUnavailable
+		default:
+			selfTest["status"] = "passed"
+		}
+		if ran {
+			selfTest["durationMs"], selfTest["ranAt"] = milliseconds(duration), at.UTC().Format(time.RFC3339)
+		}
+		ready["selfTest"] = selfTest
+	}
+	if status != http.StatusOK {
+		ready["status"] = "not ready"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ready)
+}
@@ -0,0 +1,50 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestReady(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       Config
+		selfTestErr  error // of the self-test run before /readyz, nil runs none
+		runSelfTest  bool
+		wantStatus   int
+		wantReady    string
+		wantSelfTest interface{}
+	}{
+		{"memory store", Config{}, nil, false, http.StatusOK, "ready", nil},
+		{"self-test pending", Config{SelfTest: selfTestStrict}, nil, false, http.StatusServiceUnavailable, "not ready", "pending"},
+		{"self-test passed", Config{SelfTest: selfTestStrict}, nil, true, http.StatusOK, "ready", "passed"},
+		{"self-test failed", Config{SelfTest: selfTestLenient}, errors.New("down"), true, http.StatusServiceUnavailable, "not ready", "failed"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			searcher := &fakeSearcher{}
+			if test.selfTestErr != nil {
+				searcher.errs = map[string]error{"": test.selfTestErr}
+			}
+			s := newTestServer(t, test.config, searcher)
+			if test.runSelfTest {
+				s.SelfTest(context.Background())
+			}
+			startConversation(t, s)
+			reply := do(t, s.Routes(), http.MethodGet, "/readyz", "", nil)
+			if reply.Status != test.wantStatus || reply.Body["status"] != test.wantReady {
+				t.Errorf("/readyz answered %v %v, want %v %v", reply.Status, reply.Raw, test.wantStatus, test.wantReady)
+			}
+			sessions, _ := reply.Body["sessions"].(map[string]interface{})
+			if sessions["backend"] != "memory" || sessions["status"] != "ok" || sessions["active"] != 1.0 {
+				t.Errorf("/readyz reported the sessions as %v", sessions)
+			}
+			selfTest, _ := reply.Body["selfTest"].(map[string]interface{})
+			if selfTest["status"] != test.wantSelfTest {
+				t.Errorf("/readyz reported the self-test as %v, want %v", selfTest, test.wantSelfTest)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package theluxuryshopper
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// referenceCode Matches model numbers and reference codes like "116610LN", "M41526" or "5711/1A":
+	// letters and at least three digits, with inner dashes, slashes or dots
+	referenceCode = regexp.MustCompile(`^(?:[A-Za-z]*\d){3,}[A-Za-z0-9]*(?:[-/.][A-Za-z0-9]+)*$`)
+	// referenceNumber Matches the references made of digits only, like the "1675" of "rolex 1675"
+	referenceNumber = regexp.MustCompile(`^\d{4,9}$`)
+	// hasLetter Matches the words of a keyword naming what the reference is of
+	hasLetter = regexp.MustCompile(`[A-Za-z]`)
+)
+
+const (
+	minReferenceLength = 4
+	maxReferenceLength = 20
+)
+
+// priceWords Are the words making the number after them a price rather than a reference, like "under 1675"
+var priceWords = map[string]bool{
+	"under": true, "below": true, "over": true, "above": true, "than": true, "to": true, "up": true,
+	"between": true, "and": true, "least": true, "max": true, "min": true, "budget": true, "around": true,
+}
+
+// keywordReferences Returns the reference codes of keyword verbatim, in order. Mixes of letters and digits
+// always are; numbers only are next to another word and away from the words and signs of a price, so
+// "rolex 1675" holds one but "1675" alone or "rolex under 1675" don't.
+func keywordReferences(keyword string) []string {
+	words := strings.Fields(strings.Replace(keyword, `"`, " ", -1))
+	named := false
+	for _, word := range words {
+		if hasLetter.MatchString(word) && !referenceCode.MatchString(trimReference(word)) {
+			named = true
+		}
+	}
+	var references []string
+	for i, word := range words {
+		token := trimReference(word)
+		if len(token) < minReferenceLength || len(token) > maxReferenceLength || containsStep(references, token) {
+			continue
+		}
+		switch {
+		case referenceNumber.MatchString(token):
+			if !named || strings.ContainsAny(word, "$€£¥+") || i > 0 && priceWords[strings.ToLower(words[i-1])] {
+				continue
+			}
+		case !referenceCode.MatchString(token) || !hasLetter.MatchString(token):
+			continue
+		}
+		references = append(references, token)
+	}
+	return references
+}
+
+// trimReference Drops the punctuation around a word, like the comma of "M41526,"
+func trimReference(word string) string {
+	return strings.Trim(word, `.,;:!?()[]{}'"$€£¥+`)
+}
+
+// hasReference Reports whether message holds a reference code, which is never a typo of anything
+func hasReference(message string) bool {
+	return len(keywordReferences(message)) > 0
+}
+
+// keepReferences Returns keyword with the references of message in it as they were typed: an extraction
+// that changed their case gets it back and one that dropped them, like into a price, gets them appended
+func keepReferences(keyword, message string) string {
+	if keyword == "" {
+		return keyword
+	}
+	words := strings.Fields(keyword)
+	for _, reference := range keywordReferences(message) {
+		found := false
+		for i, word := range words {
+			if strings.EqualFold(trimReference(word), reference) {
+				words[i] = strings.Replace(word, trimReference(word), reference, 1)
+				found = true
+			}
+		}
+		if !found {
+			words = append(words, reference)
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,63 @@
+package theluxuryshopper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeywordReferences(t *testing.T) {
+	tests := []struct {
+		keyword string
+		want    []string
+	}{
+		{"rolex 116610LN", []string{"116610LN"}},
+		{"louis vuitton M41526, speedy", []string{"M41526"}},
+		{"patek 5711/1A", []string{"5711/1A"}},
+		{"rolex 1675", []string{"1675"}},
+		{"1675", nil},
+		{"116610LN", []string{"116610LN"}},
+		{"rolex under 1675", nil},
+		{"rolex $1675", nil},
+		{"rolex 1675+", nil},
+		{"rolex 1675 1675", []string{"1675"}},
+		{"chanel 2.55", nil},
+		{"hermes kelly 28", nil},
+		{"gucci belt", nil},
+		{`"cartier WSTA0029" tank`, []string{"WSTA0029"}},
+		{"omega 311.30.42.30.01.005", nil},
+		{"tudor 1234567890", nil},
+	}
+	for _, test := range tests {
+		if got := keywordReferences(test.keyword); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("keywordReferences(%q) = %q, want %q", test.keyword, got, test.want)
+		}
+	}
+}
+
+func TestKeepReferences(t *testing.T) {
+	tests := []struct {
+		keyword, message, want string
+	}{
+		{"rolex 116610ln", "Rolex 116610LN under 9000", "rolex 116610LN"},
+		{"rolex", "rolex 1675", "rolex 1675"},
+		{"louis vuitton m41526,", "louis vuitton M41526", "louis vuitton M41526,"},
+		{"gucci belt", "gucci belt", "gucci belt"},
+		{"", "rolex 1675", ""},
+	}
+	for _, test := range tests {
+		if got := keepReferences(test.keyword, test.message); got != test.want {
+			t.Errorf("keepReferences(%q, %q) = %q, want %q", test.keyword, test.message, got, test.want)
+		}
+	}
+}
+
+func TestReferencesSearchedVerbatim(t *testing.T) {
+	for _, keyword := range []string{"Rolex 116610LN", "rolex 1675", "patek 5711/1A"} {
+		searcher := &fakeSearcher{}
+		c := startConversation(t, newTestServer(t, Config{}, searcher))
+		c.sayAll(keyword, "none", "none", "none")
+		if queries := searcher.Queries(); len(queries) != 1 || queries[0].Keyword != keyword {
+			t.Errorf("%q searched %+v, want the keyword as typed", keyword, queries)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// refineCommand Matches "only the ones mentioning horsebit" and "filter horsebit", the leading word
+	// telling them from a new search
+	refineCommand = regexp.MustCompile(`(?i)^\s*(?:only|filter(?:\s+(?:by|for|to))?)\s+(?:(?:the\s+)?(?:ones|items)\s+)?(?:mentioning|containing|matching|with|saying|that\s+say)?\s*['"‘“]?([^'"’”]+?)['"’”]?\W*$`)
+	// refineElsewhere Matches "only from Italy" and the like, which handleLocationCommand answers
+	refineElsewhere = regexp.MustCompile(`(?i)^\s*only\s+(?:(?:items|ones)\s+)?(?:from|located)\b`)
+	// searchInsteadCommand Matches taking the offer to search eBay for the refined keyword
+	searchInsteadCommand = regexp.MustCompile(`(?i)^\s*search\s+(?:on\s+)?ebay\b.*\binstead\W*$`)
+)
+
+// titleMentions Reports whether title contains term, or every word of term when it has several, ignoring case
+func titleMentions(title, term string) bool {
+	title, term = strings.ToLower(title), strings.ToLower(term)
+	if strings.Contains(title, term) {
+		return true
+	}
+	for _, token := range strings.Fields(term) {
+		if !strings.Contains(title, token) {
+			return false
+		}
+	}
+	return true
+}
+
+// handleRefineCommand Answers "only the ones mentioning <term>" by listing the items of the last search
+// whose title mentions it, with their numbers, and offers to search eBay for the keyword with the term.
+// Taking the offer, with "yes" or "search eBay for ... instead", searches it. Reports whether message was one of them.
+func (s *Server) handleRefineCommand(session Session, message string, w http.ResponseWriter) bool {
+	if _, searching := session["searchByKeyword"]; searching {
+		return false
+	}
+	offered, found := session["refineKeyword"].(string)
+	delete(session, "refineKeyword")
+	if found && (confirmCommandAnswer.MatchString(message) || searchInsteadCommand.MatchString(message)) {
+		s.searchRefined(session, offered, w)
+		return true
+	}
+
+	match := refineCommand.FindStringSubmatch(message)
+	list := listedResults(session)
+	if match == nil || refineElsewhere.MatchString(message) || list == nil || len(list.Items) == 0 {
+		return false
+	}
+	term := strings.Join(strings.Fields(match[1]), " ")
+	keyword := list.Query.Keyword + " " + term
+	session["refineKeyword"] = keyword
+	offer := "search eBay for '" + keyword + "' instead"
+
+	numbers := make([]int, 0, len(list.Items))
+	for number, item := range list.Items {
+		if titleMentions(item.Title, term) {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+	if len(numbers) == 0 {
+		WriteReply(w, ReplyZeroResults, JSON{
+			"message":     "None of the items listed last mention '" + term + "'. Shall I " + offer + "? Say yes, or what else you would like to search for.",
+			"items":       []Item{},
+			"numbers":     numbers,
+			"query":       list.Query,
+			"suggestions": []string{"yes"},
+		})
+		return true
+	}
+
+	var listing strings.Builder
+	matching := make([]Item, 0, len(numbers))
+	for _, number := range numbers {
+		matching = append(matching, list.Items[number])
+		writeNumberedItems(&listing, []Item{list.Items[number]}, number, requestView(w))
+	}
+	WriteReply(w, ReplyResults, JSON{
+		"message":     "Of the items listed last these mention '" + term + "' :\n" + listing.String() + "\n\n Say 'search eBay instead' to search for '" + keyword + "', 'details <number>' for one of these, or what else you would like to search for.",
+		"items":       matching,
+		"numbers":     numbers,
+		"query":       list.Query,
+		"suggestions": []string{offer},
+	})
+	return true
+}
+
+// searchRefined Searches the query of the last search again for keyword, listing the results like a new search
+func (s *Server) searchRefined(session Session, keyword string, w http.ResponseWriter) {
+	if allowed, reason := s.allowKeyword(keyword); !allowed {
+		s.answerRejected(w, "keyword")
+		refuseKeyword(reason, session, w)
+		return
+	}
+	list := listedResults(session)
+	if list == nil {
+		WriteReply(w, ReplyInfo, JSON{"message": "There is no search to refine anymore, tell me what you are looking for.\n " + keywordQuestion})
+		return
+	}
+	query := list.Query
+	query.Keyword, query.Page = keyword, 0
+	result, err := s.search(requestContext(w), query)
+	if handleError(err, session, w) == 1 {
+		return
+	}
+	if handleCaseZero(query, result, session, w) == 1 {
+		return
+	}
+	generateResponse(query, result, session, w)
+}
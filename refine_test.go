@@ -0,0 +1,93 @@
+package theluxuryshopper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTitleMentions(t *testing.T) {
+	tests := []struct {
+		title, term string
+		want        bool
+	}{
+		{"Gucci Horsebit 1955 Shoulder Bag", "horsebit", true},
+		{"Gucci Horsebit 1955 Shoulder Bag", "shoulder horsebit", true},
+		{"Gucci Horsebit 1955 Shoulder Bag", "HORSEBIT 1955", true},
+		{"Gucci Horsebit 1955 Shoulder Bag", "horsebit tote", false},
+		{"Gucci Marmont Bag", "horsebit", false},
+	}
+	for _, test := range tests {
+		if got := titleMentions(test.title, test.term); got != test.want {
+			t.Errorf("titleMentions(%q, %q) = %v, want %v", test.title, test.term, got, test.want)
+		}
+	}
+}
+
+func TestRefineCommand(t *testing.T) {
+	tests := []struct {
+		message, want string
+	}{
+		{"only the ones mentioning horsebit", "horsebit"},
+		{"filter horsebit", "horsebit"},
+		{"filter by 'gold hardware'", "gold hardware"},
+		{"only items with “dust bag”", "dust bag"},
+		{"only ones that say mint", "mint"},
+		{"horsebit", ""},
+	}
+	for _, test := range tests {
+		got := ""
+		if match := refineCommand.FindStringSubmatch(test.message); match != nil {
+			got = match[1]
+		}
+		if got != test.want {
+			t.Errorf("refineCommand captures %q in %q, want %q", got, test.message, test.want)
+		}
+	}
+	if !refineElsewhere.MatchString("only items from italy") || refineElsewhere.MatchString("only the ones mentioning italy") {
+		t.Error("refineElsewhere doesn't tell the locations from the terms")
+	}
+}
+
+func TestHandleRefineCommand(t *testing.T) {
+	items := []Item{
+		{ID: "1", Title: "Gucci Marmont Bag", Price: "900", Currency: "USD"},
+		{ID: "2", Title: "Gucci Horsebit 1955 Bag", Price: "1900", Currency: "USD"},
+		{ID: "3", Title: "Gucci Horsebit Loafers", Price: "500", Currency: "USD"},
+	}
+	tests := []struct {
+		name       string
+		messages   []string
+		wantPrefix string
+		wantItems  []string
+		wantSearch string // the keyword searched by the last message, "" for no search
+	}{
+		{"mentions", []string{"only the ones mentioning horsebit"}, "Of the items listed last these mention 'horsebit' :\n", []string{"2", "3"}, ""},
+		{"none mention", []string{"filter jackie"}, "None of the items listed last mention 'jackie'. Shall I search eBay for 'gucci jackie' instead?", []string{}, ""},
+		{"offer taken", []string{"filter jackie", "yes"}, "", nil, "gucci jackie"},
+		{"search instead", []string{"filter horsebit", "search eBay instead"}, "", nil, "gucci horsebit"},
+		{"offer expired", []string{"filter jackie", "details 1", "yes"}, "", nil, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: items, Count: len(items)}}}
+			c := startConversation(t, newTestServer(t, Config{}, searcher))
+			c.sayAll("gucci", "none", "none", "none")
+			reply := c.sayAll(test.messages...)
+			if !strings.HasPrefix(reply.message(), test.wantPrefix) {
+				t.Errorf("the last message answered %q, want it to start with %q", reply.message(), test.wantPrefix)
+			}
+			if got := reply.itemIDs(); test.wantItems != nil && !reflect.DeepEqual(got, test.wantItems) {
+				t.Errorf("the last message answered the items %v, want %v", got, test.wantItems)
+			}
+			queries := searcher.Queries()
+			searched := ""
+			if len(queries) > 1 {
+				searched = queries[len(queries)-1].Keyword
+			}
+			if searched != test.wantSearch {
+				t.Errorf("the refinement searched %q, want %q", searched, test.wantSearch)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplyTypes(t *testing.T) {
+	searcher := &fakeSearcher{
+		results: map[string]SearchResult{"": {Items: testItems("belt", 1, 2), PageURL: "https://www.ebay.com/sch/i.html?_nkw=gucci+belt"}, "nothing": {}},
+		errs:    map[string]error{"broken": &searchFailure{message: "Invalid keyword", id: "5", category: failureInvalidInput}},
+	}
+	tests := []struct {
+		replyType ReplyType
+		messages  []string
+		// fields are the sub-objects the type carries besides message
+		fields []string
+	}{
+		{ReplyQuestion, []string{"gucci belt"}, []string{"step", "suggestions", "progress"}},
+		{ReplyResults, []string{"gucci belt", "none", "none", "none"}, []string{"items", "query", "suggestions"}},
+		{ReplyZeroResults, []string{"nothing", "none", "none", "none"}, []string{"items", "query"}},
+		{ReplyError, []string{"broken", "none", "none", "none"}, []string{"error"}},
+		{ReplyInfo, []string{"show lots"}, []string{"message"}},
+	}
+	for _, test := range tests {
+		t.Run(string(test.replyType), func(t *testing.T) {
+			c := startConversation(t, newTestServer(t, Config{}, searcher))
+			reply := c.sayAll(test.messages...)
+			if reply.Body["type"] != string(test.replyType) {
+				t.Fatalf("%q answered type %v, want %v: %v", test.messages, reply.Body["type"], test.replyType, reply.Raw)
+			}
+			for _, field := range test.fields {
+				if _, found := reply.Body[field]; !found {
+					t.Errorf("the %v reply lacks %v: %v", test.replyType, field, reply.Raw)
+				}
+			}
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, []byte(strings.Replace(reply.Raw, c.uuid, "<uuid>", -1)), "", "  "); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, filepath.Join("testdata", "replies", string(test.replyType)+".json"), indented.Bytes())
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultRestrictAfterFailures Is how many searches in a row eBay may refuse for their input before the session is restricted
+	defaultRestrictAfterFailures = 5
+	// defaultRestrictCooldown Is how long a restricted session can't search
+	defaultRestrictCooldown = 15 * time.Minute
+)
+
+// sessionRestricted Is returned for the searches of a session restricted for sending input eBay refuses
+type sessionRestricted struct {
+	until time.Time
+}
+
+func (e *sessionRestricted) Error() string {
+	return "the session is restricted until " + e.until.UTC().Format(time.RFC3339) + " after searches eBay refused"
+}
+
+// userMessage Tells the user searches are paused, answering the questions still works
+func (e *sessionRestricted) userMessage(location *time.Location) string {
+	return "eBay couldn't run your last few searches, so searching is paused until " + e.until.In(location).Format("15:04") +
+		". You can keep answering the questions in the meantime."
+}
+
+// RestrictionSink Is an EventSink that is also told about the sessions restricted for failing searches
+type RestrictionSink interface {
+	EventSink
+	// SessionRestricted is sent once per restriction, with the failures in a row that caused it
+	SessionRestricted(failures int, until time.Time)
+}
+
+// searchGuard Counts the searches of one chat turn eBay refused for their input on top of the ones of earlier
+// turns, and refuses them while the session is restricted. Only invalid input counts: failures of eBay
+// itself or of the app id aren't the user's doing, and successful searches start the count over.
+type searchGuard struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	until     time.Time
+	tripped   bool // the turn restricted the session
+}
+
+// searchGuardKey Is the context key of the searchGuard of the turn a search runs for
+type searchGuardKey struct{}
+
+// newSearchGuard Creates the guard of a chat turn of session, nil when restricting is disabled
+func (s *Server) newSearchGuard(session Session) *searchGuard {
+	if s.config.RestrictAfterFailures <= 0 {
+		return nil
+	}
+	until, _ := session["restrictedUntil"].(time.Time)
+	return &searchGuard{
+		now:       s.sessions.now,
+		threshold: s.config.RestrictAfterFailures,
+		cooldown:  s.config.RestrictCooldown,
+		failures:  session.GetInt("inputFailures", 0),
+		until:     until,
+	}
+}
+
+// withSearchGuard Notes guard on ctx for the searches of the turn
+func withSearchGuard(ctx context.Context, guard *searchGuard) context.Context {
+	if guard == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, searchGuardKey{}, guard)
+}
+
+// searchGuardOf Returns the guard noted on ctx, nil for searches outside of the chat
+func searchGuardOf(ctx context.Context) *searchGuard {
+	guard, _ := ctx.Value(searchGuardKey{}).(*searchGuard)
+	return guard
+}
+
+// Check Returns sessionRestricted while the session is restricted
+func (g *searchGuard) Check() error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.now().Before(g.until) {
+		return &sessionRestricted{until: g.until}
+	}
+	return nil
+}
+
+// Record Counts the outcome of a search, restricting the session once enough in a row failed for their input
+func (g *searchGuard) Record(err error) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	failure, refused := err.(*searchFailure)
+	switch {
+	case err == nil:
+		g.failures = 0
+	case refused && failure.category == failureInvalidInput:
+		g.failures++
+		if g.failures >= g.threshold {
+			g.until, g.tripped = g.now().Add(g.cooldown), true
+		}
+	}
+}
+
+// save Keeps the count and the restriction of the turn in session, reporting whether the turn restricted it
+func (g *searchGuard) save(session Session) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.tripped {
+		// The cooldown starts the count over, one refused search after it restricts nothing
+		session["restrictedUntil"], session["inputFailures"] = g.until, 0
+		return true
+	}
+	session["inputFailures"] = g.failures
+	if !g.until.IsZero() && !g.now().Before(g.until) {
+		delete(session, "restrictedUntil")
+	}
+	return false
+}
+
+// finishSearchGuard Saves guard into session once the turn is answered, counting the restriction it led to
+func (s *Server) finishSearchGuard(session Session, guard *searchGuard) {
+	if guard == nil || !guard.save(session) {
+		return
+	}
+	atomic.AddInt64(&s.restrictedSessions, 1)
+	failures, until := guard.threshold, guard.until
+	if dispatcher, ok := s.events.(*eventDispatcher); ok {
+		if _, ok := dispatcher.sink.(RestrictionSink); ok {
+			dispatcher.dispatch(func(sink EventSink) { sink.(RestrictionSink).SessionRestricted(failures, until) })
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package theluxuryshopper
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSearchGuardRecord(t *testing.T) {
+	invalid := &searchFailure{message: "Invalid keyword.", category: failureInvalidInput}
+	system := &searchFailure{message: "Internal error.", category: failureSystem}
+	tests := []struct {
+		name        string
+		outcomes    []error
+		wantFailed  int
+		wantTripped bool
+	}{
+		{"invalid input", []error{invalid, invalid}, 2, false},
+		{"restricted", []error{invalid, invalid, invalid}, 0, true},
+		{"success starts over", []error{invalid, invalid, nil, invalid}, 1, false},
+		{"not the user's doing", []error{invalid, system, errors.New("connection reset"), invalid}, 2, false},
+	}
+	for _, test := range tests {
+		now := time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)
+		guard := &searchGuard{now: func() time.Time { return now }, threshold: 3, cooldown: time.Minute}
+		for _, outcome := range test.outcomes {
+			guard.Record(outcome)
+		}
+		session := Session{}
+		if tripped := guard.save(session); tripped != test.wantTripped || session["inputFailures"] != test.wantFailed {
+			t.Errorf("%v: save() = %v and kept %v failures, want %v and %v", test.name, tripped, session["inputFailures"], test.wantTripped, test.wantFailed)
+		}
+		if _, restricted := guard.Check().(*sessionRestricted); restricted != test.wantTripped {
+			t.Errorf("%v: Check() refuses: %v, want %v", test.name, restricted, test.wantTripped)
+		}
+	}
+	var disabled *searchGuard
+	disabled.Record(invalid)
+	if err := disabled.Check(); err != nil {
+		t.Errorf("Check() of a nil guard = %v", err)
+	}
+}
+
+// restrictionSink Is a recordingSink that is also told about the restricted sessions
+type restrictionSink struct {
+	recordingSink
+}
+
+func (r *restrictionSink) SessionRestricted(failures int, until time.Time) {
+	r.record("restricted after " + strconv.Itoa(failures))
+}
+
+func TestSessionRestriction(t *testing.T) {
+	searcher := &fakeSearcher{errs: map[string]error{"bad": &searchFailure{message: "Invalid keyword.", category: failureInvalidInput}}}
+	sink := &restrictionSink{}
+	s := newTestServer(t, Config{RestrictAfterFailures: 2, RestrictCooldown: 10 * time.Minute}, searcher, WithEventSink(sink))
+	c := startConversation(t, s)
+
+	tests := []struct {
+		keyword    string
+		wantStatus int
+		wantCode   string
+	}{
+		{"bad", http.StatusBadRequest, "search_failed"},
+		{"bad", http.StatusBadRequest, "search_failed"},
+		{"kelly bag", http.StatusTooManyRequests, "session_restricted"},
+	}
+	for _, test := range tests {
+		if reply := c.sayAll(test.keyword, "none", "none", "none"); reply.Status != test.wantStatus || reply.errorCode() != test.wantCode {
+			t.Errorf("searching %q answered %v %v, want %v %v", test.keyword, reply.Status, reply.Raw, test.wantStatus, test.wantCode)
+		}
+	}
+	if len(searcher.Queries()) != 2 {
+		t.Errorf("eBay was searched %v times, want only the 2 refused searches", len(searcher.Queries()))
+	}
+	if other := startConversation(t, s).sayAll("kelly bag", "none", "none", "none"); other.Status != http.StatusOK {
+		t.Errorf("another session answered %v %v, want its results", other.Status, other.Raw)
+	}
+	health := do(t, s.Routes(), http.MethodGet, "/healthz", "", nil)
+	if health.Body["sessionsRestricted"] != float64(1) {
+		t.Errorf("/healthz counts %v restricted sessions, want 1", health.Body["sessionsRestricted"])
+	}
+	// The events are dispatched in the background
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		sink.mu.Lock()
+		told := containsStep(sink.events, "restricted after 2")
+		sink.mu.Unlock()
+		if told {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the sink wasn't told about the restriction")
+		}
+	}
+
+	s.clock.Advance(10 * time.Minute)
+	if reply := c.sayAll("kelly bag", "none", "none", "none"); reply.Status != http.StatusOK {
+		t.Errorf("a search after the cooldown answered %v %v", reply.Status, reply.Raw)
+	}
+}
@@ -0,0 +1,75 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// ResultProcessor Decorates the items of a search before they are ranked and rendered, like marking the
+// models an embedder can authenticate in Item.Extra. It may also drop or reorder items. It gets a copy
+// of the items, so a processor that fails leaves them as they were.
+type ResultProcessor func(ctx context.Context, query SearchQuery, items []Item) ([]Item, error)
+
+// resultProcessors Holds the ResultProcessors of a server and its bots, in the order they were added
+type resultProcessors struct {
+	mu   sync.RWMutex
+	list []ResultProcessor
+}
+
+// AddResultProcessor Runs p on the items of every search after the ones added before it, for every bot.
+// An error or panic of p is logged and the search goes on with the items p got, it is never failed.
+// It is safe to call while serving.
+func (s *Server) AddResultProcessor(p ResultProcessor) error {
+	if p == nil {
+		return errors.New("result processor must not be nil")
+	}
+	s.resultProcessors.mu.Lock()
+	defer s.resultProcessors.mu.Unlock()
+	s.resultProcessors.list = append(s.resultProcessors.list, p)
+	return nil
+}
+
+// apply Runs every processor on items in order, each one on what the one before returned
+func (p *resultProcessors) apply(ctx context.Context, query SearchQuery, items []Item) []Item {
+	p.mu.RLock()
+	list := p.list
+	p.mu.RUnlock()
+	for i, process := range list {
+		processed, err := runResultProcessor(ctx, process, query, copyItems(items))
+		if err != nil {
+			log.Printf("result processor %d failed, keeping the items it got: %v", i+1, err)
+			continue
+		}
+		items = processed
+	}
+	return items
+}
+
+// runResultProcessor Runs process, turning a panic into its error
+func runResultProcessor(ctx context.Context, process ResultProcessor, query SearchQuery, items []Item) (processed []Item, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return process(ctx, query, items)
+}
+
+// copyItems Returns a copy of items a processor can change without touching them, Extra included
+func copyItems(items []Item) []Item {
+	copied := make([]Item, len(items))
+	for i, item := range items {
+		if item.Extra != nil {
+			extra := make(map[string]interface{}, len(item.Extra))
+			for key, value := range item.Extra {
+				extra[key] = value
+			}
+			item.Extra = extra
+		}
+		copied[i] = item
+	}
+	return copied
+}
@@ -0,0 +1,92 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// markProcessor Returns a ResultProcessor setting Extra[key] on every item
+func markProcessor(key string) ResultProcessor {
+	return func(ctx context.Context, query SearchQuery, items []Item) ([]Item, error) {
+		for i := range items {
+			if items[i].Extra == nil {
+				items[i].Extra = map[string]interface{}{}
+			}
+			items[i].Extra[key] = query.Keyword
+		}
+		return items, nil
+	}
+}
+
+func TestResultProcessorsApply(t *testing.T) {
+	dropFirst := func(ctx context.Context, query SearchQuery, items []Item) ([]Item, error) {
+		return items[1:], nil
+	}
+	failing := func(ctx context.Context, query SearchQuery, items []Item) ([]Item, error) {
+		items[0].Title = "changed"
+		items[0].Extra["marked"] = "changed"
+		return nil, errors.New("unavailable")
+	}
+	panicking := func(ctx context.Context, query SearchQuery, items []Item) ([]Item, error) {
+		items[0].Title = "changed"
+		panic("boom")
+	}
+	tests := []struct {
+		name       string
+		processors []ResultProcessor
+		wantIDs    []string
+		wantMarked bool
+	}{
+		{"none", nil, []string{"1", "2"}, false},
+		{"marked", []ResultProcessor{markProcessor("marked")}, []string{"1", "2"}, true},
+		{"in order", []ResultProcessor{markProcessor("marked"), dropFirst}, []string{"2"}, true},
+		{"failure skipped", []ResultProcessor{markProcessor("marked"), failing}, []string{"1", "2"}, true},
+		{"panic skipped", []ResultProcessor{panicking, dropFirst}, []string{"2"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			processors := &resultProcessors{list: test.processors}
+			items := processors.apply(context.Background(), SearchQuery{Keyword: "kelly"}, []Item{{ID: "1", Title: "Kelly"}, {ID: "2", Title: "Kelly 28"}})
+			var ids []string
+			for _, item := range items {
+				ids = append(ids, item.ID)
+				if item.Title == "changed" {
+					t.Errorf("a failed processor changed the item %v", item.ID)
+				}
+				if marked := item.Extra["marked"] == "kelly"; marked != test.wantMarked {
+					t.Errorf("the item %v is marked: %v, want %v", item.ID, marked, test.wantMarked)
+				}
+			}
+			if !reflect.DeepEqual(ids, test.wantIDs) {
+				t.Errorf("apply() kept %v, want %v", ids, test.wantIDs)
+			}
+		})
+	}
+}
+
+func TestAddResultProcessor(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 2), Count: 2}}}
+	s := newTestServer(t, Config{}, searcher)
+	if err := s.AddResultProcessor(nil); err == nil {
+		t.Error("AddResultProcessor(nil) succeeded")
+	}
+	if err := s.AddResultProcessor(markProcessor("authenticated")); err != nil {
+		t.Fatal(err)
+	}
+	reply := startConversation(t, s).sayAll("kelly bag", "none", "none", "none")
+	items, _ := reply.Body["items"].([]interface{})
+	for _, raw := range items {
+		item, _ := raw.(map[string]interface{})
+		if extra, _ := item["extra"].(map[string]interface{}); extra["authenticated"] != "kelly bag" {
+			t.Errorf("the item %v has the extra %v", item["id"], item["extra"])
+		}
+	}
+	if len(items) != 2 {
+		t.Errorf("the results have %v items, want 2", len(items))
+	}
+	if results := searcher.results[""].Items; results[0].Extra != nil {
+		t.Errorf("the processor changed the items of the searcher: %v", results[0].Extra)
+	}
+}
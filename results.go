@@ -0,0 +1,214 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxListedItems Caps the items one search remembers across its pages, older pages are forgotten past it
+const maxListedItems = 200
+
+var (
+	moreCommand     = regexp.MustCompile(`(?i)^\s*(?:show\s+)?more(?:\s+results)?\W*$|^\s*next\s+page\W*$`)
+	previousCommand = regexp.MustCompile(`(?i)^\s*(?:previous|prev)(?:\s+page|\s+results)?\W*$`)
+	pageCommand     = regexp.MustCompile(`(?i)^\s*(?:(?:go\s+to|show)\s+)?page\s+#?(\d+)\W*$`)
+	detailsCommand  = regexp.MustCompile(`(?i)^\s*(?:details|item)\s+(?:of\s+)?#?(\d+)\W*$`)
+)
+
+// resultList Holds the items of the pages a search listed so far. Items are numbered by their
+// place in the whole search, so item n is on page (n-1)/PerPage+1 whichever page was fetched first.
+type resultList struct {
+	Query        SearchQuery // The query of the page shown last
+	Items        map[int]Item
+	PerPage      int
+	TotalPages   int
+	TotalEntries int
+	Pageable     bool             // false for searches that can't be paged, like several keywords at once
+	Feedback     string           // the user gave on the search last, "" until they give some
+	Sellers      map[string][]int // the numbers of the collapsed listings by lowercase seller
+}
+
+// rememberResults Starts the numbering of a new search with its first page
+func rememberResults(session Session, query SearchQuery, result SearchResult, pageable bool) {
+	list := &resultList{
+		Query:        query,
+		Items:        map[int]Item{},
+		PerPage:      query.Entries,
+		TotalPages:   result.Stats.TotalPages,
+		TotalEntries: result.Stats.TotalEntries,
+		Pageable:     pageable,
+	}
+	if !pageable || list.PerPage < len(result.Items) {
+		list.PerPage = len(result.Items)
+	}
+	list.add(query.page(), result.Items)
+	list.addCollapsed(query.page(), len(result.Items), result.Collapsed)
+	session["results"] = list
+}
+
+// add Numbers the items of page and remembers them
+func (list *resultList) add(page int, items []Item) {
+	if len(list.Items)+len(items) > maxListedItems {
+		list.Items, list.Sellers = map[int]Item{}, nil
+	}
+	first := list.firstOf(page)
+	for i, item := range items {
+		list.Items[first+i] = item
+	}
+}
+
+// firstOf Returns the number of the first item of page
+func (list *resultList) firstOf(page int) int {
+	return (page-1)*list.PerPage + 1
+}
+
+// pagination Describes the page of query for the structured replies
+func pagination(query SearchQuery, stats SearchStats) JSON {
+	return JSON{
+		"page":         query.page(),
+		"totalPages":   stats.TotalPages,
+		"totalEntries": stats.TotalEntries,
+		"perPage":      query.Entries,
+	}
+}
+
+// pageSummary Words where page stands, like "page 1 of 12 (58 items total)", or "" for a single page
+func pageSummary(page int, stats SearchStats) string {
+	if stats.TotalPages <= 1 {
+		return ""
+	}
+	return "page " + strconv.Itoa(page) + " of " + strconv.Itoa(stats.TotalPages) + " (" + strconv.Itoa(stats.TotalEntries) + " items total)"
+}
+
+// listedResults Returns the items listed by the last search of session, or nil
+func listedResults(session Session) *resultList {
+	list, _ := session["results"].(*resultList)
+	return list
+}
+
+// listedItem Returns item number of the last search, or a message telling why there is none
+func listedItem(session Session, number int) (Item, string) {
+	list := listedResults(session)
+	if list == nil || len(list.Items) == 0 {
+		return Item{}, "There are no results to pick from yet, tell me what you are looking for first."
+	}
+	item, found := list.Items[number]
+	if !found {
+		first := list.firstOf(list.Query.page())
+		return Item{}, "There is no item " + strconv.Itoa(number) + " on the pages shown, the last page listed items " +
+			strconv.Itoa(first) + "-" + strconv.Itoa(first+list.PerPage-1) + "."
+	}
+	return item, ""
+}
+
+// handleResultsCommand Answers "more", "previous", "page <n>", "details <n>" and "compare <n> and <m>" about the last search, reporting whether message was one.
+// They only apply between searches, so they never eat the answer to a question.
+func (s *Server) handleResultsCommand(session Session, message string, w http.ResponseWriter) bool {
+	if _, searching := session["searchByKeyword"]; searching {
+		return false
+	}
+	if handleCompareCommand(session, message, w) {
+		return true
+	}
+	if page, paging := requestedPage(listedResults(session), message); paging {
+		s.showPage(session, page, w)
+		return true
+	}
+	if handleExpandSellerCommand(session, message, w) {
+		return true
+	}
+	if match := detailsCommand.FindStringSubmatch(message); match != nil {
+		number, _ := strconv.Atoi(match[1])
+		item, problem := listedItem(session, number)
+		if problem != "" {
+			WriteReply(w, ReplyInfo, JSON{"message": problem})
+			return true
+		}
+		var response strings.Builder
+		response.WriteString("Here is item " + match[1] + ":\n")
+		// Details show every line whatever the display mode
+		writeNumberedItems(&response, []Item{item}, number, itemView{fields: displayFields[displayDetailed], location: requestLocation(w)})
+		WriteReply(w, ReplyInfo, JSON{
+			"message": response.String(),
+			"item":    item,
+			"number":  number,
+		})
+		return true
+	}
+	return false
+}
+
+// requestedPage Returns the page "more", "previous" or "page <n>" asks for, reporting whether message was one of them
+func requestedPage(list *resultList, message string) (int, bool) {
+	current := 0
+	if list != nil {
+		current = list.Query.page()
+	}
+	switch match := pageCommand.FindStringSubmatch(message); {
+	case moreCommand.MatchString(message):
+		return current + 1, true
+	case previousCommand.MatchString(message):
+		return current - 1, true
+	case match != nil:
+		page, _ := strconv.Atoi(match[1])
+		return page, true
+	}
+	return 0, false
+}
+
+// showPage Fetches page of the last search and lists it, numbering its items by their place in the search
+func (s *Server) showPage(session Session, page int, w http.ResponseWriter) {
+	list := listedResults(session)
+	switch {
+	case list == nil:
+		WriteReply(w, ReplyInfo, JSON{"message": "There is no search to page through, tell me what you are looking for.\n " + keywordQuestion})
+		return
+	case !list.Pageable:
+		WriteReply(w, ReplyInfo, JSON{"message": "I can't page through a search of several keywords, search for one of them to see more."})
+		return
+	case page < 1:
+		WriteReply(w, ReplyInfo, JSON{"message": "That was the first page already.\n Say 'more' for the next items, or what else you would like to search for."})
+		return
+	case page > list.TotalPages && page == list.Query.page()+1:
+		WriteReply(w, ReplyInfo, JSON{"message": "That was everything, there are " + strconv.Itoa(list.TotalEntries) + " items in all.\n What else would you like to search for?"})
+		return
+	case page > list.TotalPages:
+		WriteReply(w, ReplyInfo, JSON{"message": "There are only " + strconv.Itoa(list.TotalPages) + " pages, say 'page <number>' for one of them."})
+		return
+	}
+
+	query := list.Query
+	query.Page = page
+	result, err := s.search(requestContext(w), query)
+	noteSearch(w, result.Stats)
+	if handleError(err, session, w) == 1 {
+		return
+	}
+	list.Query = query
+	list.add(page, result.Items)
+	list.addCollapsed(page, len(result.Items), result.Collapsed)
+	if result.Stats.TotalPages > 0 {
+		list.TotalPages, list.TotalEntries = result.Stats.TotalPages, result.Stats.TotalEntries
+	}
+	stats := SearchStats{TotalPages: list.TotalPages, TotalEntries: list.TotalEntries}
+	first := list.firstOf(page)
+
+	var response strings.Builder
+	response.Grow(estimateRenderSize(result.Items) + 256)
+	response.WriteString("Items " + strconv.Itoa(first) + "-" + strconv.Itoa(first+len(result.Items)-1) + " matching your criteria, " + pageSummary(page, stats) + " : \n")
+	writeNumberedItems(&response, result.Items, first, requestView(w))
+	writeCollapsedNote(&response, result.Collapsed)
+	response.WriteString("\n Say 'more', 'previous' or 'page <number>' to browse, 'details <number>' for one of the items, or what else you would like to search for.")
+	WriteReply(w, resultsType(result.Items), JSON{
+		"message":     response.String(),
+		"items":       nonNilItems(result.Items),
+		"query":       query,
+		"first":       first,
+		"pagination":  pagination(query, stats),
+		"sellers":     sellerGrouping(result.Items, result.Collapsed),
+		"collapsed":   nonNilGroups(result.Collapsed),
+		"suggestions": resultSuggestions(first, len(result.Items), page < list.TotalPages),
+	})
+}
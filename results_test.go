@@ -0,0 +1,109 @@
+package theluxuryshopper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRequestedPage(t *testing.T) {
+	list := &resultList{Query: SearchQuery{Page: 2}}
+	tests := []struct {
+		message  string
+		want     int
+		wantPage bool
+	}{
+		{"more", 3, true},
+		{"show more results", 3, true},
+		{"next page", 3, true},
+		{"previous", 1, true},
+		{"prev page", 1, true},
+		{"page 7", 7, true},
+		{"go to page #4", 4, true},
+		{"details 2", 0, false},
+		{"more bags", 0, false},
+	}
+	for _, test := range tests {
+		if page, paging := requestedPage(list, test.message); page != test.want || paging != test.wantPage {
+			t.Errorf("requestedPage(%q) = %v, %v, want %v, %v", test.message, page, paging, test.want, test.wantPage)
+		}
+	}
+	if page, _ := requestedPage(nil, "more"); page != 1 {
+		t.Errorf("requestedPage() without a search = %v, want 1", page)
+	}
+}
+
+func TestPageSummary(t *testing.T) {
+	tests := []struct {
+		page  int
+		stats SearchStats
+		want  string
+	}{
+		{1, SearchStats{TotalPages: 12, TotalEntries: 58}, "page 1 of 12 (58 items total)"},
+		{1, SearchStats{TotalPages: 1, TotalEntries: 3}, ""},
+		{1, SearchStats{}, ""},
+	}
+	for _, test := range tests {
+		if got := pageSummary(test.page, test.stats); got != test.want {
+			t.Errorf("pageSummary(%v, %+v) = %q, want %q", test.page, test.stats, got, test.want)
+		}
+	}
+}
+
+func TestResultListAdd(t *testing.T) {
+	list := &resultList{Items: map[int]Item{}, PerPage: 50}
+	list.add(3, testItems("bag", 1, 50))
+	if _, found := list.Items[101]; !found || len(list.Items) != 50 {
+		t.Errorf("the third page numbered %v items, want 101 to 150", len(list.Items))
+	}
+	for page := 1; page <= maxListedItems/50; page++ {
+		list.add(page, testItems("bag", 1, 50))
+	}
+	if len(list.Items) != maxListedItems {
+		t.Errorf("the list holds %v items, want %v", len(list.Items), maxListedItems)
+	}
+	list.add(5, testItems("bag", 1, 50))
+	if _, found := list.Items[201]; !found || len(list.Items) != 50 {
+		t.Errorf("past %v items the list holds %v, want the last page only", maxListedItems, len(list.Items))
+	}
+}
+
+func TestBrowsingResults(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 5), Count: 5, Stats: SearchStats{TotalPages: 3, TotalEntries: 12}}}}
+	s := newTestServer(t, Config{}, searcher)
+	c := startConversation(t, s)
+	if reply := c.say("more"); !strings.HasPrefix(reply.message(), "There is no search to page through") {
+		t.Errorf("more before a search answered %q", reply.message())
+	}
+	c.sayAll("kelly bag", "none", "none", "none")
+
+	tests := []struct {
+		message, wantPrefix string
+		wantPage            int // searched by the message, 0 for none
+	}{
+		{"details 3", "Here is item 3:\n", 0},
+		{"more", "Items 6-10 matching your criteria, page 2 of 3 (12 items total) : \n", 2},
+		{"details 7", "Here is item 7:\n", 0},
+		{"details 99", "There is no item 99 on the pages shown, the last page listed items 6-10.", 0},
+		{"previous", "Items 1-5 matching your criteria, page 1 of 3 (12 items total)", 1},
+		{"previous", "That was the first page already.", 0},
+		{"page 3", "Items 11-15 matching your criteria, page 3 of 3", 3},
+		{"more", "That was everything, there are 12 items in all.", 0},
+		{"page 9", "There are only 3 pages, say 'page <number>' for one of them.", 0},
+	}
+	for _, test := range tests {
+		before := len(searcher.Queries())
+		reply := c.say(test.message)
+		if !strings.HasPrefix(reply.message(), test.wantPrefix) {
+			t.Errorf("%q answered %q, want it to start with %q", test.message, reply.message(), test.wantPrefix)
+		}
+		queries := searcher.Queries()
+		switch searched := len(queries) > before; {
+		case searched && test.wantPage == 0:
+			t.Errorf("%q searched page %v", test.message, queries[len(queries)-1].Page)
+		case !searched && test.wantPage != 0:
+			t.Errorf("%q didn't search page %v", test.message, test.wantPage)
+		case searched && queries[len(queries)-1].Page != test.wantPage:
+			t.Errorf("%q searched page %v, want %v", test.message, queries[len(queries)-1].Page, test.wantPage)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package theluxuryshopper
+
+import (
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// route Describes one route of the server, the registry drives the router, GET /routes and the / page
+type route struct {
+	method      string
+	path        string
+	handler     string
+	description string
+	handle      httprouter.Handle
+	// versioned routes are served under apiPrefix, with a deprecated unprefixed alias
+	versioned bool
+	// probe routes are hit by health checks, so they aren't traced
+	probe bool
+	// keyed routes need one of the API_KEYS when any is configured
+	keyed bool
+	// admin routes take the admin token, their requests carrying it are audited
+	admin bool
+}
+
+// registeredRoute Is one registration of a route as listed by GET /routes
+type registeredRoute struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Handler     string `json:"handler"`
+	Description string `json:"description"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+	Successor   string `json:"successor,omitempty"`
+}
+
+// routes Returns the registry of every route of the server, new endpoints only need an entry here
+func (s *Server) routes() []route {
+	return []route{
+		{method: http.MethodGet, path: "/welcome", handler: "handleWelcome", description: "Starts or resumes a session", handle: s.handleWelcome, versioned: true, keyed: true},
+		{method: http.MethodPost, path: "/welcome", handler: "handleWelcome", description: "Starts a session with the preferences of a JSON body", handle: s.handleWelcome, versioned: true, keyed: true},
+		{method: http.MethodPost, path: "/chat", handler: "handleChat", description: "Answers one message of a session", handle: s.handleChat, versioned: true},
+		{method: http.MethodOptions, path: "/chat", handler: "handleChatOptions", description: "Advertises the methods and bodies of /chat", handle: s.handleChatOptions, versioned: true},
+		{method: http.MethodGet, path: "/chat/poll", handler: "handlePoll", description: "Waits for the replies queued for a session", handle: s.handlePoll, versioned: true},
+		{method: http.MethodGet, path: "/bots/:name/welcome", handler: "handleWelcome", description: "Starts or resumes a session of a white-labeled bot", handle: s.botRoute(func(bot *Server) httprouter.Handle { return bot.handleWelcome }), versioned: true, keyed: true},
+		{method: http.MethodPost, path: "/bots/:name/welcome", handler: "handleWelcome", description: "Starts a session of a white-labeled bot with preferences", handle: s.botRoute(func(bot *Server) httprouter.Handle { return bot.handleWelcome }), versioned: true, keyed: true},
+		{method: http.MethodPost, path: "/bots/:name/chat", handler: "handleChat", description: "Answers one message of a session of a white-labeled bot", handle: s.botRoute(func(bot *Server) httprouter.Handle { return bot.handleChat }), versioned: true},
+		{method: http.MethodOptions, path: "/bots/:name/chat", handler: "handleChatOptions", description: "Advertises the methods and bodies of the chat of a white-labeled bot", handle: s.handleChatOptions, versioned: true},
+		{method: http.MethodGet, path: "/bots/:name/chat/poll", handler: "handlePoll", description: "Waits for the replies queued for a session of a white-labeled bot", handle: s.botRoute(func(bot *Server) httprouter.Handle { return bot.handlePoll }), versioned: true},
+		{method: http.MethodGet, path: "/search", handler: "handleSearch", description: "Runs one search from the query string", handle: s.handleSearch, versioned: true, keyed: true},
+		{method: http.MethodGet, path: "/items/:itemId", handler: "handleItem", description: "Answers the fresh price and availability of one listing", handle: s.handleItem, versioned: true, keyed: true},
+		{method: http.MethodPost, path: "/batch/search", handler: "handleBatchSearch", description: "Runs up to 50 searches for an admin", handle: s.handleBatchSearch, versioned: true, admin: true},
+		{method: http.MethodGet, path: "/session/export", handler: "handleSessionExport", description: "Answers a signed backup of the preferences and saved searches of a session", handle: s.handleSessionExport, versioned: true},
+		{method: http.MethodPost, path: "/session/import", handler: "handleSessionImport", description: "Merges a backup into a session", handle: s.handleSessionImport, versioned: true},
+		{method: http.MethodDelete, path: "/jobs", handler: "handleStopJobs", description: "Stops the work running for a session", handle: s.handleStopJobs, versioned: true},
+		{method: http.MethodGet, path: "/admin/funnel", handler: "handleFunnel", description: "Counts how far the conversations got for an admin", handle: s.handleFunnel, admin: true},
+		{method: http.MethodGet, path: "/admin/feedback", handler: "handleFeedback", description: "Counts the feedback on results for an admin", handle: s.handleFeedback, admin: true},
+		{method: http.MethodGet, path: "/admin/sessions", handler: "handleSessions", description: "Lists the sessions with the most turns for an admin", handle: s.handleSessions, admin: true},
+		{method: http.MethodPost, path: "/admin/maintenance", handler: "handleMaintenance", description: "Turns the maintenance mode on or off for an admin", handle: s.handleMaintenance, admin: true},
+		{method: http.MethodGet, path: "/admin/audit", handler: "handleAudit", description: "Answers the last admin requests of the audit log for an admin", handle: s.handleAudit, admin: true},
+		{method: http.MethodDelete, path: "/admin/funnel", handler: "handleFunnelReset", description: "Starts the funnel counts over for an admin", handle: s.handleFunnelReset, admin: true},
+		{method: http.MethodGet, path: "/healthz", handler: "handleHealth", description: "Reports the health of the server", handle: s.handleHealth, probe: true},
+		{method: http.MethodGet, path: "/readyz", handler: "handleReady", description: "Reports whether the server is ready for traffic", handle: s.handleReady, probe: true},
+		{method: http.MethodGet, path: "/img", handler: "handleImage", description: "Proxies an item image signed by the server", handle: s.handleImage},
+		{method: http.MethodGet, path: "/routes", handler: "handleRoutes", description: "Lists the routes as JSON", handle: s.handleRoutes},
+		{method: http.MethodGet, path: "/", handler: "handle", description: "Lists the routes as HTML", handle: s.handle},
+		{method: http.MethodHead, path: "/", handler: "handle", description: "Lists the routes as HTML", handle: s.handle},
+	}
+}
+
+// registrations Expands the registry into every path registered on the router, aliases included
+func (s *Server) registrations() []registeredRoute {
+	var registered []registeredRoute
+	for _, r := range s.routes() {
+		entry := registeredRoute{Method: r.method, Path: r.path, Handler: r.handler, Description: r.description}
+		if !r.versioned {
+			registered = append(registered, entry)
+			continue
+		}
+		alias := entry
+		alias.Deprecated = true
+		alias.Successor = apiPrefix + r.path
+		entry.Path = apiPrefix + r.path
+		registered = append(registered, entry, alias)
+	}
+	return registered
+}
+
+// handleRoutes Handles /routes, listing the registry as JSON
+func (s *Server) handleRoutes(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	writeJSON(w, JSON{"routes": s.registrations()})
+}
+
+// handle Handles /
+func (s *Server) handle(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if s.config.DisableHTMLMessages {
+		w.Header().Set("Content-Security-Policy", plainTextPolicy)
+	}
+	s.index.ServeHTTP(w, r)
+}
+
+// indexPage Builds the HTML listing the registered routes
+func (s *Server) indexPage() string {
+	var current, deprecated strings.Builder
+	for _, r := range s.registrations() {
+		line := "  " + padRight(r.Method, 5) + padRight(r.Path, 12)
+		if r.Deprecated {
+			deprecated.WriteString(html.EscapeString(line+"-> "+r.Successor) + "\n")
+			continue
+		}
+		current.WriteString(html.EscapeString(line+"-> "+padRight(r.Handler, 14)+r.Description) + "\n")
+	}
+	brand := html.EscapeString(s.brandName())
+	return "<!DOCTYPE html><html><head><title>" + brand + "</title></head><body>" +
+		"<h1 style=\"font-family: sans-serif; color: " + s.brandAccentColor() + ";\">" + brand + "</h1>" +
+		"<pre style=\"font-family: monospace;\">\n" +
+		"Available Routes:\n\n" + current.String() + "\n" +
+		"Deprecated aliases of the " + apiPrefix + " routes:\n\n" + deprecated.String() +
+		"</pre></body></html>\n"
+}
+
+// padRight Pads s with spaces to width
+func padRight(s string, width int) string {
+	if len(s) >= width {
+		return s + " "
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
@@ -0,0 +1,77 @@
+package theluxuryshopper
+
+import (
+	"html"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// routeMethods Are the methods probed on every path of the registry
+var routeMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+
+// concretePath Fills the parameters of a route path with sample values
+func concretePath(path string) string {
+	path = strings.Replace(path, ":name", "acme", -1)
+	return strings.Replace(path, ":itemId", "123", -1)
+}
+
+func TestRouterMatchesTheRegistry(t *testing.T) {
+	s := newTestServer(t, Config{}, &fakeSearcher{})
+	router := s.Routes()
+	registered := map[string]bool{}
+	for _, r := range s.registrations() {
+		key := r.Method + " " + r.Path
+		if registered[key] {
+			t.Errorf("%v is registered twice", key)
+		}
+		registered[key] = true
+		if handle, _, _ := router.Lookup(r.Method, concretePath(r.Path)); handle == nil {
+			t.Errorf("%v is in the registry but not on the router", key)
+		}
+	}
+	// Every method the router answers on a path of the registry must be registered too
+	for _, r := range s.registrations() {
+		for _, method := range routeMethods {
+			if handle, _, _ := router.Lookup(method, concretePath(r.Path)); handle != nil && !registered[method+" "+r.Path] {
+				t.Errorf("%v %v is on the router but not in the registry", method, r.Path)
+			}
+		}
+	}
+}
+
+func TestRoutesListEveryRoute(t *testing.T) {
+	s := newTestServer(t, Config{}, &fakeSearcher{})
+	registrations := s.registrations()
+
+	reply := do(t, s.Routes(), http.MethodGet, "/routes", "", nil)
+	listed, _ := reply.Body["routes"].([]interface{})
+	if len(listed) != len(registrations) {
+		t.Fatalf("/routes lists %v routes, the registry has %v", len(listed), len(registrations))
+	}
+	for i, r := range registrations {
+		entry, _ := listed[i].(map[string]interface{})
+		if entry["method"] != r.Method || entry["path"] != r.Path || entry["handler"] != r.Handler || entry["description"] != r.Description {
+			t.Errorf("/routes lists %v, want %+v", entry, r)
+		}
+		if deprecated, _ := entry["deprecated"].(bool); deprecated != r.Deprecated || r.Deprecated && entry["successor"] != r.Successor {
+			t.Errorf("/routes lists %v, want deprecated %v for %v", entry, r.Deprecated, r.Path)
+		}
+	}
+
+	page := do(t, s.Routes(), http.MethodGet, "/", "", nil)
+	if page.Status != http.StatusOK {
+		t.Fatalf("/ answered %v", page.Status)
+	}
+	for _, r := range registrations {
+		line := padRight(r.Method, 5) + padRight(r.Path, 12) + "-> "
+		if r.Deprecated {
+			line += r.Successor
+		} else {
+			line += padRight(r.Handler, 14) + r.Description
+		}
+		if !strings.Contains(page.Raw, html.EscapeString(line)) {
+			t.Errorf("/ doesn't list %v %v", r.Method, r.Path)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxSavedSearches Caps the saved searches of one conversation
+	maxSavedSearches = 10
+	// maxSeenItems Caps the item ids remembered per saved search, the soonest ending are forgotten first
+	maxSeenItems = 500
+	// seenWithoutEndTime How long an item without an end time is remembered
+	seenWithoutEndTime = 30 * 24 * time.Hour
+)
+
+var (
+	saveSearchCommand = regexp.MustCompile(`(?i)^\s*save\s+(?:this\s+)?search\s+as\s+(.+?)\s*$`)
+	runSearchCommand  = regexp.MustCompile(`(?i)^\s*run\s+(.+?)\s*$`)
+	showAllCommand    = regexp.MustCompile(`(?i)^\s*show\s+all\s*$`)
+	// listSearchesCommand is what the favorites aliases are rewritten to
+	listSearchesCommand = regexp.MustCompile(`(?i)^\s*saved\s+searches\s*$`)
+	// deleteSearchCommand is answered whatever the processor, like the conversation commands
+	deleteSearchCommand = regexp.MustCompile(`(?i)^\s*(?:delete|remove)\s+(?:saved\s+)?search\s+(.+?)\s*$`)
+)
+
+// savedSearch Is a query saved under a name along with the items its last runs returned
+type savedSearch struct {
+	Query   SearchQuery
+	LastRun time.Time
+	Seen    map[string]time.Time // item id -> when to forget it, the end of its listing
+}
+
+// savedRun Holds the items of the last run of a saved search, for "show all"
+type savedRun struct {
+	Name    string
+	Query   SearchQuery
+	New     []Item
+	Repeats []Item
+	PageURL string
+}
+
+// savedSearches Returns the saved searches of session by name
+func savedSearches(session Session) map[string]*savedSearch {
+	searches, found := session["savedSearches"].(map[string]*savedSearch)
+	if !found {
+		searches = map[string]*savedSearch{}
+		session["savedSearches"] = searches
+	}
+	return searches
+}
+
+// handleSavedSearchCommand Answers "save search as <name>", "run <name>", "saved searches", "show all" and
+// "refresh all", reporting whether message was one of them
+func (s *Server) handleSavedSearchCommand(session Session, message string, w http.ResponseWriter) bool {
+	if match := saveSearchCommand.FindStringSubmatch(message); match != nil {
+		saveSearch(session, conversationName(match[1]), w)
+		return true
+	}
+	if listSearchesCommand.MatchString(message) {
+		listSavedSearches(session, w)
+		return true
+	}
+	if showAllCommand.MatchString(message) {
+		showAll(session, w)
+		return true
+	}
+	if refreshAllCommand.MatchString(message) {
+		s.refreshAll(session, w)
+		return true
+	}
+	// "run" only counts as a command for a name that was saved, it could be a keyword otherwise
+	if match := runSearchCommand.FindStringSubmatch(message); match != nil {
+		name := conversationName(match[1])
+		if saved, found := savedSearches(session)[name]; found {
+			s.runSavedSearch(session, name, saved, w)
+			return true
+		}
+	}
+	return false
+}
+
+// handleDeleteSearchCommand Answers "delete search <name>" by removing the saved search of the active
+// conversation, reporting whether message was it. "undo" brings the search back.
+func handleDeleteSearchCommand(session Session, message string, now time.Time, w http.ResponseWriter) bool {
+	match := deleteSearchCommand.FindStringSubmatch(message)
+	if match == nil {
+		return false
+	}
+	name := conversationName(match[1])
+	owner, conversation := activeConversation(session)
+	searches := savedSearches(conversation)
+	deleted, found := searches[name]
+	if !found {
+		response := "There is no saved search called " + name + "."
+		if len(searches) > 0 {
+			response += " Your saved searches are " + strings.Join(savedSearchNames(searches), ", ") + "."
+		}
+		WriteReply(w, ReplyInfo, JSON{"message": response + "\n " + strings.TrimSpace(resumeSummary(conversation))})
+		return true
+	}
+	delete(searches, name)
+	stashUndo(session, now, func(session Session) string {
+		conversation, found := conversations(session)[owner]
+		if !found {
+			return "Conversation " + owner + " was closed, so " + name + " can't be restored."
+		}
+		searches := savedSearches(conversation)
+		if _, taken := searches[name]; taken {
+			return "A search was saved as " + name + " meanwhile, so the deleted one can't be restored."
+		}
+		searches[name] = deleted
+		return "Restored your saved search " + name + "."
+	})
+	WriteReply(w, ReplyInfo, JSON{
+		"message": "Deleted your saved search " + name + ". " + undoHint + "\n " + strings.TrimSpace(resumeSummary(conversation)),
+	})
+	return true
+}
+
+// saveSearch Saves the last query searched in session under name
+func saveSearch(session Session, name string, w http.ResponseWriter) {
+	query, found := session["lastQuery"].(SearchQuery)
+	if !found {
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "Search for something first, then say 'save search as <name>'.\n " + strings.TrimSpace(resumeSummary(session)),
+		})
+		return
+	}
+	searches := savedSearches(session)
+	if _, exists := searches[name]; !exists && len(searches) >= maxSavedSearches {
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "You already have " + strconv.Itoa(maxSavedSearches) + " saved searches: " + strings.Join(savedSearchNames(searches), ", ") + ".",
+		})
+		return
+	}
+	searches[name] = &savedSearch{Query: query, Seen: map[string]time.Time{}}
+	WriteReply(w, ReplyInfo, JSON{
+		"message": "Saved your search for '" + query.Keyword + "' as " + name + ". Say 'run " + name + "' to see what's new.\n " + strings.TrimSpace(resumeSummary(session)),
+	})
+}
+
+// runSavedSearch Searches saved again and lists the items it didn't return before first
+func (s *Server) runSavedSearch(session Session, name string, saved *savedSearch, w http.ResponseWriter) {
+	result, err := s.search(requestContext(w), saved.Query)
+	if handleError(err, session, w) == 1 {
+		return
+	}
+	if handleCaseZero(saved.Query, result, session, w) == 1 {
+		return
+	}
+
+	run := savedRun{Name: name, Query: saved.Query, PageURL: resultsURL(result)}
+	for _, item := range result.Items {
+		if _, seen := saved.Seen[item.ID]; seen {
+			run.Repeats = append(run.Repeats, item)
+		} else {
+			run.New = append(run.New, item)
+		}
+	}
+	previousRun := saved.LastRun
+	now := time.Now()
+	saved.LastRun = now
+	rememberSeen(saved, result.Items, now)
+	session["lastRun"] = run
+	recordSearch(session, saved.Query, matchCount(result))
+
+	location := requestLocation(w)
+	var response strings.Builder
+	response.Grow(estimateRenderSize(run.New) + 256)
+	switch {
+	case previousRun.IsZero():
+		response.WriteString("Here is what " + name + " finds : \n")
+	case len(run.New) == 0:
+		response.WriteString("Nothing new since " + formatRunTime(previousRun, location) + ".\n")
+	default:
+		response.WriteString("New since " + formatRunTime(previousRun, location) + " : \n")
+	}
+	writeItems(&response, run.New, requestView(w))
+	if len(run.Repeats) > 0 {
+		response.WriteString("\n " + strconv.Itoa(len(run.Repeats)) + " more you saw already, say 'show all' to include them.")
+	}
+	response.WriteString("\n Results Page URL : " + run.PageURL + "\n\n What else would you like to search for?")
+	WriteReply(w, resultsType(run.New), JSON{
+		"message": response.String(),
+		"items":   nonNilItems(run.New),
+		"repeats": len(run.Repeats),
+		"query":   saved.Query,
+	})
+}
+
+// listSavedSearches Lists the saved searches of session with their keyword and last run
+func listSavedSearches(session Session, w http.ResponseWriter) {
+	searches := savedSearches(session)
+	if len(searches) == 0 {
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "You have no saved searches yet. Search for something, then say 'save search as <name>'.\n " + strings.TrimSpace(resumeSummary(session)),
+		})
+		return
+	}
+	location := requestLocation(w)
+	var response strings.Builder
+	response.WriteString("Your saved searches :")
+	for _, name := range savedSearchNames(searches) {
+		saved := searches[name]
+		response.WriteString("\n " + name + " : '" + saved.Query.Keyword + "', ")
+		if saved.LastRun.IsZero() {
+			response.WriteString("never run")
+		} else {
+			response.WriteString("last run " + formatRunTime(saved.LastRun, location))
+		}
+	}
+	response.WriteString("\n Say 'run <name>' to see what's new.\n " + strings.TrimSpace(resumeSummary(session)))
+	WriteReply(w, ReplyInfo, JSON{
+		"message":       response.String(),
+		"savedSearches": savedSearchNames(searches),
+	})
+}
+
+// showAll Lists every item of the last saved search run, the new ones first
+func showAll(session Session, w http.ResponseWriter) {
+	run, found := session["lastRun"].(savedRun)
+	if !found {
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "Run a saved search first, then say 'show all' to include the items you saw already.",
+		})
+		return
+	}
+	items := append(append([]Item{}, run.New...), run.Repeats...)
+	var response strings.Builder
+	response.Grow(estimateRenderSize(items) + 256)
+	response.WriteString("Everything " + run.Name + " found : \n")
+	writeItems(&response, items, requestView(w))
+	response.WriteString("\n Results Page URL : " + run.PageURL + "\n\n What else would you like to search for?")
+	WriteReply(w, ReplyResults, JSON{
+		"message": response.String(),
+		"items":   nonNilItems(items),
+		"query":   run.Query,
+	})
+}
+
+// rememberSeen Adds items to the seen ids of saved until their listings end,
+// forgets the ended ones and keeps at most maxSeenItems
+func rememberSeen(saved *savedSearch, items []Item, now time.Time) {
+	for _, item := range items {
+		forgetAt := now.Add(seenWithoutEndTime)
+		if end, err := time.Parse(time.RFC3339, item.EndTime); err == nil {
+			forgetAt = end
+		}
+		saved.Seen[item.ID] = forgetAt
+	}
+	for id, forgetAt := range saved.Seen {
+		if forgetAt.Before(now) {
+			delete(saved.Seen, id)
+		}
+	}
+	if len(saved.Seen) <= maxSeenItems {
+		return
+	}
+	ids := make([]string, 0, len(saved.Seen))
+	for id := range saved.Seen {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return saved.Seen[ids[i]].Before(saved.Seen[ids[j]]) })
+	for _, id := range ids[:len(ids)-maxSeenItems] {
+		delete(saved.Seen, id)
+	}
+}
+
+// formatRunTime Formats when a saved search last ran, in location or UTC
+func formatRunTime(t time.Time, location *time.Location) string {
+	if location == nil {
+		location = time.UTC
+	}
+	return t.In(location).Format("Jan 2 15:04 MST")
+}
+
+// savedSearchNames Returns the names of searches in alphabetical order
+func savedSearchNames(searches map[string]*savedSearch) []string {
+	names := make([]string, 0, len(searches))
+	for name := range searches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// nonNilItems Returns items, or an empty slice so it encodes as [] rather than null
+func nonNilItems(items []Item) []Item {
+	if items == nil {
+		return []Item{}
+	}
+	return items
+}
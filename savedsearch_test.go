@@ -0,0 +1,112 @@
+package theluxuryshopper
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRememberSeen(t *testing.T) {
+	now := time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)
+	saved := &savedSearch{Seen: map[string]time.Time{"ended": now.Add(-time.Minute), "kept": now.Add(time.Hour)}}
+	rememberSeen(saved, []Item{
+		{ID: "ending", EndTime: now.Add(2 * time.Hour).Format(time.RFC3339)},
+		{ID: "no end"},
+		{ID: "garbled end", EndTime: "soon"},
+	}, now)
+	want := map[string]time.Time{
+		"kept":        now.Add(time.Hour),
+		"ending":      now.Add(2 * time.Hour),
+		"no end":      now.Add(seenWithoutEndTime),
+		"garbled end": now.Add(seenWithoutEndTime),
+	}
+	if !reflect.DeepEqual(saved.Seen, want) {
+		t.Errorf("rememberSeen() kept %v, want %v", saved.Seen, want)
+	}
+
+	var items []Item
+	for i := 0; i < maxSeenItems+10; i++ {
+		items = append(items, Item{ID: strconv.Itoa(i), EndTime: now.Add(time.Duration(i+1) * time.Minute).Format(time.RFC3339)})
+	}
+	saved = &savedSearch{Seen: map[string]time.Time{}}
+	rememberSeen(saved, items, now)
+	if _, found := saved.Seen["9"]; found || len(saved.Seen) != maxSeenItems {
+		t.Errorf("rememberSeen() kept %v items, the soonest ending among them: %v", len(saved.Seen), found)
+	}
+}
+
+func TestFormatRunTime(t *testing.T) {
+	at := time.Date(2026, time.October, 15, 12, 5, 0, 0, time.UTC)
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		location *time.Location
+		want     string
+	}{
+		{nil, "Oct 15 12:05 UTC"},
+		{paris, "Oct 15 14:05 CEST"},
+	}
+	for _, test := range tests {
+		if got := formatRunTime(at, test.location); got != test.want {
+			t.Errorf("formatRunTime() in %v = %q, want %q", test.location, got, test.want)
+		}
+	}
+}
+
+func TestSavedSearches(t *testing.T) {
+	searcher := &fakeFetcher{
+		fakeSearcher: fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 2), Count: 2}}},
+		items: map[string]ItemStatus{
+			"bag2": {Item: Item{ID: "bag2", Price: "150", Currency: "USD"}, Status: listingActive},
+			"bag3": {Item: Item{ID: "bag3", Price: "300", Currency: "USD"}, Status: listingEnded},
+		},
+	}
+	s := newTestServer(t, Config{}, searcher)
+	c := startConversation(t, s)
+	if reply := c.say("save search as favs"); !strings.HasPrefix(reply.message(), "Search for something first, then say 'save search as <name>'.") {
+		t.Errorf("saving before a search answered %q", reply.message())
+	}
+	c.sayAll("kelly bag", "none", "none", "none")
+
+	steps := []struct {
+		message, wantPrefix string
+		wantItems           []string
+		wantChanges         []string
+		newResults          []Item // the searcher answers from the next step on, nil keeps them
+	}{
+		{message: "saved searches", wantPrefix: "You have no saved searches yet."},
+		{message: "save search as My  Favs", wantPrefix: "Saved your search for 'kelly bag' as my favs. Say 'run my favs' to see what's new."},
+		{message: "saved searches", wantPrefix: "Your saved searches :\n my favs : 'kelly bag', never run"},
+		{message: "run my favs", wantPrefix: "Here is what my favs finds : \n", wantItems: []string{"bag1", "bag2"}, newResults: testItems("bag", 2, 2)},
+		{message: "run my favs", wantPrefix: "New since ", wantItems: []string{"bag3"}},
+		{message: "show all", wantPrefix: "Everything my favs found : \n", wantItems: []string{"bag3", "bag2"}},
+		{message: "refresh all", wantPrefix: "Everything my favs found, checked again : \n", wantItems: []string{"bag3", "bag2"}, wantChanges: []string{"bag item 3 : listing ended", "bag item 2 : price changed from 200 to 150 USD"}},
+		{message: "run my favs", wantPrefix: "Nothing new since ", wantItems: []string{}},
+		{message: "delete search favs", wantPrefix: "There is no saved search called favs. Your saved searches are my favs."},
+		{message: "delete search my favs", wantPrefix: "Deleted your saved search my favs. " + undoHint},
+		{message: "run my favs", wantPrefix: "Please specify the condition"},
+	}
+	for _, step := range steps {
+		reply := c.say(step.message)
+		if !strings.HasPrefix(reply.message(), step.wantPrefix) {
+			t.Errorf("%q answered %q, want it to start with %q", step.message, reply.message(), step.wantPrefix)
+		}
+		if got := reply.itemIDs(); step.wantItems != nil && !reflect.DeepEqual(got, step.wantItems) {
+			t.Errorf("%q answered the items %v, want %v", step.message, got, step.wantItems)
+		}
+		for _, change := range step.wantChanges {
+			if !strings.Contains(reply.message(), "\n "+change) {
+				t.Errorf("%q answered %q, want the change %q", step.message, reply.message(), change)
+			}
+		}
+		if step.newResults != nil {
+			searcher.mu.Lock()
+			searcher.results[""] = SearchResult{Items: step.newResults, Count: len(step.newResults)}
+			searcher.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package theluxuryshopper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// searchCacheControl Lets clients keep /search results but makes them revalidate every time
+	searchCacheControl = "no-cache"
+	// searchETagFreshness Is how long the ETag of a search is taken to still hold without searching again
+	searchETagFreshness = time.Minute
+	// maxSearchETags Caps the queries whose ETag is remembered
+	maxSearchETags = 1000
+)
+
+// rememberedETag Is the ETag the last search of a query got, and when
+type rememberedETag struct {
+	etag string
+	at   time.Time
+}
+
+// searchETags Remembers the ETag of the recent searches by query, so a client revalidating results it got
+// less than searchETagFreshness ago is answered 304 before searching. That spares the eBay call, the quota
+// and the side effects of a search, like the webhook and the price history.
+type searchETags struct {
+	mu   sync.Mutex
+	now  func() time.Time
+	tags map[string]rememberedETag
+}
+
+// newSearchETags Creates an empty searchETags
+func newSearchETags() *searchETags {
+	return &searchETags{now: time.Now, tags: map[string]rememberedETag{}}
+}
+
+// Fresh Returns the ETag the last search of query got, if it is recent enough to be answered without searching
+func (e *searchETags) Fresh(query SearchQuery) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	remembered, found := e.tags[searchKey(query)]
+	if !found || e.now().Sub(remembered.at) >= searchETagFreshness {
+		return "", false
+	}
+	return remembered.etag, true
+}
+
+// Remember Records the ETag a search of query just got
+func (e *searchETags) Remember(query SearchQuery, etag string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	if len(e.tags) >= maxSearchETags {
+		for key, remembered := range e.tags {
+			if now.Sub(remembered.at) >= searchETagFreshness {
+				delete(e.tags, key)
+			}
+		}
+	}
+	if len(e.tags) < maxSearchETags {
+		e.tags[searchKey(query)] = rememberedETag{etag: etag, at: now}
+	}
+}
+
+// searchKey Returns the key of query among the remembered ETags
+func searchKey(query SearchQuery) string {
+	encoded, _ := json.Marshal(query)
+	return string(encoded)
+}
+
+// searchETag Returns the strong ETag of the results of query, a hash of the query and of what eBay
+// answered. The price history isn't part of it, it moves with every search of the keyword.
+func searchETag(query SearchQuery, result SearchResult) string {
+	sum := sha256.New()
+	json.NewEncoder(sum).Encode(JSON{
+		"query":     query,
+		"count":     result.Count,
+		"items":     nonNilItems(result.Items),
+		"pageURL":   result.PageURL,
+		"searchURL": result.SearchURL,
+	})
+	return `"` + hex.EncodeToString(sum.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches Reports whether an If-None-Match header names etag, or any one with "*".
+// Like the spec asks of If-None-Match, weak tags compare by their value.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
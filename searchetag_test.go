@@ -0,0 +1,74 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{`*`, true},
+		{`"xyz"`, false},
+		{``, false},
+		{`abc`, false},
+	}
+	for _, test := range tests {
+		if got := etagMatches(test.header, `"abc"`); got != test.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", test.header, got, test.want)
+		}
+	}
+}
+
+func TestSearchNotModified(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 2)}}}
+	s := newTestServer(t, Config{}, searcher)
+	s.etags.now = s.clock.Now
+	routes := s.Routes()
+	search := func(target, etag string) testReply {
+		t.Helper()
+		header := http.Header{}
+		if etag != "" {
+			header.Set("If-None-Match", etag)
+		}
+		return do(t, routes, http.MethodGet, target, "", header)
+	}
+
+	first := search("/v1/search?keyword=gucci", "")
+	etag := first.Header.Get("ETag")
+	if first.Status != http.StatusOK || etag == "" {
+		t.Fatalf("the first search answered %v %v", first.Status, first.Raw)
+	}
+
+	tests := []struct {
+		name        string
+		target      string
+		etag        string
+		advance     bool
+		wantStatus  int
+		wantQueries int
+	}{
+		{"revalidated right away", "/v1/search?keyword=gucci", etag, false, http.StatusNotModified, 1},
+		{"another etag", "/v1/search?keyword=gucci", `"stale"`, false, http.StatusOK, 2},
+		{"another query", "/v1/search?keyword=prada", etag, false, http.StatusOK, 3},
+		{"revalidated a minute later", "/v1/search?keyword=gucci", etag, true, http.StatusNotModified, 4},
+		{"revalidated again", "/v1/search?keyword=gucci", etag, false, http.StatusNotModified, 4},
+	}
+	for _, test := range tests {
+		if test.advance {
+			s.clock.Advance(searchETagFreshness)
+		}
+		reply := search(test.target, test.etag)
+		if reply.Status != test.wantStatus || len(searcher.Queries()) != test.wantQueries {
+			t.Errorf("%v: answered %v after %v searches, want %v after %v", test.name, reply.Status, len(searcher.Queries()), test.wantStatus, test.wantQueries)
+		}
+		if reply.Status == http.StatusNotModified && (reply.Header.Get("ETag") != etag || reply.Raw != "") {
+			t.Errorf("%v: answered 304 with ETag %v and body %q", test.name, reply.Header.Get("ETag"), reply.Raw)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Self-test modes, a failed strict self-test stops the startup, a failed lenient one only fails /readyz
+const (
+	selfTestStrict  = "strict"
+	selfTestLenient = "lenient"
+)
+
+// selfTestTimeout Bounds the startup search when no SearchTimeout is configured
+const selfTestTimeout = 5 * time.Second
+
+// selfTestQuery Is the tiny search run at startup to check the app id works
+var selfTestQuery = SearchQuery{Keyword: "watch", Condition: "none", MinPrice: "none", MaxPrice: "none", Entries: 1}
+
+// selfTestStatus Remembers the outcome of the startup self-test for /readyz
+type selfTestStatus struct {
+	mu       sync.Mutex
+	ran      bool
+	err      error
+	duration time.Duration
+	at       time.Time
+}
+
+// SelfTest Runs one tiny search through the searcher of the server and returns why it failed, if it did.
+// The search bypasses the quota check and the analytics but counts once towards the quota. Until it
+// has run, /readyz answers 503 when Config.SelfTest is set.
+func (s *Server) SelfTest(ctx context.Context) error {
+	timeout := s.config.SearchTimeout
+	if timeout <= 0 {
+		timeout = selfTestTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result, err := s.searcher.Search(ctx, selfTestQuery)
+	elapsed := time.Since(start)
+	if result.Stats.Attempts == 0 && !result.Stats.CacheHit {
+		result.Stats.Attempts = 1
+	}
+	s.quota.Record(result.Stats.Attempts)
+	if err != nil {
+		err = fmt.Errorf("eBay self-test search failed (%v): %v", searchAck(err), err)
+		log.Print(err)
+	} else {
+		log.Printf("eBay self-test search passed in %v, %v items", elapsed, result.Count)
+	}
+
+	s.selfTest.mu.Lock()
+	s.selfTest.ran, s.selfTest.err, s.selfTest.duration, s.selfTest.at = true, err, elapsed, time.Now()
+	s.selfTest.mu.Unlock()
+	return err
+}
@@ -0,0 +1,46 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr string
+	}{
+		{"passes", nil, ""},
+		{"eBay failure", &searchFailure{message: "Invalid app id"}, "eBay self-test search failed (Failure)"},
+		{"unreachable", errors.New("connection refused"), "eBay self-test search failed (Unavailable"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("w", 1, 1), Count: 1}}}
+			if test.err != nil {
+				searcher.errs = map[string]error{"": test.err}
+			}
+			s := newTestServer(t, Config{SelfTest: selfTestStrict}, searcher)
+			err := s.SelfTest(context.Background())
+			if (err == nil) != (test.wantErr == "") || (err != nil && !strings.HasPrefix(err.Error(), test.wantErr)) {
+				t.Errorf("SelfTest() = %v, want %q", err, test.wantErr)
+			}
+			if queries := searcher.Queries(); len(queries) != 1 || !reflect.DeepEqual(queries[0], selfTestQuery) {
+				t.Errorf("SelfTest() searched %+v, want only %+v", queries, selfTestQuery)
+			}
+			if used, _, _ := s.quota.Usage(); used != 1 {
+				t.Errorf("SelfTest() counted %v calls towards the quota, want 1", used)
+			}
+			s.selfTest.mu.Lock()
+			ran, stored := s.selfTest.ran, s.selfTest.err
+			s.selfTest.mu.Unlock()
+			if !ran || stored != err {
+				t.Errorf("SelfTest() remembered ran %v, err %v, want true, %v", ran, stored, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// minCollapsedSellerListings Is how many listings of one page a seller needs before any gets collapsed
+const minCollapsedSellerListings = 3
+
+// expandSellerCommand Matches "expand seller <name>", listing what a search collapsed of a seller
+var expandSellerCommand = regexp.MustCompile(`(?i)^\s*expand\s+(?:seller\s+)?(\S+?)\W*$`)
+
+// SellerGroup Holds the listings of one seller a search collapsed, the first of theirs stays in the items
+type SellerGroup struct {
+	Seller string `json:"seller"`
+	Items  []Item `json:"items"`
+}
+
+// collapseSellers Keeps only the first listing of every seller owning more than share of items and at least
+// minCollapsedSellerListings of them, returning the others by seller in the order the sellers first appear.
+// Items without seller info are never collapsed.
+func collapseSellers(items []Item, share float64) ([]Item, []SellerGroup) {
+	counts := map[string]int{}
+	for _, item := range items {
+		if item.Seller != "" {
+			counts[strings.ToLower(item.Seller)]++
+		}
+	}
+	flooding := func(seller string) bool {
+		count := counts[strings.ToLower(seller)]
+		return seller != "" && count >= minCollapsedSellerListings && float64(count) > share*float64(len(items))
+	}
+	kept := make([]Item, 0, len(items))
+	var groups []SellerGroup
+	index := map[string]int{}
+	for _, item := range items {
+		if !flooding(item.Seller) {
+			kept = append(kept, item)
+			continue
+		}
+		key := strings.ToLower(item.Seller)
+		position, seen := index[key]
+		if !seen {
+			// The first listing of the seller stays, it ranked best
+			index[key] = len(groups)
+			groups = append(groups, SellerGroup{Seller: item.Seller})
+			kept = append(kept, item)
+			continue
+		}
+		groups[position].Items = append(groups[position].Items, item)
+	}
+	return kept, groups
+}
+
+// nonNilGroups Returns groups, or an empty slice so it encodes as [] rather than null
+func nonNilGroups(groups []SellerGroup) []SellerGroup {
+	if groups == nil {
+		return []SellerGroup{}
+	}
+	return groups
+}
+
+// sellerGrouping Describes the sellers of a page for the structured replies: the listings of each seller
+// shown and collapsed, in the order the sellers appear. Items without seller info are left out.
+func sellerGrouping(items []Item, collapsed []SellerGroup) []JSON {
+	hidden := map[string]int{}
+	for _, group := range collapsed {
+		hidden[strings.ToLower(group.Seller)] = len(group.Items)
+	}
+	groups := []JSON{}
+	index := map[string]int{}
+	for _, item := range items {
+		if item.Seller == "" {
+			continue
+		}
+		key := strings.ToLower(item.Seller)
+		position, seen := index[key]
+		if !seen {
+			position = len(groups)
+			index[key] = position
+			groups = append(groups, JSON{"seller": item.Seller, "shown": 0, "collapsed": hidden[key]})
+		}
+		groups[position]["shown"] = groups[position]["shown"].(int) + 1
+	}
+	return groups
+}
+
+// writeCollapsedNote Tells the user about the listings a page collapsed by seller
+func writeCollapsedNote(response *strings.Builder, collapsed []SellerGroup) {
+	for _, group := range collapsed {
+		response.WriteString("\n " + strconv.Itoa(len(group.Items)) + " more from seller " + group.Seller +
+			" are hidden, say 'expand seller " + group.Seller + "' to see them.")
+	}
+}
+
+// addCollapsed Numbers the collapsed listings of page after the shown ones, which take up its first numbers
+func (list *resultList) addCollapsed(page, shown int, collapsed []SellerGroup) {
+	if list.Sellers == nil {
+		list.Sellers = map[string][]int{}
+	}
+	number := list.firstOf(page) + shown
+	for _, group := range collapsed {
+		key := strings.ToLower(group.Seller)
+		for _, item := range group.Items {
+			list.Items[number] = item
+			list.Sellers[key] = append(list.Sellers[key], number)
+			number++
+		}
+	}
+}
+
+// handleExpandSellerCommand Answers "expand seller <name>" with the listings of the seller the pages
+// shown so far collapsed, reporting whether message was it
+func handleExpandSellerCommand(session Session, message string, w http.ResponseWriter) bool {
+	match := expandSellerCommand.FindStringSubmatch(message)
+	if match == nil {
+		return false
+	}
+	list := listedResults(session)
+	var numbers []int
+	if list != nil {
+		numbers = list.Sellers[strings.ToLower(match[1])]
+	}
+	items, listed := make([]Item, 0, len(numbers)), make([]int, 0, len(numbers))
+	for _, number := range numbers {
+		if item, found := list.Items[number]; found {
+			items, listed = append(items, item), append(listed, number)
+		}
+	}
+	if len(items) == 0 {
+		WriteReply(w, ReplyInfo, JSON{"message": "No listings of seller " + match[1] + " were hidden from the results."})
+		return true
+	}
+	var response strings.Builder
+	response.Grow(estimateRenderSize(items) + 256)
+	response.WriteString("The other listings of seller " + items[0].Seller + " : \n")
+	view := requestView(w)
+	for i, item := range items {
+		writeNumberedItems(&response, []Item{item}, listed[i], view)
+	}
+	response.WriteString("\n Say 'details <number>' for one of them, or what else you would like to search for.")
+	WriteReply(w, ReplyResults, JSON{
+		"message": response.String(),
+		"items":   items,
+		"numbers": listed,
+		"seller":  items[0].Seller,
+	})
+	return true
+}
@@ -0,0 +1,108 @@
+package theluxuryshopper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sellerItems Returns one item per seller in sellers, numbered in order, "" leaving the seller unknown
+func sellerItems(sellers ...string) []Item {
+	items := testItems("bag", 1, len(sellers))
+	for i, seller := range sellers {
+		items[i].Seller = seller
+	}
+	return items
+}
+
+// itemIDsOf Returns the ids of items, in order
+func itemIDsOf(items []Item) []string {
+	ids := []string{}
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestCollapseSellers(t *testing.T) {
+	tests := []struct {
+		name        string
+		sellers     []string
+		share       float64
+		wantKept    []string
+		wantGrouped map[string][]string
+	}{
+		{"no flooding", []string{"a", "b", "c", "d"}, 0.3, []string{"bag1", "bag2", "bag3", "bag4"}, map[string][]string{}},
+		{"one seller floods", []string{"a", "b", "a", "a", "c"}, 0.5, []string{"bag1", "bag2", "bag5"}, map[string][]string{"a": {"bag3", "bag4"}}},
+		{"case insensitive", []string{"Luxe", "luxe", "LUXE", "b"}, 0.5, []string{"bag1", "bag4"}, map[string][]string{"Luxe": {"bag2", "bag3"}}},
+		{"too few listings", []string{"a", "a", "b"}, 0.3, []string{"bag1", "bag2", "bag3"}, map[string][]string{}},
+		{"at the share", []string{"a", "a", "a", "b", "c", "d"}, 0.5, []string{"bag1", "bag2", "bag3", "bag4", "bag5", "bag6"}, map[string][]string{}},
+		{"unknown sellers", []string{"", "", "", ""}, 0.1, []string{"bag1", "bag2", "bag3", "bag4"}, map[string][]string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kept, groups := collapseSellers(sellerItems(test.sellers...), test.share)
+			if ids := itemIDsOf(kept); !reflect.DeepEqual(ids, test.wantKept) {
+				t.Errorf("collapseSellers() kept %v, want %v", ids, test.wantKept)
+			}
+			grouped := map[string][]string{}
+			for _, group := range groups {
+				grouped[group.Seller] = itemIDsOf(group.Items)
+			}
+			if !reflect.DeepEqual(grouped, test.wantGrouped) {
+				t.Errorf("collapseSellers() collapsed %v, want %v", grouped, test.wantGrouped)
+			}
+		})
+	}
+}
+
+func TestSellerGrouping(t *testing.T) {
+	items := sellerItems("a", "b", "", "A")
+	collapsed := []SellerGroup{{Seller: "a", Items: sellerItems("a", "a")}}
+	want := []JSON{
+		{"seller": "a", "shown": 2, "collapsed": 2},
+		{"seller": "b", "shown": 1, "collapsed": 0},
+	}
+	if got := sellerGrouping(items, collapsed); !reflect.DeepEqual(got, want) {
+		t.Errorf("sellerGrouping() = %v, want %v", got, want)
+	}
+	if got := nonNilGroups(nil); got == nil || len(got) != 0 {
+		t.Errorf("nonNilGroups(nil) = %#v, want an empty slice", got)
+	}
+}
+
+func TestExpandSeller(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: sellerItems("luxe", "b", "luxe", "luxe", "luxe"), Count: 5}}}
+	s := newTestServer(t, Config{SellerCollapseShare: 0.5}, searcher)
+	c := startConversation(t, s)
+	reply := c.sayAll("gucci bag", "none", "none", "none")
+	if ids := reply.itemIDs(); !reflect.DeepEqual(ids, []string{"bag1", "bag2"}) {
+		t.Fatalf("the search showed %v, want bag1 and bag2", ids)
+	}
+	if !strings.Contains(reply.message(), "3 more from seller luxe are hidden, say 'expand seller luxe' to see them.") {
+		t.Errorf("the search didn't mention the hidden listings: %q", reply.message())
+	}
+
+	tests := []struct {
+		message   string
+		wantIDs   []string
+		wantReply string
+	}{
+		{"expand seller LUXE", []string{"bag3", "bag4", "bag5"}, "The other listings of seller luxe : \n"},
+		{"expand luxe!", []string{"bag3", "bag4", "bag5"}, "The other listings of seller luxe : \n"},
+		{"expand seller b", []string{}, "No listings of seller b were hidden from the results."},
+		{"expand seller nobody", []string{}, "No listings of seller nobody were hidden from the results."},
+	}
+	for _, test := range tests {
+		reply := c.say(test.message)
+		if ids := reply.itemIDs(); !reflect.DeepEqual(ids, test.wantIDs) {
+			t.Errorf("%q listed %v, want %v", test.message, ids, test.wantIDs)
+		}
+		if !strings.HasPrefix(reply.message(), test.wantReply) {
+			t.Errorf("%q answered %q, want it to start with %q", test.message, reply.message(), test.wantReply)
+		}
+	}
+	if reply := c.say("details 4"); !strings.Contains(reply.message(), "bag item 4") {
+		t.Errorf("details of a collapsed listing answered %q, want bag item 4", reply.message())
+	}
+}
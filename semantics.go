@@ -0,0 +1,58 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"strings"
+)
+
+// responseSemanticsHeader Is the request header opting a client into the v2 statuses of /welcome and /chat,
+// the response echoes it when they apply
+const responseSemanticsHeader = "X-Response-Semantics"
+
+// reauthErrors Are the error codes only a new session gets past, v2 replies hint the client at /welcome
+var reauthErrors = map[string]bool{
+	"missing_authorization": true,
+	"session_expired":       true,
+	"unknown_session":       true,
+}
+
+// negotiateSemantics Applies the v2 statuses to the replies written to w when r asks for them with
+// X-Response-Semantics: v2. Clients that don't keep getting 200 for every reply.
+func negotiateSemantics(w http.ResponseWriter, r *http.Request) {
+	if strings.EqualFold(strings.TrimSpace(r.Header.Get(responseSemanticsHeader)), "v2") {
+		w.Header().Set(responseSemanticsHeader, "v2")
+	}
+}
+
+// semanticsV2 Reports whether the replies written to w use the v2 statuses
+func semanticsV2(w http.ResponseWriter) bool {
+	return w.Header().Get(responseSemanticsHeader) == "v2"
+}
+
+// answerRejected Counts the answer to step as rejected, the question asked again answers 422 under v2
+func (s *Server) answerRejected(w http.ResponseWriter, step string) {
+	s.events.AnswerRejected(step)
+	if mw, ok := w.(*metaWriter); ok {
+		mw.rejected = true
+	}
+}
+
+// replyStatus Returns the status of the reply data written to w: under v2 a question asked again after a
+// rejected answer is 422, everything else keeps the 200 of writeJSON
+func replyStatus(w http.ResponseWriter, data JSON) int {
+	if !semanticsV2(w) {
+		return http.StatusOK
+	}
+	mw, ok := w.(*metaWriter)
+	if t, _ := data["type"].(ReplyType); t == ReplyQuestion && ok && mw.rejected {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusOK
+}
+
+// addReauth Hints the client of a v2 error reply needing a new session to call /welcome again
+func addReauth(w http.ResponseWriter, code string, envelope JSON) {
+	if semanticsV2(w) && reauthErrors[code] {
+		envelope["reauth"] = true
+	}
+}
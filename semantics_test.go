@@ -0,0 +1,58 @@
+package theluxuryshopper
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestResponseSemantics(t *testing.T) {
+	tests := []struct {
+		name       string
+		semantics  string
+		uuid       string // "" sends no Authorization, "session" the uuid of the conversation
+		message    string
+		wantStatus int
+		wantEcho   string
+		wantReauth interface{}
+	}{
+		{"rejected answer", "", "session", "banana", http.StatusOK, "", nil},
+		{"rejected answer v2", "v2", "session", "banana", http.StatusUnprocessableEntity, "v2", nil},
+		{"rejected answer V2 padded", " V2 ", "session", "banana", http.StatusUnprocessableEntity, "v2", nil},
+		{"rejected answer v1", "v1", "session", "banana", http.StatusOK, "", nil},
+		{"accepted answer v2", "v2", "session", "used", http.StatusOK, "v2", nil},
+		{"missing authorization", "", "", "used", http.StatusUnauthorized, "", nil},
+		{"missing authorization v2", "v2", "", "used", http.StatusUnauthorized, "v2", true},
+		{"unknown session v2", "v2", "e7c2a1f0-0000-4000-8000-000000000000", "used", http.StatusUnauthorized, "v2", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestServer(t, Config{}, &fakeSearcher{})
+			c := startConversation(t, s)
+			c.say("gucci bag")
+			c.clock.Advance(2 * duplicateGrace)
+
+			header := http.Header{}
+			if test.semantics != "" {
+				header.Set(responseSemanticsHeader, test.semantics)
+			}
+			if uuid := test.uuid; uuid != "" {
+				if uuid == "session" {
+					uuid = c.uuid
+				}
+				header.Set("Authorization", uuid)
+			}
+			body, _ := json.Marshal(JSON{"message": test.message})
+			reply := do(t, c.handler, http.MethodPost, "/v1/chat", string(body), header)
+			if reply.Status != test.wantStatus {
+				t.Errorf("/v1/chat answered %v %v, want %v", reply.Status, reply.Raw, test.wantStatus)
+			}
+			if echo := reply.Header.Get(responseSemanticsHeader); echo != test.wantEcho {
+				t.Errorf("/v1/chat echoed %v %q, want %q", responseSemanticsHeader, echo, test.wantEcho)
+			}
+			if reauth := reply.Body["reauth"]; reauth != test.wantReauth {
+				t.Errorf("/v1/chat answered reauth %v, want %v", reauth, test.wantReauth)
+			}
+		})
+	}
+}
@@ -0,0 +1,619 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	cors "github.com/heppu/simple-cors"
+	"github.com/julienschmidt/httprouter"
+)
+
+// apiPrefix The prefix of the versioned routes, unprefixed routes are deprecated aliases
+const apiPrefix = "/v1"
+
+// Server Holds the routes of one chatbot
+type Server struct {
+	config    Config
+	sessions  *SessionStore
+	transport http.RoundTripper
+	searcher  Searcher
+	index     *staticAsset
+	processor atomic.Value // Always holds a non-nil Processor
+	tracer    *tracer
+
+	blocklist     *keywordBlocklist
+	keywordFilter KeywordFilter
+	profanity     *keywordBlocklist
+	messageFilter MessageFilter
+	landedCosts   LandedCostRates
+	events        EventSink
+	prices        *priceHistoryStore
+	etags         *searchETags
+	images        *imageSigner // nil when the image proxy is disabled
+	imageClient   *http.Client
+	steps         []string // The conversation steps asked, in order
+	none          noneSynonyms
+	quota         *callQuota
+	throttle      *throttleCooldown
+	backupSecret  []byte   // nil when session backups are disabled
+	adminToken    []byte   // nil when the admin routes are disabled
+	apiKeys       *apiKeys // nil when sessions and searches need no key
+	selfTest      selfTestStatus
+	profiles      *profileStore
+	funnel        *funnelCounts
+	funnelStop    chan struct{} // nil when the funnel counts aren't flushed
+	feedback      *feedbackCounts
+	webhook       *searchWebhook // nil when SEARCH_WEBHOOK_URL isn't set
+	jobs          *jobRegistry
+	maintenance   *maintenanceMode
+	accessories   *keywordBlocklist // the titles "exclude accessories" leaves out
+	audit         *auditLog         // nil when AUDIT_LOG_FILE isn't set
+	// resultProcessors decorate the items of every search, see AddResultProcessor
+	resultProcessors *resultProcessors
+
+	restrictedSessions int64 // atomic, sessions restricted for searches eBay refused
+
+	botName     string             // "" for the default bot
+	bots        map[string]*Server // the bots of BOTS_FILE by name
+	botRequests int64              // atomic, welcome and chat requests
+}
+
+// ServerOption Customizes a Server created by NewServer
+type ServerOption func(*Server)
+
+// WithTransport Sends the eBay calls of the server through transport, to add tracing or mTLS
+func WithTransport(transport http.RoundTripper) ServerOption {
+	return func(s *Server) {
+		s.transport = transport
+	}
+}
+
+// WithSessionStore Keeps the sessions of the server in store, to share them between servers
+func WithSessionStore(store *SessionStore) ServerOption {
+	return func(s *Server) {
+		s.sessions = store
+	}
+}
+
+// WithSearcher Replaces the eBay client of the server
+func WithSearcher(searcher Searcher) ServerOption {
+	return func(s *Server) {
+		s.searcher = searcher
+	}
+}
+
+// NewServer Creates a Server for config searching eBay with the scripted processor
+func NewServer(config Config, options ...ServerOption) *Server {
+	s := &Server{
+		config:      config,
+		sessions:    NewSessionStore(config.SessionTTL),
+		blocklist:   newKeywordBlocklist(config.KeywordBlocklist),
+		profanity:   newKeywordBlocklist(config.ProfanityList),
+		accessories: newKeywordBlocklist(config.AccessoryTerms),
+		tracer:      newTracer(config.TraceEndpoint, config.TraceServiceName),
+		events:      noopEvents{},
+		prices:      newPriceHistoryStore(),
+		etags:       newSearchETags(),
+		steps:       config.Steps,
+		none:        newNoneSynonyms(config.NoneSynonyms),
+		quota:       newCallQuota(config.DailyCallLimit, config.QuotaFile),
+		profiles:    newProfileStore(config.ProfileTTL, config.ProfileFile),
+		throttle:    newThrottleCooldown(config.ThrottleCooldown),
+		apiKeys:     newAPIKeys(config.APIKeys),
+		funnel:      newFunnelCounts(),
+		feedback:    newFeedbackCounts(),
+		jobs:        newJobRegistry(),
+		maintenance: newMaintenanceMode(config.Maintenance, config.MaintenanceMessage, config.MaintenanceRetryAfter),
+
+		resultProcessors: &resultProcessors{},
+	}
+	if s.steps == nil {
+		s.steps = defaultSteps
+	}
+	if config.SessionBackupSecret != "" {
+		s.backupSecret = []byte(config.SessionBackupSecret)
+	}
+	if config.AdminToken != "" {
+		s.adminToken = []byte(config.AdminToken)
+	}
+	if config.AuditLogFile != "" {
+		s.audit = newAuditLog(config.AuditLogFile, config.AuditLogMaxSize)
+	}
+	if config.ImageProxySecret != "" {
+		s.images = &imageSigner{secret: []byte(config.ImageProxySecret), base: publicBaseURL(config)}
+	}
+	if config.AccessoryTerms == nil {
+		s.accessories = newKeywordBlocklist(defaultAccessoryTerms)
+	}
+	if s.landedCosts = config.LandedCostRates; s.landedCosts == nil {
+		s.landedCosts = defaultLandedCostRates()
+	}
+	s.processor.Store(Processor(s.sampleProcessor))
+	for _, option := range options {
+		option(s)
+	}
+	if s.searcher == nil {
+		client := newEbayClient(config, defaultMaxConcurrent, s.transport)
+		log.Printf("Searching the eBay %v environment on %v", client.env, client.host)
+		s.searcher = client
+	}
+	if config.Chaos.Enabled() {
+		if config.EbayEnv == "sandbox" {
+			log.Printf("Injecting faults into the searches: %+v", config.Chaos)
+			s.searcher = newChaosSearcher(s.searcher, config.Chaos)
+		} else {
+			log.Printf("Ignoring the chaos settings: %v", errChaosInProduction)
+		}
+	}
+	s.startFunnelFlush(config.FunnelFlushInterval)
+	if config.SearchWebhookURL != "" {
+		s.webhook = newSearchWebhook(config.SearchWebhookURL, config.SearchWebhookSecret, s.jobs)
+	}
+	s.imageClient = &http.Client{Transport: s.transport, Timeout: imageFetchTimeout}
+	s.index = newStaticAsset([]byte(s.indexPage()), "text/html; charset=utf-8", indexMaxAge)
+	s.bots = make(map[string]*Server, len(config.Bots))
+	for name, profile := range config.Bots {
+		s.bots[name] = s.newBot(name, profile)
+	}
+	return s
+}
+
+// SetProcessor Replaces the processor of the chat messages, it is safe to call while serving
+func (s *Server) SetProcessor(p Processor) error {
+	if p == nil {
+		return errors.New("processor must not be nil")
+	}
+	s.processor.Store(p)
+	return nil
+}
+
+// Processor Returns the processor currently handling chat messages
+func (s *Server) Processor() Processor {
+	return s.processor.Load().(Processor)
+}
+
+// Handler Returns the routes of the server with the CORS and gzip handling the binary serves them with
+func (s *Server) Handler() http.Handler {
+	return withChatOptions(cors.CORS(gzipHandler(s.Routes())))
+}
+
+// Shutdown Sends the spans of the last requests, the last funnel counts and the searches queued for the
+// webhook, waiting at most until ctx is done
+func (s *Server) Shutdown(ctx context.Context) {
+	if s.funnelStop != nil {
+		close(s.funnelStop)
+		s.funnelStop = nil
+	}
+	if s.webhook != nil {
+		s.webhook.Shutdown(ctx)
+	}
+	s.tracer.Shutdown(ctx)
+}
+
+// Routes Registers every route of the server on a new router.
+// Embedders that want the routes under their own prefix can mount the
+// router with http.StripPrefix.
+func (s *Server) Routes() *httprouter.Router {
+	router := httprouter.New()
+
+	for _, route := range s.routes() {
+		if route.keyed {
+			route.handle = s.requireAPIKey(route.handle)
+		}
+		if route.admin {
+			route.handle = s.audited(route.handle)
+		}
+		switch {
+		case route.probe:
+			router.Handle(route.method, route.path, route.handle)
+		case route.versioned:
+			router.Handle(route.method, apiPrefix+route.path, s.traced(apiPrefix+route.path, route.handle))
+			router.Handle(route.method, route.path, s.traced(route.path, deprecated(apiPrefix+route.path, route.handle)))
+		default:
+			router.Handle(route.method, route.path, s.traced(route.path, route.handle))
+		}
+	}
+	return router
+}
+
+// deprecated Marks the responses of a legacy route as deprecated in favor of successor
+func deprecated(successor string, handle httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+">; rel=\"successor-version\"")
+		handle(w, r, ps)
+	}
+}
+
+// search Runs query against eBay, every entry point searches through it so all of them are timed and logged
+func (s *Server) search(ctx context.Context, query SearchQuery) (SearchResult, error) {
+	ctx, sp := s.tracer.Start(ctx, "ebay findItemsByKeywords", spanClient)
+	defer sp.End()
+	sp.SetAttribute("search.keyword", query.Keyword)
+	sp.SetAttribute("search.condition", query.Condition)
+	sp.SetAttribute("search.min_price", query.MinPrice)
+	sp.SetAttribute("search.max_price", query.MaxPrice)
+	sp.SetAttribute("search.sort_order", query.SortOrder)
+
+	if s.config.SearchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.SearchTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	// Refuse rather than hammer eBay once the day's calls are used up or while it throttles us
+	result, err := SearchResult{}, s.quota.Check()
+	if err == nil {
+		err = s.throttle.Check()
+	}
+	if err == nil {
+		err = searchGuardOf(ctx).Check()
+	}
+	// The accessories the keyword doesn't name are left out by eBay as far as it can, and by title
+	accessories := &keywordBlocklist{}
+	if query.ExcludeAccessories {
+		accessories = s.accessories.excluding(query.Keyword)
+		query.ExcludeTerms = accessories.negativeTerms(query.Keyword)
+	}
+	called := err == nil
+	if called {
+		result, err = s.searcher.Search(ctx, query)
+	}
+	if called {
+		searchGuardOf(ctx).Record(err)
+	}
+	if limited, ok := err.(*throttled); ok && called {
+		s.throttle.Start(limited)
+		log.Printf("eBay throttled the app id, pausing searches until %v", limited.retryAt.Format(time.RFC3339))
+	}
+	// The wait the user sees, whatever part of it the searcher reports as eBay time
+	elapsed := time.Since(start)
+	if err == nil && query.ExcludeLots {
+		result = withoutLots(result)
+	}
+	if err == nil {
+		result = withoutAccessories(result, accessories)
+	}
+	if err == nil {
+		result = withLocations(result, query)
+	}
+	// The items of the embedder, as parsed, before anything ranks or renders them
+	if err == nil {
+		result.Items = s.resultProcessors.apply(ctx, query, result.Items)
+		result.Count = len(result.Items)
+	}
+	if err == nil && s.config.Ranking.Enabled() {
+		result.Items, result.Stats.Scores = rankItems(result.Items, query, s.config.Ranking)
+	}
+	// Collapsing after ranking keeps the best listing of a seller flooding the page
+	if err == nil && s.config.SellerCollapseShare > 0 {
+		result.Items, result.Collapsed = collapseSellers(result.Items, s.config.SellerCollapseShare)
+	}
+	estimateLandedCosts(result.Items, query.ShipsTo, s.landedCosts)
+	s.proxyImages(result.Items)
+	if err == nil {
+		result.PriceHistory = s.prices.Record(query.Keyword, result.Items)
+	}
+	if result.Stats.Duration == 0 {
+		result.Stats.Duration = elapsed
+	}
+	result.Stats.Slow = s.config.SearchSoftDeadline > 0 && elapsed > s.config.SearchSoftDeadline
+	if called && result.Stats.Attempts == 0 && !result.Stats.CacheHit {
+		result.Stats.Attempts = 1
+	}
+	s.quota.Record(result.Stats.Attempts)
+	if failure, refused := err.(*searchFailure); refused {
+		logFailure(ctx, failure)
+	}
+	if err != nil {
+		s.events.SearchFailed(searchAck(err) + ": " + err.Error())
+	} else {
+		s.events.SearchExecuted(query, result.Count, milliseconds(result.Stats.Duration))
+		if s.webhook != nil {
+			s.webhook.send(ctx, query, result)
+		}
+	}
+
+	sp.SetAttribute("search.result_count", result.Count)
+	sp.SetAttribute("search.cache_hit", result.Stats.CacheHit)
+	sp.SetAttribute("search.slow", result.Stats.Slow)
+	sp.SetAttribute("ebay.ack", searchAck(err))
+	if category := failureCategory(err); category != "" {
+		sp.SetAttribute("ebay.failure", category)
+	}
+	if _, limited := err.(*throttled); limited {
+		sp.SetAttribute("ebay.throttled", true)
+	}
+	sp.SetError(err)
+
+	stats := result.Stats
+	log.Printf("search %q: %v items, page %v/%v of %v entries, took %v in %v attempts, cache hit %v, slow %v, error %v",
+		query.Keyword, result.Count, stats.Page, stats.TotalPages, stats.TotalEntries, stats.Duration, stats.Attempts, stats.CacheHit, stats.Slow, err)
+	return result, err
+}
+
+const (
+	// defaultSearchTimeout Bounds a search when SEARCH_TIMEOUT isn't set
+	defaultSearchTimeout = 2 * time.Second
+	// defaultSearchSoftDeadline Marks searches slow when SEARCH_SOFT_DEADLINE isn't set
+	defaultSearchSoftDeadline = 1500 * time.Millisecond
+)
+
+// searchAck Returns the ack eBay answered with for a search that ended with err
+func searchAck(err error) string {
+	switch err.(type) {
+	case nil:
+		return "Success"
+	case *searchFailure:
+		return "Failure"
+	case *quotaExhausted:
+		return "QuotaExhausted"
+	case *throttled:
+		return "Throttled"
+	case *sessionRestricted:
+		return "Restricted"
+	}
+	if category := failureCategory(err); category != "" {
+		return "Unavailable (" + category + ")"
+	}
+	return "Unavailable"
+}
+
+// handleHealth Handles /healthz, reporting which eBay environment the server searches
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	health := JSON{"status": "ok"}
+	if chaos, ok := s.searcher.(*chaosSearcher); ok {
+		health["chaosInjected"] = chaos.Injected()
+	}
+	if client, ok := s.ebay(); ok {
+		health["ebayEnv"] = client.env
+		health["ebayHost"] = client.host
+	}
+	if dispatcher, ok := s.events.(*eventDispatcher); ok {
+		health["eventsDropped"] = dispatcher.Dropped()
+	}
+	used, limit, resetAt := s.quota.Usage()
+	health["ebayCalls24h"] = used
+	health["ebayDailyCallLimit"] = limit
+	if limit > 0 && used >= limit {
+		health["ebayQuotaResetsAt"] = resetAt.UTC().Format(time.RFC3339)
+	}
+	if until := s.throttle.Until(); !until.IsZero() {
+		health["ebayThrottledUntil"] = until.UTC().Format(time.RFC3339)
+	}
+	if s.apiKeys != nil {
+		health["apiKeyRequests"], health["apiKeyRejected"] = s.apiKeys.Counts()
+	}
+	health["sessionsRestricted"] = atomic.LoadInt64(&s.restrictedSessions)
+	health["maintenance"] = s.maintenance.Snapshot()
+	if s.audit != nil {
+		health["audit"] = s.audit.Counts()
+	}
+	if len(s.bots) > 0 {
+		health["botRequests"] = s.botRequestCounts()
+	}
+	if s.webhook != nil {
+		health["webhookDelivered"], health["webhookFailed"], health["webhookDropped"] = s.webhook.Counts()
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, health)
+}
+
+// writeError Writes the error envelope shared by every route
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	if plainReplies(w) {
+		message = plainMessage(message)
+	}
+	envelope := JSON{
+		"type": ReplyError,
+		"error": JSON{
+			"code":    code,
+			"message": message,
+		},
+	}
+	addReauth(w, code, envelope)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(envelope)
+}
+
+// handleSearch Handles /search, running one search from the query string without a conversation
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w = s.withPlainText(withMeta(w, r), r)
+	query, err := ParseSearchQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_query", err.Error())
+		return
+	}
+	if allowed, reason := s.allowKeyword(query.Keyword); !allowed {
+		writeError(w, http.StatusBadRequest, "keyword_blocked", strings.TrimSpace("Sorry, I can't search for that. "+reason))
+		return
+	}
+	// A dry run shows the call to eBay instead of making it
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_query", "dryRun must be true or false.")
+			return
+		}
+		if dryRun {
+			writeJSON(w, s.dryRun(query))
+			return
+		}
+	}
+
+	// Clients polling the same query are spared the results they already have, without searching again
+	// while the last search of the query is recent
+	if etag, fresh := s.etags.Fresh(query); fresh && etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", searchCacheControl)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	result, err := s.search(r.Context(), query)
+	if err != nil {
+		status, code, message, retryAt := describeSearchError(err)
+		if !retryAt.IsZero() {
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(retryAt).Seconds())+1))
+		}
+		writeError(w, status, code, message)
+		return
+	}
+
+	etag := searchETag(query, result)
+	s.etags.Remember(query, etag)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", searchCacheControl)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	items := result.Items
+	if items == nil {
+		items = []Item{}
+	}
+	noteSearch(w, result.Stats)
+	writeJSON(w, JSON{
+		"query":        query,
+		"count":        result.Count,
+		"items":        items,
+		"pageURL":      result.PageURL,
+		"searchURL":    result.SearchURL,
+		"priceHistory": result.PriceHistory,
+		"priceTrend":   priceTrend(result.PriceHistory),
+		"sellers":      sellerGrouping(items, result.Collapsed),
+		"collapsed":    nonNilGroups(result.Collapsed),
+	})
+}
+
+// describeSearchError Returns the status, error code and message a failed search answers with,
+// and when it may be retried for the failures that say so
+func describeSearchError(err error) (status int, code, message string, retryAt time.Time) {
+	switch e := err.(type) {
+	case *searchFailure:
+		return http.StatusBadRequest, "search_failed", e.userMessage(), time.Time{}
+	case *quotaExhausted:
+		return http.StatusTooManyRequests, "quota_exhausted", e.Error(), e.resetAt
+	case *throttled:
+		return http.StatusTooManyRequests, "throttled", throttledMessage, e.retryAt
+	case *upstreamError:
+		return http.StatusBadGateway, "upstream_unavailable", e.userMessage(), time.Time{}
+	case *sessionRestricted:
+		return http.StatusTooManyRequests, "session_restricted", e.userMessage(time.UTC), e.until
+	}
+	return http.StatusBadGateway, "upstream_unavailable", "Couldn't reach eBay right now.", time.Time{}
+}
+
+// ParseSearchQuery Validates the /search parameters and builds the SearchQuery for them.
+// The search subcommand of the binary parses its flags through it too.
+func ParseSearchQuery(values url.Values) (SearchQuery, error) {
+	get := func(key string) string {
+		return strings.TrimSpace(values.Get(key))
+	}
+
+	query := SearchQuery{
+		Keyword:   get("keyword"),
+		Condition: "none",
+		MinPrice:  "none",
+		MaxPrice:  "none",
+		Entries:   5,
+	}
+	if query.Keyword == "" {
+		return query, errors.New("Missing keyword parameter.")
+	}
+	keyword, err := normalizeKeyword(query.Keyword)
+	if err != nil {
+		return query, err
+	}
+	query.Keyword, query.References = keyword, keywordReferences(keyword)
+	if condition := get("condition"); condition != "" {
+		if query.Condition = normalizeCondition(condition); query.Condition == "" {
+			return query, errors.New("Condition must be New, Used or None.")
+		}
+	}
+	if minPrice := get("minPrice"); minPrice != "" {
+		if query.MinPrice = normalizePrice(minPrice); query.MinPrice == "" {
+			return query, errors.New("minPrice must be a number or None.")
+		}
+	}
+	if maxPrice := get("maxPrice"); maxPrice != "" {
+		if query.MaxPrice = normalizePrice(maxPrice); query.MaxPrice == "" {
+			return query, errors.New("maxPrice must be a number or None.")
+		}
+	}
+	if currency := strings.ToUpper(get("currency")); currency != "" {
+		if !validCurrency(currency) {
+			return query, errors.New("currency must be the currency code of an eBay site, like EUR.")
+		}
+		query.Currency = currency
+	}
+	if sort := get("sort"); sort != "" {
+		if query.SortOrder = sortOrders[strings.ToLower(sort)]; query.SortOrder == "" {
+			return query, errors.New("Unknown sort order: " + sort + ".")
+		}
+	}
+	if excludeLots := get("excludeLots"); excludeLots != "" {
+		exclude, err := strconv.ParseBool(excludeLots)
+		if err != nil {
+			return query, errors.New("excludeLots must be true or false.")
+		}
+		query.ExcludeLots = exclude
+	}
+	if excludeAccessories := get("excludeAccessories"); excludeAccessories != "" {
+		exclude, err := strconv.ParseBool(excludeAccessories)
+		if err != nil {
+			return query, errors.New("excludeAccessories must be true or false.")
+		}
+		query.ExcludeAccessories = exclude
+	}
+	if categories := get("excludeCategories"); categories != "" {
+		for _, name := range strings.Split(categories, ",") {
+			id := categoryByName(name)
+			if id == "" {
+				return query, errors.New("Unknown category to exclude: " + strings.TrimSpace(name) + ".")
+			}
+			query.ExcludeCategories = append(query.ExcludeCategories, id)
+		}
+		if len(query.ExcludeCategories) > maxExcludedCategories {
+			return query, errors.New("excludeCategories takes at most " + strconv.Itoa(maxExcludedCategories) + " categories.")
+		}
+	}
+	if shipsTo := get("shipsTo"); shipsTo != "" {
+		if query.ShipsTo = countryCode(shipsTo); query.ShipsTo == "" {
+			return query, errors.New("shipsTo must be a two letter country code.")
+		}
+	}
+	if locatedIn := get("locatedIn"); locatedIn != "" {
+		if query.LocatedIn = countryCode(locatedIn); query.LocatedIn == "" {
+			return query, errors.New("locatedIn must be a two letter country code.")
+		}
+	}
+	if excluded := get("excludeCountries"); excluded != "" {
+		for _, country := range strings.Split(excluded, ",") {
+			code := countryCode(country)
+			if code == "" {
+				return query, errors.New("excludeCountries must be a comma separated list of two letter country codes.")
+			}
+			query.ExcludeCountries = append(query.ExcludeCountries, code)
+		}
+	}
+	if entries := get("entries"); entries != "" {
+		n, err := strconv.Atoi(entries)
+		if err != nil || n < 1 || n > 100 {
+			return query, errors.New("entries must be between 1 and 100.")
+		}
+		query.Entries = n
+	}
+	return query, nil
+}
@@ -0,0 +1,367 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeSearcher Answers searches with canned results by keyword and records the queries it got
+type fakeSearcher struct {
+	mu      sync.Mutex
+	results map[string]SearchResult // by keyword, "" answers every other keyword
+	errs    map[string]error        // by keyword, "" fails every other keyword
+	delay   time.Duration           // how long every search takes, unless ctx ends first
+	queries []SearchQuery
+
+	inFlight, maxInFlight int
+}
+
+// Search Implements Searcher
+func (f *fakeSearcher) Search(ctx context.Context, query SearchQuery) (SearchResult, error) {
+	f.mu.Lock()
+	f.queries = append(f.queries, query)
+	f.inFlight++
+	if f.inFlight > f.maxInFlight {
+		f.maxInFlight = f.inFlight
+	}
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return SearchResult{}, ctx.Err()
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err, found := f.errs[query.Keyword]; found {
+		return SearchResult{}, err
+	}
+	if err, found := f.errs[""]; found {
+		return SearchResult{}, err
+	}
+	result, found := f.results[query.Keyword]
+	if !found {
+		result = f.results[""]
+	}
+	result.Count = len(result.Items)
+	result.Stats.Attempts = 1
+	return result, nil
+}
+
+// Queries Returns the queries searched so far
+func (f *fakeSearcher) Queries() []SearchQuery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]SearchQuery(nil), f.queries...)
+}
+
+// testItems Returns n items of prefix with prices counting up from first hundreds
+func testItems(prefix string, first, n int) []Item {
+	items := make([]Item, n)
+	for i := range items {
+		number := strconv.Itoa(first + i)
+		items[i] = Item{
+			ID:        prefix + number,
+			Title:     prefix + " item " + number,
+			Condition: "New",
+			Price:     strconv.Itoa((first+i)*100) + ".0",
+			Currency:  "USD",
+			ItemURL:   "https://www.ebay.com/itm/" + prefix + number,
+		}
+	}
+	return items
+}
+
+// testClock Is a clock that only moves when told to
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// Now Returns the time of the clock
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance Moves the clock forward by d
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// testServer Is a Server whose sessions follow a testClock
+type testServer struct {
+	*Server
+	clock *testClock
+}
+
+// newTestServer Creates a Server of config searching through searcher, shut down with the test
+func newTestServer(t testing.TB, config Config, searcher Searcher, options ...ServerOption) *testServer {
+	t.Helper()
+	clock := &testClock{now: time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)}
+	store := NewSessionStore(config.SessionTTL)
+	store.now = clock.Now
+	s := NewServer(config, append([]ServerOption{WithSearcher(searcher), WithSessionStore(store)}, options...)...)
+	s.prices.now = clock.Now
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		s.Shutdown(ctx)
+	})
+	return &testServer{Server: s, clock: clock}
+}
+
+// testReply Is one response of the server, its body decoded
+type testReply struct {
+	Status int
+	Header http.Header
+	Body   JSON
+	Raw    string
+}
+
+// do Sends a request to handler and decodes the JSON it answers with
+func do(t testing.TB, handler http.Handler, method, target, body string, header http.Header) testReply {
+	t.Helper()
+	var reader *strings.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	var r *http.Request
+	if reader != nil {
+		r = httptest.NewRequest(method, target, reader)
+		r.Header.Set("Content-Type", "application/json")
+	} else {
+		r = httptest.NewRequest(method, target, nil)
+	}
+	for key, values := range header {
+		r.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	reply := testReply{Status: w.Code, Header: w.Header(), Raw: w.Body.String()}
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") && w.Body.Len() > 0 {
+		if err := json.Unmarshal(w.Body.Bytes(), &reply.Body); err != nil {
+			t.Fatalf("%v %v answered invalid JSON %q: %v", method, target, w.Body.String(), err)
+		}
+	}
+	return reply
+}
+
+// conversation Talks to a server through its routes, as one client would
+type conversation struct {
+	t       testing.TB
+	handler http.Handler
+	clock   *testClock
+	uuid    string
+}
+
+// startConversation Starts a session on s with /v1/welcome
+func startConversation(t testing.TB, s *testServer) *conversation {
+	t.Helper()
+	c := &conversation{t: t, handler: s.Routes(), clock: s.clock}
+	reply := do(t, c.handler, http.MethodGet, "/v1/welcome", "", nil)
+	uuid, _ := reply.Body["uuid"].(string)
+	if reply.Status != http.StatusOK || uuid == "" {
+		t.Fatalf("/v1/welcome answered %v %v", reply.Status, reply.Raw)
+	}
+	c.uuid = uuid
+	return c
+}
+
+// say Sends message to /v1/chat and returns the reply. The clock moves past duplicateGrace first, so
+// answering the same twice in a row isn't taken for a retry.
+func (c *conversation) say(message string) testReply {
+	c.t.Helper()
+	c.clock.Advance(2 * duplicateGrace)
+	body, _ := json.Marshal(JSON{"message": message})
+	return do(c.t, c.handler, http.MethodPost, "/v1/chat", string(body), http.Header{"Authorization": {c.uuid}})
+}
+
+// sayAll Sends every message in turn and returns the last reply
+func (c *conversation) sayAll(messages ...string) testReply {
+	c.t.Helper()
+	var reply testReply
+	for _, message := range messages {
+		reply = c.say(message)
+	}
+	return reply
+}
+
+// message Returns the message of a reply, or the message of its error
+func (r testReply) message() string {
+	if message, found := r.Body["message"].(string); found {
+		return message
+	}
+	if envelope, found := r.Body["error"].(map[string]interface{}); found {
+		message, _ := envelope["message"].(string)
+		return message
+	}
+	return ""
+}
+
+// errorCode Returns the code of the error envelope of a reply, "" for replies that aren't errors
+func (r testReply) errorCode() string {
+	envelope, _ := r.Body["error"].(map[string]interface{})
+	code, _ := envelope["code"].(string)
+	return code
+}
+
+// itemIDs Returns the ids of the items of a reply
+func (r testReply) itemIDs() []string {
+	items, _ := r.Body["items"].([]interface{})
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		fields, _ := item.(map[string]interface{})
+		id, _ := fields["id"].(string)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  string
+		want    SearchQuery
+		wantErr string
+	}{
+		{"keyword only", "keyword=gucci+belt", SearchQuery{Keyword: "gucci belt", Condition: "none", MinPrice: "none", MaxPrice: "none", Entries: 5}, ""},
+		{"every filter", "keyword=gucci&condition=used&minPrice=10&maxPrice=500&sort=cheapest&entries=20", SearchQuery{Keyword: "gucci", Condition: "Used", MinPrice: "10", MaxPrice: "500", SortOrder: "PricePlusShippingLowest", Entries: 20}, ""},
+		{"missing keyword", "condition=new", SearchQuery{}, "Missing keyword parameter."},
+		{"bad condition", "keyword=gucci&condition=broken", SearchQuery{}, "Condition must be New, Used or None."},
+		{"bad max price", "keyword=gucci&maxPrice=cheap", SearchQuery{}, "maxPrice must be a number or None."},
+		{"too many entries", "keyword=gucci&entries=101", SearchQuery{}, "entries must be between 1 and 100."},
+		{"bad sort", "keyword=gucci&sort=random", SearchQuery{}, "Unknown sort order: random."},
+		{"bad country", "keyword=gucci&locatedIn=Atlantis", SearchQuery{}, "locatedIn must be a two letter country code."},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(test.values)
+			query, err := ParseSearchQuery(values)
+			if test.wantErr != "" {
+				if err == nil || err.Error() != test.wantErr {
+					t.Fatalf("ParseSearchQuery(%q) = %v, want error %q", test.values, err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSearchQuery(%q) failed: %v", test.values, err)
+			}
+			if query.Keyword != test.want.Keyword || query.Condition != test.want.Condition || query.MinPrice != test.want.MinPrice ||
+				query.MaxPrice != test.want.MaxPrice || query.SortOrder != test.want.SortOrder || query.Entries != test.want.Entries {
+				t.Errorf("ParseSearchQuery(%q) = %+v, want %+v", test.values, query, test.want)
+			}
+		})
+	}
+}
+
+func TestSwapProcessorsDuringTurnsOfOneSession(t *testing.T) {
+	s := newTestServer(t, Config{}, &fakeSearcher{})
+	other := newTestServer(t, Config{}, &fakeSearcher{})
+	c := startConversation(t, s)
+
+	// Each processor answers with its name twice, a reply mixing two names was written by a torn processor
+	var active, overlaps int32
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	processor := func(name string) Processor {
+		return func(session Session, message string, w http.ResponseWriter) {
+			if atomic.AddInt32(&active, 1) > 1 {
+				atomic.AddInt32(&overlaps, 1)
+			}
+			defer atomic.AddInt32(&active, -1)
+			session["turns"] = session.GetInt("turns", 0) + 1
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			seen[message] = true
+			mu.Unlock()
+			WriteReply(w, ReplyInfo, JSON{"message": name + ": " + message, "processor": name})
+		}
+	}
+	otherCalled := int32(0)
+	other.SetProcessor(func(session Session, message string, w http.ResponseWriter) {
+		atomic.AddInt32(&otherCalled, 1)
+		WriteReply(w, ReplyInfo, JSON{"message": "other"})
+	})
+
+	const turns = 40
+	s.SetProcessor(processor("a"))
+	stop := make(chan struct{})
+	swapped := make(chan int)
+	go func() {
+		swaps := 0
+		defer func() { swapped <- swaps }()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			name := "b"
+			if swaps%2 == 1 {
+				name = "a"
+			}
+			if err := s.SetProcessor(processor(name)); err != nil {
+				t.Error(err)
+			}
+			swaps++
+			time.Sleep(100 * time.Microsecond)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	replies := make(chan testReply, turns)
+	for i := 0; i < turns; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body, _ := json.Marshal(JSON{"message": "message " + strconv.Itoa(i)})
+			replies <- do(t, c.handler, http.MethodPost, "/v1/chat", string(body), http.Header{"Authorization": {c.uuid}})
+		}(i)
+	}
+	wg.Wait()
+	close(stop)
+	close(replies)
+	if swaps := <-swapped; swaps < 2 {
+		t.Errorf("the processor was swapped %v times during the turns, want at least 2", swaps)
+	}
+
+	for reply := range replies {
+		name, _ := reply.Body["processor"].(string)
+		if reply.Status != http.StatusOK || (name != "a" && name != "b") || !strings.HasPrefix(reply.message(), name+": message ") {
+			t.Errorf("a turn answered %v %v", reply.Status, reply.Raw)
+		}
+	}
+	if overlaps != 0 {
+		t.Errorf("%v turns of the session ran while another one did", overlaps)
+	}
+	if len(seen) != turns {
+		t.Errorf("the processors saw %v distinct messages, want %v", len(seen), turns)
+	}
+	session, _, _ := s.sessions.Get(c.uuid)
+	if _, conversation := activeConversation(session); conversation.GetInt("turns", 0) != turns {
+		t.Errorf("the session counted %v turns, want %v", conversation.GetInt("turns", 0), turns)
+	}
+	if otherCalled != 0 {
+		t.Errorf("the processor of another server answered %v messages", otherCalled)
+	}
+}
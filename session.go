@@ -0,0 +1,269 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// defaultSessionTTL How long a session survives without any message
+const defaultSessionTTL = 30 * time.Minute
+
+// maxRememberedExpired Caps how many expired uuids are remembered to tell their clients
+const maxRememberedExpired = 10000
+
+// idleRecapGap How long a session has to sit idle before its next reply recaps the search
+const idleRecapGap = 10 * time.Minute
+
+// sessionState Tells what a uuid refers to
+type sessionState int
+
+const (
+	sessionUnknown sessionState = iota
+	sessionActive
+	sessionExpired
+)
+
+// sessionLockTimeout Bounds how long a message waits for the previous message of its session
+const sessionLockTimeout = 30 * time.Second
+
+// errSessionGone Is returned when a session expired while a message waited for it
+var errSessionGone = errors.New("session expired")
+
+// storedSession Holds a session along with its activity timestamps
+type storedSession struct {
+	session      Session
+	createdAt    time.Time
+	lastActivity time.Time
+	turn         chan struct{} // Holds a token while a message of the session is processed
+	turns        int           // of its active conversation since its last search, see noteTurns
+	replies      *replyQueue   // waiting for GET /chat/poll
+	bot          string        // that started it, "" for the default one, never changes
+}
+
+// sessionActivity Tells when a session was used, as of one Create or Get
+type sessionActivity struct {
+	createdAt    time.Time
+	lastActivity time.Time     // the activity before this one
+	idle         time.Duration // since lastActivity
+	expiresAt    time.Time     // unless another message arrives first
+}
+
+// activity Describes stored as of now, before recording the activity of now
+func (st *SessionStore) activity(stored *storedSession, now time.Time) sessionActivity {
+	return sessionActivity{
+		createdAt:    stored.createdAt,
+		lastActivity: stored.lastActivity,
+		idle:         now.Sub(stored.lastActivity),
+		expiresAt:    now.Add(st.ttl),
+	}
+}
+
+// recapDue Reports whether the session sat idle long enough to deserve a recap.
+// Short TTLs recap after half of the TTL instead.
+func (st *SessionStore) recapDue(activity sessionActivity) bool {
+	gap := idleRecapGap
+	if st.ttl < 2*gap {
+		gap = st.ttl / 2
+	}
+	return activity.idle >= gap
+}
+
+// SessionStore Keeps the sessions in memory and expires the idle ones
+type SessionStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	now       func() time.Time
+	sessions  map[string]*storedSession
+	expired   map[string]time.Time // uuid -> expiry of recently expired sessions
+	lastSweep time.Time
+}
+
+// NewSessionStore Creates a SessionStore expiring sessions idle for longer than ttl
+func NewSessionStore(ttl time.Duration) *SessionStore {
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+	return &SessionStore{
+		ttl:      ttl,
+		now:      time.Now,
+		sessions: map[string]*storedSession{},
+		expired:  map[string]time.Time{},
+	}
+}
+
+// newUUID Generates a random session id
+func newUUID() string {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		panic(err)
+	}
+	sum := sha256.Sum256(random)
+	return hex.EncodeToString(sum[:])
+}
+
+// clientSessionID Matches the uuids clients may choose themselves: an RFC 4122 UUID, or the 64 hex digits of newUUID
+var clientSessionID = regexp.MustCompile(`^(?:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-f]{64})$`)
+
+// exampleClientSessionID Shows clients what a uuid of their own looks like
+const exampleClientSessionID = "0b6f4a4e-9c1d-4b59-8f3e-2d7a1c5e9f20"
+
+// validClientSessionID Reports whether a client may create a session under uuid
+func validClientSessionID(uuid string) bool {
+	return clientSessionID.MatchString(uuid)
+}
+
+// Create Starts a new empty session of the default bot
+func (st *SessionStore) Create() (string, Session, sessionActivity) {
+	return st.create("")
+}
+
+// create Starts a new empty session of bot, "" for the default one
+func (st *SessionStore) create(bot string) (string, Session, sessionActivity) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := st.now()
+	st.sweep(now)
+	uuid := newUUID()
+	stored := st.add(uuid, bot, now)
+	return uuid, stored.session, st.activity(stored, now)
+}
+
+// Provision Starts a new empty session of bot under the uuid a client chose, reporting whether it created it.
+// When another message created it first meanwhile, that session is returned instead.
+// An empty bot is the default one.
+func (st *SessionStore) Provision(uuid, bot string) (Session, sessionActivity, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := st.now()
+	st.sweep(now)
+	if stored, found := st.sessions[uuid]; found {
+		activity := st.activity(stored, now)
+		stored.lastActivity = now
+		return stored.session, activity, false
+	}
+	stored := st.add(uuid, bot, now)
+	return stored.session, st.activity(stored, now), true
+}
+
+// add Stores a new empty session of bot for uuid
+func (st *SessionStore) add(uuid, bot string, now time.Time) *storedSession {
+	session := Session{"schemaVersion": sessionSchemaVersion}
+	if bot != "" {
+		session["bot"] = bot
+	}
+	stored := &storedSession{session: session, createdAt: now, lastActivity: now, turn: make(chan struct{}, 1), replies: newReplyQueue(), bot: bot}
+	st.sessions[uuid] = stored
+	return stored
+}
+
+// Get Returns the session of uuid and records activity on it.
+// The session may only be read or written once Lock took its turn.
+func (st *SessionStore) Get(uuid string) (Session, sessionActivity, sessionState) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := st.now()
+	stored, found := st.sessions[uuid]
+	if !found {
+		if _, expired := st.expired[uuid]; expired {
+			return nil, sessionActivity{}, sessionExpired
+		}
+		return nil, sessionActivity{}, sessionUnknown
+	}
+	if now.Sub(stored.lastActivity) > st.ttl {
+		st.expire(uuid, stored)
+		return nil, sessionActivity{}, sessionExpired
+	}
+	activity := st.activity(stored, now)
+	stored.lastActivity = now
+	return stored.session, activity, sessionActive
+}
+
+// State Tells what uuid refers to without touching its session or recording activity,
+// for the handlers that may not wait for its turn
+func (st *SessionStore) State(uuid string) sessionState {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	stored, found := st.sessions[uuid]
+	if !found {
+		if _, expired := st.expired[uuid]; expired {
+			return sessionExpired
+		}
+		return sessionUnknown
+	}
+	if st.now().Sub(stored.lastActivity) > st.ttl {
+		st.expire(uuid, stored)
+		return sessionExpired
+	}
+	return sessionActive
+}
+
+// Lock Waits until no other message of uuid is processed and returns the func ending the turn.
+// Messages of one session are thus processed one at a time, in the order they got the lock,
+// and waiting ends with ctx or after sessionLockTimeout.
+func (st *SessionStore) Lock(ctx context.Context, uuid string) (func(), error) {
+	st.mu.Lock()
+	stored, found := st.sessions[uuid]
+	st.mu.Unlock()
+	if !found {
+		return nil, errSessionGone
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sessionLockTimeout)
+	defer cancel()
+	select {
+	case stored.turn <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return func() { <-stored.turn }, nil
+}
+
+// expire Forgets a session but remembers that uuid existed
+func (st *SessionStore) expire(uuid string, stored *storedSession) {
+	delete(st.sessions, uuid)
+	stored.replies.close()
+	if len(st.expired) < maxRememberedExpired {
+		st.expired[uuid] = stored.lastActivity.Add(st.ttl)
+	}
+}
+
+// sweep Expires idle sessions and forgets old expired uuids, at most once a minute
+func (st *SessionStore) sweep(now time.Time) {
+	if now.Sub(st.lastSweep) < time.Minute {
+		return
+	}
+	st.lastSweep = now
+	for uuid, stored := range st.sessions {
+		if now.Sub(stored.lastActivity) > st.ttl {
+			st.expire(uuid, stored)
+		}
+	}
+	for uuid, expiredAt := range st.expired {
+		if now.Sub(expiredAt) > st.ttl {
+			delete(st.expired, uuid)
+		}
+	}
+}
+
+// Ping Reports whether the store can serve sessions. The memory store always can,
+// the method is the health check /readyz runs against whatever keeps the sessions.
+func (st *SessionStore) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// Len Returns the number of live sessions
+func (st *SessionStore) Len() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return len(st.sessions)
+}
@@ -0,0 +1,151 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionStoreStates(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)}
+	store := NewSessionStore(time.Hour)
+	store.now = clock.Now
+	uuid, session, _ := store.Create()
+	session["keyword"] = "gucci bag"
+
+	tests := []struct {
+		name      string
+		advance   time.Duration
+		uuid      string
+		wantState sessionState
+	}{
+		{"fresh", 0, uuid, sessionActive},
+		{"unknown", 0, "nobody", sessionUnknown},
+		{"idle within the TTL", 59 * time.Minute, uuid, sessionActive},
+		{"activity restarts the TTL", 59 * time.Minute, uuid, sessionActive},
+		{"idle past the TTL", 61 * time.Minute, uuid, sessionExpired},
+		{"remembered as expired", 0, uuid, sessionExpired},
+		{"expired uuid forgotten after another TTL", 2 * time.Hour, uuid, sessionUnknown},
+	}
+	for _, test := range tests {
+		clock.Advance(test.advance)
+		// A sweep runs on every Create a minute apart, forgetting the old expired uuids
+		store.Create()
+		got, _, state := store.Get(test.uuid)
+		if state != test.wantState {
+			t.Errorf("%v: Get() = %v, want %v", test.name, state, test.wantState)
+		}
+		if state == sessionActive && got["keyword"] != "gucci bag" {
+			t.Errorf("%v: Get() returned %v, want the stored session", test.name, got)
+		}
+	}
+}
+
+func TestSessionStoreStateDoesntRecordActivity(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)}
+	store := NewSessionStore(time.Hour)
+	store.now = clock.Now
+	uuid, _, _ := store.Create()
+	clock.Advance(40 * time.Minute)
+	if state := store.State(uuid); state != sessionActive {
+		t.Fatalf("State() = %v, want active", state)
+	}
+	clock.Advance(40 * time.Minute)
+	if state := store.State(uuid); state != sessionExpired {
+		t.Errorf("State() after 80 idle minutes = %v, want expired", state)
+	}
+}
+
+func TestSessionActivity(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		idle       time.Duration
+		wantRecap  bool
+		wantExpiry time.Duration
+	}{
+		{"busy", 30 * time.Minute, time.Minute, false, 30 * time.Minute},
+		{"idle", 30 * time.Minute, idleRecapGap, true, 30 * time.Minute},
+		{"short TTL, idle half of it", 10 * time.Minute, 5 * time.Minute, true, 10 * time.Minute},
+		{"short TTL, busy", 10 * time.Minute, 4 * time.Minute, false, 10 * time.Minute},
+	}
+	for _, test := range tests {
+		store := NewSessionStore(test.ttl)
+		store.now = clock.Now
+		uuid, _, created := store.Create()
+		clock.Advance(test.idle)
+		_, activity, _ := store.Get(uuid)
+		if activity.idle != test.idle || !activity.createdAt.Equal(created.createdAt) {
+			t.Errorf("%v: Get() reported %+v, want %v idle since %v", test.name, activity, test.idle, created.createdAt)
+		}
+		if want := clock.Now().Add(test.wantExpiry); !activity.expiresAt.Equal(want) {
+			t.Errorf("%v: Get() reported the expiry %v, want %v", test.name, activity.expiresAt, want)
+		}
+		if got := store.recapDue(activity); got != test.wantRecap {
+			t.Errorf("%v: recapDue() = %v, want %v", test.name, got, test.wantRecap)
+		}
+	}
+}
+
+func TestValidClientSessionID(t *testing.T) {
+	tests := []struct {
+		uuid string
+		want bool
+	}{
+		{exampleClientSessionID, true},
+		{strings.ToUpper(exampleClientSessionID), true},
+		{newUUID(), true},
+		{"", false},
+		{"not-a-uuid", false},
+		{"0b6f4a4e9c1d4b598f3e2d7a1c5e9f20", false},
+		{strings.ToUpper(newUUID()), false},
+		{exampleClientSessionID + "\n", false},
+	}
+	for _, test := range tests {
+		if got := validClientSessionID(test.uuid); got != test.want {
+			t.Errorf("validClientSessionID(%q) = %v, want %v", test.uuid, got, test.want)
+		}
+	}
+}
+
+func TestSessionStoreProvision(t *testing.T) {
+	store := NewSessionStore(time.Hour)
+	session, _, created := store.Provision(exampleClientSessionID, "concierge")
+	if !created || session["bot"] != "concierge" || session["schemaVersion"] != sessionSchemaVersion {
+		t.Fatalf("Provision() = %v, %v, want a new session of concierge", session, created)
+	}
+	session["keyword"] = "gucci bag"
+	again, _, created := store.Provision(exampleClientSessionID, "")
+	if created || again["keyword"] != "gucci bag" || again["bot"] != "concierge" {
+		t.Errorf("Provision() of a live uuid = %v, %v, want the existing session", again, created)
+	}
+	if store.Len() != 1 {
+		t.Errorf("Len() = %v, want 1", store.Len())
+	}
+}
+
+func TestSessionStoreLock(t *testing.T) {
+	store := NewSessionStore(time.Hour)
+	uuid, _, _ := store.Create()
+	unlock, err := store.Lock(context.Background(), uuid)
+	if err != nil {
+		t.Fatalf("Lock() = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := store.Lock(ctx, uuid); err != context.DeadlineExceeded {
+		t.Errorf("Lock() of a busy session = %v, want %v", err, context.DeadlineExceeded)
+	}
+	unlock()
+	if unlock, err := store.Lock(context.Background(), uuid); err != nil {
+		t.Errorf("Lock() after the turn ended = %v", err)
+	} else {
+		unlock()
+	}
+	if _, err := store.Lock(context.Background(), "nobody"); err != errSessionGone {
+		t.Errorf("Lock() of an unknown session = %v, want %v", err, errSessionGone)
+	}
+}
@@ -0,0 +1,34 @@
+package theluxuryshopper
+
+// sessionSchemaVersion Is the version of the session layout this server writes. Sessions only live in the
+// memory of the server that created them, so none of another layout ever reaches it and there is nothing to
+// migrate. A store keeping sessions across versions will have to bump it and upgrade what it loads.
+const sessionSchemaVersion = 1
+
+// GetString Returns the string at key, or fallback when it is missing or not a string
+func (s Session) GetString(key, fallback string) string {
+	if value, ok := s[key].(string); ok {
+		return value
+	}
+	return fallback
+}
+
+// GetBool Returns the bool at key, or fallback when it is missing or not a bool
+func (s Session) GetBool(key string, fallback bool) bool {
+	if value, ok := s[key].(bool); ok {
+		return value
+	}
+	return fallback
+}
+
+// GetInt Returns the number at key, or fallback when it is missing or not a number.
+// Numbers decoded from JSON are float64s, they are truncated.
+func (s Session) GetInt(key string, fallback int) int {
+	switch value := s[key].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
+	}
+	return fallback
+}
@@ -0,0 +1,31 @@
+package theluxuryshopper
+
+import "testing"
+
+func TestSessionAccessors(t *testing.T) {
+	session := Session{"keyword": "gucci bag", "skip": true, "turns": 3, "decoded": 4.9, "wrong": "3"}
+	tests := []struct {
+		key        string
+		wantString string
+		wantBool   bool
+		wantInt    int
+	}{
+		{"keyword", "gucci bag", false, -1},
+		{"skip", "fallback", true, -1},
+		{"turns", "fallback", false, 3},
+		{"decoded", "fallback", false, 4},
+		{"wrong", "3", false, -1},
+		{"missing", "fallback", false, -1},
+	}
+	for _, test := range tests {
+		if got := session.GetString(test.key, "fallback"); got != test.wantString {
+			t.Errorf("GetString(%q) = %q, want %q", test.key, got, test.wantString)
+		}
+		if got := session.GetBool(test.key, false); got != test.wantBool {
+			t.Errorf("GetBool(%q) = %v, want %v", test.key, got, test.wantBool)
+		}
+		if got := session.GetInt(test.key, -1); got != test.wantInt {
+			t.Errorf("GetInt(%q) = %v, want %v", test.key, got, test.wantInt)
+		}
+	}
+}
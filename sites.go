@@ -0,0 +1,205 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ebaySite Describes one eBay site a search can switch to
+type ebaySite struct {
+	Name     string // How the site is called, like eBay UK
+	GlobalID string // The GLOBAL-ID the Finding API knows it by
+	Currency string // The currency its prices are in
+	Domain   string // The host of its website
+}
+
+// ebaySites Maps the names users call the sites by to the sites
+var ebaySites = map[string]ebaySite{}
+
+func init() {
+	for _, site := range []struct {
+		ebaySite
+		names []string
+	}{
+		{ebaySite{"eBay US", "EBAY-US", "USD", "www.ebay.com"}, []string{"us", "usa", "america", "com"}},
+		{ebaySite{"eBay UK", "EBAY-GB", "GBP", "www.ebay.co.uk"}, []string{"uk", "gb", "britain", "co.uk"}},
+		{ebaySite{"eBay Germany", "EBAY-DE", "EUR", "www.ebay.de"}, []string{"de", "germany"}},
+		{ebaySite{"eBay France", "EBAY-FR", "EUR", "www.ebay.fr"}, []string{"fr", "france"}},
+		{ebaySite{"eBay Italy", "EBAY-IT", "EUR", "www.ebay.it"}, []string{"it", "italy"}},
+		{ebaySite{"eBay Spain", "EBAY-ES", "EUR", "www.ebay.es"}, []string{"es", "spain"}},
+		{ebaySite{"eBay Ireland", "EBAY-IE", "EUR", "www.ebay.ie"}, []string{"ie", "ireland"}},
+		{ebaySite{"eBay Austria", "EBAY-AT", "EUR", "www.ebay.at"}, []string{"at", "austria"}},
+		{ebaySite{"eBay Canada", "EBAY-ENCA", "CAD", "www.ebay.ca"}, []string{"ca", "canada"}},
+		{ebaySite{"eBay Australia", "EBAY-AU", "AUD", "www.ebay.com.au"}, []string{"au", "australia"}},
+	} {
+		for _, name := range site.names {
+			ebaySites[name] = site.ebaySite
+		}
+		ebaySites[strings.ToLower(site.GlobalID)] = site.ebaySite
+	}
+}
+
+var (
+	// switchSiteCommand Matches "search eBay UK instead", "try ebay.de" or "switch to eBay Canada"
+	switchSiteCommand = regexp.MustCompile(`(?i)^\s*(?:(?:search|try|use|switch\s+to)\s+)?(?:on\s+)?ebay[\s.-]*([a-z.-]+?)(?:\s+instead)?\W*$`)
+	// backToSiteCommand Matches "back to US" and "go back to the original site"
+	backToSiteCommand = regexp.MustCompile(`(?i)^\s*(?:go\s+|switch\s+)?back\s+to\s+(?:ebay[\s.-]*)?(?:the\s+)?([a-z.-]+?)(?:\s+site)?\W*$`)
+)
+
+// siteByGlobalID Returns the site of globalID, reporting false for ids the table doesn't know
+func siteByGlobalID(globalID string) (ebaySite, bool) {
+	site, found := ebaySites[strings.ToLower(globalID)]
+	return site, found
+}
+
+// siteName Names the site of globalID for people, "" is the site of the app id
+func siteName(globalID string) string {
+	if globalID == "" {
+		return "the default eBay site"
+	}
+	if site, found := siteByGlobalID(globalID); found {
+		return site.Name
+	}
+	return globalID
+}
+
+// handleSiteCommand Answers "search eBay UK instead" and "back to US" by running the last query on
+// another site, reporting whether message was one. The site searched first is kept in
+// session["originalSite"] until a new search starts, so "back to the original site" works.
+func (s *Server) handleSiteCommand(session Session, message string, w http.ResponseWriter) bool {
+	if _, searching := session["searchByKeyword"]; searching {
+		return false
+	}
+	var globalID string
+	if match := switchSiteCommand.FindStringSubmatch(message); match != nil {
+		site, found := ebaySites[strings.ToLower(match[1])]
+		if !found {
+			return false
+		}
+		globalID = site.GlobalID
+	} else if match := backToSiteCommand.FindStringSubmatch(message); match != nil {
+		name := strings.ToLower(match[1])
+		original, switched := session["originalSite"].(string)
+		site, found := ebaySites[name]
+		switch {
+		case found:
+			globalID = site.GlobalID
+		case name != "original" && name != "previous" && name != "first":
+			return false
+		case !switched:
+			WriteReply(w, ReplyInfo, JSON{
+				"message": "Your last search didn't switch sites, so there is nothing to go back to.\n " + keywordQuestion,
+			})
+			return true
+		default:
+			globalID = original
+		}
+	} else {
+		return false
+	}
+
+	last, found := session["lastQuery"].(SearchQuery)
+	if !found {
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "Search for something first, then I can try it on " + siteName(globalID) + ".\n " + keywordQuestion,
+		})
+		return true
+	}
+	if strings.EqualFold(last.Site, globalID) {
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "Your last search for '" + last.Keyword + "' already ran on " + siteName(globalID) + ".\n What else would you like to search for?",
+		})
+		return true
+	}
+	if _, switched := session["originalSite"]; !switched {
+		session["originalSite"] = last.Site
+	}
+	s.searchOnSite(session, last, globalID, w)
+	return true
+}
+
+// searchOnSite Runs query again on the site of globalID, with the same keyword and filters
+func (s *Server) searchOnSite(session Session, query SearchQuery, globalID string, w http.ResponseWriter) {
+	from := query.Site
+	query.Site, query.Page = globalID, 0
+	query = withDisplay(query, requestDisplay(w))
+	session["lastQuery"] = query
+	// Back on the first site the filters mean what they meant at first
+	original, _ := session["originalSite"].(string)
+	returning := strings.EqualFold(original, globalID)
+	if returning {
+		delete(session, "originalSite")
+	}
+
+	result, err := s.search(requestContext(w), query)
+	if handleError(err, session, w) == 1 {
+		return
+	}
+	note := "Searching " + siteName(globalID) + " instead of " + siteName(from) + " for '" + query.Keyword + "'."
+	if priceNote := sitePriceNote(query, original, globalID); priceNote != "" && !returning {
+		note += " " + priceNote
+	}
+	if result.Count == 0 {
+		WriteReply(w, ReplyZeroResults, JSON{
+			"message": note + "\n There are no items matching your criteria there.\n What else would you like to search for? ",
+			"items":   []Item{},
+			"query":   query,
+		})
+		resetSession(session)
+		return
+	}
+
+	var response strings.Builder
+	response.Grow(estimateRenderSize(result.Items) + 512)
+	response.WriteString(note + "\n There are " + strconv.Itoa(len(result.Items)) + " items matching your criteria : \n")
+	writeItems(&response, result.Items, requestView(w))
+	response.WriteString("\n Results Page URL : " + resultsURL(result))
+	response.WriteString("\n\n Say 'back to " + backName(from) + "' to search the first site again, or tell me what else to look for.")
+	WriteReply(w, ReplyResults, JSON{
+		"message":   response.String(),
+		"items":     result.Items,
+		"query":     query,
+		"site":      globalID,
+		"searchURL": result.SearchURL,
+		"pageURL":   result.PageURL,
+	})
+	resetSession(session)
+	rememberResults(session, query, result, true)
+	recordSearch(session, query, matchCount(result))
+}
+
+// sitePriceNote Warns that the price filters of query, given for the site from, keep their numbers on
+// a site with another currency
+func sitePriceNote(query SearchQuery, from, to string) string {
+	var limits []string
+	if query.MinPrice != "" && query.MinPrice != "none" {
+		limits = append(limits, "from "+query.MinPrice)
+	}
+	if query.MaxPrice != "" && query.MaxPrice != "none" {
+		limits = append(limits, "up to "+query.MaxPrice)
+	}
+	// Filters naming their currency are searched in it on every site
+	if len(limits) == 0 || query.Currency != "" {
+		return ""
+	}
+	fromSite, knownFrom := siteByGlobalID(from)
+	toSite, knownTo := siteByGlobalID(to)
+	if knownFrom && knownTo && fromSite.Currency == toSite.Currency {
+		return ""
+	}
+	currency := "the currency of that site"
+	if knownTo {
+		currency = toSite.Currency
+	}
+	return "Your price filter (" + strings.Join(limits, " ") + ") is applied as is in " + currency + ", it isn't converted."
+}
+
+// backName Is what the user says to get back to globalID, "the original site" for the default one
+func backName(globalID string) string {
+	if site, found := siteByGlobalID(globalID); found {
+		return strings.TrimPrefix(site.Name, "eBay ")
+	}
+	return "the original site"
+}
@@ -0,0 +1,89 @@
+package theluxuryshopper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSiteNames(t *testing.T) {
+	tests := []struct {
+		globalID string
+		wantName string
+		wantBack string
+	}{
+		{"", "the default eBay site", "the original site"},
+		{"EBAY-GB", "eBay UK", "UK"},
+		{"ebay-enca", "eBay Canada", "Canada"},
+		{"EBAY-XX", "EBAY-XX", "the original site"},
+	}
+	for _, test := range tests {
+		if got := siteName(test.globalID); got != test.wantName {
+			t.Errorf("siteName(%q) = %q, want %q", test.globalID, got, test.wantName)
+		}
+		if got := backName(test.globalID); got != test.wantBack {
+			t.Errorf("backName(%q) = %q, want %q", test.globalID, got, test.wantBack)
+		}
+	}
+}
+
+func TestSitePriceNote(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    SearchQuery
+		from, to string
+		want     string
+	}{
+		{"no filters", SearchQuery{MinPrice: "none", MaxPrice: "none"}, "EBAY-US", "EBAY-GB", ""},
+		{"same currency", SearchQuery{MinPrice: "100", MaxPrice: "none"}, "EBAY-DE", "EBAY-FR", ""},
+		{"currency named", SearchQuery{MinPrice: "100", MaxPrice: "500", Currency: "USD"}, "EBAY-US", "EBAY-GB", ""},
+		{"other currency", SearchQuery{MinPrice: "100", MaxPrice: "500"}, "EBAY-US", "EBAY-GB", "Your price filter (from 100 up to 500) is applied as is in GBP, it isn't converted."},
+		{"from the default site", SearchQuery{MinPrice: "none", MaxPrice: "500"}, "", "EBAY-DE", "Your price filter (up to 500) is applied as is in EUR, it isn't converted."},
+		{"to an unknown site", SearchQuery{MinPrice: "100"}, "EBAY-US", "EBAY-XX", "Your price filter (from 100) is applied as is in the currency of that site, it isn't converted."},
+	}
+	for _, test := range tests {
+		if got := sitePriceNote(test.query, test.from, test.to); got != test.want {
+			t.Errorf("%v: sitePriceNote() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSiteCommands(t *testing.T) {
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 2), Count: 2}}}
+	s := newTestServer(t, Config{}, searcher)
+	c := startConversation(t, s)
+	if reply := c.say("search ebay uk instead"); !strings.HasPrefix(reply.message(), "Search for something first, then I can try it on eBay UK.") {
+		t.Errorf("switching sites before any search answered %q", reply.message())
+	}
+	c.sayAll("gucci bag", "none", "none", "500")
+
+	tests := []struct {
+		message    string
+		wantPrefix string
+		wantSite   string // of the last search, "" when the message didn't search
+	}{
+		{"back to the original site", "Your last search didn't switch sites, so there is nothing to go back to.", ""},
+		{"search eBay UK instead", "Searching eBay UK instead of the default eBay site for 'gucci bag'. Your price filter (up to 500) is applied as is in GBP", "EBAY-GB"},
+		{"try ebay.co.uk", "Your last search for 'gucci bag' already ran on eBay UK.", ""},
+		{"switch to eBay Germany", "Searching eBay Germany instead of eBay UK for 'gucci bag'. Your price filter (up to 500) is applied as is in EUR", "EBAY-DE"},
+		{"use ebay france", "Searching eBay France instead of eBay Germany for 'gucci bag'. Your price filter (up to 500) is applied as is in EUR", "EBAY-FR"},
+		{"go back to the original site", "Searching the default eBay site instead of eBay France for 'gucci bag'.\n", ""},
+		{"back to the original site", "Your last search didn't switch sites, so there is nothing to go back to.", ""},
+	}
+	for _, test := range tests {
+		before := len(searcher.Queries())
+		reply := c.say(test.message)
+		if !strings.HasPrefix(reply.message(), test.wantPrefix) {
+			t.Errorf("%q answered %q, want it to start with %q", test.message, reply.message(), test.wantPrefix)
+		}
+		queries := searcher.Queries()
+		searched := len(queries) > before
+		if wantSearch := strings.HasPrefix(test.wantPrefix, "Searching"); searched != wantSearch {
+			t.Errorf("%q searched: %v, want %v", test.message, searched, wantSearch)
+		} else if searched && queries[len(queries)-1].Site != test.wantSite {
+			t.Errorf("%q searched %q, want %q", test.message, queries[len(queries)-1].Site, test.wantSite)
+		}
+	}
+	if reply := c.say("ebay narnia"); strings.HasPrefix(reply.message(), "Searching") {
+		t.Errorf("an unknown site was searched: %q", reply.message())
+	}
+}
@@ -0,0 +1,43 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// indexMaxAge Keeps the index fresh for a short while, it changes with every deploy
+const indexMaxAge = time.Minute
+
+// staticAsset Serves a body built once at startup with validators for conditional requests
+type staticAsset struct {
+	body        []byte
+	contentType string
+	etag        string
+	modified    time.Time
+	maxAge      time.Duration
+}
+
+// newStaticAsset Creates a staticAsset whose ETag is derived from body
+func newStaticAsset(body []byte, contentType string, maxAge time.Duration) *staticAsset {
+	sum := sha256.Sum256(body)
+	return &staticAsset{
+		body:        body,
+		contentType: contentType,
+		etag:        `"` + hex.EncodeToString(sum[:8]) + `"`,
+		modified:    time.Now().UTC().Truncate(time.Second),
+		maxAge:      maxAge,
+	}
+}
+
+// ServeHTTP Answers GET and HEAD requests, replying 304 when the client's copy is still current
+func (a *staticAsset) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	header.Set("Content-Type", a.contentType)
+	header.Set("ETag", a.etag)
+	header.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(a.maxAge.Seconds())))
+	http.ServeContent(w, r, "", a.modified, bytes.NewReader(a.body))
+}
@@ -0,0 +1,45 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStaticAsset(t *testing.T) {
+	asset := newStaticAsset([]byte("<h1>The Luxury Shopper</h1>"), "text/html; charset=utf-8", indexMaxAge)
+	tests := []struct {
+		name       string
+		method     string
+		header     http.Header
+		wantStatus int
+		wantBody   string
+	}{
+		{"get", http.MethodGet, nil, http.StatusOK, "<h1>The Luxury Shopper</h1>"},
+		{"head", http.MethodHead, nil, http.StatusOK, ""},
+		{"current etag", http.MethodGet, http.Header{"If-None-Match": {asset.etag}}, http.StatusNotModified, ""},
+		{"stale etag", http.MethodGet, http.Header{"If-None-Match": {`"0000"`}}, http.StatusOK, "<h1>The Luxury Shopper</h1>"},
+		{"not modified since", http.MethodGet, http.Header{"If-Modified-Since": {asset.modified.Add(time.Hour).Format(http.TimeFormat)}}, http.StatusNotModified, ""},
+		{"modified since", http.MethodGet, http.Header{"If-Modified-Since": {asset.modified.Add(-time.Hour).Format(http.TimeFormat)}}, http.StatusOK, "<h1>The Luxury Shopper</h1>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, "/", nil)
+			for key, values := range test.header {
+				r.Header[key] = values
+			}
+			w := httptest.NewRecorder()
+			asset.ServeHTTP(w, r)
+			if w.Code != test.wantStatus || w.Body.String() != test.wantBody {
+				t.Errorf("ServeHTTP() answered %v %q, want %v %q", w.Code, w.Body.String(), test.wantStatus, test.wantBody)
+			}
+			if etag, cache := w.Header().Get("ETag"), w.Header().Get("Cache-Control"); etag != asset.etag || cache != "public, max-age=60" {
+				t.Errorf("ServeHTTP() sent ETag %q and Cache-Control %q", etag, cache)
+			}
+		})
+	}
+	if other := newStaticAsset([]byte("<h1>Another</h1>"), "text/html", indexMaxAge); other.etag == asset.etag {
+		t.Errorf("two bodies got the same ETag %v", asset.etag)
+	}
+}
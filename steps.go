@@ -0,0 +1,122 @@
+package theluxuryshopper
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Step Asks for one filter and records the answer, returning 1 when it replied to the message
+type Step func(s *Server, session Session, message string, w http.ResponseWriter) int
+
+// conversationSteps Maps the step names STEPS may list to the step asking for each filter.
+// A step's name is also the session key of its answer. RegisterStep adds to it.
+var conversationSteps = map[string]Step{
+	"condition": (*Server).filterByCondition,
+	"minPrice":  (*Server).filterByMinPrice,
+	"maxPrice":  (*Server).filterByMaxPrice,
+}
+
+// defaultSteps Is the order of the steps when STEPS isn't set
+var defaultSteps = []string{"condition", "minPrice", "maxPrice"}
+
+var (
+	skipQuestionsCommand = regexp.MustCompile(`(?i)^\s*(?:skip\s+(?:the\s+)?questions|just\s+search)(?:\W+(?:just\s+search|search))?\W*$`)
+	askQuestionsCommand  = regexp.MustCompile(`(?i)^\s*ask\s+(?:me\s+)?(?:the\s+)?questions(?:\s+again)?\W*$`)
+)
+
+// RegisterStep Adds a step STEPS can list by name, it must be called before LoadConfig and NewServer
+func RegisterStep(name string, step Step) error {
+	if _, found := conversationSteps[name]; found {
+		return fmt.Errorf("step %q is already registered", name)
+	}
+	if step == nil || strings.ContainsAny(name, ", ") || name == "" {
+		return fmt.Errorf("step %q needs a name without commas or spaces and a non-nil func", name)
+	}
+	conversationSteps[name] = step
+	return nil
+}
+
+// stepNames Returns the registered step names in alphabetical order
+func stepNames() []string {
+	names := make([]string, 0, len(conversationSteps))
+	for name := range conversationSteps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseSteps Validates the comma separated step names of STEPS, an empty list disables every step
+func parseSteps(list string) ([]string, error) {
+	steps := []string{}
+	seen := map[string]bool{}
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if _, found := conversationSteps[name]; !found {
+			return nil, fmt.Errorf("STEPS lists unknown step %q, the steps are %v", name, strings.Join(stepNames(), ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("STEPS lists %q twice", name)
+		}
+		seen[name] = true
+		steps = append(steps, name)
+	}
+	return steps, nil
+}
+
+// runSteps Runs the enabled steps in order until one replies, reporting whether one did.
+// The filters of disabled steps, and of every step once the session skips the questions,
+// are searched with "none" unless a default or an answer set them.
+func (s *Server) runSteps(session Session, message string, w http.ResponseWriter) bool {
+	skip, _ := session["skipQuestions"].(bool)
+	if !skip {
+		for _, name := range s.steps {
+			replied := conversationSteps[name](s, session, message, w) == 1
+			s.funnel.markStep(session, name, replied)
+			if replied {
+				return true
+			}
+		}
+	}
+	s.answerNone(session)
+	return false
+}
+
+// answerNone Answers "none" to the questions of the search left unanswered, those of the steps
+// RegisterStep added and of the steps STEPS leaves out included
+func (s *Server) answerNone(session Session) {
+	for name := range conversationSteps {
+		if _, answered := session[name]; !answered {
+			session[name] = "none"
+		}
+	}
+}
+
+// handleSkipCommand Answers "skip the questions, just search" and "ask me the questions again",
+// reporting whether message was one. Skipping searches right away when a keyword is known.
+func (s *Server) handleSkipCommand(session Session, message string, w http.ResponseWriter) (handled, search bool) {
+	switch {
+	case skipQuestionsCommand.MatchString(message):
+		session["skipQuestions"] = true
+		if _, found := session["searchByKeyword"]; found {
+			return true, true
+		}
+		WriteReply(w, ReplyQuestion, JSON{
+			"message": "OK, no more questions, I'll search as soon as you tell me what you are looking for.\n " + keywordQuestion,
+			"step":    "keyword",
+		})
+		return true, false
+	case askQuestionsCommand.MatchString(message):
+		delete(session, "skipQuestions")
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "OK, I'll ask about the condition and prices again.\n " + strings.TrimSpace(resumeSummary(session)),
+		})
+		return true, false
+	}
+	return false, false
+}
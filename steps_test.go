@@ -0,0 +1,119 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSteps(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []string
+		wantErr string
+	}{
+		{"condition,minPrice,maxPrice", []string{"condition", "minPrice", "maxPrice"}, ""},
+		{" maxPrice , condition ", []string{"maxPrice", "condition"}, ""},
+		{"", []string{}, ""},
+		{",,", []string{}, ""},
+		{"condition,colour", nil, `STEPS lists unknown step "colour", the steps are condition, maxPrice, minPrice`},
+		{"condition,condition", nil, `STEPS lists "condition" twice`},
+	}
+	for _, test := range tests {
+		got, err := parseSteps(test.list)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseSteps(%q) = %v, want %v", test.list, got, test.want)
+		}
+		if gotErr := errorString(err); gotErr != test.wantErr {
+			t.Errorf("parseSteps(%q) failed with %q, want %q", test.list, gotErr, test.wantErr)
+		}
+	}
+}
+
+// errorString Returns the message of err, "" for nil
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func TestRegisterStep(t *testing.T) {
+	colour := func(s *Server, session Session, message string, w http.ResponseWriter) int {
+		if _, answered := session["colour"]; answered {
+			return 0
+		}
+		if _, asked := session["colourAsked"]; asked {
+			session["colour"] = message
+			return 0
+		}
+		session["colourAsked"] = true
+		WriteReply(w, ReplyQuestion, JSON{"message": "Which colour?", "step": "colour"})
+		return 1
+	}
+	t.Cleanup(func() { delete(conversationSteps, "colour") })
+
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr bool
+	}{
+		{"colour", colour, false},
+		{"colour", colour, true},
+		{"condition", colour, true},
+		{"", colour, true},
+		{"two words", colour, true},
+		{"a,b", colour, true},
+		{"size", nil, true},
+	}
+	for _, test := range tests {
+		if err := RegisterStep(test.name, test.step); (err != nil) != test.wantErr {
+			t.Errorf("RegisterStep(%q) = %v, want an error: %v", test.name, err, test.wantErr)
+		}
+	}
+
+	searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 1), Count: 1}}}
+	s := newTestServer(t, Config{Steps: []string{"colour", "maxPrice"}}, searcher)
+	c := startConversation(t, s)
+	if reply := c.say("gucci bag"); reply.message() != "Which colour?" {
+		t.Fatalf("the first step asked %q, want the colour", reply.message())
+	}
+	c.sayAll("red", "500")
+	queries := searcher.Queries()
+	if len(queries) != 1 {
+		t.Fatalf("the steps searched %v times, want once", len(queries))
+	}
+	if query := queries[0]; query.Condition != "none" || query.MinPrice != "none" || query.MaxPrice != "500" {
+		t.Errorf("the steps searched %+v, want the left out steps answered none", query)
+	}
+}
+
+func TestSkipQuestions(t *testing.T) {
+	tests := []struct {
+		name       string
+		messages   []string
+		wantPrefix string
+		wantSearch bool
+	}{
+		{"before the keyword", []string{"skip the questions"}, "OK, no more questions, I'll search as soon as you tell me what you are looking for.", false},
+		{"then the keyword", []string{"just search", "gucci bag"}, "There are ", true},
+		{"after the keyword", []string{"gucci bag", "skip questions, just search"}, "There are ", true},
+		{"asked again", []string{"skip the questions", "ask me the questions again"}, "OK, I'll ask about the condition and prices again.", false},
+		{"asked again then the keyword", []string{"skip the questions", "ask questions again", "gucci bag"}, "Please specify the condition of the required item.", false},
+		{"not a command", []string{"skip bag"}, "Please specify the condition of the required item.", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 1), Count: 1}}}
+			c := startConversation(t, newTestServer(t, Config{}, searcher))
+			reply := c.sayAll(test.messages...)
+			if !strings.HasPrefix(reply.message(), test.wantPrefix) {
+				t.Errorf("%q answered %q, want it to start with %q", test.messages, reply.message(), test.wantPrefix)
+			}
+			if searched := len(searcher.Queries()) > 0; searched != test.wantSearch {
+				t.Errorf("%q searched: %v, want %v", test.messages, searched, test.wantSearch)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package theluxuryshopper
+
+import "strconv"
+
+// priceSuggestions Are the quick replies of both price questions, parsePriceRange reads the ranges
+var priceSuggestions = []string{"None", "Under 100", "100-500", "500+"}
+
+// stepSuggestions Are the quick replies of the question each step asks, every one is an answer the step accepts.
+// WriteReply adds them to the question replies of the step that don't bring their own.
+var stepSuggestions = map[string][]string{
+	"keyword":         {"Surprise me"},
+	"condition":       {"New", "Used", "None"},
+	"minPrice":        priceSuggestions,
+	"maxPrice":        priceSuggestions,
+	"maxPriceConfirm": {"Search anyway", "Raise it"},
+}
+
+// addSuggestions Sets the suggestions of the step a question reply asks for, unless it has some
+func addSuggestions(data JSON) {
+	if _, set := data["suggestions"]; set {
+		return
+	}
+	step, _ := data["step"].(string)
+	if suggestions, found := stepSuggestions[step]; found {
+		data["suggestions"] = suggestions
+	}
+}
+
+// resultSuggestions Returns the quick replies of a results reply listing count items numbered from first,
+// offering "More" only when there is another page
+func resultSuggestions(first, count int, more bool) []string {
+	var suggestions []string
+	if more {
+		suggestions = append(suggestions, "More")
+	}
+	if count > 0 {
+		suggestions = append(suggestions, "Details "+strconv.Itoa(first))
+	}
+	if count > 1 {
+		suggestions = append(suggestions, "Compare "+strconv.Itoa(first)+" and "+strconv.Itoa(first+1))
+	}
+	return append(suggestions, "New search")
+}
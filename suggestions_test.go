@@ -0,0 +1,74 @@
+package theluxuryshopper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAddSuggestions(t *testing.T) {
+	tests := []struct {
+		name string
+		data JSON
+		want interface{}
+	}{
+		{"condition", JSON{"step": "condition"}, []string{"New", "Used", "None"}},
+		{"price", JSON{"step": "maxPrice"}, priceSuggestions},
+		{"own suggestions", JSON{"step": "condition", "suggestions": []string{"Used"}}, []string{"Used"}},
+		{"unknown step", JSON{"step": "results"}, nil},
+		{"no step", JSON{}, nil},
+	}
+	for _, test := range tests {
+		addSuggestions(test.data)
+		if got := test.data["suggestions"]; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: addSuggestions() set %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestResultSuggestions(t *testing.T) {
+	tests := []struct {
+		first, count int
+		more         bool
+		want         []string
+	}{
+		{1, 10, true, []string{"More", "Details 1", "Compare 1 and 2", "New search"}},
+		{11, 3, false, []string{"Details 11", "Compare 11 and 12", "New search"}},
+		{1, 1, false, []string{"Details 1", "New search"}},
+		{1, 0, false, []string{"New search"}},
+	}
+	for _, test := range tests {
+		if got := resultSuggestions(test.first, test.count, test.more); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("resultSuggestions(%v, %v, %v) = %q, want %q", test.first, test.count, test.more, got, test.want)
+		}
+	}
+}
+
+func TestStepSuggestionsAreAccepted(t *testing.T) {
+	tests := []struct {
+		before     []string
+		wantStep   string
+		suggestion string
+	}{
+		{[]string{"kelly bag"}, "condition", "New"},
+		{[]string{"kelly bag"}, "condition", "Used"},
+		{[]string{"kelly bag"}, "condition", "None"},
+		{[]string{"kelly bag", "none"}, "minPrice", "None"},
+		{[]string{"kelly bag", "none"}, "minPrice", "Under 100"},
+		{[]string{"kelly bag", "none"}, "minPrice", "100-500"},
+		{[]string{"kelly bag", "none"}, "minPrice", "500+"},
+		{[]string{"kelly bag", "none", "none"}, "maxPrice", "Under 100"},
+		{[]string{"kelly bag", "none", "none"}, "maxPrice", "500+"},
+	}
+	for _, test := range tests {
+		c := startConversation(t, newTestServer(t, Config{}, &fakeSearcher{}))
+		question := c.sayAll(test.before...)
+		suggestions, _ := question.Body["suggestions"].([]interface{})
+		if question.Body["step"] != test.wantStep || len(suggestions) != len(stepSuggestions[test.wantStep]) {
+			t.Errorf("%q asked %v with the suggestions %v", test.before, question.Body["step"], question.Body["suggestions"])
+			continue
+		}
+		if reply := c.say(test.suggestion); reply.Body["step"] == test.wantStep {
+			t.Errorf("the %v suggestion %q was asked again: %q", test.wantStep, test.suggestion, reply.message())
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package theluxuryshopper
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// luxuryCategory Is one brand and category pair of the luxury dictionary
+type luxuryCategory struct {
+	Brand    string
+	Category string
+}
+
+// luxuryCategories The brands and categories "surprise me" picks from
+var luxuryCategories = []luxuryCategory{
+	{"Gucci", "handbags"},
+	{"Gucci", "loafers"},
+	{"Louis Vuitton", "wallets"},
+	{"Louis Vuitton", "handbags"},
+	{"Prada", "sunglasses"},
+	{"Prada", "backpacks"},
+	{"Chanel", "flap bags"},
+	{"Hermes", "scarves"},
+	{"Hermes", "belts"},
+	{"Rolex", "watches"},
+	{"Cartier", "bracelets"},
+	{"Burberry", "trench coats"},
+	{"Dior", "sneakers"},
+	{"Balenciaga", "sneakers"},
+	{"Saint Laurent", "clutches"},
+	{"Fendi", "baguette bags"},
+	{"Omega", "watches"},
+	{"Tiffany", "necklaces"},
+}
+
+// surpriseCommands The messages that start a surprise search
+var surpriseCommands = map[string]bool{
+	"surprise me": true,
+	"surprise":    true,
+	"deals":       true,
+}
+
+// isSurpriseCommand Reports whether message asks for a surprise search
+func isSurpriseCommand(message string) bool {
+	return surpriseCommands[strings.ToLower(strings.Trim(strings.TrimSpace(message), "!.?"))]
+}
+
+// newRand Returns the random source of one surprise search, seeded per call
+var newRand = func() *rand.Rand {
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
+// pickSurprise Picks a random category, never the one the session was shown last
+func pickSurprise(random *rand.Rand, last string) luxuryCategory {
+	for {
+		category := luxuryCategories[random.Intn(len(luxuryCategories))]
+		if category.Brand+" "+category.Category != last || len(luxuryCategories) == 1 {
+			return category
+		}
+	}
+}
+
+// surprise Searches the cheapest new items of a random luxury category without asking any question
+func (s *Server) surprise(session Session, w http.ResponseWriter) {
+	last, _ := session["lastSurprise"].(string)
+	category := pickSurprise(newRand(), last)
+	keyword := category.Brand + " " + category.Category
+
+	resetSession(session)
+	session["lastSurprise"] = keyword
+
+	query := SearchQuery{
+		Keyword:   keyword,
+		Condition: "New",
+		MinPrice:  "none",
+		MaxPrice:  "none",
+		SortOrder: "PricePlusShippingLowest",
+		Entries:   5,
+	}
+	query.ExcludeLots, _ = session["excludeLots"].(bool)
+	query.ExcludeAccessories, _ = session["excludeAccessories"].(bool)
+	query.ExcludeCategories, _ = session["excludedCategories"].([]string)
+	query.ShipsTo, _ = session["shipsTo"].(string)
+	query.LocatedIn, _ = session["locatedIn"].(string)
+	query.ExcludeCountries, _ = session["excludedCountries"].([]string)
+	result, err := s.search(requestContext(w), query)
+	if handleError(err, session, w) == 1 {
+		return
+	}
+	if handleCaseZero(query, result, session, w) == 1 {
+		return
+	}
+
+	var response strings.Builder
+	response.Grow(estimateRenderSize(result.Items) + 256)
+	response.WriteString("Today's finds in " + keyword + " : \n")
+	writeItems(&response, result.Items, requestView(w))
+	response.WriteString("\n Results Page URL : " + resultsURL(result) + "\n\n Say 'surprise me' again, or tell me what you are looking for.")
+	WriteReply(w, ReplyResults, JSON{
+		"message":   response.String(),
+		"items":     result.Items,
+		"query":     query,
+		"searchURL": result.SearchURL,
+		"pageURL":   result.PageURL,
+	})
+}
@@ -0,0 +1,105 @@
+package theluxuryshopper
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestIsSurpriseCommand(t *testing.T) {
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{"surprise me", true},
+		{"  Surprise Me! ", true},
+		{"surprise?", true},
+		{"DEALS.", true},
+		{"surprise me with a bag", false},
+		{"deal", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := isSurpriseCommand(test.message); got != test.want {
+			t.Errorf("isSurpriseCommand(%q) = %v, want %v", test.message, got, test.want)
+		}
+	}
+}
+
+func TestPickSurpriseNeverRepeats(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	last := ""
+	for i := 0; i < 200; i++ {
+		category := pickSurprise(random, last)
+		keyword := category.Brand + " " + category.Category
+		if keyword == last {
+			t.Fatalf("pickSurprise() picked %q twice in a row", keyword)
+		}
+		last = keyword
+	}
+}
+
+func TestSurprise(t *testing.T) {
+	saved := newRand
+	t.Cleanup(func() { newRand = saved })
+	newRand = func() *rand.Rand { return rand.New(rand.NewSource(7)) }
+
+	tests := []struct {
+		name       string
+		results    SearchResult
+		before     []string
+		wantPrefix string
+		check      func(t *testing.T, query SearchQuery)
+	}{
+		{
+			name:       "cheapest new items",
+			results:    SearchResult{Items: testItems("bag", 1, 2), Count: 2},
+			wantPrefix: "Today's finds in ",
+			check: func(t *testing.T, query SearchQuery) {
+				if query.Condition != "New" || query.SortOrder != "PricePlusShippingLowest" || query.Entries != 5 {
+					t.Errorf("surprise me searched %+v, want the 5 cheapest new items", query)
+				}
+			},
+		},
+		{
+			name:       "keeps the exclusions",
+			results:    SearchResult{Items: testItems("bag", 1, 2), Count: 2},
+			before:     []string{"exclude lots"},
+			wantPrefix: "Today's finds in ",
+			check: func(t *testing.T, query SearchQuery) {
+				if !query.ExcludeLots {
+					t.Errorf("surprise me searched %+v, want the lots excluded", query)
+				}
+			},
+		},
+		{
+			name:       "nothing found",
+			results:    SearchResult{},
+			wantPrefix: "There are no items matching your criteria",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			searcher := &fakeSearcher{results: map[string]SearchResult{"": test.results}}
+			c := startConversation(t, newTestServer(t, Config{}, searcher))
+			c.sayAll(test.before...)
+			reply := c.say("surprise me")
+			if !strings.HasPrefix(reply.message(), test.wantPrefix) {
+				t.Errorf("surprise me answered %q, want it to start with %q", reply.message(), test.wantPrefix)
+			}
+			queries := searcher.Queries()
+			if len(queries) != 1 {
+				t.Fatalf("surprise me searched %v times, want once", len(queries))
+			}
+			if test.check != nil {
+				test.check(t, queries[0])
+			}
+
+			// The same seed picks the same category first, the second surprise must still differ
+			c.say("surprise me")
+			if queries := searcher.Queries(); len(queries) != 2 || queries[0].Keyword == queries[1].Keyword {
+				t.Errorf("two surprises searched %v", queries)
+			}
+		})
+	}
+}
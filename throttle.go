@@ -0,0 +1,113 @@
+package theluxuryshopper
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultThrottleCooldown How long searches stay off eBay after it throttled the app id
+const defaultThrottleCooldown = time.Minute
+
+// throttleErrorIDs Are the Finding API error ids meaning the app id made too many calls
+var throttleErrorIDs = map[string]bool{
+	"10001": true, // Service call has exceeded the number of times the operation is allowed to be called
+	"18000": true, // Request limit reached for the application
+}
+
+// throttledMessage Is what users are told while eBay throttles the app id
+const throttledMessage = "Searches are temporarily limited, please try again in a minute."
+
+// throttled Is returned when eBay throttled the app id, or while the cool-down it started lasts
+type throttled struct {
+	message    string        // What eBay said, empty for a search refused during the cool-down
+	retryAfter time.Duration // The Retry-After eBay sent, if any
+	retryAt    time.Time     // When the cool-down ends, set by the Server
+}
+
+func (e *throttled) Error() string {
+	if e.message == "" {
+		return "eBay throttled the app id, searches are paused until " + e.retryAt.UTC().Format(time.RFC3339)
+	}
+	return "eBay throttled the app id: " + e.message
+}
+
+// findingError Mirrors one error of a Finding API errorMessage block
+type findingError struct {
+	ErrorID   []string `json:"errorId"`
+	Domain    []string `json:"domain"`
+	Subdomain []string `json:"subdomain"`
+	Severity  []string `json:"severity"`
+	Category  []string `json:"category"`
+	Message   []string `json:"message"`
+}
+
+// throttleError Returns the throttled error for the errors of a failed response, or nil if none is a throttling one
+func throttleError(errors []findingError) *throttled {
+	for _, e := range errors {
+		if throttleErrorIDs[first(e.ErrorID)] || strings.EqualFold(first(e.Subdomain), "RateLimiter") {
+			return &throttled{message: first(e.Message)}
+		}
+	}
+	return nil
+}
+
+// parseRetryAfter Reads a Retry-After header given in seconds, 0 when it is missing or a date
+func parseRetryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(header))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// throttleCooldown Short-circuits the searches of a Server for a while after eBay throttled it
+type throttleCooldown struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	duration time.Duration
+	until    time.Time
+}
+
+// newThrottleCooldown Creates a throttleCooldown pausing searches for duration after each throttling
+func newThrottleCooldown(duration time.Duration) *throttleCooldown {
+	if duration <= 0 {
+		duration = defaultThrottleCooldown
+	}
+	return &throttleCooldown{now: time.Now, duration: duration}
+}
+
+// Check Returns a throttled error while the cool-down lasts
+func (c *throttleCooldown) Check() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.now().Before(c.until) {
+		return &throttled{retryAt: c.until}
+	}
+	return nil
+}
+
+// Start Pauses the searches for the cool-down, or longer if eBay asked for it, and sets when err can be retried
+func (c *throttleCooldown) Start(err *throttled) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	duration := c.duration
+	if err.retryAfter > duration {
+		duration = err.retryAfter
+	}
+	if until := c.now().Add(duration); until.After(c.until) {
+		c.until = until
+	}
+	err.retryAt = c.until
+}
+
+// Until Returns when the current cool-down ends, the zero time when there is none
+func (c *throttleCooldown) Until() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.now().Before(c.until) {
+		return c.until
+	}
+	return time.Time{}
+}
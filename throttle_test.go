@@ -0,0 +1,89 @@
+package theluxuryshopper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottleError(t *testing.T) {
+	tests := []struct {
+		name        string
+		errors      []findingError
+		wantMessage string
+		wantNil     bool
+	}{
+		{"call limit", []findingError{{ErrorID: []string{"10001"}, Message: []string{"Service call has exceeded the number of times"}}}, "Service call has exceeded the number of times", false},
+		{"request limit", []findingError{{ErrorID: []string{"18000"}, Message: []string{"Request limit reached"}}}, "Request limit reached", false},
+		{"rate limiter", []findingError{{ErrorID: []string{"2"}, Subdomain: []string{"ratelimiter"}, Message: []string{"Slow down"}}}, "Slow down", false},
+		{"second error", []findingError{{ErrorID: []string{"3"}}, {ErrorID: []string{"18000"}, Message: []string{"Limit"}}}, "Limit", false},
+		{"other error", []findingError{{ErrorID: []string{"11"}, Subdomain: []string{"Search"}}}, "", true},
+		{"no errors", nil, "", true},
+	}
+	for _, test := range tests {
+		got := throttleError(test.errors)
+		if (got == nil) != test.wantNil || (got != nil && got.message != test.wantMessage) {
+			t.Errorf("%v: throttleError() = %+v, want %q, nil: %v", test.name, got, test.wantMessage, test.wantNil)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"120", 2 * time.Minute},
+		{" 5 ", 5 * time.Second},
+		{"0", 0},
+		{"", 0},
+		{"-3", 0},
+		{"Wed, 21 Oct 2026 07:28:00 GMT", 0},
+	}
+	for _, test := range tests {
+		if got := parseRetryAfter(test.header); got != test.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", test.header, got, test.want)
+		}
+	}
+}
+
+func TestThrottleCooldown(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, time.October, 15, 12, 0, 0, 0, time.UTC)}
+	start := clock.Now()
+	cooldown := newThrottleCooldown(0)
+	cooldown.now = clock.Now
+
+	tests := []struct {
+		name       string
+		advance    time.Duration
+		throttle   *throttled // started before checking, nil starts none
+		wantRetry  time.Time  // of the started throttle
+		wantPaused bool
+		wantUntil  time.Time
+	}{
+		{"no cool-down", 0, nil, time.Time{}, false, time.Time{}},
+		{"throttled", 0, &throttled{}, start.Add(time.Minute), true, start.Add(time.Minute)},
+		{"during the cool-down", 30 * time.Second, nil, time.Time{}, true, start.Add(time.Minute)},
+		{"throttled again", 0, &throttled{retryAfter: time.Second}, start.Add(90 * time.Second), true, start.Add(90 * time.Second)},
+		{"Retry-After beyond the cool-down", 0, &throttled{retryAfter: 5 * time.Minute}, start.Add(330 * time.Second), true, start.Add(330 * time.Second)},
+		{"over", 5 * time.Minute, nil, time.Time{}, false, time.Time{}},
+	}
+	for _, test := range tests {
+		clock.Advance(test.advance)
+		if test.throttle != nil {
+			cooldown.Start(test.throttle)
+			if !test.throttle.retryAt.Equal(test.wantRetry) {
+				t.Errorf("%v: Start() set the retry at %v, want %v", test.name, test.throttle.retryAt, test.wantRetry)
+			}
+		}
+		err := cooldown.Check()
+		if paused := err != nil; paused != test.wantPaused {
+			t.Errorf("%v: Check() = %v, want paused: %v", test.name, err, test.wantPaused)
+		}
+		if e, ok := err.(*throttled); ok && (!e.retryAt.Equal(test.wantUntil) || e.Error() != "eBay throttled the app id, searches are paused until "+test.wantUntil.Format(time.RFC3339)) {
+			t.Errorf("%v: Check() = %v, want a pause until %v", test.name, err, test.wantUntil)
+		}
+		if until := cooldown.Until(); !until.Equal(test.wantUntil) {
+			t.Errorf("%v: Until() = %v, want %v", test.name, until, test.wantUntil)
+		}
+	}
+}
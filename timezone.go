@@ -0,0 +1,100 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timezoneCommand Matches "timezone Europe/Berlin"
+var timezoneCommand = regexp.MustCompile(`(?i)^\s*(?:timezone|time\s+zone)\s+(\S+)\s*$`)
+
+// timezoneExamples Are suggested when a timezone isn't recognized
+const timezoneExamples = "Europe/Berlin, America/New_York or Asia/Tokyo"
+
+// timezoneHint Tells users without a timezone how end times are shown
+const timezoneHint = "Times are in UTC, say 'timezone Europe/Berlin' to see them in your own."
+
+// loadTimezone Returns the location of an IANA timezone name, rejecting the process' own Local
+func loadTimezone(name string) (*time.Location, bool) {
+	if name == "" || strings.EqualFold(name, "local") {
+		return nil, false
+	}
+	location, err := time.LoadLocation(name)
+	return location, err == nil
+}
+
+// sessionLocation Returns the location of the timezone of session, or nil if none was set
+func sessionLocation(session Session) *time.Location {
+	name, _ := session["timezone"].(string)
+	location, _ := loadTimezone(name)
+	return location
+}
+
+// handleTimezoneCommand Answers "timezone <name>", reporting whether message was one.
+// The timezone belongs to the whole session, whatever the active conversation.
+func handleTimezoneCommand(session Session, message string, w http.ResponseWriter) bool {
+	match := timezoneCommand.FindStringSubmatch(message)
+	if match == nil {
+		return false
+	}
+	location, valid := loadTimezone(match[1])
+	if !valid {
+		WriteReply(w, ReplyInfo, JSON{
+			"message": "Sorry, I don't know the timezone " + match[1] + ". Try a name like " + timezoneExamples + ".",
+		})
+		return true
+	}
+	session["timezone"] = location.String()
+	noteLocation(w, location)
+	WriteReply(w, ReplyInfo, JSON{
+		"message":  "OK, end times are now shown in " + location.String() + ", where it is " + time.Now().In(location).Format("3:04 PM") + ".",
+		"timezone": location.String(),
+	})
+	return true
+}
+
+// formatEndTime Describes when an auction ending at end ends, both relative to now and as a local time
+// like "tonight at 9:14 PM (Tue Oct 14 21:14 CEST)"
+func formatEndTime(end, now time.Time, location *time.Location) string {
+	if location == nil {
+		location = time.UTC
+	}
+	end = end.In(location)
+	now = now.In(location)
+	absolute := end.Format("Mon Jan 2 15:04 MST")
+	if !end.After(now) {
+		return "ended (" + absolute + ")"
+	}
+	return humanizeEnd(end, now) + " (" + absolute + ")"
+}
+
+// humanizeEnd Describes end relative to now, both in the same location.
+// Days are counted on the calendar so a DST change doesn't shift "tomorrow".
+func humanizeEnd(end, now time.Time) string {
+	if until := end.Sub(now); until < time.Hour {
+		minutes := int(until / time.Minute)
+		if minutes <= 1 {
+			return "in a minute"
+		}
+		return "in " + strconv.Itoa(minutes) + " minutes"
+	}
+
+	clock := end.Format("3:04 PM")
+	endDay := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch days := int(endDay.Sub(today).Hours() / 24); {
+	case days == 0 && end.Hour() >= 18:
+		return "tonight at " + clock
+	case days == 0:
+		return "today at " + clock
+	case days == 1:
+		return "tomorrow at " + clock
+	case days < 7:
+		return "on " + end.Format("Monday") + " at " + clock
+	default:
+		return "on " + end.Format("Jan 2") + " at " + clock
+	}
+}
@@ -0,0 +1,77 @@
+package theluxuryshopper
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadTimezone(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantValid bool
+	}{
+		{"Europe/Berlin", true},
+		{"UTC", true},
+		{"Local", false},
+		{"local", false},
+		{"", false},
+		{"Mars/Olympus", false},
+	}
+	for _, test := range tests {
+		if _, valid := loadTimezone(test.name); valid != test.wantValid {
+			t.Errorf("loadTimezone(%q) valid: %v, want %v", test.name, valid, test.wantValid)
+		}
+	}
+}
+
+func TestFormatEndTime(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	// 14:00 in Berlin on a Wednesday, the clocks go back on Sunday Oct 25
+	now := time.Date(2026, time.October, 21, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		end      time.Time
+		location *time.Location
+		want     string
+	}{
+		{"ended", now.Add(-time.Minute), nil, "ended (Wed Oct 21 11:59 UTC)"},
+		{"now", now, nil, "ended (Wed Oct 21 12:00 UTC)"},
+		{"a minute", now.Add(90 * time.Second), nil, "in a minute (Wed Oct 21 12:01 UTC)"},
+		{"minutes", now.Add(25 * time.Minute), nil, "in 25 minutes (Wed Oct 21 12:25 UTC)"},
+		{"today", now.Add(2 * time.Hour), nil, "today at 2:00 PM (Wed Oct 21 14:00 UTC)"},
+		{"tonight", now.Add(5 * time.Hour), berlin, "tonight at 7:00 PM (Wed Oct 21 19:00 CEST)"},
+		{"tomorrow in Berlin, still today in UTC", now.Add(11 * time.Hour), berlin, "tomorrow at 1:00 AM (Thu Oct 22 01:00 CEST)"},
+		{"weekday", now.Add(48 * time.Hour), nil, "on Friday at 12:00 PM (Fri Oct 23 12:00 UTC)"},
+		{"after the DST change", now.Add(5 * 24 * time.Hour), berlin, "on Monday at 1:00 PM (Mon Oct 26 13:00 CET)"},
+		{"next week", now.Add(10 * 24 * time.Hour), nil, "on Oct 31 at 12:00 PM (Sat Oct 31 12:00 UTC)"},
+	}
+	for _, test := range tests {
+		if got := formatEndTime(test.end, now, test.location); got != test.want {
+			t.Errorf("%v: formatEndTime() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTimezoneCommand(t *testing.T) {
+	tests := []struct {
+		message      string
+		wantPrefix   string
+		wantTimezone interface{}
+	}{
+		{"timezone Europe/Berlin", "OK, end times are now shown in Europe/Berlin, where it is ", "Europe/Berlin"},
+		{"Time zone Mars/Olympus", "Sorry, I don't know the timezone Mars/Olympus.", nil},
+		{"time zone Asia/Tokyo", "OK, end times are now shown in Asia/Tokyo, where it is ", "Asia/Tokyo"},
+		{"timezone local", "Sorry, I don't know the timezone local. Try a name like " + timezoneExamples + ".", nil},
+	}
+	for _, test := range tests {
+		c := startConversation(t, newTestServer(t, Config{}, &fakeSearcher{}))
+		reply := c.say(test.message)
+		if !strings.HasPrefix(reply.message(), test.wantPrefix) {
+			t.Errorf("%q answered %q, want it to start with %q", test.message, reply.message(), test.wantPrefix)
+		}
+		if got := reply.Body["timezone"]; got != test.wantTimezone {
+			t.Errorf("%q answered the timezone %v, want %v", test.message, got, test.wantTimezone)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Span kinds as numbered by OTLP
+const (
+	spanInternal = 1
+	spanServer   = 2
+	spanClient   = 3
+)
+
+const (
+	// traceBatchSize Sends the finished spans once this many are queued
+	traceBatchSize = 256
+	// traceFlushInterval Sends the queued spans at least this often
+	traceFlushInterval = 5 * time.Second
+	// traceQueueSize Caps the spans waiting to be sent, further spans are dropped
+	traceQueueSize = 2048
+)
+
+// tracer Records spans and sends them to an OTLP/HTTP collector as JSON.
+// A nil tracer is disabled: it starts no spans and every span method is a no-op.
+type tracer struct {
+	endpoint string
+	service  string
+	client   *http.Client
+	spans    chan *span
+	flush    chan chan struct{}
+}
+
+// span Is one timed operation of a trace
+type span struct {
+	tracer   *tracer
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	sampled  bool
+	name     string
+	kind     int
+	start    time.Time
+	end      time.Time
+	attrs    []spanAttribute
+	err      string
+}
+
+// spanAttribute Is a key value pair annotating a span
+type spanAttribute struct {
+	key   string
+	value interface{}
+}
+
+// spanContextKey Keys the current span in a context
+type spanContextKey struct{}
+
+// newTracer Creates a tracer sending to the OTLP/HTTP traces endpoint, or nil if endpoint is empty
+func newTracer(endpoint, service string) *tracer {
+	if endpoint == "" {
+		return nil
+	}
+	t := &tracer{
+		endpoint: endpoint,
+		service:  service,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		spans:    make(chan *span, traceQueueSize),
+		flush:    make(chan chan struct{}),
+	}
+	go t.export()
+	log.Printf("Sending traces to %v", endpoint)
+	return t
+}
+
+// Start Starts a span named name as a child of the span or remote parent in ctx
+func (t *tracer) Start(ctx context.Context, name string, kind int) (context.Context, *span) {
+	if t == nil {
+		return ctx, nil
+	}
+	sp := &span{tracer: t, name: name, kind: kind, start: time.Now(), sampled: true}
+	if parent, ok := ctx.Value(spanContextKey{}).(*span); ok && parent != nil {
+		sp.traceID = parent.traceID
+		sp.parentID = parent.spanID
+		sp.sampled = parent.sampled
+	} else {
+		rand.Read(sp.traceID[:])
+	}
+	rand.Read(sp.spanID[:])
+	return context.WithValue(ctx, spanContextKey{}, sp), sp
+}
+
+// SetAttribute Annotates the span, value is a string, bool, int or float64
+func (sp *span) SetAttribute(key string, value interface{}) {
+	if sp == nil {
+		return
+	}
+	sp.attrs = append(sp.attrs, spanAttribute{key, value})
+}
+
+// SetError Marks the span as failed with err
+func (sp *span) SetError(err error) {
+	if sp == nil || err == nil {
+		return
+	}
+	sp.err = err.Error()
+}
+
+// End Finishes the span and queues it for export
+func (sp *span) End() {
+	if sp == nil {
+		return
+	}
+	sp.end = time.Now()
+	if !sp.sampled {
+		return
+	}
+	select {
+	case sp.tracer.spans <- sp:
+	default:
+		// The collector can't keep up, losing spans beats blocking requests
+	}
+}
+
+// Shutdown Sends the queued spans, waiting at most until ctx is done
+func (t *tracer) Shutdown(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	done := make(chan struct{})
+	select {
+	case t.flush <- done:
+	case <-ctx.Done():
+		return
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// export Batches the finished spans and sends them to the collector
+func (t *tracer) export() {
+	ticker := time.NewTicker(traceFlushInterval)
+	defer ticker.Stop()
+
+	var batch []*span
+	for {
+		select {
+		case sp := <-t.spans:
+			if batch = append(batch, sp); len(batch) >= traceBatchSize {
+				t.send(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			t.send(batch)
+			batch = nil
+		case done := <-t.flush:
+			for len(t.spans) > 0 {
+				batch = append(batch, <-t.spans)
+			}
+			t.send(batch)
+			batch = nil
+			close(done)
+		}
+	}
+}
+
+// send Posts batch to the collector in the OTLP/HTTP JSON encoding
+func (t *tracer) send(batch []*span) {
+	if len(batch) == 0 {
+		return
+	}
+	spans := make([]JSON, 0, len(batch))
+	for _, sp := range batch {
+		spans = append(spans, sp.otlp())
+	}
+	body, err := json.Marshal(JSON{
+		"resourceSpans": []JSON{{
+			"resource": JSON{
+				"attributes": []JSON{otlpAttribute("service.name", t.service)},
+			},
+			"scopeSpans": []JSON{{
+				"scope": JSON{"name": "theluxuryshopper"},
+				"spans": spans,
+			}},
+		}},
+	})
+	if err != nil {
+		log.Printf("couldn't encode %v spans: %v", len(batch), err)
+		return
+	}
+	res, err := t.client.Post(t.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("couldn't send %v spans: %v", len(batch), err)
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode >= 300 {
+		log.Printf("couldn't send %v spans: collector answered %v", len(batch), res.Status)
+	}
+}
+
+// otlp Returns the OTLP JSON form of the span
+func (sp *span) otlp() JSON {
+	attributes := make([]JSON, 0, len(sp.attrs))
+	for _, attr := range sp.attrs {
+		attributes = append(attributes, otlpAttribute(attr.key, attr.value))
+	}
+	encoded := JSON{
+		"traceId":           hex.EncodeToString(sp.traceID[:]),
+		"spanId":            hex.EncodeToString(sp.spanID[:]),
+		"name":              sp.name,
+		"kind":              sp.kind,
+		"startTimeUnixNano": strconv.FormatInt(sp.start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(sp.end.UnixNano(), 10),
+		"attributes":        attributes,
+	}
+	if sp.parentID != [8]byte{} {
+		encoded["parentSpanId"] = hex.EncodeToString(sp.parentID[:])
+	}
+	if sp.err != "" {
+		encoded["status"] = JSON{"code": 2, "message": sp.err}
+	}
+	return encoded
+}
+
+// otlpAttribute Encodes one attribute as an OTLP KeyValue
+func otlpAttribute(key string, value interface{}) JSON {
+	var encoded JSON
+	switch v := value.(type) {
+	case bool:
+		encoded = JSON{"boolValue": v}
+	case int:
+		encoded = JSON{"intValue": strconv.Itoa(v)}
+	case float64:
+		encoded = JSON{"doubleValue": v}
+	default:
+		encoded = JSON{"stringValue": v}
+	}
+	return JSON{"key": key, "value": encoded}
+}
+
+// remoteParent Returns ctx carrying the parent described by a W3C traceparent header, if it is valid.
+// The format is version-traceid-parentid-flags, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func remoteParent(ctx context.Context, t *tracer, traceparent string) context.Context {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return ctx
+	}
+	parent := &span{tracer: t}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return ctx
+	}
+	if _, err := hex.Decode(parent.traceID[:], []byte(parts[1])); err != nil || parent.traceID == [16]byte{} {
+		return ctx
+	}
+	if _, err := hex.Decode(parent.spanID[:], []byte(parts[2])); err != nil || parent.spanID == [8]byte{} {
+		return ctx
+	}
+	parent.sampled = flags[0]&1 == 1
+	return context.WithValue(ctx, spanContextKey{}, parent)
+}
+
+// traced Wraps handle in a server span named after its route, continuing the trace of an
+// incoming traceparent header
+func (s *Server) traced(route string, handle httprouter.Handle) httprouter.Handle {
+	if s.tracer == nil {
+		return handle
+	}
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		ctx := remoteParent(r.Context(), s.tracer, r.Header.Get("traceparent"))
+		ctx, sp := s.tracer.Start(ctx, r.Method+" "+route, spanServer)
+		defer sp.End()
+		sp.SetAttribute("http.method", r.Method)
+		sp.SetAttribute("http.route", route)
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handle(recorder, r.WithContext(ctx), ps)
+		sp.SetAttribute("http.status_code", recorder.status)
+		if recorder.status >= 500 {
+			sp.err = http.StatusText(recorder.status)
+		}
+	}
+}
+
+// chatStep Names the step of the conversation the next message of session answers
+func chatStep(session Session) string {
+	if _, found := session["searchByKeyword"]; !found {
+		return "keyword"
+	}
+	if pending := pendingQuestion(session); pending != "" {
+		return pending
+	}
+	return "search"
+}
+
+// statusRecorder Remembers the status code written through it
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader Records status before writing it
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Flush Lets streaming responses flush through the recorder
+func (w *statusRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,161 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestRemoteParent(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		wantParent  bool
+		wantSampled bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"not sampled", " 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00 ", true, false},
+		{"future version with more fields", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"invalid version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"zero parent id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"not hex", "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01", false, false},
+		{"short trace id", "00-4bf92f35-00f067aa0ba902b7-01", false, false},
+		{"missing", "", false, false},
+	}
+	for _, test := range tests {
+		ctx := remoteParent(context.Background(), nil, test.traceparent)
+		parent, found := ctx.Value(spanContextKey{}).(*span)
+		if found != test.wantParent {
+			t.Errorf("%v: remoteParent() found a parent: %v, want %v", test.name, found, test.wantParent)
+			continue
+		}
+		if !found {
+			continue
+		}
+		if hex.EncodeToString(parent.traceID[:]) != "4bf92f3577b34da6a3ce929d0e0e4736" || hex.EncodeToString(parent.spanID[:]) != "00f067aa0ba902b7" || parent.sampled != test.wantSampled {
+			t.Errorf("%v: remoteParent() = %x %x sampled %v", test.name, parent.traceID, parent.spanID, parent.sampled)
+		}
+	}
+}
+
+func TestOTLPAttribute(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  JSON
+	}{
+		{"gucci bag", JSON{"stringValue": "gucci bag"}},
+		{true, JSON{"boolValue": true}},
+		{42, JSON{"intValue": "42"}},
+		{0.5, JSON{"doubleValue": 0.5}},
+	}
+	for _, test := range tests {
+		if got := otlpAttribute("key", test.value); !reflect.DeepEqual(got, JSON{"key": "key", "value": test.want}) {
+			t.Errorf("otlpAttribute(%v) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestDisabledTracer(t *testing.T) {
+	var disabled *tracer
+	ctx, sp := disabled.Start(context.Background(), "noop", spanInternal)
+	if sp != nil || ctx.Value(spanContextKey{}) != nil {
+		t.Fatalf("a nil tracer started %v", sp)
+	}
+	sp.SetAttribute("key", "value")
+	sp.SetError(errors.New("failed"))
+	sp.End()
+	disabled.Shutdown(context.Background())
+}
+
+func TestChatStep(t *testing.T) {
+	tests := []struct {
+		session Session
+		want    string
+	}{
+		{Session{}, "keyword"},
+		{Session{"searchByKeyword": "gucci bag", "conditionBool": true}, "condition"},
+		{Session{"searchByKeyword": "gucci bag", "condition": "New", "minPriceBool": true}, "minPrice"},
+		{Session{"searchByKeyword": "gucci bag", "condition": "New", "minPrice": "none", "maxPrice": "none"}, "search"},
+	}
+	for _, test := range tests {
+		if got := chatStep(test.session); got != test.want {
+			t.Errorf("chatStep(%v) = %q, want %q", test.session, got, test.want)
+		}
+	}
+}
+
+func TestTracedRequests(t *testing.T) {
+	var mu sync.Mutex
+	var spans []map[string]interface{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []map[string]interface{} `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, resource := range body.ResourceSpans {
+			for _, scope := range resource.ScopeSpans {
+				spans = append(spans, scope.Spans...)
+			}
+		}
+	}))
+	defer collector.Close()
+
+	s := newTestServer(t, Config{TraceEndpoint: collector.URL}, &fakeSearcher{})
+	tests := []struct {
+		name        string
+		traceparent string
+		wantTrace   string // "" for a new trace
+		wantParent  interface{}
+		wantSpan    bool
+	}{
+		{"new trace", "", "", nil, true},
+		{"continued trace", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4737-00f067aa0ba902b7-00", "", nil, false},
+	}
+	for _, test := range tests {
+		mu.Lock()
+		spans = nil
+		mu.Unlock()
+		header := http.Header{}
+		if test.traceparent != "" {
+			header.Set("traceparent", test.traceparent)
+		}
+		do(t, s.Routes(), http.MethodGet, "/v1/welcome", "", header)
+		s.tracer.Shutdown(context.Background())
+
+		mu.Lock()
+		var found map[string]interface{}
+		for _, sp := range spans {
+			if sp["name"] == "GET /v1/welcome" {
+				found = sp
+			}
+		}
+		mu.Unlock()
+		if (found != nil) != test.wantSpan {
+			t.Errorf("%v: the collector got the span %v, want one: %v", test.name, found, test.wantSpan)
+			continue
+		}
+		if found == nil {
+			continue
+		}
+		if test.wantTrace != "" && found["traceId"] != test.wantTrace {
+			t.Errorf("%v: the span is of trace %v, want %v", test.name, found["traceId"], test.wantTrace)
+		}
+		if found["parentSpanId"] != test.wantParent || found["kind"] != float64(spanServer) {
+			t.Errorf("%v: the span is %v, want a server span of parent %v", test.name, found, test.wantParent)
+		}
+	}
+}
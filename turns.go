@@ -0,0 +1,79 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// defaultMaxTurns Is the MAX_TURNS of servers that don't set it
+const defaultMaxTurns = 30
+
+// maxListedSessions Caps the sessions /admin/sessions lists
+const maxListedSessions = 100
+
+// countTurn Counts message as one more turn of the search being collected, reporting whether it is
+// the last one Config.MaxTurns allows. The count starts over with every search, as resetSession drops it.
+func (s *Server) countTurn(session Session) bool {
+	turns := session.GetInt("turns", 0) + 1
+	session["turns"] = turns
+	return s.config.MaxTurns > 0 && turns >= s.config.MaxTurns
+}
+
+// turnCeilingNote Tells why a search ran before every question was answered
+func (s *Server) turnCeilingNote() string {
+	return "That's " + strconv.Itoa(s.config.MaxTurns) + " messages for this search already, so I searched with what you told me so far and left out the other filters."
+}
+
+// endLongConversation Starts over a conversation that reached Config.MaxTurns without a keyword to search
+func (s *Server) endLongConversation(session Session, w http.ResponseWriter) {
+	resetSession(session)
+	WriteReply(w, ReplyQuestion, JSON{
+		"message": "That's " + strconv.Itoa(s.config.MaxTurns) + " messages without anything I could search for, so let's start over.\n " + keywordQuestion,
+		"step":    "keyword",
+	})
+}
+
+// noteTurns Remembers the turns of the active conversation of uuid for /admin/sessions
+func (st *SessionStore) noteTurns(uuid string, turns int) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if stored, found := st.sessions[uuid]; found {
+		stored.turns = turns
+	}
+}
+
+// handleSessions Handles GET /admin/sessions, listing the live sessions with the most turns since
+// their last search first, to spot the stuck ones. Sessions are named by the start of their uuid only.
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if !s.authorizeAdmin(w, r, "Session listings") {
+		return
+	}
+	st := s.sessions
+	st.mu.Lock()
+	listed := make([]JSON, 0, len(st.sessions))
+	for uuid, stored := range st.sessions {
+		listed = append(listed, JSON{
+			"id":           uuid[:8],
+			"turns":        stored.turns,
+			"createdAt":    stored.createdAt.UTC().Format(time.RFC3339),
+			"lastActivity": stored.lastActivity.UTC().Format(time.RFC3339),
+		})
+	}
+	total := len(st.sessions)
+	st.mu.Unlock()
+	sort.Slice(listed, func(i, j int) bool {
+		if listed[i]["turns"] != listed[j]["turns"] {
+			return listed[i]["turns"].(int) > listed[j]["turns"].(int)
+		}
+		return listed[i]["lastActivity"].(string) > listed[j]["lastActivity"].(string)
+	})
+	if len(listed) > maxListedSessions {
+		listed = listed[:maxListedSessions]
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, JSON{"sessions": listed, "total": total, "maxTurns": s.config.MaxTurns})
+}
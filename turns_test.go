@@ -0,0 +1,87 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMaxTurns(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxTurns     int
+		messages     []string
+		wantContains string
+		wantSearch   bool
+	}{
+		{"under the ceiling", 4, []string{"gucci bag", "banana", "banana"}, "Please specify the condition", false},
+		{"ceiling searches", 3, []string{"gucci bag", "banana", "banana"}, "That's 3 messages for this search already, so I searched with what you told me so far", true},
+		{"ceiling without a keyword", 2, []string{`""`, `""`}, "That's 2 messages without anything I could search for, so let's start over.", false},
+		{"count starts over after a search", 4, []string{"gucci bag", "new", "none", "none", "prada bag", "banana", "banana"}, "Please specify the condition", true},
+		{"no ceiling", 0, []string{"gucci bag", "banana", "banana", "banana", "banana"}, "Please specify the condition", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 1), Count: 1}}}
+			c := startConversation(t, newTestServer(t, Config{MaxTurns: test.maxTurns}, searcher))
+			reply := c.sayAll(test.messages...)
+			if !strings.Contains(reply.message(), test.wantContains) {
+				t.Errorf("%q answered %q, want it to contain %q", test.messages, reply.message(), test.wantContains)
+			}
+			if searched := len(searcher.Queries()) > 0; searched != test.wantSearch {
+				t.Errorf("%q searched: %v, want %v", test.messages, searched, test.wantSearch)
+			}
+		})
+	}
+}
+
+func TestAdminSessions(t *testing.T) {
+	s := newTestServer(t, Config{AdminToken: "secret", MaxTurns: 10}, &fakeSearcher{})
+	stuck := startConversation(t, s)
+	stuck.sayAll("gucci bag", "banana", "banana")
+	busy := startConversation(t, s)
+	busy.sayAll("prada bag")
+	startConversation(t, s)
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"admin", "Bearer secret", http.StatusOK},
+		{"wrong token", "Bearer wrong", http.StatusUnauthorized},
+		{"no token", "", http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		header := http.Header{}
+		if test.token != "" {
+			header.Set("Authorization", test.token)
+		}
+		reply := do(t, s.Routes(), http.MethodGet, "/admin/sessions", "", header)
+		if reply.Status != test.wantStatus {
+			t.Errorf("%v: /admin/sessions answered %v %v, want %v", test.name, reply.Status, reply.Raw, test.wantStatus)
+			continue
+		}
+		if reply.Status != http.StatusOK {
+			continue
+		}
+		sessions, _ := reply.Body["sessions"].([]interface{})
+		if len(sessions) != 3 || reply.Body["total"] != 3.0 || reply.Body["maxTurns"] != 10.0 {
+			t.Fatalf("%v: /admin/sessions answered %v", test.name, reply.Raw)
+		}
+		var turns []float64
+		for _, listed := range sessions {
+			fields := listed.(map[string]interface{})
+			turns = append(turns, fields["turns"].(float64))
+			if id := fields["id"].(string); len(id) != 8 {
+				t.Errorf("%v: /admin/sessions named a session %q, want the start of its uuid", test.name, id)
+			}
+		}
+		if turns[0] != 3 || turns[1] != 1 || turns[2] != 0 {
+			t.Errorf("%v: /admin/sessions listed the turns %v, want the most first", test.name, turns)
+		}
+		if first := sessions[0].(map[string]interface{})["id"]; first != stuck.uuid[:8] {
+			t.Errorf("%v: /admin/sessions listed %v first, want %v", test.name, first, stuck.uuid[:8])
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"time"
+)
+
+// undoWindow How long "undo" can restore what the last destructive command removed
+const undoWindow = 2 * time.Minute
+
+// undoHint Ends the replies of the destructive commands
+const undoHint = "Say 'undo' within 2 minutes to restore it."
+
+// undoSlot Holds what the last destructive command of a session removed. It is kept at the top of the
+// session, so resetting a search doesn't clear it, and backups don't export it.
+type undoSlot struct {
+	expiresAt time.Time
+	// restore puts the removed data back into the session and returns the reply saying so
+	restore func(session Session) string
+}
+
+// stashUndo Remembers how to restore what a destructive command removed from session, replacing what
+// the previous one removed
+func stashUndo(session Session, now time.Time, restore func(session Session) string) {
+	session["undo"] = &undoSlot{expiresAt: now.Add(undoWindow), restore: restore}
+}
+
+// handleUndoCommand Answers "undo" by restoring what the last destructive command removed, reporting whether message was it
+func handleUndoCommand(session Session, message string, now time.Time, w http.ResponseWriter) bool {
+	if message != "undo" {
+		return false
+	}
+	slot, found := session["undo"].(*undoSlot)
+	delete(session, "undo")
+	_, conversation := activeConversation(session)
+	switch {
+	case !found:
+		WriteReply(w, ReplyInfo, JSON{"message": "There is nothing to undo.\n " + resumeSummary(conversation)})
+	case now.After(slot.expiresAt):
+		WriteReply(w, ReplyInfo, JSON{"message": "Sorry, it's too late to undo that, it can only be done within 2 minutes.\n " + resumeSummary(conversation)})
+	default:
+		message := slot.restore(session)
+		_, conversation = activeConversation(session)
+		WriteReply(w, ReplyInfo, JSON{"message": message + "\n " + resumeSummary(conversation)})
+	}
+	return true
+}
+
+// copySession Returns a shallow copy of session
+func copySession(session Session) Session {
+	copied := make(Session, len(session))
+	for key, value := range session {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,55 @@
+package theluxuryshopper
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUndo(t *testing.T) {
+	tests := []struct {
+		name       string
+		messages   []string
+		wait       time.Duration // before the last message
+		last       string
+		wantPrefix string
+		wantActive string // the keyword of the active conversation after the last message, "" for none
+	}{
+		{"nothing to undo", []string{"gucci bag"}, 0, "undo", "There is nothing to undo.", "gucci bag"},
+		{"restart", []string{"gucci bag", "restart"}, 0, "undo", "Restored conversation main as it was before starting over.", "gucci bag"},
+		{"restart, too late", []string{"gucci bag", "restart"}, undoWindow + time.Second, "undo", "Sorry, it's too late to undo that, it can only be done within 2 minutes.", ""},
+		{"undone once only", []string{"gucci bag", "restart", "undo"}, 0, "undo", "There is nothing to undo.", "gucci bag"},
+		{"latest command only", []string{"gucci bag", "new", "none", "none", "save search as bags", "delete search bags", "prada bag", "restart"}, 0, "undo", "Restored conversation main as it was before starting over.", "prada bag"},
+		{"deleted search", []string{"gucci bag", "new", "none", "none", "save search as bags", "delete search bags"}, 0, "undo", "Restored your saved search bags.", ""},
+		{"taken name", []string{"gucci bag", "new", "none", "none", "save search as bags", "delete search bags", "save search as bags"}, 0, "undo", "A search was saved as bags meanwhile, so the deleted one can't be restored.", ""},
+		{"not the command", []string{"gucci bag"}, 0, "undo it", "Please specify the condition", "gucci bag"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			searcher := &fakeSearcher{results: map[string]SearchResult{"": {Items: testItems("bag", 1, 1), Count: 1}}}
+			s := newTestServer(t, Config{}, searcher)
+			c := startConversation(t, s)
+			c.sayAll(test.messages...)
+			c.clock.Advance(test.wait)
+			reply := c.say(test.last)
+			if !strings.HasPrefix(reply.message(), test.wantPrefix) {
+				t.Errorf("%q answered %q, want it to start with %q", test.last, reply.message(), test.wantPrefix)
+			}
+			session, _, _ := s.sessions.Get(c.uuid)
+			_, conversation := activeConversation(session)
+			if keyword := conversation.GetString("searchByKeyword", ""); keyword != test.wantActive {
+				t.Errorf("%q left the keyword %q, want %q", test.last, keyword, test.wantActive)
+			}
+		})
+	}
+}
+
+func TestCopySession(t *testing.T) {
+	session := Session{"searchByKeyword": "gucci bag", "condition": "New"}
+	copied := copySession(session)
+	delete(session, "condition")
+	session["searchByKeyword"] = "prada bag"
+	if copied["searchByKeyword"] != "gucci bag" || copied["condition"] != "New" || len(copied) != 2 {
+		t.Errorf("copySession() = %v, want the session as it was copied", copied)
+	}
+}
@@ -0,0 +1,110 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultConnectTimeout Bounds the dial of an eBay call when EBAY_CONNECT_TIMEOUT isn't set
+	defaultConnectTimeout = time.Second
+	// defaultTLSTimeout Bounds the TLS handshake when EBAY_TLS_TIMEOUT isn't set
+	defaultTLSTimeout = time.Second
+	// defaultHeaderTimeout Bounds the wait for the response headers when EBAY_HEADER_TIMEOUT isn't set
+	defaultHeaderTimeout = 1500 * time.Millisecond
+
+	// maxSearchAttempts Caps the calls one search makes
+	maxSearchAttempts = 2
+	// headerTimeoutBackoff Is the pause before retrying an eBay call that answered too slowly
+	headerTimeoutBackoff = 250 * time.Millisecond
+)
+
+// Categories of the eBay calls that got no usable answer
+const (
+	failureConnect       = "connect"        // eBay couldn't be reached, retried right away
+	failureTLS           = "tls"            // the TLS handshake failed or timed out
+	failureHeaderTimeout = "header_timeout" // eBay was reached but slow to answer, retried after a backoff
+	failureBodyRead      = "body_read"      // the response couldn't be read in time or is too large, never retried
+	failureDeadline      = "deadline"       // the search ran out of time
+	failureOther         = "other"
+)
+
+// upstreamError Is returned when an eBay call got no usable answer, category tells why
+type upstreamError struct {
+	category string
+	err      error
+}
+
+func (e *upstreamError) Error() string {
+	return e.category + ": " + e.err.Error()
+}
+
+// Unwrap Returns the error of the call
+func (e *upstreamError) Unwrap() error {
+	return e.err
+}
+
+// retryable Reports whether the call is worth making again and how long to wait first
+func (e *upstreamError) retryable() (bool, time.Duration) {
+	switch e.category {
+	case failureConnect:
+		return true, 0
+	case failureHeaderTimeout:
+		return true, headerTimeoutBackoff
+	}
+	return false, 0
+}
+
+// userMessage Tells the user what went wrong in words that fit the category
+func (e *upstreamError) userMessage() string {
+	switch e.category {
+	case failureHeaderTimeout, failureDeadline:
+		return "eBay is slow to answer right now, please try again in a moment."
+	case failureBodyRead:
+		return "eBay's answer couldn't be read, please try a narrower search."
+	}
+	return "Couldn't reach eBay right now."
+}
+
+// classifyCallError Sorts the error of sending a request or reading its headers into a category
+func classifyCallError(ctx context.Context, err error) string {
+	var opErr *net.OpError
+	var recordErr tls.RecordHeaderError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	switch {
+	// net/http's own timeouts count as deadline errors too, so they are told apart first
+	case strings.Contains(err.Error(), "timeout awaiting response headers"):
+		return failureHeaderTimeout
+	case ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded):
+		return failureDeadline
+	case strings.Contains(err.Error(), "TLS handshake"), errors.As(err, &recordErr), errors.As(err, &certErr), errors.As(err, &unknownAuthority):
+		return failureTLS
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return failureConnect
+	}
+	return failureOther
+}
+
+// unreadableBody Tells the errors of decoding a response body apart: malformed JSON is eBay's fault,
+// anything else means the body couldn't be read in time or was cut at maxFindingResponseSize
+func unreadableBody(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr)
+}
+
+// failureCategory Returns the category of a search that ended with err, or "" if eBay answered
+func failureCategory(err error) string {
+	var upstream *upstreamError
+	if errors.As(err, &upstream) {
+		return upstream.category
+	}
+	return ""
+}
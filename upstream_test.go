@@ -0,0 +1,96 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClassifyCallError(t *testing.T) {
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want string
+	}{
+		{"header timeout", context.Background(), &url.Error{Op: "Get", URL: "https://svcs.ebay.com", Err: errors.New("net/http: timeout awaiting response headers")}, failureHeaderTimeout},
+		{"header timeout past the deadline", expired, errors.New("net/http: timeout awaiting response headers"), failureHeaderTimeout},
+		{"context ended", expired, errors.New("context canceled"), failureDeadline},
+		{"deadline exceeded", context.Background(), fmt.Errorf("call: %w", context.DeadlineExceeded), failureDeadline},
+		{"TLS handshake timeout", context.Background(), errors.New("net/http: TLS handshake timeout"), failureTLS},
+		{"TLS record", context.Background(), &url.Error{Op: "Get", URL: "https://svcs.ebay.com", Err: tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}}, failureTLS},
+		{"unknown authority", context.Background(), x509.UnknownAuthorityError{}, failureTLS},
+		{"dial", context.Background(), &url.Error{Op: "Get", URL: "https://svcs.ebay.com", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, failureConnect},
+		{"read", context.Background(), &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, failureOther},
+		{"other", context.Background(), errors.New("something else"), failureOther},
+	}
+	for _, test := range tests {
+		if got := classifyCallError(test.ctx, test.err); got != test.want {
+			t.Errorf("%v: classifyCallError() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestUpstreamError(t *testing.T) {
+	cause := errors.New("connection refused")
+	tests := []struct {
+		category    string
+		wantRetry   bool
+		wantBackoff time.Duration
+		wantMessage string
+	}{
+		{failureConnect, true, 0, "Couldn't reach eBay right now."},
+		{failureHeaderTimeout, true, headerTimeoutBackoff, "eBay is slow to answer right now, please try again in a moment."},
+		{failureDeadline, false, 0, "eBay is slow to answer right now, please try again in a moment."},
+		{failureBodyRead, false, 0, "eBay's answer couldn't be read, please try a narrower search."},
+		{failureTLS, false, 0, "Couldn't reach eBay right now."},
+		{failureOther, false, 0, "Couldn't reach eBay right now."},
+	}
+	for _, test := range tests {
+		err := &upstreamError{category: test.category, err: cause}
+		if retry, backoff := err.retryable(); retry != test.wantRetry || backoff != test.wantBackoff {
+			t.Errorf("%v: retryable() = %v, %v, want %v, %v", test.category, retry, backoff, test.wantRetry, test.wantBackoff)
+		}
+		if got := err.userMessage(); got != test.wantMessage {
+			t.Errorf("%v: userMessage() = %q, want %q", test.category, got, test.wantMessage)
+		}
+		if got := err.Error(); got != test.category+": connection refused" || !errors.Is(err, cause) {
+			t.Errorf("%v: Error() = %q, want it to wrap the cause", test.category, got)
+		}
+		if got := failureCategory(fmt.Errorf("search: %w", err)); got != test.category {
+			t.Errorf("failureCategory() of a wrapped %v error = %q", test.category, got)
+		}
+	}
+	if got := failureCategory(cause); got != "" {
+		t.Errorf("failureCategory() of an answered search = %q, want \"\"", got)
+	}
+}
+
+func TestUnreadableBody(t *testing.T) {
+	var value struct{ Count int }
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"malformed", json.Unmarshal([]byte(`{"Count" 1}`), &value), false},
+		{"wrong type", json.Unmarshal([]byte(`{"Count":"one"}`), &value), false},
+		{"cut", json.NewDecoder(strings.NewReader(`{"Count":`)).Decode(&value), true},
+		{"read timeout", context.DeadlineExceeded, true},
+	}
+	for _, test := range tests {
+		if got := unreadableBody(test.err); got != test.want {
+			t.Errorf("%v: unreadableBody(%v) = %v, want %v", test.name, test.err, got, test.want)
+		}
+	}
+}
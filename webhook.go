@@ -0,0 +1,211 @@
+package theluxuryshopper
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	webhookQueueSize = 256                    // searches waiting for delivery before new ones are dropped
+	webhookAttempts  = 4                      // deliveries tried per search
+	webhookBackoff   = 500 * time.Millisecond // before the 2nd attempt, doubling after
+	webhookTimeout   = 10 * time.Second       // per attempt
+)
+
+// webhookSignatureHeader Carries the hex HMAC-SHA256 of the body under SEARCH_WEBHOOK_SECRET
+const webhookSignatureHeader = "X-Webhook-Signature"
+
+// sessionHashKey Is the context key of the hashed id of the session a search runs for
+type sessionHashKey struct{}
+
+// withSessionHash Notes on ctx the session its searches run for, hashed so the webhook can't be used to take it over
+func withSessionHash(ctx context.Context, uuid string) context.Context {
+	sum := sha256.Sum256([]byte(uuid))
+	return context.WithValue(ctx, sessionHashKey{}, hex.EncodeToString(sum[:16]))
+}
+
+// webhookDelivery Is the copy of one search waiting for delivery, with the job its session can stop it by
+type webhookDelivery struct {
+	body     []byte
+	ctx      context.Context
+	finished func()
+}
+
+// searchWebhook Posts a copy of every successful search to SEARCH_WEBHOOK_URL from its own goroutine.
+// Like the events, a search never waits for it: when the queue is full the copy is dropped and counted.
+type searchWebhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+	mu     sync.RWMutex // held to send to queue, and to close it
+	closed bool
+	queue  chan webhookDelivery
+	done   chan struct{}   // closed once the queue is drained
+	ctx    context.Context // canceled when Shutdown gives up on the queue
+	cancel context.CancelFunc
+	jobs   *jobRegistry // registers the deliveries of sessions
+
+	delivered, failed, dropped int64 // atomic
+}
+
+// newSearchWebhook Starts delivering to url, signing with secret when it isn't empty
+func newSearchWebhook(url, secret string, jobs *jobRegistry) *searchWebhook {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &searchWebhook{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: webhookTimeout},
+		queue:  make(chan webhookDelivery, webhookQueueSize),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+		jobs:   jobs,
+	}
+	go func() {
+		defer close(h.done)
+		for delivery := range h.queue {
+			switch {
+			case h.ctx.Err() != nil:
+				// Shutdown gave up, the rest is only counted
+				atomic.AddInt64(&h.failed, 1)
+			case delivery.ctx.Err() == nil:
+				h.deliver(delivery.ctx, delivery.body)
+			}
+			// Deliveries their session stopped are neither delivered nor failed
+			delivery.finished()
+		}
+	}()
+	return h
+}
+
+// send Queues the copy of one search
+func (h *searchWebhook) send(ctx context.Context, query SearchQuery, result SearchResult) {
+	session := sessionOf(ctx)
+	body, err := json.Marshal(JSON{
+		"query":   query,
+		"count":   result.Count,
+		"items":   nonNilItems(result.Items),
+		"session": session,
+		"stats": JSON{
+			"totalPages":   result.Stats.TotalPages,
+			"totalEntries": result.Stats.TotalEntries,
+			"attempts":     result.Stats.Attempts,
+			"cacheHit":     result.Stats.CacheHit,
+			"durationMs":   milliseconds(result.Stats.Duration),
+		},
+		"searchedAt": time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		log.Printf("couldn't encode the search webhook: %v", err)
+		return
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return
+	}
+	delivery := webhookDelivery{body: body}
+	delivery.ctx, delivery.finished = h.jobs.start(h.ctx, session, jobWebhook)
+	select {
+	case h.queue <- delivery:
+	default:
+		delivery.finished()
+		if dropped := atomic.AddInt64(&h.dropped, 1); dropped&(dropped-1) == 0 {
+			log.Printf("search webhook can't keep up, %v searches dropped so far", dropped)
+		}
+	}
+}
+
+// deliver Posts body until the receiver takes it or the attempts run out. Answers of 4xx other
+// than 408 and 429 aren't retried, the same body would only get them again.
+func (h *searchWebhook) deliver(ctx context.Context, body []byte) {
+	if err := h.attempt(ctx, body); err != nil {
+		if ctx.Err() != nil && h.ctx.Err() == nil {
+			// Stopped by its session while being delivered
+			return
+		}
+		atomic.AddInt64(&h.failed, 1)
+		log.Printf("couldn't deliver a search to the webhook: %v", err)
+		return
+	}
+	atomic.AddInt64(&h.delivered, 1)
+}
+
+// attempt Posts body up to webhookAttempts times, backing off in between, until ctx is done
+func (h *searchWebhook) attempt(ctx context.Context, body []byte) error {
+	backoff := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := h.post(ctx, body)
+		if err == nil || !retry || attempt == webhookAttempts {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return fmt.Errorf("%v, then the server shut down", err)
+		}
+	}
+}
+
+// post Makes one attempt, reporting whether a failure is worth retrying
+func (h *searchWebhook) post(ctx context.Context, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(h.secret) > 0 {
+		mac := hmac.New(sha256.New, h.secret)
+		mac.Write(body)
+		req.Header.Set(webhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	res, err := h.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	res.Body.Close()
+	switch {
+	case res.StatusCode < 300:
+		return false, nil
+	case res.StatusCode >= 500 || res.StatusCode == http.StatusRequestTimeout || res.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("the webhook answered %v", res.Status)
+	}
+	return false, fmt.Errorf("the webhook answered %v", res.Status)
+}
+
+// Shutdown Stops taking searches and waits for the queued ones until ctx is done, then gives up on the rest
+func (h *searchWebhook) Shutdown(ctx context.Context) {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	h.closed = true
+	close(h.queue)
+	h.mu.Unlock()
+	select {
+	case <-h.done:
+	case <-ctx.Done():
+		h.cancel()
+		<-h.done
+		_, failed, _ := h.Counts()
+		log.Printf("search webhook stopped before its queue was drained, %v searches failed in all", failed)
+	}
+	h.cancel()
+}
+
+// Counts Returns how many searches were delivered, failed after their attempts and dropped from a full queue
+func (h *searchWebhook) Counts() (delivered, failed, dropped int64) {
+	return atomic.LoadInt64(&h.delivered), atomic.LoadInt64(&h.failed), atomic.LoadInt64(&h.dropped)
+}
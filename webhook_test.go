@@ -0,0 +1,112 @@
+package theluxuryshopper
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// webhookReceiver Answers the deliveries of a searchWebhook with statuses in turn, the last one repeating
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+// ServeHTTP Records the delivery and answers the next status
+func (rc *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.bodies = append(rc.bodies, body)
+	rc.headers = append(rc.headers, r.Header.Clone())
+	status := rc.statuses[0]
+	if len(rc.statuses) > 1 {
+		rc.statuses = rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func TestSearchWebhook(t *testing.T) {
+	tests := []struct {
+		name          string
+		secret        string
+		statuses      []int
+		wantAttempts  int
+		wantDelivered int64
+		wantFailed    int64
+	}{
+		{"delivered", "", []int{http.StatusNoContent}, 1, 1, 0},
+		{"signed", "secret", []int{http.StatusOK}, 1, 1, 0},
+		{"server error retried", "", []int{http.StatusServiceUnavailable, http.StatusOK}, 2, 1, 0},
+		{"too many requests retried", "", []int{http.StatusTooManyRequests, http.StatusOK}, 2, 1, 0},
+		{"client error not retried", "", []int{http.StatusBadRequest}, 1, 0, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			receiver := &webhookReceiver{statuses: test.statuses}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			h := newSearchWebhook(server.URL, test.secret, newJobRegistry())
+			ctx := withSessionHash(context.Background(), "session uuid")
+			h.send(ctx, SearchQuery{Keyword: "gucci bag"}, SearchResult{Items: testItems("bag", 1, 2), Count: 2})
+			h.Shutdown(context.Background())
+
+			if delivered, failed, dropped := h.Counts(); delivered != test.wantDelivered || failed != test.wantFailed || dropped != 0 {
+				t.Errorf("Counts() = %v, %v, %v, want %v, %v, 0", delivered, failed, dropped, test.wantDelivered, test.wantFailed)
+			}
+			receiver.mu.Lock()
+			defer receiver.mu.Unlock()
+			if len(receiver.bodies) != test.wantAttempts {
+				t.Fatalf("the webhook got %v attempts, want %v", len(receiver.bodies), test.wantAttempts)
+			}
+			var body struct {
+				Query   SearchQuery `json:"query"`
+				Count   int         `json:"count"`
+				Items   []Item      `json:"items"`
+				Session string      `json:"session"`
+			}
+			if err := json.Unmarshal(receiver.bodies[0], &body); err != nil || body.Query.Keyword != "gucci bag" || body.Count != 2 || len(body.Items) != 2 {
+				t.Errorf("the webhook got %s, %v", receiver.bodies[0], err)
+			}
+			if body.Session != sessionOf(ctx) || body.Session == "session uuid" || body.Session == "" {
+				t.Errorf("the webhook got the session %q, want its hash %q", body.Session, sessionOf(ctx))
+			}
+			signature := receiver.headers[0].Get(webhookSignatureHeader)
+			if test.secret == "" {
+				if signature != "" {
+					t.Errorf("an unsigned webhook sent the signature %q", signature)
+				}
+				return
+			}
+			mac := hmac.New(sha256.New, []byte(test.secret))
+			mac.Write(receiver.bodies[0])
+			if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+				t.Errorf("the webhook was signed %q, want %q", signature, want)
+			}
+		})
+	}
+}
+
+func TestSearchWebhookAfterShutdown(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{http.StatusOK}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	h := newSearchWebhook(server.URL, "", newJobRegistry())
+	h.Shutdown(context.Background())
+	h.send(context.Background(), SearchQuery{Keyword: "gucci bag"}, SearchResult{})
+	h.Shutdown(context.Background())
+	if delivered, failed, dropped := h.Counts(); delivered != 0 || failed != 0 || dropped != 0 {
+		t.Errorf("Counts() after a search sent past the shutdown = %v, %v, %v, want nothing", delivered, failed, dropped)
+	}
+}
@@ -0,0 +1,99 @@
+package theluxuryshopper
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// maxWelcomeBodySize Caps the JSON body of /welcome
+const maxWelcomeBodySize = 4 << 10
+
+// localeTag Matches the BCP 47 language tags clients send, like en, en-US or pt_BR
+var localeTag = regexp.MustCompile(`^([a-zA-Z]{2,3})(?:[-_]([a-zA-Z]{2}|\d{3}))?$`)
+
+// welcomePreferences Is the optional bundle /welcome seeds a new session with, in one JSON body or in
+// query parameters of the same names, which win over the body
+type welcomePreferences struct {
+	Locale   string `json:"locale"`
+	Currency string `json:"currency"`
+	Timezone string `json:"timezone"`
+	ShipsTo  string `json:"shipsTo"`
+}
+
+// preferenceProblem Tells why one preference of the bundle was left out
+type preferenceProblem struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// readWelcomePreferences Reads the bundle of r, an error means its body isn't valid JSON
+func readWelcomePreferences(r *http.Request) (welcomePreferences, error) {
+	var bundle welcomePreferences
+	if r.Body != nil {
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxWelcomeBodySize)).Decode(&bundle); err != nil && err != io.EOF {
+			return bundle, err
+		}
+	}
+	query := r.URL.Query()
+	for name, field := range map[string]*string{"locale": &bundle.Locale, "currency": &bundle.Currency, "timezone": &bundle.Timezone, "shipsTo": &bundle.ShipsTo} {
+		if value := strings.TrimSpace(query.Get(name)); value != "" {
+			*field = value
+		}
+	}
+	return bundle, nil
+}
+
+// seedPreferences Sets the valid preferences of bundle on a new session, returning the ones it set and
+// the problems of the others. The timezone and locale belong to the session, the currency and the
+// country items ship to to its main conversation, like the commands setting them.
+func seedPreferences(session Session, bundle welcomePreferences) (JSON, []preferenceProblem) {
+	_, conversation := activeConversation(session)
+	accepted := JSON{}
+	var problems []preferenceProblem
+	if bundle.Locale != "" {
+		if match := localeTag.FindStringSubmatch(bundle.Locale); match != nil {
+			locale := strings.ToLower(match[1])
+			if match[2] != "" {
+				locale += "-" + strings.ToUpper(match[2])
+			}
+			session["locale"], accepted["locale"] = locale, locale
+		} else {
+			problems = append(problems, preferenceProblem{"locale", "invalid_locale", "The locale must be a language tag like en or en-US."})
+		}
+	}
+	if bundle.Currency != "" {
+		if currency := strings.ToUpper(bundle.Currency); validCurrency(currency) {
+			conversation["currency"], accepted["currency"] = currency, currency
+		} else {
+			problems = append(problems, preferenceProblem{"currency", "invalid_currency", "The currency must be the code of the currency of an eBay site, like EUR."})
+		}
+	}
+	if bundle.Timezone != "" {
+		if location, valid := loadTimezone(bundle.Timezone); valid {
+			session["timezone"], accepted["timezone"] = location.String(), location.String()
+		} else {
+			problems = append(problems, preferenceProblem{"timezone", "invalid_timezone", "The timezone must be a name like " + timezoneExamples + "."})
+		}
+	}
+	if bundle.ShipsTo != "" {
+		if code := countryCode(bundle.ShipsTo); code != "" {
+			conversation["shipsTo"], accepted["shipsTo"] = code, code
+		} else {
+			problems = append(problems, preferenceProblem{"shipsTo", "invalid_ships_to", "shipsTo must be a two letter country code."})
+		}
+	}
+	return accepted, problems
+}
+
+// preferenceFields Lists the fields of problems for the message of the reply
+func preferenceFields(problems []preferenceProblem) string {
+	fields := make([]string, len(problems))
+	for i, problem := range problems {
+		fields[i] = problem.Field
+	}
+	return strings.Join(fields, ", ")
+}
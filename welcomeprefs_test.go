@@ -0,0 +1,90 @@
+package theluxuryshopper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadWelcomePreferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		body    string
+		want    welcomePreferences
+		wantErr bool
+	}{
+		{"nothing", "/v1/welcome", "", welcomePreferences{}, false},
+		{"body", "/v1/welcome", `{"locale":"en-GB","currency":"gbp","timezone":"Europe/London","shipsTo":"GB"}`, welcomePreferences{"en-GB", "gbp", "Europe/London", "GB"}, false},
+		{"query", "/v1/welcome?locale=de&shipsTo=%20DE%20", "", welcomePreferences{Locale: "de", ShipsTo: "DE"}, false},
+		{"query wins", "/v1/welcome?currency=EUR", `{"currency":"USD","locale":"fr"}`, welcomePreferences{Locale: "fr", Currency: "EUR"}, false},
+		{"invalid JSON", "/v1/welcome", `{"locale":`, welcomePreferences{}, true},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodPost, test.target, strings.NewReader(test.body))
+		got, err := readWelcomePreferences(r)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: readWelcomePreferences() = %v, want an error: %v", test.name, err, test.wantErr)
+			continue
+		}
+		if !test.wantErr && got != test.want {
+			t.Errorf("%v: readWelcomePreferences() = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSeedPreferences(t *testing.T) {
+	tests := []struct {
+		name         string
+		bundle       welcomePreferences
+		wantAccepted JSON
+		wantProblems []string
+	}{
+		{"none", welcomePreferences{}, JSON{}, nil},
+		{"valid", welcomePreferences{"pt_br", "eur", "Europe/Lisbon", "pt"}, JSON{"locale": "pt-BR", "currency": "EUR", "timezone": "Europe/Lisbon", "shipsTo": "PT"}, nil},
+		{"region code", welcomePreferences{Locale: "es-419"}, JSON{"locale": "es-419"}, nil},
+		{"invalid kept apart", welcomePreferences{"english", "EUR", "Mars/Olympus", "Atlantis"}, JSON{"currency": "EUR"}, []string{"invalid_locale", "invalid_timezone", "invalid_ships_to"}},
+		{"unknown currency", welcomePreferences{Currency: "XYZ"}, JSON{}, []string{"invalid_currency"}},
+	}
+	for _, test := range tests {
+		session := Session{}
+		accepted, problems := seedPreferences(session, test.bundle)
+		if !reflect.DeepEqual(accepted, test.wantAccepted) {
+			t.Errorf("%v: seedPreferences() accepted %v, want %v", test.name, accepted, test.wantAccepted)
+		}
+		var codes []string
+		for _, problem := range problems {
+			codes = append(codes, problem.Code)
+		}
+		if !reflect.DeepEqual(codes, test.wantProblems) {
+			t.Errorf("%v: seedPreferences() found the problems %v, want %v", test.name, codes, test.wantProblems)
+		}
+		_, conversation := activeConversation(session)
+		if session["locale"] != test.wantAccepted["locale"] || session["timezone"] != test.wantAccepted["timezone"] ||
+			conversation["currency"] != test.wantAccepted["currency"] || conversation["shipsTo"] != test.wantAccepted["shipsTo"] {
+			t.Errorf("%v: seedPreferences() left the session %v", test.name, session)
+		}
+	}
+}
+
+func TestWelcomePreferences(t *testing.T) {
+	s := newTestServer(t, Config{}, &fakeSearcher{})
+	reply := do(t, s.Routes(), http.MethodPost, "/v1/welcome?timezone=Mars/Olympus", `{"locale":"en-US","currency":"usd","shipsTo":"Atlantis"}`, nil)
+	if reply.Status != http.StatusOK {
+		t.Fatalf("/v1/welcome answered %v %v", reply.Status, reply.Raw)
+	}
+	if want := "Sorry, I couldn't use your timezone, shipsTo (see invalidPreferences).\n "; !strings.HasPrefix(reply.message(), want) {
+		t.Errorf("/v1/welcome answered %q, want it to start with %q", reply.message(), want)
+	}
+	if preferences, _ := reply.Body["preferences"].(map[string]interface{}); !reflect.DeepEqual(preferences, map[string]interface{}{"locale": "en-US", "currency": "USD"}) {
+		t.Errorf("/v1/welcome accepted %v", reply.Body["preferences"])
+	}
+	if problems, _ := reply.Body["invalidPreferences"].([]interface{}); len(problems) != 2 {
+		t.Errorf("/v1/welcome reported the problems %v", reply.Body["invalidPreferences"])
+	}
+	if got := preferenceFields(nil); got != "" {
+		t.Errorf("preferenceFields(nil) = %q, want \"\"", got)
+	}
+}